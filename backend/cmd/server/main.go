@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"test-management-service/internal/config"
 	"test-management-service/internal/handler"
@@ -60,6 +62,9 @@ func main() {
 		&models.WorkflowVariableChange{},
 		&models.User{},
 		&models.Role{},
+		&models.SchemaDocument{},
+		&models.Snapshot{},
+		&models.TestPlan{},
 	); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -79,10 +84,13 @@ func main() {
 	stepLogRepo := repository.NewStepLogRepository(db)
 	actionTemplateRepo := repository.NewActionTemplateRepository(db)
 	roleRepo := repository.NewRoleRepository(db)
+	schemaRepo := repository.NewSchemaDocumentRepository(db)
+	snapshotRepo := repository.NewSnapshotRepository(db)
 
 	// Initialize environment service and variable injector
 	envService := service.NewEnvironmentService(envRepo, envVarRepo)
 	variableInjector := service.NewVariableInjector(envService)
+	variableInjector.SetStrict(cfg.Test.StrictVariables)
 
 	// Initialize WebSocket hub
 	hub := websocket.NewHub()
@@ -91,11 +99,37 @@ func main() {
 	// Initialize unified test executor (for workflow HTTP/Command steps)
 	unifiedExecutor := testcase.NewExecutorWithInjector(cfg.Test.TargetHost, nil, caseRepo, nil, variableInjector)
 
+	// Script policy and process sandbox are configured per tenant
+	scriptPolicyService := service.NewScriptPolicyService(tenantRepo)
+	unifiedExecutor.SetSandboxProvider(scriptPolicyService)
+
+	// Stored JSON Schema / OpenAPI documents for json_schema assertions
+	schemaService := service.NewSchemaService(schemaRepo)
+	unifiedExecutor.SetSchemaLoader(schemaService)
+
+	// Approved response snapshots for snapshot assertions
+	snapshotService := service.NewSnapshotService(snapshotRepo)
+	unifiedExecutor.SetSnapshotStore(snapshotService)
+	unifiedExecutor.SetStrictVariables(cfg.Test.StrictVariables)
+	unifiedExecutor.SetAllowedPaths(cfg.Test.AllowedPaths)
+
+	// OpenAPI contracts linked to test groups and environments
+	contractResolver := service.NewContractResolver(groupRepo, envRepo)
+
 	// Initialize workflow executor with unified executor
 	workflowExecutor := workflow.NewWorkflowExecutor(db, caseRepo, workflowRepo, unifiedExecutor, hub, variableInjector, actionTemplateRepo)
+	workflowExecutor.SetScriptPolicyProvider(scriptPolicyService)
+	workflowExecutor.SetSchemaLoader(schemaService)
+	workflowExecutor.SetContractResolver(contractResolver)
+	workflowExecutor.SetStrictVariables(cfg.Test.StrictVariables)
 
 	// Initialize executor with variable injection (for test service)
 	executor := testcase.NewExecutorWithInjector(cfg.Test.TargetHost, nil, caseRepo, nil, variableInjector)
+	executor.SetSandboxProvider(scriptPolicyService)
+	executor.SetSchemaLoader(schemaService)
+	executor.SetSnapshotStore(snapshotService)
+	executor.SetStrictVariables(cfg.Test.StrictVariables)
+	executor.SetAllowedPaths(cfg.Test.AllowedPaths)
 
 	// Initialize services
 	tenantService := service.NewTenantService(tenantRepo)
@@ -108,6 +142,31 @@ func main() {
 	if ts, ok := testService.(interface{ SetWorkflowCaseRepo(*repository.WorkflowTestCaseRepository) }); ok {
 		ts.SetWorkflowCaseRepo(workflowCaseRepo)
 	}
+	if ts, ok := testService.(interface{ SetGroupConcurrency(int) }); ok {
+		ts.SetGroupConcurrency(cfg.Test.GroupConcurrency)
+	}
+	if ts, ok := testService.(interface{ SetQuarantineReleasePasses(int) }); ok {
+		ts.SetQuarantineReleasePasses(cfg.Test.QuarantineReleasePasses)
+	}
+	if ts, ok := testService.(interface{ SetContractResolver(service.ContractResolver) }); ok {
+		ts.SetContractResolver(contractResolver)
+	}
+	// Stream group run progress over the WebSocket hub
+	if ts, ok := testService.(interface{ SetRunPublisher(service.TestRunPublisher) }); ok {
+		ts.SetRunPublisher(hub)
+	}
+
+	scoringService := service.NewScoringService(caseRepo, resultRepo, projectRepo)
+	if cfg.Test.ScoreInterval > 0 {
+		scoringService.Start(context.Background(), time.Duration(cfg.Test.ScoreInterval)*time.Minute)
+	}
+
+	// Test plans run saved selections on their schedule and retry their failures
+	planService := service.NewTestPlanService(repository.NewTestPlanRepository(db), runRepo, testService, envService)
+	if ts, ok := testService.(interface{ SetRunListener(service.TestRunListener) }); ok {
+		ts.SetRunListener(planService)
+	}
+	planService.Start(context.Background(), time.Minute)
 
 	workflowService := service.NewWorkflowService(workflowRepo, workflowRunRepo, stepExecRepo, stepLogRepo, nil, workflowExecutor)
 	actionTemplateService := service.NewActionTemplateService(actionTemplateRepo)
@@ -121,10 +180,14 @@ func main() {
 	testHandler := handler.NewTestHandler(testService)
 	envHandler := handler.NewEnvironmentHandler(envService)
 	workflowHandler := handler.NewWorkflowHandler(workflowService)
-	wsHandler := handler.NewWebSocketHandler(hub)
+	wsHandler := handler.NewWebSocketHandler(hub, runRepo)
 	userService := service.NewUserService(roleRepo)
 	userHandler := handler.NewUserHandler(userService)
 	actionTemplateHandler := handler.NewActionTemplateHandler(actionTemplateService)
+	schemaHandler := handler.NewSchemaHandler(schemaService)
+	snapshotHandler := handler.NewSnapshotHandler(snapshotService)
+	scoringHandler := handler.NewScoringHandler(scoringService)
+	planHandler := handler.NewTestPlanHandler(planService)
 
 	// Setup Gin router
 	r := gin.Default()
@@ -154,6 +217,10 @@ func main() {
 		workflowHandler.RegisterRoutes(api)
 		wsHandler.RegisterRoutes(api)
 		actionTemplateHandler.RegisterRoutes(api)
+		schemaHandler.RegisterRoutes(api)
+		snapshotHandler.RegisterRoutes(api)
+		scoringHandler.RegisterRoutes(api)
+		planHandler.RegisterRoutes(api)
 	}
 
 	// Serve static files (Web UI)
@@ -0,0 +1,181 @@
+// Package assertion evaluates assertions with a shared operator registry, so
+// test cases, test steps and workflows judge the same input the same way.
+package assertion
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"test-management-service/internal/jsonpath"
+	"test-management-service/internal/models"
+)
+
+// DefaultOperator is used when an assertion does not name an operator
+const DefaultOperator = "equals"
+
+// Missing is the actual value of an assertion whose path selected nothing.
+// It differs from nil, which is a JSON null that is present.
+var Missing interface{} = missing{}
+
+type missing struct{}
+
+func (missing) String() string { return "<missing>" }
+
+// Operator checks an actual value against the expected one. It returns nil
+// when the assertion holds, or an error describing the mismatch. The actual
+// value is Missing when the assertion path selected nothing.
+type Operator func(actual, expected interface{}) error
+
+// Assertion is a single check of a subject value
+type Assertion struct {
+	Name     string      // optional label reported with the result
+	Target   string      // what the subject is, e.g. "status_code", "body", "stdout"
+	Operator string      // registered operator name or alias; defaults to equals
+	Path     string      // JSONPath into the subject; empty checks the subject itself
+	Expected interface{} // expected value, the operand of the operator
+	Message  string      // custom failure message, prefixed to the mismatch
+}
+
+// Registry maps operator names and aliases to operators. Names are matched
+// case-insensitively, ignoring '_' and '-', so "not_equals" and "notEquals"
+// are the same operator.
+type Registry struct {
+	mu        sync.RWMutex
+	operators map[string]registered
+}
+
+type registered struct {
+	name string // canonical name, reported in results
+	op   Operator
+}
+
+// NewRegistry creates a registry holding the built-in operators
+func NewRegistry() *Registry {
+	r := &Registry{operators: make(map[string]registered)}
+	registerBuiltins(r)
+	return r
+}
+
+// Register adds an operator under a canonical name and optional aliases,
+// replacing any operator already registered under those names
+func (r *Registry) Register(name string, op Operator, aliases ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range append([]string{name}, aliases...) {
+		r.operators[normalizeName(n)] = registered{name: name, op: op}
+	}
+}
+
+// Lookup returns the canonical name and operator registered under name
+func (r *Registry) Lookup(name string) (string, Operator, bool) {
+	if name == "" {
+		name = DefaultOperator
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.operators[normalizeName(name)]
+	return entry.name, entry.op, ok
+}
+
+// Operators returns the canonical names of all registered operators
+func (r *Registry) Operators() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(r.operators))
+	for _, entry := range r.operators {
+		if !seen[entry.name] {
+			seen[entry.name] = true
+			names = append(names, entry.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Evaluate checks the assertion against subject and returns the structured result
+func (r *Registry) Evaluate(a Assertion, subject interface{}) models.AssertionResult {
+	result := models.AssertionResult{
+		Name:     a.Name,
+		Type:     a.Operator,
+		Target:   a.Target,
+		Path:     a.Path,
+		Expected: a.Expected,
+	}
+	fail := func(err error) models.AssertionResult {
+		result.Message = err.Error()
+		if a.Message != "" {
+			result.Message = a.Message + ": " + result.Message
+		}
+		return result
+	}
+
+	name, op, ok := r.Lookup(a.Operator)
+	if !ok {
+		return fail(fmt.Errorf("unsupported operator %q", a.Operator))
+	}
+	result.Type = name
+
+	actual := subject
+	if a.Path != "" {
+		value, found, err := jsonpath.Lookup(subject, a.Path)
+		if err != nil {
+			return fail(err)
+		}
+		actual = Missing
+		if found {
+			actual = value
+		}
+	}
+	if actual != Missing {
+		result.Actual = actual
+	}
+
+	if err := op(actual, a.Expected); err != nil {
+		return fail(err)
+	}
+	result.Passed = true
+	return result
+}
+
+// Check runs the named operator directly on a value
+func (r *Registry) Check(operator string, actual, expected interface{}) error {
+	_, op, ok := r.Lookup(operator)
+	if !ok {
+		return fmt.Errorf("unsupported operator %q", operator)
+	}
+	return op(actual, expected)
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "", " ", "").Replace(name))
+}
+
+var defaultRegistry = NewRegistry()
+
+// Register adds an operator to the default registry
+func Register(name string, op Operator, aliases ...string) {
+	defaultRegistry.Register(name, op, aliases...)
+}
+
+// Lookup finds an operator in the default registry
+func Lookup(name string) (string, Operator, bool) {
+	return defaultRegistry.Lookup(name)
+}
+
+// Operators lists the operators of the default registry
+func Operators() []string {
+	return defaultRegistry.Operators()
+}
+
+// Evaluate checks an assertion with the default registry
+func Evaluate(a Assertion, subject interface{}) models.AssertionResult {
+	return defaultRegistry.Evaluate(a, subject)
+}
+
+// Check runs an operator of the default registry on a value
+func Check(operator string, actual, expected interface{}) error {
+	return defaultRegistry.Check(operator, actual, expected)
+}
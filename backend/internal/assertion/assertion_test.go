@@ -0,0 +1,147 @@
+package assertion
+
+import (
+	"errors"
+	"testing"
+
+	"test-management-service/internal/expression"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		operator string
+		actual   interface{}
+		expected interface{}
+		pass     bool
+	}{
+		{"equals", 3, float64(3), true},
+		{"equals", "3", 3, false},
+		{"eq", map[string]interface{}{"a": []interface{}{1}}, map[string]interface{}{"a": []interface{}{1.0}}, true},
+		{"not_equals", 1, 2, true},
+		{"notEquals", 1, 1.0, false},
+		{"contains", "hello world", "world", true},
+		{"contains", []interface{}{"a", 2}, 2, true},
+		{"contains", []string{"a"}, "b", false},
+		{"contains", map[string]interface{}{"id": 1}, "id", true},
+		{"not_contains", "hello", "x", true},
+		{"startsWith", "hello", "he", true},
+		{"endsWith", "hello", "he", false},
+		{"regex", "test@example.com", `^[a-z]+@[a-z]+\.[a-z]+$`, true},
+		{"notMatches", "abc", `\d`, true},
+		{"gt", 10, 5, true},
+		{"greaterThan", "10", 9, true},
+		{"gte", 5, 5.0, true},
+		{"lt", "a", "b", true},
+		{"lessThanOrEqual", 6, 5, false},
+		{"gt", "abc", 1, false},
+		{"between", 5, []interface{}{1, 10}, true},
+		{"between", 11, map[string]interface{}{"min": 1, "max": 10}, false},
+		{"between", 0, map[string]interface{}{"max": 10}, true},
+		{"in", 404, []interface{}{200, 404}, true},
+		{"in", "x", []string{"a", "b"}, false},
+		{"not_in", "x", []string{"a", "b"}, true},
+		{"length", []interface{}{1, 2, 3}, 3, true},
+		{"arrayLength", "héllo", 5, true},
+		{"length", 42, 2, false},
+		{"minLength", map[string]interface{}{"a": 1}, 2, false},
+		{"maxLength", "abc", 3, true},
+		{"type", "x", "string", true},
+		{"type", 3.0, "integer", true},
+		{"type", 3.5, "integer", false},
+		{"typeof", []int{1}, "array", true},
+		{"type", map[string]interface{}{}, "object", true},
+		{"type", nil, "null", true},
+		{"exists", nil, nil, true},
+		{"exists", Missing, nil, false},
+		{"not_exists", Missing, nil, true},
+		{"isNull", Missing, nil, true},
+		{"notNull", nil, nil, false},
+		{"empty", []interface{}{}, nil, true},
+		{"notEmpty", "", nil, false},
+		{"equals", Missing, nil, false},
+	}
+	for _, tt := range tests {
+		err := Check(tt.operator, tt.actual, tt.expected)
+		assert.Equal(t, tt.pass, err == nil, "%s(%v, %v): %v", tt.operator, tt.actual, tt.expected, err)
+	}
+
+	assert.Error(t, Check("nope", 1, 1))
+	assert.Error(t, Check("regex", "x", "("))
+}
+
+func TestEvaluate(t *testing.T) {
+	body := map[string]interface{}{
+		"items": []interface{}{map[string]interface{}{"id": "a"}, map[string]interface{}{"id": "b"}},
+	}
+
+	result := Evaluate(Assertion{Target: "body", Path: "$.items[*].id", Operator: "contains", Expected: "b"}, body)
+	assert.True(t, result.Passed, result.Message)
+	assert.Equal(t, "contains", result.Type)
+	assert.Equal(t, "$.items[*].id", result.Path)
+	assert.Equal(t, []interface{}{"a", "b"}, result.Actual)
+
+	result = Evaluate(Assertion{Path: "$.items.length()", Expected: 3, Message: "two items"}, body)
+	assert.False(t, result.Passed)
+	assert.Equal(t, "equals", result.Type)
+	assert.Equal(t, "two items: expected 3, got 2", result.Message)
+
+	result = Evaluate(Assertion{Path: "$.total", Operator: "gt", Expected: 0}, body)
+	assert.False(t, result.Passed)
+	assert.Nil(t, result.Actual)
+	assert.Equal(t, "not found", result.Message)
+
+	result = Evaluate(Assertion{Path: "$.items[?(", Operator: "exists"}, body)
+	assert.False(t, result.Passed)
+	assert.Contains(t, result.Message, "invalid JSONPath")
+
+	result = Evaluate(Assertion{Operator: "isBlue"}, body)
+	assert.False(t, result.Passed)
+	assert.Contains(t, result.Message, "unsupported operator")
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Register("even", func(actual, _ interface{}) error {
+		if n, ok := number(actual); !ok || int(n)%2 != 0 {
+			return errors.New("should be even")
+		}
+		return nil
+	}, "is_even")
+
+	name, _, ok := r.Lookup("IS-EVEN")
+	require.True(t, ok)
+	assert.Equal(t, "even", name)
+	assert.True(t, r.Evaluate(Assertion{Operator: "isEven"}, 4).Passed)
+	assert.Contains(t, r.Operators(), "even")
+
+	// Custom registries don't leak into the default one
+	_, _, ok = Lookup("even")
+	assert.False(t, ok)
+	assert.Contains(t, Operators(), "between")
+}
+
+func TestEvaluateExpr(t *testing.T) {
+	evaluator := expression.NewEvaluator(map[string]interface{}{
+		"item": map[string]interface{}{"qty": 2, "total": 20},
+	}, nil)
+
+	result := EvaluateExpr("body", "{{item.total > 10 && item.qty >= 1}}", evaluator)
+	assert.True(t, result.Passed, result.Message)
+	assert.Equal(t, "expr", result.Type)
+	assert.Equal(t, true, result.Actual)
+
+	result = EvaluateExpr("body", "item.total < 10", evaluator)
+	assert.False(t, result.Passed)
+	assert.Equal(t, "expression is false\nitem.total < 10\n|          |\n|          false\n20", result.Message)
+
+	result = EvaluateExpr("body", "item.qty", evaluator)
+	assert.False(t, result.Passed)
+	assert.Contains(t, result.Message, "evaluated to 2, not a boolean")
+
+	result = EvaluateExpr("body", "item.missing == 1", evaluator)
+	assert.False(t, result.Passed)
+	assert.Contains(t, result.Message, "cannot evaluate expression")
+}
@@ -0,0 +1,38 @@
+package assertion
+
+import (
+	"fmt"
+
+	"test-management-service/internal/expression"
+	"test-management-service/internal/models"
+)
+
+// ExprType is the assertion type of boolean expression assertions
+const ExprType = "expr"
+
+// EvaluateExpr checks that a boolean expression holds, evaluated with
+// evaluator. A failing expression reports a power-assert diagram with the
+// value of each sub-expression, so the message shows why it was false.
+func EvaluateExpr(target, expr string, evaluator *expression.Evaluator) models.AssertionResult {
+	result := models.AssertionResult{Type: ExprType, Target: target, Expected: expr}
+	if expr == "" {
+		result.Message = "expression is required"
+		return result
+	}
+
+	value, trace, err := evaluator.Trace(expr)
+	if err != nil {
+		result.Message = fmt.Sprintf("cannot evaluate expression: %v", err)
+		return result
+	}
+	result.Actual = value
+
+	if holds, ok := value.(bool); !ok {
+		result.Message = fmt.Sprintf("expression evaluated to %s, not a boolean\n%s", expression.FormatValue(value), trace)
+	} else if !holds {
+		result.Message = fmt.Sprintf("expression is false\n%s", trace)
+	} else {
+		result.Passed = true
+	}
+	return result
+}
@@ -0,0 +1,377 @@
+package assertion
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"test-management-service/internal/jsonpath"
+)
+
+func registerBuiltins(r *Registry) {
+	r.Register("equals", present(equals), "eq", "equal", "==")
+	r.Register("notEquals", present(notEquals), "ne", "notEqual", "!=")
+	r.Register("contains", present(contains))
+	r.Register("notContains", present(negate(contains, "should not contain %v")))
+	r.Register("startsWith", present(startsWith))
+	r.Register("endsWith", present(endsWith))
+	r.Register("regex", present(matches), "regexp", "matches", "match")
+	r.Register("notRegex", present(negate(matches, "should not match /%v/")), "notMatches")
+	r.Register("gt", present(compare(">", func(c int) bool { return c > 0 })), "greaterThan", ">")
+	r.Register("gte", present(compare(">=", func(c int) bool { return c >= 0 })), "greaterThanOrEqual", ">=")
+	r.Register("lt", present(compare("<", func(c int) bool { return c < 0 })), "lessThan", "<")
+	r.Register("lte", present(compare("<=", func(c int) bool { return c <= 0 })), "lessThanOrEqual", "<=")
+	r.Register("between", present(between), "range")
+	r.Register("in", present(in), "oneOf")
+	r.Register("notIn", present(negate(in, "should not be one of %v")))
+	r.Register("length", present(length), "len", "arrayLength", "size")
+	r.Register("minLength", present(minLength))
+	r.Register("maxLength", present(maxLength))
+	r.Register("type", present(hasType), "typeOf")
+	r.Register("exists", exists)
+	r.Register("notExists", notExists)
+	r.Register("isNull", isNull, "null")
+	r.Register("notNull", present(notNull))
+	r.Register("empty", present(empty), "isEmpty")
+	r.Register("notEmpty", present(negate(empty, "should not be empty")))
+}
+
+// present fails operators that need a value when the path selected nothing
+func present(op Operator) Operator {
+	return func(actual, expected interface{}) error {
+		if actual == Missing {
+			return fmt.Errorf("not found")
+		}
+		return op(actual, expected)
+	}
+}
+
+// negate inverts an operator; format receives the expected value
+func negate(op Operator, format string) Operator {
+	return func(actual, expected interface{}) error {
+		if op(actual, expected) == nil {
+			return fmt.Errorf(format+", got %v", expected, actual)
+		}
+		return nil
+	}
+}
+
+func equals(actual, expected interface{}) error {
+	if !jsonpath.Equal(actual, expected) {
+		return fmt.Errorf("expected %v, got %v", expected, actual)
+	}
+	return nil
+}
+
+func notEquals(actual, expected interface{}) error {
+	if jsonpath.Equal(actual, expected) {
+		return fmt.Errorf("should not equal %v", expected)
+	}
+	return nil
+}
+
+// contains checks a substring, an array element or an object key
+func contains(actual, expected interface{}) error {
+	switch value := jsonpath.Normalize(actual).(type) {
+	case string:
+		if strings.Contains(value, text(expected)) {
+			return nil
+		}
+	case []interface{}:
+		for _, item := range value {
+			if jsonpath.Equal(item, expected) {
+				return nil
+			}
+		}
+	case map[string]interface{}:
+		if _, ok := value[text(expected)]; ok {
+			return nil
+		}
+	case nil:
+		return fmt.Errorf("cannot check whether null contains %v", expected)
+	default:
+		if strings.Contains(text(actual), text(expected)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("should contain %v, got %v", expected, actual)
+}
+
+func startsWith(actual, expected interface{}) error {
+	if !strings.HasPrefix(text(actual), text(expected)) {
+		return fmt.Errorf("should start with %v, got %v", expected, actual)
+	}
+	return nil
+}
+
+func endsWith(actual, expected interface{}) error {
+	if !strings.HasSuffix(text(actual), text(expected)) {
+		return fmt.Errorf("should end with %v, got %v", expected, actual)
+	}
+	return nil
+}
+
+func matches(actual, expected interface{}) error {
+	pattern := text(expected)
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid regex pattern %s: %w", pattern, err)
+	}
+	if !re.MatchString(text(actual)) {
+		return fmt.Errorf("should match /%s/, got %v", pattern, actual)
+	}
+	return nil
+}
+
+// compare orders numbers, or strings when neither side is numeric
+func compare(symbol string, holds func(int) bool) Operator {
+	return func(actual, expected interface{}) error {
+		c, err := order(actual, expected)
+		if err != nil {
+			return err
+		}
+		if !holds(c) {
+			return fmt.Errorf("should be %s %v, got %v", symbol, expected, actual)
+		}
+		return nil
+	}
+}
+
+func order(a, b interface{}) (int, error) {
+	an, aok := number(a)
+	bn, bok := number(b)
+	switch {
+	case aok && bok:
+		switch {
+		case an < bn:
+			return -1, nil
+		case an > bn:
+			return 1, nil
+		}
+		return 0, nil
+	case !aok && !bok:
+		as, aIsString := a.(string)
+		bs, bIsString := b.(string)
+		if aIsString && bIsString {
+			return strings.Compare(as, bs), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s %v with %s %v", typeName(a), a, typeName(b), b)
+}
+
+// between checks min <= actual <= max; expected is [min, max] or {"min", "max"}
+func between(actual, expected interface{}) error {
+	var low, high interface{}
+	switch bounds := jsonpath.Normalize(expected).(type) {
+	case []interface{}:
+		if len(bounds) != 2 {
+			return fmt.Errorf("between expects [min, max], got %v", expected)
+		}
+		low, high = bounds[0], bounds[1]
+	case map[string]interface{}:
+		low, high = bounds["min"], bounds["max"]
+	default:
+		return fmt.Errorf("between expects [min, max], got %v", expected)
+	}
+
+	for _, check := range []struct {
+		bound interface{}
+		ok    func(int) bool
+	}{{low, func(c int) bool { return c >= 0 }}, {high, func(c int) bool { return c <= 0 }}} {
+		if check.bound == nil {
+			continue
+		}
+		c, err := order(actual, check.bound)
+		if err != nil {
+			return err
+		}
+		if !check.ok(c) {
+			return fmt.Errorf("should be between %v and %v, got %v", low, high, actual)
+		}
+	}
+	return nil
+}
+
+func in(actual, expected interface{}) error {
+	options, ok := jsonpath.Normalize(expected).([]interface{})
+	if !ok {
+		return fmt.Errorf("expected value must be a list, got %v", expected)
+	}
+	for _, option := range options {
+		if jsonpath.Equal(actual, option) {
+			return nil
+		}
+	}
+	return fmt.Errorf("should be one of %v, got %v", expected, actual)
+}
+
+func length(actual, expected interface{}) error {
+	return checkLength(actual, expected, "have length", func(n, want int) bool { return n == want })
+}
+
+func minLength(actual, expected interface{}) error {
+	return checkLength(actual, expected, "have length at least", func(n, want int) bool { return n >= want })
+}
+
+func maxLength(actual, expected interface{}) error {
+	return checkLength(actual, expected, "have length at most", func(n, want int) bool { return n <= want })
+}
+
+func checkLength(actual, expected interface{}, want string, holds func(n, want int) bool) error {
+	n, ok := lengthOf(actual)
+	if !ok {
+		return fmt.Errorf("%s %v has no length", typeName(actual), actual)
+	}
+	limit, ok := number(expected)
+	if !ok || limit != math.Trunc(limit) {
+		return fmt.Errorf("expected length must be an integer, got %v", expected)
+	}
+	if !holds(n, int(limit)) {
+		return fmt.Errorf("should %s %v, got %d", want, expected, n)
+	}
+	return nil
+}
+
+func lengthOf(v interface{}) (int, bool) {
+	if s, ok := v.(string); ok {
+		return utf8.RuneCountInString(s), true
+	}
+	if v == nil {
+		return 0, false
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len(), true
+	}
+	return 0, false
+}
+
+// typeAliases maps accepted type names to JSON types
+var typeAliases = map[string]string{
+	"string": "string", "str": "string",
+	"number": "number", "float": "number", "double": "number", "float64": "number",
+	"integer": "integer", "int": "integer",
+	"boolean": "boolean", "bool": "boolean",
+	"object": "object", "map": "object", "dict": "object",
+	"array": "array", "list": "array", "slice": "array",
+	"null": "null", "nil": "null",
+}
+
+func hasType(actual, expected interface{}) error {
+	want, ok := typeAliases[strings.ToLower(text(expected))]
+	if !ok {
+		return fmt.Errorf("unknown type %v", expected)
+	}
+	got := typeName(actual)
+	if got == want {
+		return nil
+	}
+	if want == "integer" {
+		if n, ok := number(actual); ok && got == "number" && n == math.Trunc(n) {
+			return nil
+		}
+	}
+	return fmt.Errorf("should be of type %s, got %s", want, got)
+}
+
+func exists(actual, _ interface{}) error {
+	if actual == Missing {
+		return fmt.Errorf("should exist")
+	}
+	return nil
+}
+
+func notExists(actual, _ interface{}) error {
+	if actual != Missing {
+		return fmt.Errorf("should not exist, got %v", actual)
+	}
+	return nil
+}
+
+func isNull(actual, _ interface{}) error {
+	if actual != nil && actual != Missing {
+		return fmt.Errorf("should be null, got %v", actual)
+	}
+	return nil
+}
+
+func notNull(actual, _ interface{}) error {
+	if actual == nil {
+		return fmt.Errorf("should not be null")
+	}
+	return nil
+}
+
+func empty(actual, _ interface{}) error {
+	if actual == nil {
+		return nil
+	}
+	if n, ok := lengthOf(actual); ok && n == 0 {
+		return nil
+	}
+	return fmt.Errorf("should be empty, got %v", actual)
+}
+
+// number converts Go numbers and numeric strings to float64
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case nil, bool:
+		return 0, false
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	if s, ok := v.(fmt.Stringer); ok {
+		f, err := strconv.ParseFloat(s.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// typeName returns the JSON type of a value
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case missing:
+		return "missing"
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	if _, ok := number(v); ok {
+		return "number" // json.Number
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func text(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -28,8 +28,15 @@ type DatabaseConfig struct {
 
 // TestConfig 测试配置
 type TestConfig struct {
-	TargetHost   string `toml:"target_host"`   // 被测试服务的地址
-	RegistryPath string `toml:"registry_path"` // 测试用例注册路径（可选，用于导入）
+	TargetHost       string `toml:"target_host"`       // 被测试服务的地址
+	RegistryPath     string `toml:"registry_path"`     // 测试用例注册路径（可选，用于导入）
+	StrictVariables  bool   `toml:"strict_variables"`  // 严格模式：无法解析的 {{ }} 占位符视为错误
+	GroupConcurrency int    `toml:"group_concurrency"` // 分组执行的默认并发数（默认 4）
+
+	AllowedPaths []string `toml:"allowed_paths"` // 用例可读取的文件目录（multipart 文件、TLS 证书），为空时只允许内联内容
+
+	QuarantineReleasePasses int `toml:"quarantine_release_passes"` // 隔离用例连续通过多少次后自动解除（默认 5）
+	ScoreInterval           int `toml:"score_interval"`            // 定期重新计算用例价值评分的间隔（分钟），0 表示不定期计算
 }
 
 // LoadConfig 加载配置文件
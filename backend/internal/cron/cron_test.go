@@ -0,0 +1,61 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2026, time.March, 4, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.March, 4, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.March, 4, 10, 30, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2026, time.March, 5, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", time.Date(2026, time.March, 5, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 jan,jul *", time.Date(2026, time.July, 1, 12, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2026, time.March, 4, 10, 25, 0, 0, time.UTC)},
+		// Day of month or day of week when both are restricted
+		{"0 0 13 * fri", time.Date(2026, time.March, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, s.Next(from))
+		})
+	}
+
+	t.Run("never fires", func(t *testing.T) {
+		s, err := Parse("0 0 30 2 *")
+		require.NoError(t, err)
+		assert.True(t, s.Next(from).IsZero())
+	})
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * * funday",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
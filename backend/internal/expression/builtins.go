@@ -0,0 +1,643 @@
+package expression
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"test-management-service/internal/jsonpath"
+)
+
+func init() {
+	for name, fn := range builtins {
+		RegisterFunction(name, fn)
+	}
+}
+
+// builtins is the standard function library
+var builtins = map[string]Function{
+	// Dates
+	"$now":       {Params: []string{"string?", "string?"}, Call: now},
+	"$timestamp": {Params: []string{"date?"}, Call: timestamp},
+	"$dateAdd":   {Params: []string{"date", "number", "string"}, Call: dateAdd},
+	"$dateDiff":  {Params: []string{"date", "date", "string?"}, Call: dateDiff},
+	"$format":    {Params: []string{"date", "string", "string?"}, Call: format},
+
+	// Strings
+	"$upper":      {Params: []string{"string"}, Call: stringFunc(strings.ToUpper)},
+	"$lower":      {Params: []string{"string"}, Call: stringFunc(strings.ToLower)},
+	"$trim":       {Params: []string{"string", "string?"}, Call: trim},
+	"$substring":  {Params: []string{"string", "integer", "integer?"}, Call: substring},
+	"$replace":    {Params: []string{"string", "string", "string"}, Call: replace},
+	"$split":      {Params: []string{"string", "string"}, Call: split},
+	"$join":       {Params: []string{"array", "string?"}, Call: join},
+	"$padStart":   {Params: []string{"string", "integer", "string?"}, Call: pad(true)},
+	"$padEnd":     {Params: []string{"string", "integer", "string?"}, Call: pad(false)},
+	"$contains":   {Params: []string{"any", "any"}, Call: contains},
+	"$startsWith": {Params: []string{"string", "string"}, Call: stringTest(strings.HasPrefix)},
+	"$endsWith":   {Params: []string{"string", "string"}, Call: stringTest(strings.HasSuffix)},
+
+	// Encoding
+	"$base64Encode": {Params: []string{"string"}, Call: stringFunc(func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) })},
+	"$base64Decode": {Params: []string{"string"}, Call: base64Decode},
+	"$urlEncode":    {Params: []string{"string"}, Call: stringFunc(url.QueryEscape)},
+	"$urlDecode":    {Params: []string{"string"}, Call: urlDecode},
+	"$hexEncode":    {Params: []string{"string"}, Call: stringFunc(func(s string) string { return hex.EncodeToString([]byte(s)) })},
+	"$hexDecode":    {Params: []string{"string"}, Call: hexDecode},
+
+	// Hashing
+	"$md5":    {Params: []string{"string"}, Call: digest(md5.New)},
+	"$sha1":   {Params: []string{"string"}, Call: digest(sha1.New)},
+	"$sha256": {Params: []string{"string"}, Call: digest(sha256.New)},
+	"$sha512": {Params: []string{"string"}, Call: digest(sha512.New)},
+	"$hmac":   {Params: []string{"string", "string", "string", "string?"}, Call: hmacSign},
+
+	// JSON
+	"$jsonParse":     {Params: []string{"string"}, Call: jsonParse},
+	"$jsonStringify": {Params: []string{"any", "boolean?"}, Call: jsonStringify},
+	"$jsonPath":      {Params: []string{"any", "string"}, Call: jsonPath},
+
+	// Collections
+	"$len":        {Params: []string{"any"}, Call: length},
+	"$isEmpty":    {Params: []string{"any?"}, Call: isEmpty},
+	"$isNotEmpty": {Params: []string{"any?"}, Call: isNotEmpty},
+	"$keys":       {Params: []string{"object"}, Call: keys},
+	"$map":        {Params: []string{"array", "lambda"}, Call: mapArray},
+	"$filter":     {Params: []string{"array", "lambda"}, Call: filterArray},
+	"$sort":       {Params: []string{"array", "lambda?"}, Call: sortArray},
+	"$unique":     {Params: []string{"array"}, Call: unique},
+	"$sum":        {Params: []string{"...any"}, Call: sum},
+	"$min":        {Params: []string{"...any"}, Call: extreme(-1)},
+	"$max":        {Params: []string{"...any"}, Call: extreme(1)},
+
+	// Random values; a seed makes them reproducible
+	"$uuid":         {Params: []string{"integer?"}, Call: newUUID},
+	"$random":       {Params: []string{"integer?"}, Call: random},
+	"$randomInt":    {Params: []string{"integer", "integer", "integer?"}, Call: randomInt},
+	"$randomString": {Params: []string{"integer", "integer?"}, Call: randomString},
+	"$randomItem":   {Params: []string{"array", "integer?"}, Call: randomItem},
+}
+
+// Dates
+
+// formatTime renders a time the way date functions return it
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func now(args []interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return time.Now().Format(time.RFC3339), nil
+	}
+	return format(append([]interface{}{time.Now()}, args...))
+}
+
+func timestamp(args []interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return time.Now().Unix(), nil
+	}
+	return args[0].(time.Time).Unix(), nil
+}
+
+func dateAdd(args []interface{}) (interface{}, error) {
+	t, amount, unit := args[0].(time.Time), args[1].(float64), args[2].(string)
+	switch unit {
+	case "M", "month", "months":
+		return formatTime(t.AddDate(0, int(amount), 0)), nil
+	case "y", "year", "years":
+		return formatTime(t.AddDate(int(amount), 0, 0)), nil
+	}
+	d, err := unitDuration(unit)
+	if err != nil {
+		return nil, err
+	}
+	return formatTime(t.Add(time.Duration(amount * float64(d)))), nil
+}
+
+func dateDiff(args []interface{}) (interface{}, error) {
+	from, to := args[0].(time.Time), args[1].(time.Time)
+	unit := "s"
+	if len(args) > 2 {
+		unit = args[2].(string)
+	}
+	d, err := unitDuration(unit)
+	if err != nil {
+		return nil, err
+	}
+	return float64(to.Sub(from)) / float64(d), nil
+}
+
+// unitDuration returns the length of a fixed-size date unit
+func unitDuration(unit string) (time.Duration, error) {
+	switch unit {
+	case "ms", "millisecond", "milliseconds":
+		return time.Millisecond, nil
+	case "s", "second", "seconds":
+		return time.Second, nil
+	case "m", "minute", "minutes":
+		return time.Minute, nil
+	case "h", "hour", "hours":
+		return time.Hour, nil
+	case "d", "day", "days":
+		return 24 * time.Hour, nil
+	case "w", "week", "weeks":
+		return 7 * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("unknown date unit %q (use ms, s, m, h, d, w, M or y)", unit)
+}
+
+// dateTokens translates familiar date tokens to Go layout elements
+var dateTokens = strings.NewReplacer(
+	"YYYY", "2006", "YY", "06", "MM", "01", "DD", "02",
+	"HH", "15", "hh", "03", "mm", "04", "ss", "05", "SSS", "000",
+	"A", "PM", "ZZ", "-0700", "Z", "-07:00",
+)
+
+// format renders a date with a layout of tokens such as "YYYY-MM-DD HH:mm:ss",
+// a Go layout, or one of unix, unixMs and rfc3339, optionally in a time zone
+func format(args []interface{}) (interface{}, error) {
+	t, layout := args[0].(time.Time), args[1].(string)
+	if len(args) > 2 {
+		loc, err := time.LoadLocation(args[2].(string))
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", args[2])
+		}
+		t = t.In(loc)
+	}
+
+	switch layout {
+	case "unix":
+		return t.Unix(), nil
+	case "unixMs":
+		return t.UnixMilli(), nil
+	case "rfc3339", "iso":
+		return formatTime(t), nil
+	}
+	if !strings.Contains(layout, "2006") {
+		layout = dateTokens.Replace(layout)
+	}
+	return t.Format(layout), nil
+}
+
+// Strings
+
+func stringFunc(f func(string) string) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		return f(args[0].(string)), nil
+	}
+}
+
+func stringTest(f func(string, string) bool) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		return f(args[0].(string), args[1].(string)), nil
+	}
+}
+
+func trim(args []interface{}) (interface{}, error) {
+	if len(args) > 1 {
+		return strings.Trim(args[0].(string), args[1].(string)), nil
+	}
+	return strings.TrimSpace(args[0].(string)), nil
+}
+
+// substring returns the characters from start up to end; negative positions
+// count from the end
+func substring(args []interface{}) (interface{}, error) {
+	runes := []rune(args[0].(string))
+	clamp := func(i int) int {
+		if i < 0 {
+			i += len(runes)
+		}
+		return max(0, min(i, len(runes)))
+	}
+	start, end := clamp(args[1].(int)), len(runes)
+	if len(args) > 2 {
+		end = clamp(args[2].(int))
+	}
+	if start >= end {
+		return "", nil
+	}
+	return string(runes[start:end]), nil
+}
+
+func replace(args []interface{}) (interface{}, error) {
+	return strings.ReplaceAll(args[0].(string), args[1].(string), args[2].(string)), nil
+}
+
+func split(args []interface{}) (interface{}, error) {
+	parts := strings.Split(args[0].(string), args[1].(string))
+	result := make([]interface{}, len(parts))
+	for i, part := range parts {
+		result[i] = part
+	}
+	return result, nil
+}
+
+func join(args []interface{}) (interface{}, error) {
+	separator := ","
+	if len(args) > 1 {
+		separator = args[1].(string)
+	}
+	items := args[0].([]interface{})
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = Text(item)
+	}
+	return strings.Join(parts, separator), nil
+}
+
+// pad fills a string to a length in characters with a pad string, a space by default
+func pad(start bool) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		s, length, fill := args[0].(string), args[1].(int), " "
+		if len(args) > 2 {
+			fill = args[2].(string)
+		}
+		missing := length - utf8.RuneCountInString(s)
+		if missing <= 0 || fill == "" {
+			return s, nil
+		}
+		padding := []rune(strings.Repeat(fill, missing))[:missing]
+		if start {
+			return string(padding) + s, nil
+		}
+		return s + string(padding), nil
+	}
+}
+
+// contains checks for a substring, an array element or an object key
+func contains(args []interface{}) (interface{}, error) {
+	switch haystack := args[0].(type) {
+	case string:
+		return strings.Contains(haystack, Text(args[1])), nil
+	case map[string]interface{}:
+		_, ok := haystack[Text(args[1])]
+		return ok, nil
+	}
+	if items, ok := toArray(args[0]); ok {
+		for _, item := range items {
+			if looseEqual(item, args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return nil, fmt.Errorf("argument 1 must be a string, array or object, got %s", FormatValue(args[0]))
+}
+
+// Encoding
+
+func base64Decode(args []interface{}) (interface{}, error) {
+	s := strings.TrimSpace(args[0].(string))
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if data, err := encoding.DecodeString(s); err == nil {
+			return string(data), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid base64 input")
+}
+
+func urlDecode(args []interface{}) (interface{}, error) {
+	s, err := url.QueryUnescape(args[0].(string))
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func hexDecode(args []interface{}) (interface{}, error) {
+	data, err := hex.DecodeString(args[0].(string))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Hashing
+
+func digest(newHash func() hash.Hash) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		h := newHash()
+		h.Write([]byte(args[0].(string)))
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+}
+
+// hmacSign signs a message: $hmac(algorithm, key, message, encoding) with
+// algorithm md5, sha1, sha256 or sha512 and encoding hex (default) or base64
+func hmacSign(args []interface{}) (interface{}, error) {
+	algorithms := map[string]func() hash.Hash{
+		"md5": md5.New, "sha1": sha1.New, "sha256": sha256.New, "sha384": sha512.New384, "sha512": sha512.New,
+	}
+	newHash, ok := algorithms[strings.ToLower(strings.ReplaceAll(args[0].(string), "-", ""))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm %q", args[0])
+	}
+	mac := hmac.New(newHash, []byte(args[1].(string)))
+	mac.Write([]byte(args[2].(string)))
+	sum := mac.Sum(nil)
+
+	encoding := "hex"
+	if len(args) > 3 {
+		encoding = args[3].(string)
+	}
+	switch encoding {
+	case "hex":
+		return hex.EncodeToString(sum), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(sum), nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q (use hex or base64)", encoding)
+}
+
+// JSON
+
+func jsonParse(args []interface{}) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(args[0].(string)), &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func jsonStringify(args []interface{}) (interface{}, error) {
+	var data []byte
+	var err error
+	if len(args) > 1 && args[1].(bool) {
+		data, err = json.MarshalIndent(args[0], "", "  ")
+	} else {
+		data, err = json.Marshal(args[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// jsonPath looks a JSONPath up in a value, or in a JSON string; it returns
+// null when nothing matches
+func jsonPath(args []interface{}) (interface{}, error) {
+	doc := args[0]
+	if s, ok := doc.(string); ok {
+		if err := json.Unmarshal([]byte(s), &doc); err != nil {
+			return nil, fmt.Errorf("argument 1 is not valid JSON: %w", err)
+		}
+	}
+	value, _, err := jsonpath.Lookup(doc, args[1].(string))
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Collections
+
+// length counts the characters of a string or the elements of a collection
+func length(args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case nil:
+		return 0, nil
+	case string:
+		return utf8.RuneCountInString(v), nil
+	case map[string]interface{}:
+		return len(v), nil
+	}
+	if items, ok := toArray(args[0]); ok {
+		return len(items), nil
+	}
+	return nil, fmt.Errorf("argument 1 must be a string, array or object, got %s", FormatValue(args[0]))
+}
+
+func isEmpty(args []interface{}) (interface{}, error) {
+	return len(args) == 0 || isEmptyValue(args[0]), nil
+}
+
+func isNotEmpty(args []interface{}) (interface{}, error) {
+	return len(args) > 0 && !isEmptyValue(args[0]), nil
+}
+
+func keys(args []interface{}) (interface{}, error) {
+	names := make([]string, 0, len(args[0].(map[string]interface{})))
+	for name := range args[0].(map[string]interface{}) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]interface{}, len(names))
+	for i, name := range names {
+		result[i] = name
+	}
+	return result, nil
+}
+
+// mapArray evaluates the lambda for each element: $map(items, it.id)
+func mapArray(args []interface{}) (interface{}, error) {
+	items, fn := args[0].([]interface{}), args[1].(Lambda)
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		value, err := fn(item, i)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = value
+	}
+	return result, nil
+}
+
+// filterArray keeps the elements the lambda holds for: $filter(items, it.price > 10)
+func filterArray(args []interface{}) (interface{}, error) {
+	items, fn := args[0].([]interface{}), args[1].(Lambda)
+	result := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		keep, err := fn(item, i)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(keep) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// sortArray sorts numbers and strings ascending, by the lambda's value when
+// given: $sort(users, it.age)
+func sortArray(args []interface{}) (interface{}, error) {
+	items := args[0].([]interface{})
+	sortKeys := items
+	if len(args) > 1 {
+		fn := args[1].(Lambda)
+		sortKeys = make([]interface{}, len(items))
+		for i, item := range items {
+			key, err := fn(item, i)
+			if err != nil {
+				return nil, err
+			}
+			sortKeys[i] = key
+		}
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	var err error
+	sort.SliceStable(order, func(i, j int) bool {
+		cmp, ok := compare(sortKeys[order[i]], sortKeys[order[j]])
+		if !ok && err == nil {
+			err = fmt.Errorf("cannot compare %s and %s", FormatValue(sortKeys[order[i]]), FormatValue(sortKeys[order[j]]))
+		}
+		return cmp < 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, len(items))
+	for i, index := range order {
+		result[i] = items[index]
+	}
+	return result, nil
+}
+
+func unique(args []interface{}) (interface{}, error) {
+	var result []interface{}
+	for _, item := range args[0].([]interface{}) {
+		seen := false
+		for _, kept := range result {
+			if jsonpath.Equal(item, kept) {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			result = append(result, item)
+		}
+	}
+	if result == nil {
+		result = []interface{}{}
+	}
+	return result, nil
+}
+
+// numbers returns the numbers passed as arguments or as a single array
+func numbers(args []interface{}) ([]float64, error) {
+	if len(args) == 1 {
+		if items, ok := toArray(args[0]); ok {
+			args = items
+		}
+	}
+	result := make([]float64, len(args))
+	for i, arg := range args {
+		f, ok := toNumber(arg)
+		if !ok {
+			return nil, fmt.Errorf("expects numbers, got %s", FormatValue(arg))
+		}
+		result[i] = f
+	}
+	return result, nil
+}
+
+func sum(args []interface{}) (interface{}, error) {
+	values, err := numbers(args)
+	if err != nil {
+		return nil, err
+	}
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total, nil
+}
+
+// extreme returns the smallest (sign -1) or largest (sign 1) number
+func extreme(sign float64) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		values, err := numbers(args)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("needs at least one number")
+		}
+		best := values[0]
+		for _, v := range values[1:] {
+			if (v-best)*sign > 0 {
+				best = v
+			}
+		}
+		return best, nil
+	}
+}
+
+// Random values
+
+// generator returns a random source seeded by the optional argument at index,
+// or the shared unseeded source
+func generator(args []interface{}, index int) *rand.Rand {
+	if len(args) > index {
+		return rand.New(rand.NewSource(int64(args[index].(int))))
+	}
+	return rand.New(rand.NewSource(rand.Int63()))
+}
+
+func newUUID(args []interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return uuid.New().String(), nil
+	}
+	id, err := uuid.NewRandomFromReader(generator(args, 0))
+	if err != nil {
+		return nil, err
+	}
+	return id.String(), nil
+}
+
+func random(args []interface{}) (interface{}, error) {
+	return generator(args, 0).Float64(), nil
+}
+
+// randomInt returns a whole number between min and max inclusive
+func randomInt(args []interface{}) (interface{}, error) {
+	low, high := args[0].(int), args[1].(int)
+	if high < low {
+		return nil, fmt.Errorf("max %d is less than min %d", high, low)
+	}
+	return low + generator(args, 2).Intn(high-low+1), nil
+}
+
+const randomAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+func randomString(args []interface{}) (interface{}, error) {
+	n := args[0].(int)
+	if n < 0 {
+		return nil, fmt.Errorf("length must not be negative")
+	}
+	r := generator(args, 1)
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = randomAlphabet[r.Intn(len(randomAlphabet))]
+	}
+	return string(b), nil
+}
+
+func randomItem(args []interface{}) (interface{}, error) {
+	items := args[0].([]interface{})
+	if len(items) == 0 {
+		return nil, fmt.Errorf("the array is empty")
+	}
+	return items[generator(args, 1).Intn(len(items))], nil
+}
@@ -0,0 +1,445 @@
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"test-management-service/internal/jsonpath"
+)
+
+// errorf reports an evaluation error at the position of n
+func (e *Evaluator) errorf(expr string, n node, format string, args ...interface{}) error {
+	return &EvalError{Expr: expr, Column: column(expr, n.pos()), Msg: fmt.Sprintf(format, args...)}
+}
+
+// eval evaluates a parsed expression. expr is its source, for error columns.
+func (e *Evaluator) eval(expr string, n node) (interface{}, error) {
+	value, err := e.evalNode(expr, n)
+	if err == nil {
+		switch n.(type) {
+		case *literalNode, *arrayNode:
+		case *identNode, *memberNode, *indexNode, *callNode:
+			// The whole chain is shown under its first character; outer
+			// links are evaluated last and replace inner values
+			e.record(start(n), value)
+		default:
+			e.record(n.pos(), value)
+		}
+	}
+	return value, err
+}
+
+func (e *Evaluator) evalNode(expr string, n node) (interface{}, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *identNode:
+		return e.resolve(expr, n)
+
+	case *memberNode:
+		object, err := e.eval(expr, n.object)
+		if err != nil {
+			return nil, err
+		}
+		value, ok, err := member(object, n.name)
+		if err != nil {
+			return nil, e.errorf(expr, n, "%v", err)
+		}
+		if !ok {
+			return nil, e.errorf(expr, n, "field not found: %s", n.name)
+		}
+		return value, nil
+
+	case *indexNode:
+		object, err := e.eval(expr, n.object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := e.eval(expr, n.index)
+		if err != nil {
+			return nil, err
+		}
+		return e.index(expr, n, object, index)
+
+	case *callNode:
+		return e.call(expr, n)
+
+	case *arrayNode:
+		elements := make([]interface{}, len(n.elements))
+		for i, element := range n.elements {
+			value, err := e.eval(expr, element)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = value
+		}
+		return elements, nil
+
+	case *unaryNode:
+		operand, err := e.eval(expr, n.operand)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			return !truthy(operand), nil
+		}
+		f, ok := toNumber(operand)
+		if !ok {
+			return nil, e.errorf(expr, n, "operator %s needs a number, got %s", n.op, FormatValue(operand))
+		}
+		if n.op == "-" {
+			return -f, nil
+		}
+		return f, nil
+
+	case *conditionalNode:
+		cond, err := e.eval(expr, n.cond)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(cond) {
+			return e.eval(expr, n.then)
+		}
+		return e.eval(expr, n.other)
+
+	case *binaryNode:
+		return e.evalBinary(expr, n)
+	}
+	return nil, e.errorf(expr, n, "unsupported expression")
+}
+
+// resolve looks a name up in the lambda scope, the variables, then the node
+// outputs. "nodes" names the node outputs themselves, and a function name
+// without parentheses calls it, so {{$now}} works like {{$now()}}.
+func (e *Evaluator) resolve(expr string, n *identNode) (interface{}, error) {
+	if value, ok := e.scope[n.name]; ok {
+		return value, nil
+	}
+	if n.name == "nodes" {
+		return e.nodeOutputs, nil
+	}
+	if value, ok := e.variables[n.name]; ok {
+		return value, nil
+	}
+	if value, ok := e.nodeOutputs[n.name]; ok {
+		return value, nil
+	}
+	if fn, ok := lookupFunction(n.name); ok {
+		value, err := fn.invoke(n.name, nil)
+		if err != nil {
+			return nil, e.errorf(expr, n, "%v", err)
+		}
+		return value, nil
+	}
+	return nil, e.errorf(expr, n, "variable not found: %s", n.name)
+}
+
+// index returns object[index] for arrays (negative indexes count from the
+// end) and objects
+func (e *Evaluator) index(expr string, n *indexNode, object, index interface{}) (interface{}, error) {
+	if key, ok := index.(string); ok {
+		value, found, err := member(object, key)
+		if err != nil {
+			return nil, e.errorf(expr, n, "%v", err)
+		}
+		if !found {
+			return nil, e.errorf(expr, n, "field not found: %s", key)
+		}
+		return value, nil
+	}
+
+	f, ok := toNumber(index)
+	if !ok || f != math.Trunc(f) {
+		return nil, e.errorf(expr, n, "invalid array index: %s", FormatValue(index))
+	}
+	rv := reflect.ValueOf(object)
+	if object == nil || rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, e.errorf(expr, n, "cannot index %s", kind(object))
+	}
+	i := int(f)
+	if i < 0 {
+		i += rv.Len()
+	}
+	if i < 0 || i >= rv.Len() {
+		return nil, e.errorf(expr, n, "array index out of bounds: %d", int(f))
+	}
+	return rv.Index(i).Interface(), nil
+}
+
+// member returns a field of an object, map or struct. Numeric names also
+// index arrays, so dotted paths like items.0.name keep working.
+func member(object interface{}, name string) (interface{}, bool, error) {
+	switch v := object.(type) {
+	case nil:
+		return nil, false, fmt.Errorf("cannot access field %s of null", name)
+	case map[string]interface{}:
+		value, ok := v[name]
+		return value, ok, nil
+	case []interface{}:
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(v) {
+			return v[i], true, nil
+		}
+		return nil, false, nil
+	}
+
+	rv := reflect.ValueOf(object)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, false, fmt.Errorf("cannot access field %s of null", name)
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			value := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+			if !value.IsValid() {
+				return nil, false, nil
+			}
+			return value.Interface(), true, nil
+		}
+	case reflect.Struct:
+		field := rv.FieldByName(name)
+		if !field.IsValid() || !field.CanInterface() {
+			return nil, false, nil
+		}
+		return field.Interface(), true, nil
+	case reflect.Slice, reflect.Array:
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < rv.Len() {
+			return rv.Index(i).Interface(), true, nil
+		}
+		return nil, false, nil
+	}
+	return nil, false, fmt.Errorf("cannot access field %s on %s", name, kind(object))
+}
+
+func (e *Evaluator) evalBinary(expr string, n *binaryNode) (interface{}, error) {
+	left, err := e.eval(expr, n.left)
+	if err != nil {
+		return nil, err
+	}
+
+	// && and || only evaluate the right side when it decides the result
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := e.eval(expr, n.right)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := e.eval(expr, n.right)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	}
+
+	right, err := e.eval(expr, n.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return looseEqual(left, right), nil
+	case "!=":
+		return !looseEqual(left, right), nil
+	case "===":
+		return strictEqual(left, right), nil
+	case "!==":
+		return !strictEqual(left, right), nil
+	case "<", "<=", ">", ">=":
+		cmp, ok := compare(left, right)
+		if !ok {
+			return nil, e.errorf(expr, n, "cannot compare %s %s %s", FormatValue(left), n.op, FormatValue(right))
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	case "+":
+		_, leftString := left.(string)
+		_, rightString := right.(string)
+		if leftString || rightString {
+			return Text(left) + Text(right), nil
+		}
+	}
+
+	a, aok := toNumber(left)
+	b, bok := toNumber(right)
+	if !aok || !bok {
+		return nil, e.errorf(expr, n, "operator %s needs numbers, got %s and %s", n.op, FormatValue(left), FormatValue(right))
+	}
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, e.errorf(expr, n, "division by zero")
+		}
+		return a / b, nil
+	case "%":
+		if b == 0 {
+			return nil, e.errorf(expr, n, "division by zero")
+		}
+		return math.Mod(a, b), nil
+	}
+	return nil, e.errorf(expr, n, "unsupported operator: %s", n.op)
+}
+
+// kind classifies a value for typed comparison
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if _, ok := number(v); ok {
+		return "number"
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "null"
+		}
+		return kind(rv.Elem().Interface())
+	}
+	return "object"
+}
+
+// strictEqual compares values of the same kind only: 1 === '1' is false
+func strictEqual(a, b interface{}) bool {
+	return kind(a) == kind(b) && jsonpath.Equal(a, b)
+}
+
+// looseEqual also matches a number with its string form and a boolean with
+// 'true' or 'false', as placeholders often hold strings
+func looseEqual(a, b interface{}) bool {
+	ka, kb := kind(a), kind(b)
+	switch {
+	case ka == "number" && kb == "string", ka == "string" && kb == "number":
+		x, xok := toNumber(a)
+		y, yok := toNumber(b)
+		return xok && yok && x == y
+	case ka == "boolean" && kb == "string", ka == "string" && kb == "boolean":
+		return Text(a) == Text(b)
+	}
+	return jsonpath.Equal(a, b)
+}
+
+// compare orders numbers (and numeric strings) by value and other strings
+// lexically; ok is false when the values cannot be ordered
+func compare(a, b interface{}) (int, bool) {
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	x, xok := a.(string)
+	y, yok := b.(string)
+	if !xok || !yok {
+		return 0, false
+	}
+	return strings.Compare(x, y), true
+}
+
+// number converts Go and JSON numbers to float64
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toNumber converts numbers and numeric strings to float64
+func toNumber(v interface{}) (float64, bool) {
+	if s, ok := v.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return f, err == nil
+	}
+	return number(v)
+}
+
+// truthy reports whether a value counts as true in a condition: false, null,
+// zero, empty strings, "false", "0" and empty collections do not
+func truthy(v interface{}) bool {
+	switch b := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return b
+	case string:
+		return b != "" && b != "false" && b != "0"
+	}
+	if f, ok := number(v); ok {
+		return f != 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() > 0
+	case reflect.Ptr, reflect.Interface:
+		return !rv.IsNil()
+	}
+	return true
+}
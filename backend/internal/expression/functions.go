@@ -0,0 +1,273 @@
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Function is a built-in function expressions can call as $name(args).
+//
+// Params lists the parameter types, checked before Call runs:
+//
+//	any      any value
+//	string   a string; numbers and booleans are converted
+//	number   a number or numeric string, passed as float64
+//	integer  a whole number, passed as int
+//	boolean  a boolean
+//	array    any array, passed as []interface{}
+//	object   an object, passed as map[string]interface{}
+//	date     an RFC 3339 or date string, or a unix timestamp, passed as time.Time
+//	lambda   an expression evaluated per element, passed as Lambda
+//
+// A "?" suffix marks an optional parameter (only trailing parameters may be
+// optional) and a "..." prefix on the last one accepts any number of values.
+type Function struct {
+	Params []string
+	Call   func(args []interface{}) (interface{}, error)
+}
+
+// Lambda evaluates a lambda argument for one element, with the element bound
+// to "it" and its position to "index"
+type Lambda func(it interface{}, index int) (interface{}, error)
+
+var (
+	functionsMu sync.RWMutex
+	functions   = make(map[string]Function)
+)
+
+// RegisterFunction adds a function to the library, replacing any function
+// with the same name. Names start with "$".
+func RegisterFunction(name string, fn Function) {
+	if !strings.HasPrefix(name, "$") {
+		name = "$" + name
+	}
+	functionsMu.Lock()
+	functions[name] = fn
+	functionsMu.Unlock()
+}
+
+func lookupFunction(name string) (Function, bool) {
+	functionsMu.RLock()
+	fn, ok := functions[name]
+	functionsMu.RUnlock()
+	return fn, ok
+}
+
+// param returns the type of the i-th argument, without markers
+func (f Function) param(i int) string {
+	if len(f.Params) == 0 {
+		return ""
+	}
+	if i >= len(f.Params) {
+		last := f.Params[len(f.Params)-1]
+		if !strings.HasPrefix(last, "...") {
+			return ""
+		}
+		i = len(f.Params) - 1
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(f.Params[i], "..."), "?")
+}
+
+// arity returns the minimum and maximum argument count; max is -1 when the
+// function is variadic
+func (f Function) arity() (int, int) {
+	min, max := 0, len(f.Params)
+	for _, p := range f.Params {
+		if strings.HasPrefix(p, "...") {
+			return min, -1
+		}
+		if !strings.HasSuffix(p, "?") {
+			min++
+		}
+	}
+	return min, max
+}
+
+// invoke validates and converts args, then calls the function
+func (f Function) invoke(name string, args []interface{}) (interface{}, error) {
+	min, max := f.arity()
+	if len(args) < min || max >= 0 && len(args) > max {
+		return nil, fmt.Errorf("%s: expects %s, got %d", name, describeArity(min, max), len(args))
+	}
+
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		value, err := convertArg(f.param(i), arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: argument %d %v", name, i+1, err)
+		}
+		converted[i] = value
+	}
+
+	value, err := f.Call(converted)
+	var evalErr *EvalError
+	if err != nil && !errors.As(err, &evalErr) {
+		// Errors from lambda arguments already name their position
+		err = fmt.Errorf("%s: %w", name, err)
+	}
+	return value, err
+}
+
+func describeArity(min, max int) string {
+	plural := func(n int) string {
+		if n == 1 {
+			return "1 argument"
+		}
+		return fmt.Sprintf("%d arguments", n)
+	}
+	switch {
+	case max < 0:
+		return "at least " + plural(min)
+	case min == max:
+		return plural(min)
+	}
+	return fmt.Sprintf("%d to %s", min, plural(max))
+}
+
+// convertArg checks an argument against its parameter type
+func convertArg(kind string, value interface{}) (interface{}, error) {
+	switch kind {
+	case "", "any", "lambda":
+		return value, nil
+	case "string":
+		switch value.(type) {
+		case string, bool:
+			return Text(value), nil
+		}
+		if _, ok := number(value); ok {
+			return Text(value), nil
+		}
+	case "number":
+		if f, ok := toNumber(value); ok {
+			return f, nil
+		}
+	case "integer":
+		if f, ok := toNumber(value); ok && f == math.Trunc(f) {
+			return int(f), nil
+		}
+	case "boolean":
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case "array":
+		if array, ok := toArray(value); ok {
+			return array, nil
+		}
+	case "object":
+		if object, ok := value.(map[string]interface{}); ok {
+			return object, nil
+		}
+	case "date":
+		if t, ok := toTime(value); ok {
+			return t, nil
+		}
+	default:
+		return nil, fmt.Errorf("has unknown parameter type %s", kind)
+	}
+	if kind == "integer" {
+		kind = "whole number"
+	}
+	return nil, fmt.Errorf("must be %s %s, got %s", article(kind), kind, FormatValue(value))
+}
+
+func article(word string) string {
+	if strings.ContainsRune("aeiou", rune(word[0])) {
+		return "an"
+	}
+	return "a"
+}
+
+// toArray converts any slice or array to []interface{}
+func toArray(value interface{}) ([]interface{}, bool) {
+	if array, ok := value.([]interface{}); ok {
+		return array, true
+	}
+	rv := reflect.ValueOf(value)
+	if value == nil || rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	array := make([]interface{}, rv.Len())
+	for i := range array {
+		array[i] = rv.Index(i).Interface()
+	}
+	return array, true
+}
+
+// dateLayouts are the date formats accepted as date arguments
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// toTime converts a date string or unix timestamp (seconds, or milliseconds
+// when too large for seconds) to a time
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t, true
+			}
+		}
+	}
+	if f, ok := toNumber(value); ok {
+		if math.Abs(f) >= 1e11 {
+			return time.UnixMilli(int64(f)).UTC(), true
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// lambda wraps an unevaluated argument so a function can evaluate it per element
+func (e *Evaluator) lambda(expr string, n node) Lambda {
+	return func(it interface{}, index int) (interface{}, error) {
+		scoped := *e
+		scoped.trace = nil
+		scoped.scope = map[string]interface{}{"it": it, "index": index}
+		return scoped.eval(expr, n)
+	}
+}
+
+// call evaluates the arguments of a function call and invokes it
+func (e *Evaluator) call(expr string, n *callNode) (interface{}, error) {
+	fn, ok := lookupFunction(n.name)
+	if !ok {
+		return nil, e.errorf(expr, n, "unknown function: %s", n.name)
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		if fn.param(i) == "lambda" {
+			args[i] = e.lambda(expr, arg)
+			continue
+		}
+		value, err := e.eval(expr, arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	value, err := fn.invoke(n.name, args)
+	var evalErr *EvalError
+	if errors.As(err, &evalErr) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, e.errorf(expr, n, "%v", err)
+	}
+	return value, nil
+}
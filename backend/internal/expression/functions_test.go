@@ -0,0 +1,189 @@
+package expression
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	evaluator := NewEvaluator(map[string]interface{}{
+		"created": "2024-01-31T10:00:00Z",
+		"user":    map[string]interface{}{"name": "alice", "roles": []interface{}{"admin", "dev"}},
+		"items": []interface{}{
+			map[string]interface{}{"id": "b", "price": 30},
+			map[string]interface{}{"id": "a", "price": 5},
+			map[string]interface{}{"id": "c", "price": 12},
+		},
+		"body": `{"data":{"token":"t-1"}}`,
+	}, nil)
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		// Dates
+		{"$dateAdd(created, 2, 'd')", "2024-02-02T10:00:00Z"},
+		{"$dateAdd(created, -90, 'minutes')", "2024-01-31T08:30:00Z"},
+		{"$dateAdd(created, 1, 'M')", "2024-03-02T10:00:00Z"},
+		{"$format(created, 'YYYY/MM/DD HH:mm')", "2024/01/31 10:00"},
+		{"$format(created, 'DD.MM.YYYY HH:mm', 'Asia/Shanghai')", "31.01.2024 18:00"},
+		{"$format(created, '2006-01-02')", "2024-01-31"},
+		{"$format(created, 'unix')", int64(1706695200)},
+		{"$format(1706695200, 'rfc3339')", "2024-01-31T10:00:00Z"},
+		{"$dateDiff(created, $dateAdd(created, 3, 'h'), 'm')", 180.0},
+		{"$timestamp(created)", int64(1706695200)},
+
+		// Strings
+		{"$upper(user.name)", "ALICE"},
+		{"$lower('MiXeD')", "mixed"},
+		{"$trim('  x  ')", "x"},
+		{"$trim('--x--', '-')", "x"},
+		{"$substring('abcdef', 1, 3)", "bc"},
+		{"$substring('abcdef', -2)", "ef"},
+		{"$replace('a-b-c', '-', '+')", "a+b+c"},
+		{"$split('a,b', ',')", []interface{}{"a", "b"}},
+		{"$join(user.roles, '|')", "admin|dev"},
+		{"$padStart(7, 3, '0')", "007"},
+		{"$padEnd('ab', 4, '.')", "ab.."},
+		{"$contains(user.roles, 'dev')", true},
+		{"$contains('team-alpha', 'alpha')", true},
+		{"$startsWith(user.name, 'al')", true},
+		{"$endsWith(user.name, 'x')", false},
+
+		// Encoding
+		{"$base64Encode('user:pass')", "dXNlcjpwYXNz"},
+		{"$base64Decode('dXNlcjpwYXNz')", "user:pass"},
+		{"$urlEncode('a b&c')", "a+b%26c"},
+		{"$urlDecode('a+b%26c')", "a b&c"},
+		{"$hexEncode('hi')", "6869"},
+		{"$hexDecode('6869')", "hi"},
+
+		// Hashing
+		{"$md5('abc')", "900150983cd24fb0d6963f7d28e17f72"},
+		{"$sha1('abc')", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"$sha256('abc')", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"$hmac('sha256', 'key', 'The quick brown fox jumps over the lazy dog')", "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"$hmac('sha256', 'key', 'The quick brown fox jumps over the lazy dog', 'base64')", "97yD9DBThCSxMpjmqm+xQ+9NWaFJRhdZl0edvC0aPNg="},
+
+		// JSON
+		{"$jsonParse(body).data.token", "t-1"},
+		{"$jsonStringify(user.roles)", `["admin","dev"]`},
+		{"$jsonPath(body, '$.data.token')", "t-1"},
+		{"$jsonPath(items, '$[?(@.price > 10)].id')", []interface{}{"b", "c"}},
+		{"$jsonPath(user, '$.missing')", nil},
+
+		// Collections
+		{"$len(items)", 3},
+		{"$len('héllo')", 5},
+		{"$keys(user)", []interface{}{"name", "roles"}},
+		{"$map(items, it.id)", []interface{}{"b", "a", "c"}},
+		{"$map(items, index)", []interface{}{0, 1, 2}},
+		{"$len($filter(items, it.price > 10))", 2},
+		{"$map($sort(items, it.price), it.id)", []interface{}{"a", "c", "b"}},
+		{"$sort(['b', 'a', 'c'])", []interface{}{"a", "b", "c"}},
+		{"$unique([1, 2, 1, '1'])", []interface{}{1.0, 2.0, "1"}},
+		{"$sum($map(items, it.price))", 47.0},
+		{"$sum(1, 2, 3)", 6.0},
+		{"$min($map(items, it.price))", 5.0},
+		{"$max(3, '7', 5)", 7.0},
+
+		// Seeded random values repeat
+		{"$randomInt(1, 100, 42) == $randomInt(1, 100, 42)", true},
+		{"$randomString(12, 7) == $randomString(12, 7)", true},
+		{"$uuid(1) == $uuid(1)", true},
+		{"$uuid(1) != $uuid(2)", true},
+		{"$len($randomString(16))", 16},
+		{"$contains(user.roles, $randomItem(user.roles))", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evaluator.Evaluate("{{" + tt.expr + "}}")
+			if err != nil {
+				t.Fatalf("Evaluate(%s) error = %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate(%s) = %v (%T), want %v (%T)", tt.expr, got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctions_Random(t *testing.T) {
+	evaluator := NewEvaluator(nil, nil)
+	for i := 0; i < 50; i++ {
+		got, err := evaluator.Evaluate("{{$randomInt(3, 5)}}")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if n := got.(int); n < 3 || n > 5 {
+			t.Fatalf("$randomInt(3, 5) = %d, want 3 to 5", n)
+		}
+	}
+}
+
+func TestFunctions_Validation(t *testing.T) {
+	evaluator := NewEvaluator(map[string]interface{}{
+		"user":  map[string]interface{}{"name": "alice"},
+		"items": []interface{}{map[string]interface{}{"id": 1}},
+	}, nil)
+
+	tests := []struct {
+		expr   string
+		column int
+		msg    string
+	}{
+		{"$substring('abc')", 1, "$substring: expects 2 to 3 arguments, got 1"},
+		{"$upper('a', 'b')", 1, "$upper: expects 1 argument, got 2"},
+		{"$upper(user)", 1, `$upper: argument 1 must be a string, got {"name":"alice"}`},
+		{"$padStart('a', 2.5)", 1, "$padStart: argument 2 must be a whole number, got 2.5"},
+		{"$dateAdd('soon', 1, 'd')", 1, `$dateAdd: argument 1 must be a date, got "soon"`},
+		{"$dateAdd('2024-01-01', 1, 'fortnight')", 1, `$dateAdd: unknown date unit "fortnight" (use ms, s, m, h, d, w, M or y)`},
+		{"$hmac('sha3', 'k', 'm')", 1, `$hmac: unsupported algorithm "sha3"`},
+		{"$min([])", 1, "$min: needs at least one number"},
+		{"$max('a', 1)", 1, `$max: expects numbers, got "a"`},
+		{"$map(items, it.name)", 15, "field not found: name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := evaluator.Evaluate("{{" + tt.expr + "}}")
+			var evalErr *EvalError
+			if !errors.As(err, &evalErr) {
+				t.Fatalf("Evaluate(%s) error = %v, want an EvalError", tt.expr, err)
+			}
+			if evalErr.Column != tt.column || evalErr.Msg != tt.msg {
+				t.Errorf("Evaluate(%s) error at column %d %q, want column %d %q",
+					tt.expr, evalErr.Column, evalErr.Msg, tt.column, tt.msg)
+			}
+		})
+	}
+}
+
+func TestRegisterFunction(t *testing.T) {
+	RegisterFunction("greet", Function{
+		Params: []string{"string", "string?"},
+		Call: func(args []interface{}) (interface{}, error) {
+			greeting := "hello"
+			if len(args) > 1 {
+				greeting = args[1].(string)
+			}
+			return greeting + " " + args[0].(string), nil
+		},
+	})
+
+	evaluator := NewEvaluator(map[string]interface{}{"name": "bob"}, nil)
+	got, err := evaluator.EvaluateString("{{$greet(name)}}, {{ $upper($greet(name, 'hi')) }}")
+	if err != nil {
+		t.Fatalf("EvaluateString() error = %v", err)
+	}
+	if want := "hello bob, HI BOB"; got != want {
+		t.Errorf("EvaluateString() = %s, want %s", got, want)
+	}
+
+	if _, err := evaluator.Evaluate("{{$greet()}}"); err == nil || !strings.Contains(err.Error(), "expects 1 to 2 arguments") {
+		t.Errorf("Evaluate($greet()) error = %v, want an argument count error", err)
+	}
+}
@@ -0,0 +1,141 @@
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholderPattern matches {{ expression }} placeholders
+var placeholderPattern = regexp.MustCompile(`\{\{(.+?)\}\}`)
+
+// UnresolvedError reports a placeholder a strict evaluator could not resolve
+type UnresolvedError struct {
+	Placeholder string // as written, with braces
+	Err         error
+}
+
+func (e *UnresolvedError) Error() string {
+	return fmt.Sprintf("unresolved placeholder %s: %v", e.Placeholder, e.Err)
+}
+
+func (e *UnresolvedError) Unwrap() error {
+	return e.Err
+}
+
+// SetStrict makes interpolation fail with an UnresolvedError on placeholders
+// that cannot be evaluated, instead of leaving them in the text
+func (e *Evaluator) SetStrict(strict bool) {
+	e.strict = strict
+}
+
+// Interpolate renders the {{ }} placeholders of s. A string that is exactly
+// one placeholder evaluates to the value itself, keeping its type; otherwise
+// each placeholder is replaced with the text of its value (see Text).
+func (e *Evaluator) Interpolate(s string) (interface{}, error) {
+	matches := placeholderPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, nil
+	}
+
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(s) {
+		value, err := e.evaluateExpression(s[matches[0][2]:matches[0][3]])
+		if err != nil {
+			if e.strict {
+				return nil, &UnresolvedError{Placeholder: s, Err: err}
+			}
+			return s, nil
+		}
+		return value, nil
+	}
+	return e.substitute(s, matches)
+}
+
+// InterpolateValue renders placeholders in every string of a value made of
+// maps and arrays, returning a copy
+func (e *Evaluator) InterpolateValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return e.Interpolate(v)
+
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered, err := e.InterpolateValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			result[key] = rendered
+		}
+		return result, nil
+
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered, err := e.InterpolateValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			result[i] = rendered
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+// substitute replaces each matched placeholder of s with the text of its value
+func (e *Evaluator) substitute(s string, matches [][]int) (string, error) {
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(s[last:m[0]])
+		last = m[1]
+
+		value, err := e.evaluateExpression(s[m[2]:m[3]])
+		if err != nil {
+			if e.strict {
+				return "", &UnresolvedError{Placeholder: s[m[0]:m[1]], Err: err}
+			}
+			b.WriteString(s[m[0]:m[1]])
+			continue
+		}
+		b.WriteString(Text(value))
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+// Text renders a value the way placeholders inside text are substituted:
+// strings as they are, null as nothing, whole numbers without a fraction or
+// exponent, and objects and arrays as JSON
+func Text(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case bool:
+		return strconv.FormatBool(s)
+	case json.Number:
+		return s.String()
+	}
+	if f, ok := number(v); ok {
+		if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+			return strconv.FormatInt(int64(f), 10)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	if stringer, ok := v.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	switch kind(v) {
+	case "array", "object":
+		if data, err := json.Marshal(v); err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,102 @@
+package expression
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	evaluator := NewEvaluator(map[string]interface{}{
+		"host":  "api.example.com",
+		"port":  8080,
+		"ratio": 0.5,
+		"user":  map[string]interface{}{"id": 42, "tags": []interface{}{"a", "b"}},
+		"empty": nil,
+	}, map[string]interface{}{
+		"login": map[string]interface{}{"token": "t-1"},
+	})
+
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"plain", "plain"},
+		{"{{port}}", 8080},
+		{"{{ user.tags }}", []interface{}{"a", "b"}},
+		{"{{user.id + 1}}", 43.0},
+		{"{{login.token}}", "t-1"},
+		{"{{nodes.login.token}}", "t-1"},
+		{"http://{{host}}:{{port}}/users/{{user.id}}", "http://api.example.com:8080/users/42"},
+		{"ratio={{ratio}} tags={{user.tags}} empty={{empty}}", `ratio=0.5 tags=["a","b"] empty=`},
+		{"{{$upper(host)}}-{{$len(user.tags)}}", "API.EXAMPLE.COM-2"},
+		{"{{missing}}", "{{missing}}"},
+		{"id={{user.id}} x={{user.missing}}", "id=42 x={{user.missing}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := evaluator.Interpolate(tt.input)
+			if err != nil {
+				t.Fatalf("Interpolate(%s) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Interpolate(%s) = %v (%T), want %v (%T)", tt.input, got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpolate_Strict(t *testing.T) {
+	evaluator := NewEvaluator(map[string]interface{}{"id": 7}, nil)
+	evaluator.SetStrict(true)
+
+	got, err := evaluator.InterpolateValue(map[string]interface{}{
+		"path": "/items/{{id}}",
+		"ids":  []interface{}{"{{id}}"},
+	})
+	if err != nil {
+		t.Fatalf("InterpolateValue() error = %v", err)
+	}
+	want := map[string]interface{}{"path": "/items/7", "ids": []interface{}{7}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InterpolateValue() = %v, want %v", got, want)
+	}
+
+	_, err = evaluator.InterpolateValue(map[string]interface{}{
+		"args": []interface{}{"ok", "id={{id}}&page={{page}}"},
+	})
+	var unresolved *UnresolvedError
+	if !errors.As(err, &unresolved) {
+		t.Fatalf("InterpolateValue() error = %v, want an UnresolvedError", err)
+	}
+	if unresolved.Placeholder != "{{page}}" {
+		t.Errorf("Placeholder = %s, want {{page}}", unresolved.Placeholder)
+	}
+	if want := "args: [1]: unresolved placeholder {{page}}: variable not found: page (at column 1)"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, ""},
+		{"s", "s"},
+		{true, "true"},
+		{3, "3"},
+		{3.0, "3"},
+		{1.25, "1.25"},
+		{1e20, "100000000000000000000"},
+		{[]interface{}{1, "a"}, `[1,"a"]`},
+		{map[string]interface{}{"k": "v"}, `{"k":"v"}`},
+	}
+
+	for _, tt := range tests {
+		if got := Text(tt.value); got != tt.want {
+			t.Errorf("Text(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError reports an expression that cannot be parsed
+type SyntaxError struct {
+	Expr   string
+	Column int // 1-based, in characters
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at column %d: %s", e.Column, e.Msg)
+}
+
+// EvalError reports an expression that parsed but could not be evaluated
+type EvalError struct {
+	Expr   string
+	Column int // 1-based, in characters
+	Msg    string
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("%s (at column %d)", e.Msg, e.Column)
+}
+
+// column converts a byte offset of expr to a 1-based character column
+func column(expr string, offset int) int {
+	if offset > len(expr) {
+		offset = len(expr)
+	}
+	return utf8.RuneCountInString(expr[:offset]) + 1
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenPunct // operators and punctuation
+)
+
+type token struct {
+	kind  tokenKind
+	text  string      // source text; the operator for tokenPunct
+	value interface{} // number or string literal value
+	pos   int         // byte offset in the expression
+}
+
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// punctuation, longest first so "===" wins over "=="
+var punctuation = []string{
+	"===", "!==",
+	"==", "!=", "<=", ">=", "&&", "||",
+	"<", ">", "!", "+", "-", "*", "/", "%",
+	"(", ")", "[", "]", ",", ".", "?", ":",
+}
+
+// tokenize splits an expression into tokens.
+//
+// Identifiers may contain hyphens between letters ("step-login", "wf4-n1") so
+// hyphenated step IDs can be referenced; write "a - b" to subtract. Member
+// names after a dot may also start with a digit ("nodes.TC-010.output").
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		afterDot := len(tokens) > 0 && tokens[len(tokens)-1].kind == tokenPunct && tokens[len(tokens)-1].text == "."
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case afterDot && isIdentPart(c):
+			// Member name such as items.0 or nodes.TC-010
+			start := i
+			i = scanName(expr, i, true)
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[start:i], pos: start})
+
+		case isDigit(c):
+			start := i
+			for i < len(expr) && isDigit(expr[i]) {
+				i++
+			}
+			if i+1 < len(expr) && expr[i] == '.' && isDigit(expr[i+1]) {
+				i++
+				for i < len(expr) && isDigit(expr[i]) {
+					i++
+				}
+			}
+			if i < len(expr) && (expr[i] == 'e' || expr[i] == 'E') {
+				j := i + 1
+				if j < len(expr) && (expr[j] == '+' || expr[j] == '-') {
+					j++
+				}
+				if j < len(expr) && isDigit(expr[j]) {
+					for i = j; i < len(expr) && isDigit(expr[i]); i++ {
+					}
+				}
+			}
+			value, err := strconv.ParseFloat(expr[start:i], 64)
+			if err != nil {
+				return nil, &SyntaxError{Expr: expr, Column: column(expr, start), Msg: fmt.Sprintf("invalid number '%s'", expr[start:i])}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: expr[start:i], value: value, pos: start})
+
+		case c == '\'' || c == '"':
+			value, end, err := scanString(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: expr[i:end], value: value, pos: i})
+			i = end
+
+		case isIdentStart(c):
+			start := i
+			i = scanName(expr, i, false)
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[start:i], pos: start})
+
+		default:
+			matched := ""
+			for _, p := range punctuation {
+				if strings.HasPrefix(expr[i:], p) {
+					matched = p
+					break
+				}
+			}
+			if matched == "" {
+				r, _ := utf8.DecodeRuneInString(expr[i:])
+				return nil, &SyntaxError{Expr: expr, Column: column(expr, i), Msg: fmt.Sprintf("unexpected character '%c'", r)}
+			}
+			tokens = append(tokens, token{kind: tokenPunct, text: matched, pos: i})
+			i += len(matched)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+// scanName scans an identifier or member name starting at i. A hyphen joins
+// the name when a letter follows it (or a digit, for member names).
+func scanName(expr string, i int, member bool) int {
+	for i < len(expr) {
+		c := expr[i]
+		if isIdentPart(c) {
+			i++
+			continue
+		}
+		if c == '-' && i+1 < len(expr) && (isIdentStart(expr[i+1]) || member && isDigit(expr[i+1])) {
+			i++
+			continue
+		}
+		break
+	}
+	return i
+}
+
+// scanString scans a quoted string literal starting at i and returns its
+// value and the offset after the closing quote
+func scanString(expr string, i int) (string, int, error) {
+	quote := expr[i]
+	var b strings.Builder
+	for j := i + 1; j < len(expr); j++ {
+		c := expr[j]
+		switch {
+		case c == quote:
+			return b.String(), j + 1, nil
+		case c == '\\' && j+1 < len(expr):
+			j++
+			switch expr[j] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(expr[j])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, &SyntaxError{Expr: expr, Column: column(expr, i), Msg: "unterminated string"}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentStart accepts any byte of a multi-byte character, so identifiers may
+// use non-ASCII letters
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
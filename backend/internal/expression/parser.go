@@ -0,0 +1,335 @@
+package expression
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Operator precedence, lowest first. Binary operators of equal precedence
+// associate to the left; the conditional operator associates to the right.
+//
+//	1  ? :                conditional
+//	2  ||                 logical or (short-circuit)
+//	3  &&                 logical and (short-circuit)
+//	4  == != === !==      equality (=== and !== do not convert types)
+//	5  < <= > >=          comparison
+//	6  + -                addition, string concatenation, subtraction
+//	7  * / %              multiplication, division, remainder
+//	8  ! - +              unary operators
+//	9  .name [index] f()  member access, indexing, calls
+const (
+	precLowest = iota
+	precConditional
+	precOr
+	precAnd
+	precEquality
+	precComparison
+	precAdditive
+	precMultiplicative
+	precUnary
+	precPostfix
+)
+
+var binaryPrecedence = map[string]int{
+	"?":  precConditional,
+	"||": precOr,
+	"&&": precAnd,
+	"==": precEquality, "!=": precEquality, "===": precEquality, "!==": precEquality,
+	"<": precComparison, "<=": precComparison, ">": precComparison, ">=": precComparison,
+	"+": precAdditive, "-": precAdditive,
+	"*": precMultiplicative, "/": precMultiplicative, "%": precMultiplicative,
+	".": precPostfix, "[": precPostfix, "(": precPostfix,
+}
+
+// node is a parsed expression. pos is the byte offset reported for the node:
+// its operator, or the start of a literal, name or call.
+type node interface {
+	pos() int
+}
+
+type literalNode struct {
+	at    int
+	value interface{}
+}
+
+type identNode struct {
+	at   int
+	name string
+}
+
+type memberNode struct {
+	at     int // the dot
+	object node
+	name   string
+}
+
+type indexNode struct {
+	at     int // the opening bracket
+	object node
+	index  node
+}
+
+type callNode struct {
+	at   int
+	name string
+	args []node
+}
+
+type unaryNode struct {
+	at      int
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	at          int
+	op          string
+	left, right node
+}
+
+type conditionalNode struct {
+	at                int // the question mark
+	cond, then, other node
+}
+
+type arrayNode struct {
+	at       int
+	elements []node
+}
+
+func (n *literalNode) pos() int     { return n.at }
+func (n *identNode) pos() int       { return n.at }
+func (n *memberNode) pos() int      { return n.at }
+func (n *indexNode) pos() int       { return n.at }
+func (n *callNode) pos() int        { return n.at }
+func (n *unaryNode) pos() int       { return n.at }
+func (n *binaryNode) pos() int      { return n.at }
+func (n *conditionalNode) pos() int { return n.at }
+func (n *arrayNode) pos() int       { return n.at }
+
+// start returns the offset where the source of n begins
+func start(n node) int {
+	switch n := n.(type) {
+	case *memberNode:
+		return start(n.object)
+	case *indexNode:
+		return start(n.object)
+	case *binaryNode:
+		return start(n.left)
+	case *conditionalNode:
+		return start(n.cond)
+	}
+	return n.pos()
+}
+
+// parser is a Pratt parser over the tokens of one expression
+type parser struct {
+	expr   string
+	tokens []token
+	next   int
+}
+
+var (
+	cacheMu   sync.RWMutex
+	cache     = make(map[string]node)
+	cacheSize = 1024
+)
+
+// parse returns the syntax tree of expr, cached by source text
+func parse(expr string) (node, error) {
+	cacheMu.RLock()
+	tree, ok := cache[expr]
+	cacheMu.RUnlock()
+	if ok {
+		return tree, nil
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{expr: expr, tokens: tokens}
+	tree, err = p.parseExpression(precLowest)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok.describe())
+	}
+
+	cacheMu.Lock()
+	if len(cache) >= cacheSize {
+		cache = make(map[string]node)
+	}
+	cache[expr] = tree
+	cacheMu.Unlock()
+	return tree, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return &SyntaxError{Expr: p.expr, Column: column(p.expr, tok.pos), Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(text string) (token, error) {
+	tok := p.advance()
+	if tok.kind != tokenPunct || tok.text != text {
+		return tok, p.errorf(tok, "expected '%s' but found %s", text, tok.describe())
+	}
+	return tok, nil
+}
+
+// parseExpression parses operators binding tighter than precedence
+func (p *parser) parseExpression(precedence int) (node, error) {
+	left, err := p.parsePrefix()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if tok.kind != tokenPunct {
+			return left, nil
+		}
+		prec, ok := binaryPrecedence[tok.text]
+		if !ok || prec <= precedence {
+			return left, nil
+		}
+		p.advance()
+
+		switch tok.text {
+		case ".":
+			name := p.advance()
+			if name.kind != tokenIdent {
+				return nil, p.errorf(name, "expected a member name after '.' but found %s", name.describe())
+			}
+			left = &memberNode{at: tok.pos, object: left, name: name.text}
+
+		case "[":
+			index, err := p.parseExpression(precLowest)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			left = &indexNode{at: tok.pos, object: left, index: index}
+
+		case "(":
+			ident, ok := left.(*identNode)
+			if !ok {
+				return nil, p.errorf(tok, "only named functions can be called")
+			}
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			left = &callNode{at: ident.at, name: ident.name, args: args}
+
+		case "?":
+			// Right-associative: a ? b : c ? d : e
+			then, err := p.parseExpression(precLowest)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			other, err := p.parseExpression(precConditional - 1)
+			if err != nil {
+				return nil, err
+			}
+			left = &conditionalNode{at: tok.pos, cond: left, then: then, other: other}
+
+		default:
+			right, err := p.parseExpression(prec)
+			if err != nil {
+				return nil, err
+			}
+			left = &binaryNode{at: tok.pos, op: tok.text, left: left, right: right}
+		}
+	}
+}
+
+// parsePrefix parses a literal, name, array, parenthesized expression or
+// unary operation
+func (p *parser) parsePrefix() (node, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokenNumber, tokenString:
+		return &literalNode{at: tok.pos, value: tok.value}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{at: tok.pos, value: true}, nil
+		case "false":
+			return &literalNode{at: tok.pos, value: false}, nil
+		case "null":
+			return &literalNode{at: tok.pos, value: nil}, nil
+		}
+		return &identNode{at: tok.pos, name: tok.text}, nil
+	case tokenPunct:
+		switch tok.text {
+		case "(":
+			inner, err := p.parseExpression(precLowest)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		case "[":
+			elements, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &arrayNode{at: tok.pos, elements: elements}, nil
+		case "!", "-", "+":
+			operand, err := p.parseExpression(precUnary)
+			if err != nil {
+				return nil, err
+			}
+			return &unaryNode{at: tok.pos, op: tok.text, operand: operand}, nil
+		}
+	}
+	if tok.kind == tokenEOF {
+		return nil, p.errorf(tok, "unexpected end of expression")
+	}
+	return nil, p.errorf(tok, "unexpected %s", tok.describe())
+}
+
+// parseList parses comma-separated expressions up to the closing token
+func (p *parser) parseList(closing string) ([]node, error) {
+	var items []node
+	if tok := p.peek(); tok.kind == tokenPunct && tok.text == closing {
+		p.advance()
+		return items, nil
+	}
+	for {
+		item, err := p.parseExpression(precLowest)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		tok := p.advance()
+		if tok.kind == tokenPunct && tok.text == closing {
+			return items, nil
+		}
+		if tok.kind != tokenPunct || tok.text != "," {
+			return nil, p.errorf(tok, "expected ',' or '%s' but found %s", closing, tok.describe())
+		}
+	}
+}
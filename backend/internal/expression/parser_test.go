@@ -0,0 +1,177 @@
+package expression
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEvaluate_Operators(t *testing.T) {
+	evaluator := NewEvaluator(map[string]interface{}{
+		"a":      2,
+		"b":      3,
+		"name":   "a && b || c",
+		"status": "200",
+		"items": []interface{}{
+			map[string]interface{}{"id": "first"},
+			map[string]interface{}{"id": "last"},
+		},
+		"order":  map[string]interface{}{"total": 50.0, "tags": []interface{}{"new"}},
+		"active": true,
+	}, map[string]interface{}{
+		"step-login": map[string]interface{}{"token": "abc"},
+		"TC-010":     map[string]interface{}{"status": 201},
+	})
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		// Precedence and associativity
+		{"a + b * 2", 8.0},
+		{"(a + b) * 2", 10.0},
+		{"10 - 4 - 3", 3.0},
+		{"7 % 4", 3.0},
+		{"-a + 5", 3.0},
+		{"a < b == true", true},
+		{"a > b || b > a && a == 2", true},
+		{"!(a > b) && !false", true},
+		{"false || true && false", false},
+
+		// Conditional, right associative
+		{"a > b ? 'big' : 'small'", "small"},
+		{"a == 1 ? 'one' : a == 2 ? 'two' : 'many'", "two"},
+
+		// Strings may contain operators and quotes
+		{"name == 'a && b || c'", true},
+		{`'it\'s' + " " + "ok"`, "it's ok"},
+		{"'id-' + a", "id-2"},
+
+		// Member access and indexing
+		{"items[0].id", "first"},
+		{"items[-1].id", "last"},
+		{"items.1.id", "last"},
+		{"order['total'] >= 50", true},
+		{"order.tags[a - 2]", "new"},
+		{"nodes.TC-010.status", 201},
+		{"step-login.token", "abc"},
+		{"[a, b][1]", 3},
+
+		// Typed and loose comparisons
+		{"status == 200", true},
+		{"status === 200", false},
+		{"status === '200'", true},
+		{"a === 2.0", true},
+		{"active == 'true'", true},
+		{"active === 'true'", false},
+		{"'10' > '9'", true},
+		{"'b' > 'a'", true},
+
+		// Short circuit: the right side is never evaluated
+		{"false && missing.field", false},
+		{"true || 1 / 0", true},
+		{"active ? 'yes' : missing", "yes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evaluator.Evaluate("{{" + tt.expr + "}}")
+			if err != nil {
+				t.Fatalf("Evaluate(%s) error = %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate(%s) = %v (%T), want %v (%T)", tt.expr, got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate_SyntaxErrors(t *testing.T) {
+	evaluator := NewEvaluator(map[string]interface{}{"a": 1}, nil)
+
+	tests := []struct {
+		expr   string
+		column int
+		msg    string
+	}{
+		{"a ==", 5, "unexpected end of expression"},
+		{"a == 'open", 6, "unterminated string"},
+		{"(a + 1", 7, "expected ')' but found end of expression"},
+		{"a # 1", 3, "unexpected character '#'"},
+		{"a ? 1", 6, "expected ':' but found end of expression"},
+		{"a b", 3, "unexpected 'b'"},
+		{"f(1 2)", 5, "expected ',' or ')' but found '2'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := evaluator.Evaluate("{{" + tt.expr + "}}")
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Evaluate(%s) error = %v, want a SyntaxError", tt.expr, err)
+			}
+			if syntaxErr.Column != tt.column || syntaxErr.Msg != tt.msg {
+				t.Errorf("Evaluate(%s) error at column %d %q, want column %d %q",
+					tt.expr, syntaxErr.Column, syntaxErr.Msg, tt.column, tt.msg)
+			}
+		})
+	}
+}
+
+func TestEvaluate_EvalErrors(t *testing.T) {
+	evaluator := NewEvaluator(map[string]interface{}{
+		"a":     1,
+		"user":  map[string]interface{}{"name": "alice"},
+		"items": []interface{}{1},
+	}, nil)
+
+	tests := []struct {
+		expr   string
+		column int
+		msg    string
+	}{
+		{"a == missing", 6, "variable not found: missing"},
+		{"user.email", 5, "field not found: email"},
+		{"items[3]", 6, "array index out of bounds: 3"},
+		{"a / 0", 3, "division by zero"},
+		{"user > 1", 6, `cannot compare {"name":"alice"} > 1`},
+		{"$unknown(a)", 1, "unknown function: $unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := evaluator.Evaluate("{{" + tt.expr + "}}")
+			var evalErr *EvalError
+			if !errors.As(err, &evalErr) {
+				t.Fatalf("Evaluate(%s) error = %v, want an EvalError", tt.expr, err)
+			}
+			if evalErr.Column != tt.column || evalErr.Msg != tt.msg {
+				t.Errorf("Evaluate(%s) error at column %d %q, want column %d %q",
+					tt.expr, evalErr.Column, evalErr.Msg, tt.column, tt.msg)
+			}
+		})
+	}
+}
+
+func TestEvaluateString_KeepsFailedPlaceholders(t *testing.T) {
+	evaluator := NewEvaluator(map[string]interface{}{"id": 7}, nil)
+
+	got, err := evaluator.EvaluateString("/items/{{id}}?q={{missing}}&n={{id * 2}}")
+	if err != nil {
+		t.Fatalf("EvaluateString() error = %v", err)
+	}
+	if want := "/items/7?q={{missing}}&n=14"; got != want {
+		t.Errorf("EvaluateString() = %s, want %s", got, want)
+	}
+}
+
+func TestParse_Cached(t *testing.T) {
+	first, err := parse("a.b + 1")
+	if err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+	second, _ := parse("a.b + 1")
+	if first != second {
+		t.Error("parse() should return the cached tree for the same expression")
+	}
+}
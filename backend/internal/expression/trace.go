@@ -0,0 +1,132 @@
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTracedValueLength caps how much of a value a trace diagram prints
+const maxTracedValueLength = 80
+
+// Trace records the value of every sub-expression of an evaluated
+// expression, for power-assert style failure messages
+type Trace struct {
+	Expr   string        // the evaluated expression, without {{ }}
+	Values []TracedValue // sub-expression values, ordered by column
+}
+
+// TracedValue is the value of the sub-expression at a byte offset of Trace.Expr:
+// the start of a variable reference or function call, or an operator
+type TracedValue struct {
+	Offset int
+	Value  interface{}
+}
+
+// Trace evaluates expr (with or without {{ }}) like Evaluate, recording the
+// value of each variable, function call and operator along the way. Operands
+// skipped by && and || are not evaluated, so they have no value.
+func (e *Evaluator) Trace(expr string) (interface{}, *Trace, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "{{") && strings.HasSuffix(expr, "}}") {
+		expr = strings.TrimSpace(expr[2 : len(expr)-2])
+	}
+
+	// Trace on a copy so the evaluator stays safe to share
+	traced := *e
+	traced.trace = &Trace{Expr: expr}
+	value, err := traced.evaluateExpression(expr)
+
+	sort.SliceStable(traced.trace.Values, func(i, j int) bool {
+		return traced.trace.Values[i].Offset < traced.trace.Values[j].Offset
+	})
+	return value, traced.trace, err
+}
+
+// record notes the value of the sub-expression at offset. A later value at
+// the same offset replaces the earlier one, so a chain like a.b.c shows its
+// final value.
+func (e *Evaluator) record(offset int, value interface{}) {
+	if e.trace == nil {
+		return
+	}
+	for i, v := range e.trace.Values {
+		if v.Offset == offset {
+			e.trace.Values[i].Value = value
+			return
+		}
+	}
+	e.trace.Values = append(e.trace.Values, TracedValue{Offset: offset, Value: value})
+}
+
+// String renders the trace as a power-assert diagram: the expression, then
+// the value of each sub-expression hanging below its column
+//
+//	order.total == order.expected
+//	|           |  |
+//	|           |  49
+//	|           false
+//	50
+func (t *Trace) String() string {
+	columns := make([]int, len(t.Values))
+	for i, v := range t.Values {
+		columns[i] = utf8.RuneCountInString(t.Expr[:v.Offset])
+	}
+
+	var b strings.Builder
+	b.WriteString(t.Expr)
+	if len(columns) == 0 {
+		return b.String()
+	}
+	b.WriteByte('\n')
+	b.WriteString(traceLine(columns, ""))
+	for i := len(columns) - 1; i >= 0; i-- {
+		b.WriteByte('\n')
+		b.WriteString(traceLine(columns[:i+1], FormatValue(t.Values[i].Value)))
+	}
+	return b.String()
+}
+
+// traceLine draws a '|' at each column, with text replacing the last one
+func traceLine(columns []int, text string) string {
+	var b strings.Builder
+	pos := 0
+	for i, col := range columns {
+		b.WriteString(strings.Repeat(" ", col-pos))
+		if i == len(columns)-1 && text != "" {
+			b.WriteString(text)
+			break
+		}
+		b.WriteByte('|')
+		pos = col + 1
+	}
+	return b.String()
+}
+
+// FormatValue renders a value the way trace diagrams show it: strings quoted,
+// objects and arrays as JSON, long values truncated
+func FormatValue(value interface{}) string {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		text = "null"
+	case string:
+		text = strconv.Quote(v)
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			text = fmt.Sprintf("%v", v)
+		} else {
+			text = string(data)
+		}
+	default:
+		text = fmt.Sprintf("%v", v)
+	}
+	if utf8.RuneCountInString(text) > maxTracedValueLength {
+		text = string([]rune(text)[:maxTracedValueLength-3]) + "..."
+	}
+	return text
+}
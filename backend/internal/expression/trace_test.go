@@ -0,0 +1,71 @@
+package expression
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	evaluator := NewEvaluator(map[string]interface{}{
+		"order": map[string]interface{}{"total": 50, "expected": 49, "status": "paid"},
+	}, nil)
+
+	value, trace, err := evaluator.Trace("{{ order.total == order.expected && order.status == 'paid' }}")
+	if err != nil {
+		t.Fatalf("Trace() error = %v", err)
+	}
+	if value != false {
+		t.Errorf("Trace() value = %v, want false", value)
+	}
+
+	// The right side of && is never evaluated, so it has no values
+	want := strings.Join([]string{
+		"order.total == order.expected && order.status == 'paid'",
+		"|           |  |              |",
+		"|           |  |              false",
+		"|           |  49",
+		"|           false",
+		"50",
+	}, "\n")
+	if got := trace.String(); got != want {
+		t.Errorf("Trace.String() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTrace_SingleValue(t *testing.T) {
+	evaluator := NewEvaluator(map[string]interface{}{"done": false}, nil)
+
+	value, trace, err := evaluator.Trace("done")
+	if err != nil {
+		t.Fatalf("Trace() error = %v", err)
+	}
+	if value != false {
+		t.Errorf("Trace() value = %v, want false", value)
+	}
+	if got, want := trace.String(), "done\n|\nfalse"; got != want {
+		t.Errorf("Trace.String() = %q, want %q", got, want)
+	}
+
+	if _, _, err := evaluator.Trace("missing == 1"); err == nil {
+		t.Error("Trace() expected an error for an unknown variable")
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, "null"},
+		{"a", `"a"`},
+		{map[string]interface{}{"id": 1}, `{"id":1}`},
+		{[]interface{}{1, "x"}, `[1,"x"]`},
+		{3.5, "3.5"},
+		{strings.Repeat("x", 100), `"` + strings.Repeat("x", 76) + "..."},
+	}
+	for _, tt := range tests {
+		if got := FormatValue(tt.value); got != tt.want {
+			t.Errorf("FormatValue(%v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
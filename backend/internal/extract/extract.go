@@ -0,0 +1,370 @@
+// Package extract selects values from response bodies and command output:
+// JSONPath over decoded JSON, XPath over XML and HTML, CSS selectors over HTML
+// and regular expressions over text. Test case assertions, workflow outputs
+// and action template outputs share it, so a path selects the same value
+// everywhere.
+//
+// Extraction paths name their language with a prefix; paths without one are
+// JSONPath:
+//
+//	response.body.user.id          JSONPath
+//	xpath://order/id               XPath, text of the selected nodes
+//	xpath:count(//item)            XPath scalar expressions
+//	css:h1.title                   CSS selector, text of the selected elements
+//	css:a.next@href                CSS selector, attribute of the selected elements
+//	regex:token=(\w+)              first capture group (or the whole match)
+package extract
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+
+	"test-management-service/internal/jsonpath"
+)
+
+// Path languages
+const (
+	JSONPath = "jsonpath"
+	XPath    = "xpath"
+	CSS      = "css"
+	Regex    = "regex"
+)
+
+// Document types
+const (
+	TypeXML  = "xml"
+	TypeHTML = "html"
+)
+
+// Document is a parsed XML or HTML document
+type Document struct {
+	xml  *xmlquery.Node
+	html *html.Node
+}
+
+// ParseXML parses an XML document
+func ParseXML(text string) (*Document, error) {
+	root, err := xmlquery.Parse(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid XML: %w", err)
+	}
+	return &Document{xml: root}, nil
+}
+
+// ParseHTML parses an HTML document; fragments are completed the way browsers do
+func ParseHTML(text string) (*Document, error) {
+	root, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTML: %w", err)
+	}
+	return &Document{html: root}, nil
+}
+
+// Parse parses text as docType (xml or html). An empty docType sniffs the
+// content: documents starting with <!DOCTYPE html> or <html> are HTML, anything
+// else is XML unless it fails to parse as XML.
+func Parse(text, docType string) (*Document, error) {
+	switch docType {
+	case TypeHTML:
+		return ParseHTML(text)
+	case TypeXML:
+		return ParseXML(text)
+	}
+	if Sniff(text) == TypeHTML {
+		return ParseHTML(text)
+	}
+	if doc, err := ParseXML(text); err == nil {
+		return doc, nil
+	}
+	return ParseHTML(text)
+}
+
+// Sniff reports whether text looks like an HTML or an XML document
+func Sniff(text string) string {
+	head := strings.ToLower(strings.TrimSpace(text))
+	if strings.HasPrefix(head, "<!doctype html") || strings.HasPrefix(head, "<html") {
+		return TypeHTML
+	}
+	return TypeXML
+}
+
+// XPath evaluates expr and returns the string values of the selected nodes
+// (the text of elements, the value of attributes). Scalar expressions such as
+// count() or boolean() yield their single value.
+func (d *Document) XPath(expr string) ([]interface{}, error) {
+	compiled, err := compileXPath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var nav xpath.NodeNavigator
+	if d.xml != nil {
+		nav = xmlquery.CreateXPathNavigator(d.xml)
+	} else {
+		nav = htmlquery.CreateXPathNavigator(d.html)
+	}
+
+	switch value := compiled.Evaluate(nav).(type) {
+	case *xpath.NodeIterator:
+		values := []interface{}{}
+		for value.MoveNext() {
+			values = append(values, value.Current().Value())
+		}
+		return values, nil
+	default:
+		return []interface{}{value}, nil
+	}
+}
+
+// CSS returns the trimmed text of the elements matching selector. A trailing
+// "@name" selects the attribute instead, skipping elements without it.
+// XML documents are matched as HTML.
+func (d *Document) CSS(selector string) ([]interface{}, error) {
+	selector, attr := splitAttribute(selector)
+	compiled, err := compileCSS(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	root := d.html
+	if root == nil {
+		root, err = html.Parse(strings.NewReader(d.xml.OutputXML(true)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid HTML: %w", err)
+		}
+		d.html = root
+	}
+
+	values := []interface{}{}
+	for _, node := range compiled.MatchAll(root) {
+		if attr == "" {
+			values = append(values, strings.TrimSpace(htmlquery.InnerText(node)))
+			continue
+		}
+		for _, a := range node.Attr {
+			if a.Key == attr {
+				values = append(values, a.Val)
+				break
+			}
+		}
+	}
+	return values, nil
+}
+
+var attributeSuffix = regexp.MustCompile(`@([A-Za-z_:][-A-Za-z0-9_:.]*)$`)
+
+// splitAttribute splits "a.next@href" into the selector and the attribute name
+func splitAttribute(selector string) (string, string) {
+	selector = strings.TrimSpace(selector)
+	if m := attributeSuffix.FindStringSubmatchIndex(selector); m != nil && m[0] > 0 {
+		return strings.TrimSpace(selector[:m[0]]), selector[m[2]:m[3]]
+	}
+	return selector, ""
+}
+
+// RegexCapture returns, for every match of pattern in text, its first capture
+// group, or the whole match when the pattern has no groups
+func RegexCapture(text, pattern string) ([]interface{}, error) {
+	re, err := compileRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+	values := []interface{}{}
+	for _, m := range re.FindAllStringSubmatch(text, -1) {
+		if len(m) > 1 {
+			values = append(values, m[1])
+		} else {
+			values = append(values, m[0])
+		}
+	}
+	return values, nil
+}
+
+// Lines splits text into lines without their line endings. A final line
+// ending does not start another line, so "a\nb\n" has two lines.
+func Lines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// Split returns the language and expression of an extraction path
+func Split(path string) (string, string) {
+	for _, lang := range []string{XPath, CSS, Regex} {
+		if expr, ok := strings.CutPrefix(path, lang+":"); ok {
+			return lang, expr
+		}
+	}
+	return JSONPath, path
+}
+
+// Lookup selects the value at path in subject. JSONPath navigates subject
+// itself; the other languages read its text (see Text). Like a non-definite
+// JSONPath, a path that selects several values yields them as an array, and
+// found is false when nothing was selected.
+func Lookup(subject interface{}, path string) (interface{}, bool, error) {
+	lang, expr := Split(path)
+	if lang == JSONPath {
+		return jsonpath.Lookup(subject, expr)
+	}
+
+	text, ok := Text(subject)
+	if !ok {
+		return nil, false, fmt.Errorf("%s path %q needs a text body, got %T", lang, expr, subject)
+	}
+	values, err := Select(text, docType(subject), lang, expr)
+	if err != nil {
+		return nil, false, err
+	}
+	value, found := Single(values)
+	return value, found, nil
+}
+
+// Select evaluates expr of the given language over text and returns every
+// selected value. docType (xml or html) says how to parse XPath and CSS
+// documents; empty sniffs the content.
+func Select(text, docType, lang, expr string) ([]interface{}, error) {
+	switch lang {
+	case Regex:
+		return RegexCapture(text, expr)
+	case XPath, CSS:
+		if lang == CSS && docType == "" {
+			docType = TypeHTML
+		}
+		doc, err := Parse(text, docType)
+		if err != nil {
+			return nil, err
+		}
+		if lang == XPath {
+			return doc.XPath(expr)
+		}
+		return doc.CSS(expr)
+	}
+	return nil, fmt.Errorf("unsupported path language %q", lang)
+}
+
+// Single reduces selected values the way Lookup reports them: nothing is not
+// found, one value is itself and several are an array
+func Single(values []interface{}) (interface{}, bool) {
+	switch len(values) {
+	case 0:
+		return nil, false
+	case 1:
+		return values[0], true
+	}
+	return values, true
+}
+
+// textSources are where the text of an action result lives: the raw HTTP
+// response body, then the decoded body, then command output
+var textSources = []string{"response.bodyRaw", "response.body", "bodyRaw", "body", "stdout"}
+
+// Text returns the text a markup or regex path reads: subject itself when it
+// is a string, otherwise the first string among its response body and
+// command output fields
+func Text(subject interface{}) (string, bool) {
+	if text, ok := subject.(string); ok {
+		return text, true
+	}
+	for _, source := range textSources {
+		if value, found, _ := jsonpath.Lookup(subject, source); found {
+			if text, ok := value.(string); ok {
+				return text, true
+			}
+		}
+	}
+	return "", false
+}
+
+// docType returns the body type an HTTP response reports, if it is markup
+func docType(subject interface{}) string {
+	for _, source := range []string{"response.bodyType", "bodyType"} {
+		if value, found, _ := jsonpath.Lookup(subject, source); found {
+			if value == TypeXML || value == TypeHTML {
+				return value.(string)
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+var (
+	cacheMu   sync.RWMutex
+	cache     = make(map[string]interface{})
+	cacheSize = 1024
+)
+
+// compiled returns the cached compilation of key, compiling it on a miss
+func compiled(key string, compile func() (interface{}, error)) (interface{}, error) {
+	cacheMu.RLock()
+	value, ok := cache[key]
+	cacheMu.RUnlock()
+	if ok {
+		return value, nil
+	}
+
+	value, err := compile()
+	if err != nil {
+		return nil, err
+	}
+
+	cacheMu.Lock()
+	if len(cache) >= cacheSize {
+		cache = make(map[string]interface{})
+	}
+	cache[key] = value
+	cacheMu.Unlock()
+	return value, nil
+}
+
+// compileXPath compiles expr. Compiled expressions keep evaluation state, so
+// unlike selectors and regexes they are not cached and shared.
+func compileXPath(expr string) (*xpath.Expr, error) {
+	compiled, err := xpath.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath %q: %w", expr, err)
+	}
+	return compiled, nil
+}
+
+func compileCSS(selector string) (cascadia.Selector, error) {
+	value, err := compiled(CSS+":"+selector, func() (interface{}, error) {
+		compiled, err := cascadia.Compile(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSS selector %q: %w", selector, err)
+		}
+		return compiled, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(cascadia.Selector), nil
+}
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	value, err := compiled(Regex+":"+pattern, func() (interface{}, error) {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+		return compiled, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*regexp.Regexp), nil
+}
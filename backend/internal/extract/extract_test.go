@@ -0,0 +1,135 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const soapEnvelope = `<?xml version="1.0"?>
+<Envelope>
+  <Body>
+    <GetOrderResponse>
+      <order id="A-1" status="paid"><total>42.50</total></order>
+      <order id="A-2" status="open"><total>7.00</total></order>
+    </GetOrderResponse>
+  </Body>
+</Envelope>`
+
+const page = `<!DOCTYPE html>
+<html>
+<head><title>Orders</title></head>
+<body>
+  <h1 class="title"> Your orders </h1>
+  <ul id="orders">
+    <li class="order">A-1</li>
+    <li class="order">A-2</li>
+  </ul>
+  <a class="next" href="/orders?page=2">Next</a>
+</body>
+</html>`
+
+func TestXPath(t *testing.T) {
+	doc, err := ParseXML(soapEnvelope)
+	require.NoError(t, err)
+
+	values, err := doc.XPath("//order/total")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"42.50", "7.00"}, values)
+
+	values, err = doc.XPath("//order[@status='paid']/@id")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"A-1"}, values)
+
+	values, err = doc.XPath("count(//order)")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{float64(2)}, values)
+
+	_, err = doc.XPath("//order[")
+	assert.Error(t, err)
+
+	html, err := ParseHTML(page)
+	require.NoError(t, err)
+	values, err = html.XPath("//li[@class='order']")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"A-1", "A-2"}, values)
+}
+
+func TestCSS(t *testing.T) {
+	doc, err := ParseHTML(page)
+	require.NoError(t, err)
+
+	values, err := doc.CSS("h1.title")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"Your orders"}, values)
+
+	values, err = doc.CSS("#orders > li.order")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"A-1", "A-2"}, values)
+
+	values, err = doc.CSS("a.next@href")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"/orders?page=2"}, values)
+
+	values, err = doc.CSS("a[href^='/orders']")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"Next"}, values)
+
+	_, err = doc.CSS("li[")
+	assert.Error(t, err)
+}
+
+func TestTextHelpers(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", ""}, Lines("a\r\nb\n\n"))
+	assert.Nil(t, Lines(""))
+
+	values, err := RegexCapture("token=abc; token=def", `token=(\w+)`)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"abc", "def"}, values)
+
+	values, err = RegexCapture("v1.2.3", `\d+`)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"1", "2", "3"}, values)
+}
+
+func TestLookup(t *testing.T) {
+	output := map[string]interface{}{
+		"status": "passed",
+		"response": map[string]interface{}{
+			"statusCode": 200,
+			"body":       page,
+			"bodyRaw":    page,
+			"bodyType":   "html",
+		},
+	}
+
+	tests := []struct {
+		path  string
+		want  interface{}
+		found bool
+	}{
+		{"response.statusCode", 200, true},
+		{"css:h1.title", "Your orders", true},
+		{"css:li.order", []interface{}{"A-1", "A-2"}, true},
+		{"xpath://title", "Orders", true},
+		{`regex:page=(\d+)`, "2", true},
+		{"css:table", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			value, found, err := Lookup(output, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+
+	value, found, err := Lookup(soapEnvelope, "xpath:sum(//total)")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 49.5, value)
+
+	_, _, err = Lookup(map[string]interface{}{"count": 1}, "xpath://a")
+	assert.Error(t, err)
+}
@@ -61,6 +61,10 @@ func (h *EnvironmentHandler) CreateEnvironment(c *gin.Context) {
 
 	env, err := h.envService.CreateEnvironment(c.Request.Context(), tenantID, projectID, &req)
 	if err != nil {
+		if errors.Is(err, apierrors.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -126,6 +130,10 @@ func (h *EnvironmentHandler) UpdateEnvironment(c *gin.Context) {
 
 	env, err := h.envService.UpdateEnvironment(c.Request.Context(), envID, tenantID, projectID, &req)
 	if err != nil {
+		if errors.Is(err, apierrors.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
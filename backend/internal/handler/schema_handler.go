@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/middleware"
+	"test-management-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SchemaHandler handles HTTP requests for project schema documents
+type SchemaHandler struct {
+	schemaService service.SchemaService
+}
+
+// NewSchemaHandler creates a new schema handler
+func NewSchemaHandler(schemaService service.SchemaService) *SchemaHandler {
+	return &SchemaHandler{
+		schemaService: schemaService,
+	}
+}
+
+// RegisterRoutes registers all schema-related routes
+func (h *SchemaHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	api := rg.Group("/schemas")
+	{
+		api.POST("", h.CreateSchema)
+		api.GET("", h.ListSchemas)
+		api.GET("/:name", h.GetSchema)
+		api.PUT("/:name", h.UpdateSchema)
+		api.DELETE("/:name", h.DeleteSchema)
+	}
+}
+
+// CreateSchema stores a JSON Schema or OpenAPI document
+// POST /api/schemas
+func (h *SchemaHandler) CreateSchema(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+	projectID := middleware.GetProjectID(c)
+
+	var req service.CreateSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	doc, err := h.schemaService.CreateSchema(c.Request.Context(), tenantID, projectID, &req)
+	if err != nil {
+		respondSchemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, doc)
+}
+
+// ListSchemas lists the schema documents of the project
+// GET /api/schemas?type=openapi
+func (h *SchemaHandler) ListSchemas(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+	projectID := middleware.GetProjectID(c)
+
+	docs, err := h.schemaService.ListSchemas(c.Request.Context(), tenantID, projectID, c.Query("type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  docs,
+		"total": len(docs),
+	})
+}
+
+// GetSchema retrieves a schema document by name
+// GET /api/schemas/:name
+func (h *SchemaHandler) GetSchema(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+	projectID := middleware.GetProjectID(c)
+
+	doc, err := h.schemaService.GetSchema(c.Request.Context(), tenantID, projectID, c.Param("name"))
+	if err != nil {
+		respondSchemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+// UpdateSchema updates the content or description of a schema document
+// PUT /api/schemas/:name
+func (h *SchemaHandler) UpdateSchema(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+	projectID := middleware.GetProjectID(c)
+
+	var req service.UpdateSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	doc, err := h.schemaService.UpdateSchema(c.Request.Context(), tenantID, projectID, c.Param("name"), &req)
+	if err != nil {
+		respondSchemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+// DeleteSchema deletes a schema document
+// DELETE /api/schemas/:name
+func (h *SchemaHandler) DeleteSchema(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+	projectID := middleware.GetProjectID(c)
+
+	if err := h.schemaService.DeleteSchema(c.Request.Context(), tenantID, projectID, c.Param("name")); err != nil {
+		respondSchemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "schema deleted"})
+}
+
+func respondSchemaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apierrors.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, apierrors.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, apierrors.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
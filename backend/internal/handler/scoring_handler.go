@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/middleware"
+	"test-management-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScoringHandler handles HTTP requests for test value scores
+type ScoringHandler struct {
+	scoringService service.ScoringService
+}
+
+// NewScoringHandler creates a new scoring handler
+func NewScoringHandler(scoringService service.ScoringService) *ScoringHandler {
+	return &ScoringHandler{
+		scoringService: scoringService,
+	}
+}
+
+// RegisterRoutes registers all scoring routes
+func (h *ScoringHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/tests/:id/score", h.GetTestScore)
+	rg.POST("/scores/recompute", h.RecomputeScores)
+}
+
+// GetTestScore explains the value scores of a test
+// GET /api/tests/:id/score
+func (h *ScoringHandler) GetTestScore(c *gin.Context) {
+	breakdown, err := h.scoringService.GetTestScore(c.Request.Context(), c.Param("id"), middleware.GetTenantID(c), middleware.GetProjectID(c))
+	if err != nil {
+		respondScoringError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, breakdown)
+}
+
+// RecomputeScores rescores every test of the current project
+// POST /api/scores/recompute
+func (h *ScoringHandler) RecomputeScores(c *gin.Context) {
+	scored, err := h.scoringService.RecomputeScores(c.Request.Context(), middleware.GetTenantID(c), middleware.GetProjectID(c))
+	if err != nil {
+		respondScoringError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scored": scored})
+}
+
+func respondScoringError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apierrors.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, apierrors.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
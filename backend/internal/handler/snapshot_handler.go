@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/middleware"
+	"test-management-service/internal/repository"
+	"test-management-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SnapshotHandler handles HTTP requests for snapshot review
+type SnapshotHandler struct {
+	snapshotService service.SnapshotService
+}
+
+// NewSnapshotHandler creates a new snapshot handler
+func NewSnapshotHandler(snapshotService service.SnapshotService) *SnapshotHandler {
+	return &SnapshotHandler{
+		snapshotService: snapshotService,
+	}
+}
+
+// RegisterRoutes registers all snapshot-related routes
+func (h *SnapshotHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	api := rg.Group("/snapshots")
+	{
+		api.GET("", h.ListSnapshots)
+		api.GET("/:id", h.GetSnapshot)
+		api.POST("/:id/approve", h.ApproveSnapshot)
+		api.POST("/:id/reject", h.RejectSnapshot)
+	}
+}
+
+// ListSnapshots lists snapshot versions, newest first
+// GET /api/snapshots?testId=xxx&name=default&status=pending
+func (h *SnapshotHandler) ListSnapshots(c *gin.Context) {
+	snapshots, err := h.snapshotService.ListSnapshots(c.Request.Context(), repository.SnapshotFilter{
+		TenantID:  middleware.GetTenantID(c),
+		ProjectID: middleware.GetProjectID(c),
+		TestID:    c.Query("testId"),
+		Name:      c.Query("name"),
+		Status:    c.Query("status"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  snapshots,
+		"total": len(snapshots),
+	})
+}
+
+// GetSnapshot retrieves a snapshot version
+// GET /api/snapshots/:id
+func (h *SnapshotHandler) GetSnapshot(c *gin.Context) {
+	id, ok := snapshotID(c)
+	if !ok {
+		return
+	}
+
+	snap, err := h.snapshotService.GetSnapshot(c.Request.Context(), middleware.GetTenantID(c), middleware.GetProjectID(c), id)
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snap)
+}
+
+// ApproveSnapshotRequest identifies the reviewer; defaults to the authenticated user
+type ApproveSnapshotRequest struct {
+	ApprovedBy string `json:"approvedBy"`
+}
+
+// ApproveSnapshot accepts a pending snapshot as the new baseline
+// POST /api/snapshots/:id/approve
+func (h *SnapshotHandler) ApproveSnapshot(c *gin.Context) {
+	id, ok := snapshotID(c)
+	if !ok {
+		return
+	}
+
+	var req ApproveSnapshotRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.ApprovedBy == "" {
+		if userID, exists := c.Get("user_id"); exists {
+			req.ApprovedBy, _ = userID.(string)
+		}
+	}
+
+	snap, err := h.snapshotService.ApproveSnapshot(c.Request.Context(), middleware.GetTenantID(c), middleware.GetProjectID(c), id, req.ApprovedBy)
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snap)
+}
+
+// RejectSnapshot rejects a pending snapshot and keeps the current baseline
+// POST /api/snapshots/:id/reject
+func (h *SnapshotHandler) RejectSnapshot(c *gin.Context) {
+	id, ok := snapshotID(c)
+	if !ok {
+		return
+	}
+
+	snap, err := h.snapshotService.RejectSnapshot(c.Request.Context(), middleware.GetTenantID(c), middleware.GetProjectID(c), id)
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snap)
+}
+
+func snapshotID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid snapshot id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondSnapshotError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apierrors.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, apierrors.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, apierrors.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
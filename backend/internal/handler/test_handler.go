@@ -39,6 +39,9 @@ func (h *TestHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("/tests/advanced-search", h.AdvancedSearch)
 	rg.GET("/tests/statistics", h.GetStatistics)
 	rg.GET("/tests/flaky", h.GetFlakyTests)
+	rg.GET("/tests/:id/flakiness", h.GetTestFlakiness)
+	rg.POST("/tests/:id/quarantine", h.QuarantineTestCase)
+	rg.DELETE("/tests/:id/quarantine", h.ReleaseTestCase)
 
 	// Test tree (for Web UI)
 	rg.GET("/test-tree", h.GetTestTree)
@@ -61,6 +64,9 @@ func (h *TestHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	// Test runs
 	rg.GET("/runs/:id", h.GetTestRun)
 	rg.GET("/runs", h.ListTestRuns)
+	rg.POST("/runs/:id/rerun", h.RerunTestRun)
+	rg.POST("/test-runs/:id/rerun", h.RerunTestRun)
+	rg.GET("/runs/:id/merged", h.GetMergedTestRun)
 }
 
 // ===== Test Case Handlers =====
@@ -78,6 +84,10 @@ func (h *TestHandler) CreateTestCase(c *gin.Context) {
 
 	testCase, err := h.service.CreateTestCase(c.Request.Context(), tenantID, projectID, &req)
 	if err != nil {
+		if errors.Is(err, apierrors.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -98,6 +108,10 @@ func (h *TestHandler) UpdateTestCase(c *gin.Context) {
 
 	testCase, err := h.service.UpdateTestCase(c.Request.Context(), testID, tenantID, projectID, &req)
 	if err != nil {
+		if errors.Is(err, apierrors.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -124,6 +138,54 @@ func (h *TestHandler) DeleteTestCase(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "test case deleted"})
 }
 
+// QuarantineTestCase keeps a test running without letting its results
+// decide the outcome of runs
+func (h *TestHandler) QuarantineTestCase(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+	projectID := middleware.GetProjectID(c)
+	testID := c.Param("id")
+
+	var req service.QuarantineTestCaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	testCase, err := h.service.QuarantineTestCase(c.Request.Context(), testID, tenantID, projectID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, apierrors.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "test case not found"})
+		case errors.Is(err, apierrors.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, testCase)
+}
+
+// ReleaseTestCase lifts the quarantine of a test
+func (h *TestHandler) ReleaseTestCase(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+	projectID := middleware.GetProjectID(c)
+	testID := c.Param("id")
+
+	testCase, err := h.service.ReleaseTestCase(c.Request.Context(), testID, tenantID, projectID)
+	if err != nil {
+		if errors.Is(err, apierrors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "test case not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, testCase)
+}
+
 func (h *TestHandler) GetTestCase(c *gin.Context) {
 	tenantID := middleware.GetTenantID(c)
 	projectID := middleware.GetProjectID(c)
@@ -194,6 +256,10 @@ func (h *TestHandler) CreateTestGroup(c *gin.Context) {
 
 	group, err := h.service.CreateTestGroup(c.Request.Context(), tenantID, projectID, &req)
 	if err != nil {
+		if errors.Is(err, apierrors.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		// Check if it's an AlreadyExists error
 		if errors.Is(err, apierrors.ErrAlreadyExists) {
 			c.JSON(http.StatusConflict, gin.H{"error": "group already exists"})
@@ -220,6 +286,10 @@ func (h *TestHandler) UpdateTestGroup(c *gin.Context) {
 
 	group, err := h.service.UpdateTestGroup(c.Request.Context(), groupID, tenantID, projectID, &req)
 	if err != nil {
+		if errors.Is(err, apierrors.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -298,13 +368,27 @@ func (h *TestHandler) ExecuteTestGroup(c *gin.Context) {
 	projectID := middleware.GetProjectID(c)
 	groupID := c.Param("id")
 
-	run, err := h.service.ExecuteTestGroup(c.Request.Context(), groupID, tenantID, projectID)
+	// The body is optional: concurrency, failFast and maxDuration
+	var req service.ExecuteTestGroupRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	run, err := h.service.ExecuteTestGroup(c.Request.Context(), groupID, tenantID, projectID, &req)
 	if err != nil {
+		if errors.Is(err, apierrors.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, run)
+	// The tests run in the background; poll GET /runs/:id for progress
+	c.JSON(http.StatusAccepted, run)
 }
 
 // ===== Test Result Handlers =====
@@ -357,6 +441,10 @@ func (h *TestHandler) GetTestRun(c *gin.Context) {
 
 	run, err := h.service.GetTestRun(c.Request.Context(), runID, tenantID, projectID)
 	if err != nil {
+		if errors.Is(err, apierrors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -388,6 +476,53 @@ func (h *TestHandler) ListTestRuns(c *gin.Context) {
 	})
 }
 
+// RerunTestRun re-executes the failed (default), errored or all tests of a run
+// in a new run linked to it
+func (h *TestHandler) RerunTestRun(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+	projectID := middleware.GetProjectID(c)
+	runID := c.Param("id")
+
+	run, err := h.service.RerunTestRun(c.Request.Context(), runID, tenantID, projectID, c.Query("filter"))
+	if err != nil {
+		switch {
+		case errors.Is(err, apierrors.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, apierrors.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, apierrors.ErrConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// The tests run in the background; GET /runs/:id/merged shows which
+	// failures passed on retry
+	c.JSON(http.StatusAccepted, run)
+}
+
+// GetMergedTestRun returns the results of the original run overlaid with
+// those of its reruns up to the requested run
+func (h *TestHandler) GetMergedTestRun(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+	projectID := middleware.GetProjectID(c)
+	runID := c.Param("id")
+
+	merged, err := h.service.GetMergedTestRun(c.Request.Context(), runID, tenantID, projectID)
+	if err != nil {
+		if errors.Is(err, apierrors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, merged)
+}
+
 // ===== Web UI Specific Handlers =====
 
 // GetTestTree returns the complete test tree with groups and tests
@@ -478,7 +613,7 @@ func (h *TestHandler) GetTestStats(c *gin.Context) {
 // ===== Advanced Search and Analytics Handlers =====
 
 // AdvancedSearch performs multi-condition search
-// GET /api/v2/tests/advanced-search?keywords=login&priorities=P0,P1&statuses=Active&tags=smoke,api&successRateMin=80&successRateMax=100&ownerId=user123&lastRunBefore=2024-01-01T00:00:00Z&isFlaky=false&page=1&pageSize=20
+// GET /api/v2/tests/advanced-search?keywords=login&priorities=P0,P1&statuses=Active&tags=smoke,api&types=http,grpc&successRateMin=80&successRateMax=100&ownerId=user123&lastRunBefore=2024-01-01T00:00:00Z&isFlaky=false&page=1&pageSize=20
 func (h *TestHandler) AdvancedSearch(c *gin.Context) {
 	tenantID := middleware.GetTenantID(c)
 	if tenantID == "" {
@@ -509,6 +644,11 @@ func (h *TestHandler) AdvancedSearch(c *gin.Context) {
 		filter.Tags = strings.Split(tagsStr, ",")
 	}
 
+	// Parse types (comma-separated)
+	if typesStr := c.Query("types"); typesStr != "" {
+		filter.Types = strings.Split(typesStr, ",")
+	}
+
 	// Parse success rate range
 	if minStr := c.Query("successRateMin"); minStr != "" {
 		if min, err := strconv.Atoi(minStr); err == nil {
@@ -599,3 +739,24 @@ func (h *TestHandler) GetFlakyTests(c *gin.Context) {
 		"total": len(testCases),
 	})
 }
+
+// GetTestFlakiness returns the flakiness score of a test and the recent
+// results it is computed from
+// GET /api/v2/tests/:id/flakiness
+func (h *TestHandler) GetTestFlakiness(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+	projectID := middleware.GetProjectID(c)
+	testID := c.Param("id")
+
+	report, err := h.service.GetTestFlakiness(c.Request.Context(), testID, tenantID, projectID)
+	if err != nil {
+		if errors.Is(err, apierrors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/middleware"
+	"test-management-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestPlanHandler handles HTTP requests for test plans
+type TestPlanHandler struct {
+	planService service.TestPlanService
+}
+
+// NewTestPlanHandler creates a new test plan handler
+func NewTestPlanHandler(planService service.TestPlanService) *TestPlanHandler {
+	return &TestPlanHandler{
+		planService: planService,
+	}
+}
+
+// RegisterRoutes registers all test plan routes
+func (h *TestPlanHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	api := rg.Group("/plans")
+	{
+		api.GET("", h.ListTestPlans)
+		api.POST("", h.CreateTestPlan)
+		api.GET("/:id", h.GetTestPlan)
+		api.PUT("/:id", h.UpdateTestPlan)
+		api.DELETE("/:id", h.DeleteTestPlan)
+		api.POST("/:id/execute", h.ExecuteTestPlan)
+		api.GET("/:id/runs", h.GetTestPlanRuns)
+	}
+}
+
+// ListTestPlans lists the plans of the project
+// GET /api/plans
+func (h *TestPlanHandler) ListTestPlans(c *gin.Context) {
+	plans, err := h.planService.ListTestPlans(c.Request.Context(), middleware.GetTenantID(c), middleware.GetProjectID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  plans,
+		"total": len(plans),
+	})
+}
+
+// CreateTestPlan saves a new plan
+// POST /api/plans
+func (h *TestPlanHandler) CreateTestPlan(c *gin.Context) {
+	var req service.CreateTestPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.planService.CreateTestPlan(c.Request.Context(), middleware.GetTenantID(c), middleware.GetProjectID(c), &req)
+	if err != nil {
+		respondTestPlanError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// GetTestPlan retrieves a plan
+// GET /api/plans/:id
+func (h *TestPlanHandler) GetTestPlan(c *gin.Context) {
+	plan, err := h.planService.GetTestPlan(c.Request.Context(), c.Param("id"), middleware.GetTenantID(c), middleware.GetProjectID(c))
+	if err != nil {
+		respondTestPlanError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// UpdateTestPlan replaces the definition of a plan
+// PUT /api/plans/:id
+func (h *TestPlanHandler) UpdateTestPlan(c *gin.Context) {
+	var req service.TestPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.planService.UpdateTestPlan(c.Request.Context(), c.Param("id"), middleware.GetTenantID(c), middleware.GetProjectID(c), &req)
+	if err != nil {
+		respondTestPlanError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// DeleteTestPlan deletes a plan; its runs are kept
+// DELETE /api/plans/:id
+func (h *TestPlanHandler) DeleteTestPlan(c *gin.Context) {
+	if err := h.planService.DeleteTestPlan(c.Request.Context(), c.Param("id"), middleware.GetTenantID(c), middleware.GetProjectID(c)); err != nil {
+		respondTestPlanError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "test plan deleted"})
+}
+
+// ExecuteTestPlan starts a run of a plan
+// POST /api/plans/:id/execute
+func (h *TestPlanHandler) ExecuteTestPlan(c *gin.Context) {
+	run, err := h.planService.ExecuteTestPlan(c.Request.Context(), c.Param("id"), middleware.GetTenantID(c), middleware.GetProjectID(c))
+	if err != nil {
+		respondTestPlanError(c, err)
+		return
+	}
+
+	// The tests run in the background; GET /plans/:id shows the verdict
+	// once the retries are done
+	c.JSON(http.StatusAccepted, run)
+}
+
+// GetTestPlanRuns lists the runs of a plan, retries included, newest first
+// GET /api/plans/:id/runs?limit=20&offset=0
+func (h *TestPlanHandler) GetTestPlanRuns(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	runs, total, err := h.planService.GetTestPlanRuns(c.Request.Context(), c.Param("id"), middleware.GetTenantID(c), middleware.GetProjectID(c), limit, offset)
+	if err != nil {
+		respondTestPlanError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   runs,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+func respondTestPlanError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apierrors.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, apierrors.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, apierrors.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
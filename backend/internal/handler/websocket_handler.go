@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/middleware"
+	"test-management-service/internal/repository"
 	"test-management-service/internal/websocket"
 
 	"github.com/gin-gonic/gin"
@@ -20,21 +24,51 @@ var upgrader = gorillaws.Upgrader{
 
 // WebSocketHandler handles WebSocket connections
 type WebSocketHandler struct {
-	hub *websocket.Hub
+	hub     *websocket.Hub
+	runRepo repository.TestRunRepository
 }
 
 // NewWebSocketHandler creates a new WebSocket handler
-func NewWebSocketHandler(hub *websocket.Hub) *WebSocketHandler {
-	return &WebSocketHandler{hub: hub}
+func NewWebSocketHandler(hub *websocket.Hub, runRepo repository.TestRunRepository) *WebSocketHandler {
+	return &WebSocketHandler{hub: hub, runRepo: runRepo}
 }
 
 // RegisterRoutes registers WebSocket routes
 func (h *WebSocketHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("/workflows/runs/:runId/stream", h.StreamWorkflowRun)
+	rg.GET("/ws/test-runs/:runId", h.StreamTestRun)
 }
 
 // StreamWorkflowRun establishes WebSocket connection for workflow run
 func (h *WebSocketHandler) StreamWorkflowRun(c *gin.Context) {
+	h.stream(c)
+}
+
+// StreamTestRun establishes WebSocket connection for the progress of a test
+// run started by a group execution. The run must belong to the tenant and
+// project of the request.
+func (h *WebSocketHandler) StreamTestRun(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+	projectID := middleware.GetProjectID(c)
+
+	run, err := h.runRepo.FindByIDWithTenant(c.Request.Context(), c.Param("runId"), tenantID, projectID)
+	if err != nil {
+		if errors.Is(err, apierrors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "test run not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if run == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "test run not found"})
+		return
+	}
+	h.stream(c)
+}
+
+// stream subscribes the connection to the events of the run in the path
+func (h *WebSocketHandler) stream(c *gin.Context) {
 	runID := c.Param("runId")
 	if runID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "runId is required"})
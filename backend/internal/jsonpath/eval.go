@@ -0,0 +1,373 @@
+package jsonpath
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type selectorKind int
+
+const (
+	selectName selectorKind = iota
+	selectIndex
+	selectWildcard
+	selectSlice
+	selectFilter
+)
+
+// segment is one step of a path: .name, [selectors] or ..selectors
+type segment struct {
+	descendant bool
+	selectors  []selector
+}
+
+type selector struct {
+	kind   selectorKind
+	name   string
+	index  int
+	slice  [3]*int // start, end, step
+	filter filterExpr
+}
+
+func (s segment) apply(root, node interface{}, out []interface{}) []interface{} {
+	for _, sel := range s.selectors {
+		out = sel.apply(root, node, out)
+	}
+	return out
+}
+
+func (s selector) apply(root, node interface{}, out []interface{}) []interface{} {
+	switch s.kind {
+	case selectName:
+		if v, ok := member(node, s.name); ok {
+			out = append(out, v)
+		}
+	case selectIndex:
+		if v, ok := element(node, s.index); ok {
+			out = append(out, v)
+		}
+	case selectWildcard:
+		out = append(out, children(node)...)
+	case selectSlice:
+		if array, ok := generic(node).([]interface{}); ok {
+			out = appendSlice(out, array, s.slice)
+		}
+	case selectFilter:
+		for _, child := range children(node) {
+			if s.filter.test(root, child) {
+				out = append(out, child)
+			}
+		}
+	}
+	return out
+}
+
+// appendSlice appends array[start:end:step]
+func appendSlice(out, array []interface{}, bounds [3]*int) []interface{} {
+	for _, i := range sliceIndexes(len(array), bounds) {
+		out = append(out, array[i])
+	}
+	return out
+}
+
+// sliceIndexes returns the indexes selected by start:end:step in an array of
+// length n, with RFC 9535 semantics
+func sliceIndexes(n int, bounds [3]*int) []int {
+	step := 1
+	if bounds[2] != nil {
+		step = *bounds[2]
+	}
+	if step == 0 {
+		return nil
+	}
+
+	normalize := func(i int) int {
+		if i < 0 {
+			return n + i
+		}
+		return i
+	}
+	clamp := func(i, lo, hi int) int {
+		if i < lo {
+			return lo
+		}
+		if i > hi {
+			return hi
+		}
+		return i
+	}
+
+	var indexes []int
+	if step > 0 {
+		start, end := 0, n
+		if bounds[0] != nil {
+			start = clamp(normalize(*bounds[0]), 0, n)
+		}
+		if bounds[1] != nil {
+			end = clamp(normalize(*bounds[1]), 0, n)
+		}
+		for i := start; i < end; i += step {
+			indexes = append(indexes, i)
+		}
+		return indexes
+	}
+
+	start, end := n-1, -1
+	if bounds[0] != nil {
+		start = clamp(normalize(*bounds[0]), -1, n-1)
+	}
+	if bounds[1] != nil {
+		end = clamp(normalize(*bounds[1]), -1, n-1)
+	}
+	for i := start; i > end; i += step {
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// filterExpr is a boolean filter expression, e.g. @.price > 10 && @.tags
+type filterExpr interface {
+	test(root, current interface{}) bool
+}
+
+type orExpr struct{ left, right filterExpr }
+
+func (e orExpr) test(root, current interface{}) bool {
+	return e.left.test(root, current) || e.right.test(root, current)
+}
+
+type andExpr struct{ left, right filterExpr }
+
+func (e andExpr) test(root, current interface{}) bool {
+	return e.left.test(root, current) && e.right.test(root, current)
+}
+
+type notExpr struct{ expr filterExpr }
+
+func (e notExpr) test(root, current interface{}) bool {
+	return !e.expr.test(root, current)
+}
+
+// existsExpr tests that a path selects something, or that a function is true
+type existsExpr struct{ operand operand }
+
+func (e existsExpr) test(root, current interface{}) bool {
+	value, ok := e.operand.value(root, current)
+	if b, isBool := value.(bool); ok && isBool {
+		if _, isFunc := e.operand.(funcOperand); isFunc {
+			return b
+		}
+	}
+	return ok
+}
+
+type compareExpr struct {
+	op          string
+	left, right operand
+}
+
+func (e compareExpr) test(root, current interface{}) bool {
+	left, lok := e.left.value(root, current)
+	right, rok := e.right.value(root, current)
+
+	switch e.op {
+	case "==":
+		return equalOrNothing(left, lok, right, rok)
+	case "!=":
+		return !equalOrNothing(left, lok, right, rok)
+	case "=~":
+		s, ok := left.(string)
+		if !lok || !rok || !ok {
+			return false
+		}
+		re, err := regexFor(right)
+		return err == nil && re.MatchString(s)
+	}
+
+	if !lok || !rok {
+		return false
+	}
+	switch e.op {
+	case "<":
+		return less(left, right)
+	case "<=":
+		return less(left, right) || Equal(left, right)
+	case ">":
+		return less(right, left)
+	case ">=":
+		return less(right, left) || Equal(left, right)
+	}
+	return false
+}
+
+// equalOrNothing compares two operands where a missing value only equals another missing value
+func equalOrNothing(a interface{}, aok bool, b interface{}, bok bool) bool {
+	if !aok || !bok {
+		return !aok && !bok
+	}
+	return Equal(a, b)
+}
+
+// less orders numbers numerically and strings lexically; other types are unordered
+func less(a, b interface{}) bool {
+	if an, ok := toNumber(a); ok {
+		bn, ok := toNumber(b)
+		return ok && an < bn
+	}
+	as, ok := a.(string)
+	if !ok {
+		return false
+	}
+	bs, ok := b.(string)
+	return ok && as < bs
+}
+
+// operand is a value in a filter expression
+type operand interface {
+	value(root, current interface{}) (interface{}, bool)
+}
+
+type literalOperand struct{ v interface{} }
+
+func (o literalOperand) value(root, current interface{}) (interface{}, bool) {
+	return o.v, true
+}
+
+// pathOperand is an embedded query: @ relative to the current node, $ to the root
+type pathOperand struct {
+	path     *Path
+	relative bool
+}
+
+func (o pathOperand) value(root, current interface{}) (interface{}, bool) {
+	if o.relative {
+		return o.path.lookup(root, current)
+	}
+	return o.path.lookup(root, root)
+}
+
+func (o pathOperand) count(root, current interface{}) int {
+	start := root
+	if o.relative {
+		start = current
+	}
+	if o.path.fn != nil {
+		if _, ok := o.path.lookup(root, start); ok {
+			return 1
+		}
+		return 0
+	}
+	return len(o.path.nodes(root, start))
+}
+
+type funcOperand struct {
+	name string
+	args []operand
+}
+
+func (o funcOperand) value(root, current interface{}) (interface{}, bool) {
+	switch o.name {
+	case "length":
+		v, ok := o.args[0].value(root, current)
+		if !ok {
+			return nil, false
+		}
+		return length(v)
+	case "count":
+		if p, ok := o.args[0].(pathOperand); ok {
+			return float64(p.count(root, current)), true
+		}
+		return nil, false
+	case "value":
+		return o.args[0].value(root, current)
+	case "match", "search":
+		v, ok := o.args[0].value(root, current)
+		s, isString := v.(string)
+		if !ok || !isString {
+			return false, true
+		}
+		pattern, ok := o.args[1].value(root, current)
+		if !ok {
+			return false, true
+		}
+		re, err := regexFor(pattern)
+		if err != nil {
+			return false, true
+		}
+		if o.name == "match" {
+			// match() must cover the whole string
+			if re, err = regexFor("^(?:" + re.String() + ")$"); err != nil {
+				return false, true
+			}
+		}
+		return re.MatchString(s), true
+	}
+	return nil, false
+}
+
+// filterFunctions lists the functions usable in filters and their arity
+var filterFunctions = map[string]int{
+	"length": 1,
+	"count":  1,
+	"value":  1,
+	"match":  2,
+	"search": 2,
+}
+
+// length returns the length of a string (in characters), array or object
+func length(v interface{}) (interface{}, bool) {
+	if s, ok := v.(string); ok {
+		return float64(utf8.RuneCountInString(s)), true
+	}
+	switch node := generic(v).(type) {
+	case []interface{}:
+		return float64(len(node)), true
+	case map[string]interface{}:
+		return float64(len(node)), true
+	}
+	return nil, false
+}
+
+// regexLiteral is a /pattern/flags literal, compiled at parse time
+type regexLiteral struct{ re *regexp.Regexp }
+
+var regexCache sync.Map
+
+// regexFor returns the regexp for a regex literal or pattern string
+func regexFor(v interface{}) (*regexp.Regexp, error) {
+	switch p := v.(type) {
+	case regexLiteral:
+		return p.re, nil
+	case string:
+		if re, ok := regexCache.Load(p); ok {
+			return re.(*regexp.Regexp), nil
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		regexCache.Store(p, re)
+		return re, nil
+	}
+	return nil, errNotPattern
+}
+
+var errNotPattern = errors.New("regular expression must be a string")
+
+// regexFlags converts /.../flags into an inline flag group
+func regexFlags(flags string) string {
+	var b strings.Builder
+	for _, f := range flags {
+		switch f {
+		case 'i', 'm', 's':
+			b.WriteRune(f)
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "(?" + b.String() + ")"
+}
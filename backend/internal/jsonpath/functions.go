@@ -0,0 +1,83 @@
+package jsonpath
+
+import (
+	"math"
+)
+
+// pathFunction is a trailing function applied to the path result, e.g. $.items.length()
+type pathFunction struct {
+	name string
+	fn   func(interface{}) (interface{}, bool)
+}
+
+func (f *pathFunction) apply(v interface{}) (interface{}, bool) {
+	return f.fn(v)
+}
+
+var pathFunctions = map[string]func(interface{}) (interface{}, bool){
+	"length": length,
+	"size":   length,
+	"min":    func(v interface{}) (interface{}, bool) { return aggregate(v, "min") },
+	"max":    func(v interface{}) (interface{}, bool) { return aggregate(v, "max") },
+	"sum":    func(v interface{}) (interface{}, bool) { return aggregate(v, "sum") },
+	"avg":    func(v interface{}) (interface{}, bool) { return aggregate(v, "avg") },
+	"keys": func(v interface{}) (interface{}, bool) {
+		object, ok := generic(v).(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		keys := sortedKeys(object)
+		result := make([]interface{}, len(keys))
+		for i, k := range keys {
+			result[i] = k
+		}
+		return result, true
+	},
+	"first": func(v interface{}) (interface{}, bool) { return element(v, 0) },
+	"last":  func(v interface{}) (interface{}, bool) { return element(v, -1) },
+}
+
+// aggregate computes min, max, sum or avg over the numbers of an array
+func aggregate(v interface{}, op string) (interface{}, bool) {
+	array, ok := generic(v).([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	var numbers []float64
+	for _, item := range array {
+		if n, ok := toNumber(item); ok {
+			numbers = append(numbers, n)
+		}
+	}
+	if len(numbers) == 0 {
+		if op == "sum" {
+			return float64(0), true
+		}
+		return nil, false
+	}
+
+	switch op {
+	case "min":
+		result := math.Inf(1)
+		for _, n := range numbers {
+			result = math.Min(result, n)
+		}
+		return result, true
+	case "max":
+		result := math.Inf(-1)
+		for _, n := range numbers {
+			result = math.Max(result, n)
+		}
+		return result, true
+	}
+
+	var sum float64
+	for _, n := range numbers {
+		sum += n
+	}
+	if op == "avg" {
+		return sum / float64(len(numbers)), true
+	}
+	return sum, true
+}
@@ -0,0 +1,203 @@
+// Package jsonpath implements JSONPath (RFC 9535 with common Jayway
+// extensions) for assertions, output extraction and data mapping.
+//
+// Supported syntax:
+//
+//	$.store.book[0].title        member and index access, negative indexes
+//	$['store']["book"][-1]       bracket notation
+//	$.store.*  $..price          wildcards and recursive descent
+//	$.book[0,2]  $.book[1:3:1]   unions and slices
+//	$.book[?(@.price > 10)]      filters: == != < <= > >= =~ && || !
+//	$.book[?length(@.tags) > 1]  filter functions: length count match search value
+//	$.book.length()              trailing functions: length size min max avg sum keys first last
+//
+// Paths without a leading "$" are relative to the root, so "body.user.id" and
+// "$.body.user.id" are the same path. Numeric member names index arrays
+// ("items.0.name").
+package jsonpath
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SyntaxError reports an invalid path expression
+type SyntaxError struct {
+	Path   string
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid JSONPath %q at offset %d: %s", e.Path, e.Offset, e.Msg)
+}
+
+// Path is a compiled JSONPath expression, safe for concurrent use
+type Path struct {
+	raw      string
+	segments []segment
+	fn       *pathFunction // trailing function, e.g. .length()
+}
+
+// String returns the source expression
+func (p *Path) String() string {
+	return p.raw
+}
+
+// Definite reports whether the path selects at most one node (no wildcards,
+// slices, unions, filters or recursive descent)
+func (p *Path) Definite() bool {
+	for _, seg := range p.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		switch seg.selectors[0].kind {
+		case selectName, selectIndex:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Query returns every node selected by the path, in document order
+func (p *Path) Query(doc interface{}) []interface{} {
+	if p.fn != nil {
+		if value, ok := p.Lookup(doc); ok {
+			return []interface{}{value}
+		}
+		return nil
+	}
+	return p.nodes(doc, doc)
+}
+
+// Lookup returns the value selected by the path. A definite path yields the
+// node itself; any other path yields the selected nodes as an array, and
+// reports ok only when at least one node matched.
+func (p *Path) Lookup(doc interface{}) (interface{}, bool) {
+	return p.lookup(doc, doc)
+}
+
+func (p *Path) lookup(root, current interface{}) (interface{}, bool) {
+	nodes := p.nodes(root, current)
+
+	var value interface{}
+	var ok bool
+	if p.Definite() {
+		if len(nodes) > 0 {
+			value, ok = nodes[0], true
+		}
+	} else {
+		if nodes == nil {
+			nodes = []interface{}{}
+		}
+		value, ok = nodes, len(nodes) > 0
+		if p.fn != nil {
+			// Functions apply to the (possibly empty) result list
+			ok = true
+		}
+	}
+
+	if p.fn != nil && ok {
+		return p.fn.apply(value)
+	}
+	return value, ok
+}
+
+// nodes evaluates the segments starting at current
+func (p *Path) nodes(root, current interface{}) []interface{} {
+	nodes := []interface{}{current}
+	for _, seg := range p.segments {
+		var next []interface{}
+		for _, node := range nodes {
+			if seg.descendant {
+				descend(node, func(n interface{}) {
+					next = seg.apply(root, n, next)
+				})
+			} else {
+				next = seg.apply(root, node, next)
+			}
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// descend visits node and all of its descendants in document order
+func descend(node interface{}, visit func(interface{})) {
+	visit(node)
+	for _, child := range children(node) {
+		descend(child, visit)
+	}
+}
+
+// compiled paths are cached; paths come from stored test definitions, so the
+// set is small and stable
+var (
+	cacheMu   sync.RWMutex
+	cache     = make(map[string]*Path)
+	cacheSize = 1024
+)
+
+// Compile parses a path expression
+func Compile(expr string) (*Path, error) {
+	cacheMu.RLock()
+	p, ok := cache[expr]
+	cacheMu.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	p, err := parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheMu.Lock()
+	if len(cache) >= cacheSize {
+		cache = make(map[string]*Path)
+	}
+	cache[expr] = p
+	cacheMu.Unlock()
+	return p, nil
+}
+
+// MustCompile is like Compile but panics on invalid expressions
+func MustCompile(expr string) *Path {
+	p, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Lookup compiles expr and looks it up in doc
+func Lookup(doc interface{}, expr string) (interface{}, bool, error) {
+	p, err := Compile(expr)
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := p.Lookup(doc)
+	return value, ok, nil
+}
+
+// Query compiles expr and returns every node it selects in doc
+func Query(doc interface{}, expr string) ([]interface{}, error) {
+	p, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Query(doc), nil
+}
+
+// Get returns the value at expr, or nil when the path is invalid or matches nothing
+func Get(doc interface{}, expr string) interface{} {
+	value, ok, err := Lookup(doc, expr)
+	if err != nil || !ok {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,152 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const storeJSON = `{
+  "store": {
+    "book": [
+      {"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+      {"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99, "tags": ["war", "classic"]},
+      {"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+      {"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+    ],
+    "bicycle": {"color": "red", "price": 19.95}
+  },
+  "content-type": "application/json",
+  "empty": null
+}`
+
+func store(t *testing.T) interface{} {
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(storeJSON), &doc))
+	return doc
+}
+
+func TestLookup(t *testing.T) {
+	doc := store(t)
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"$.store.bicycle.color", "red"},
+		{"store.bicycle.color", "red"},
+		{"$['store']['bicycle'][\"price\"]", 19.95},
+		{"$.store.book[0].author", "Nigel Rees"},
+		{"$.store.book[-1].title", "The Lord of the Rings"},
+		{"store.book.1.price", 12.99},
+		{"$.content-type", "application/json"},
+		{"$.store.book[*].author", []interface{}{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}},
+		{"$.store.book[0,2].price", []interface{}{8.95, 8.99}},
+		{"$.store.book[1:3].title", []interface{}{"Sword of Honour", "Moby Dick"}},
+		{"$.store.book[::-2].price", []interface{}{22.99, 12.99}},
+		{"$..isbn", []interface{}{"0-553-21311-3", "0-395-19395-8"}},
+		{"$.store.book[?(@.price > 10)].title", []interface{}{"Sword of Honour", "The Lord of the Rings"}},
+		{"$.store.book[?@.isbn && @.price < 10].title", []interface{}{"Moby Dick"}},
+		{"$.store.book[?(@.category == 'reference' || @.price >= 22.99)].price", []interface{}{8.95, 22.99}},
+		{"$.store.book[?(!@.isbn)].title", []interface{}{"Sayings of the Century", "Sword of Honour"}},
+		{"$.store.book[?(@.author =~ /^h.*/i)].title", []interface{}{"Moby Dick"}},
+		{"$.store.book[?match(@.category, 'fic.*')].price", []interface{}{12.99, 8.99, 22.99}},
+		{"$.store.book[?search(@.title, 'Lord')].price", []interface{}{22.99}},
+		{"$.store.book[?length(@.tags) == 2].title", []interface{}{"Sword of Honour"}},
+		{"$.store.book[?(@.price > $.store.bicycle.price)].title", []interface{}{"The Lord of the Rings"}},
+		{"$.store.book[?count(@.tags[*]) == 2].title", []interface{}{"Sword of Honour"}},
+		{"$.store.book.length()", float64(4)},
+		{"$.store.book[*].price.max()", 22.99},
+		{"$.store.book[*].price.min()", 8.95},
+		{"$.store.book[?(@.price > 100)].length()", float64(0)},
+		{"$.store.bicycle.keys()", []interface{}{"color", "price"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			value, ok, err := Lookup(doc, tt.path)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
+
+func TestLookup_Missing(t *testing.T) {
+	doc := store(t)
+
+	for _, path := range []string{"$.nope", "$.store.book[10]", "$.store.book[?(@.price > 100)]", "$.store.bicycle.color.x"} {
+		_, ok, err := Lookup(doc, path)
+		require.NoError(t, err)
+		assert.False(t, ok, path)
+	}
+
+	// null is a value, not a missing member
+	value, ok, err := Lookup(doc, "$.empty")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, value)
+}
+
+func TestLookup_GoValues(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Price int    `json:"price"`
+	}
+	doc := map[string]interface{}{
+		"items":   []item{{"a", 5}, {"b", 15}},
+		"headers": map[string]string{"X-Id": "1"},
+	}
+
+	assert.Equal(t, []interface{}{"b"}, Get(doc, "$.items[?(@.price > 10)].name"))
+	assert.Equal(t, "1", Get(doc, "headers.X-Id"))
+	assert.Equal(t, float64(2), Get(doc, "$.items.length()"))
+}
+
+func TestQuery(t *testing.T) {
+	nodes, err := Query(store(t), "$..price")
+	require.NoError(t, err)
+	assert.Len(t, nodes, 5)
+
+	assert.True(t, MustCompile("$.a.b[0]").Definite())
+	assert.False(t, MustCompile("$.a[*]").Definite())
+	assert.False(t, MustCompile("$..a").Definite())
+}
+
+func TestCompile_Errors(t *testing.T) {
+	for _, path := range []string{"", "$.", "$[", "$.a[?(@.b ==)]", "$.a[?(@.b > 1]", "$.a.nope()", "$[?foo(@)]", "$['a"} {
+		_, err := Compile(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal(1, 1.0))
+	assert.True(t, Equal(int64(3), json.Number("3")))
+	assert.True(t, Equal(map[string]interface{}{"a": []interface{}{1, "x"}}, map[string]interface{}{"a": []interface{}{1.0, "x"}}))
+	assert.True(t, Equal([]string{"a"}, []interface{}{"a"}))
+	assert.False(t, Equal("1", 1))
+	assert.False(t, Equal(nil, false))
+	assert.False(t, Equal(map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1, "b": 2}))
+}
+
+func TestReplace(t *testing.T) {
+	doc := Normalize(map[string]interface{}{
+		"id":    "x1",
+		"items": []interface{}{map[string]interface{}{"id": 1, "at": "t1"}, map[string]interface{}{"id": 2, "at": "t2"}},
+	})
+	mask := func(interface{}) interface{} { return "*" }
+
+	doc = MustCompile("$..id").Replace(doc, mask)
+	doc = MustCompile("$.items[?(@.at == 't2')].at").Replace(doc, mask)
+	assert.Equal(t, map[string]interface{}{
+		"id": "*",
+		"items": []interface{}{
+			map[string]interface{}{"id": "*", "at": "t1"},
+			map[string]interface{}{"id": "*", "at": "*"},
+		},
+	}, doc)
+
+	assert.Equal(t, "*", MustCompile("$").Replace(doc, mask))
+}
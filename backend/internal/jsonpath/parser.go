@@ -0,0 +1,524 @@
+package jsonpath
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type parser struct {
+	src string
+	pos int
+}
+
+func parse(expr string) (*Path, error) {
+	src := strings.TrimSpace(expr)
+	if src == "" {
+		return nil, &SyntaxError{Path: expr, Msg: "empty path"}
+	}
+	switch src[0] {
+	case '$':
+	case '[', '.':
+		src = "$" + src
+	default:
+		src = "$." + src
+	}
+
+	p := &parser{src: src, pos: 1}
+	path, err := p.parsePath(false)
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	path.raw = expr
+	return path, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Path: p.src, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *parser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// parsePath parses segments after the root identifier. Inside filters the
+// path ends at the first character that cannot continue it.
+func (p *parser) parsePath(inFilter bool) (*Path, error) {
+	path := &Path{}
+	for !p.eof() {
+		switch {
+		case p.consume(".."):
+			seg := segment{descendant: true}
+			switch p.peek() {
+			case '[':
+				selectors, err := p.parseBracket()
+				if err != nil {
+					return nil, err
+				}
+				seg.selectors = selectors
+			case '*':
+				p.pos++
+				seg.selectors = []selector{{kind: selectWildcard}}
+			default:
+				name, err := p.parseName(inFilter)
+				if err != nil {
+					return nil, err
+				}
+				seg.selectors = []selector{{kind: selectName, name: name}}
+			}
+			path.segments = append(path.segments, seg)
+
+		case p.consume("."):
+			if p.peek() == '*' {
+				p.pos++
+				path.segments = append(path.segments, segment{selectors: []selector{{kind: selectWildcard}}})
+				continue
+			}
+			name, err := p.parseName(inFilter)
+			if err != nil {
+				return nil, err
+			}
+			if p.consume("(") {
+				if !p.consume(")") {
+					return nil, p.errorf("path functions take no arguments")
+				}
+				fn, ok := pathFunctions[name]
+				if !ok {
+					return nil, p.errorf("unknown function %s()", name)
+				}
+				path.fn = &pathFunction{name: name, fn: fn}
+				return path, nil
+			}
+			path.segments = append(path.segments, segment{selectors: []selector{{kind: selectName, name: name}}})
+
+		case p.peek() == '[':
+			selectors, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			path.segments = append(path.segments, segment{selectors: selectors})
+
+		default:
+			if inFilter {
+				return path, nil
+			}
+			return nil, p.errorf("unexpected %q", p.src[p.pos])
+		}
+	}
+	return path, nil
+}
+
+// parseName parses a dot-notation member name; "\" escapes the next character
+func (p *parser) parseName(inFilter bool) (string, error) {
+	stop := ".[]()"
+	if inFilter {
+		stop += " \t\r\n=!<>&|,~"
+	}
+
+	var b strings.Builder
+	for !p.eof() {
+		c := p.src[p.pos]
+		if c == '\\' && p.pos+1 < len(p.src) {
+			b.WriteByte(p.src[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		if strings.IndexByte(stop, c) >= 0 {
+			break
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+	if b.Len() == 0 {
+		return "", p.errorf("expected member name")
+	}
+	return b.String(), nil
+}
+
+// parseBracket parses [selector, selector, ...]
+func (p *parser) parseBracket() ([]selector, error) {
+	p.pos++ // [
+	var selectors []selector
+	for {
+		p.skipSpace()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.skipSpace()
+		switch {
+		case p.consume(","):
+		case p.consume("]"):
+			return selectors, nil
+		default:
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *parser) parseSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		return selector{kind: selectWildcard}, nil
+	case c == '\'' || c == '"':
+		name, err := p.parseString()
+		if err != nil {
+			return selector{}, err
+		}
+		return selector{kind: selectName, name: name}, nil
+	case c == '?':
+		p.pos++
+		filter, err := p.parseOr()
+		if err != nil {
+			return selector{}, err
+		}
+		return selector{kind: selectFilter, filter: filter}, nil
+	case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+		return p.parseIndexOrSlice()
+	}
+	return selector{}, p.errorf("expected selector")
+}
+
+func (p *parser) parseIndexOrSlice() (selector, error) {
+	start, err := p.parseInt()
+	if err != nil {
+		return selector{}, err
+	}
+	p.skipSpace()
+	if !p.consume(":") {
+		if start == nil {
+			return selector{}, p.errorf("expected index")
+		}
+		return selector{kind: selectIndex, index: *start}, nil
+	}
+
+	sel := selector{kind: selectSlice}
+	sel.slice[0] = start
+	p.skipSpace()
+	if sel.slice[1], err = p.parseInt(); err != nil {
+		return selector{}, err
+	}
+	p.skipSpace()
+	if p.consume(":") {
+		p.skipSpace()
+		if sel.slice[2], err = p.parseInt(); err != nil {
+			return selector{}, err
+		}
+	}
+	return sel, nil
+}
+
+// parseInt parses an optional integer
+func (p *parser) parseInt() (*int, error) {
+	begin := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for !p.eof() && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	text := p.src[begin:p.pos]
+	if text == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		p.pos = begin
+		return nil, p.errorf("invalid integer %q", text)
+	}
+	return &n, nil
+}
+
+// parseString parses a single or double quoted string with JSON escapes
+func (p *parser) parseString() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var b strings.Builder
+	for !p.eof() {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\\':
+			p.pos++
+			if p.eof() {
+				return "", p.errorf("unterminated string")
+			}
+			esc := p.src[p.pos]
+			p.pos++
+			switch esc {
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if p.pos+4 > len(p.src) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape")
+				}
+				b.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				b.WriteByte(esc)
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+			b.WriteRune(r)
+			p.pos += size
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// Filter grammar:
+//
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" or ")" | comparison
+//	comparison = operand [ op operand ]
+func (p *parser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("||") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+}
+
+func (p *parser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("&&") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+}
+
+func (p *parser) parseUnary() (filterExpr, error) {
+	p.skipSpace()
+	if p.peek() == '!' && !strings.HasPrefix(p.src[p.pos:], "!=") {
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr}, nil
+	}
+	if p.consume("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, p.errorf("expected ')'")
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+var comparisonOps = []string{"==", "!=", "<=", ">=", "=~", "<", ">"}
+
+func (p *parser) parseComparison() (filterExpr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	for _, op := range comparisonOps {
+		if !p.consume(op) {
+			continue
+		}
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return compareExpr{op: op, left: left, right: right}, nil
+	}
+
+	if _, ok := left.(literalOperand); ok {
+		return nil, p.errorf("expected comparison operator")
+	}
+	return existsExpr{left}, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	p.skipSpace()
+	c := p.peek()
+	switch {
+	case c == '@' || c == '$':
+		p.pos++
+		path, err := p.parsePath(true)
+		if err != nil {
+			return nil, err
+		}
+		path.raw = string(c)
+		return pathOperand{path: path, relative: c == '@'}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return literalOperand{s}, nil
+	case c == '/':
+		return p.parseRegex()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case c >= 'a' && c <= 'z':
+		return p.parseIdentifier()
+	}
+	if p.eof() {
+		return nil, p.errorf("unexpected end of filter")
+	}
+	return nil, p.errorf("unexpected %q in filter", c)
+}
+
+func (p *parser) parseNumber() (operand, error) {
+	begin := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for !p.eof() && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	n, err := strconv.ParseFloat(p.src[begin:p.pos], 64)
+	if err != nil {
+		p.pos = begin
+		return nil, p.errorf("invalid number")
+	}
+	return literalOperand{n}, nil
+}
+
+// parseRegex parses /pattern/flags
+func (p *parser) parseRegex() (operand, error) {
+	p.pos++ // /
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return nil, p.errorf("unterminated regular expression")
+		}
+		c := p.src[p.pos]
+		p.pos++
+		if c == '/' {
+			break
+		}
+		if c == '\\' && p.peek() == '/' {
+			c = '/'
+			p.pos++
+		}
+		b.WriteByte(c)
+	}
+	flagsStart := p.pos
+	for !p.eof() && p.src[p.pos] >= 'a' && p.src[p.pos] <= 'z' {
+		p.pos++
+	}
+	re, err := regexp.Compile(regexFlags(p.src[flagsStart:p.pos]) + b.String())
+	if err != nil {
+		return nil, p.errorf("invalid regular expression: %v", err)
+	}
+	return literalOperand{regexLiteral{re}}, nil
+}
+
+// parseIdentifier parses true, false, null or a function call
+func (p *parser) parseIdentifier() (operand, error) {
+	begin := p.pos
+	for !p.eof() && (p.src[p.pos] >= 'a' && p.src[p.pos] <= 'z' || p.src[p.pos] == '_') {
+		p.pos++
+	}
+	name := p.src[begin:p.pos]
+	switch name {
+	case "true":
+		return literalOperand{true}, nil
+	case "false":
+		return literalOperand{false}, nil
+	case "null":
+		return literalOperand{nil}, nil
+	}
+
+	arity, ok := filterFunctions[name]
+	if !ok || !p.consume("(") {
+		p.pos = begin
+		return nil, p.errorf("unknown function %s()", name)
+	}
+	fn := funcOperand{name: name}
+	for {
+		p.skipSpace()
+		if p.consume(")") {
+			break
+		}
+		if len(fn.args) > 0 && !p.consume(",") {
+			return nil, p.errorf("expected ',' or ')'")
+		}
+		arg, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		fn.args = append(fn.args, arg)
+	}
+	if len(fn.args) != arity {
+		return nil, p.errorf("%s() takes %d argument(s), got %d", name, arity, len(fn.args))
+	}
+	if name == "count" {
+		if _, ok := fn.args[0].(pathOperand); !ok {
+			return nil, p.errorf("count() requires a path argument")
+		}
+	}
+	return fn, nil
+}
@@ -0,0 +1,102 @@
+package jsonpath
+
+import "strconv"
+
+// Replace calls fn for every node selected by the path and stores the result
+// in its place. The document is modified in place and must consist of generic
+// JSON values (see Normalize); the returned value is the new root, which
+// differs from doc only when the path selects the root itself.
+func (p *Path) Replace(doc interface{}, fn func(interface{}) interface{}) interface{} {
+	if p.fn != nil {
+		// Function results are not part of the document
+		return doc
+	}
+	return replace(doc, doc, p.segments, fn)
+}
+
+func replace(root, node interface{}, segs []segment, fn func(interface{}) interface{}) interface{} {
+	if len(segs) == 0 {
+		return fn(node)
+	}
+	seg, rest := segs[0], segs[1:]
+
+	switch container := node.(type) {
+	case map[string]interface{}:
+		for _, key := range seg.memberKeys(root, container) {
+			container[key] = replace(root, container[key], rest, fn)
+		}
+		if seg.descendant {
+			for _, key := range sortedKeys(container) {
+				container[key] = replace(root, container[key], segs, fn)
+			}
+		}
+	case []interface{}:
+		for _, i := range seg.elementIndexes(root, container) {
+			container[i] = replace(root, container[i], rest, fn)
+		}
+		if seg.descendant {
+			for i := range container {
+				container[i] = replace(root, container[i], segs, fn)
+			}
+		}
+	}
+	return node
+}
+
+// memberKeys returns the keys of object members the segment's selectors select
+func (s segment) memberKeys(root interface{}, object map[string]interface{}) []string {
+	var keys []string
+	for _, sel := range s.selectors {
+		switch sel.kind {
+		case selectName:
+			if _, ok := object[sel.name]; ok {
+				keys = append(keys, sel.name)
+			}
+		case selectWildcard:
+			keys = append(keys, sortedKeys(object)...)
+		case selectFilter:
+			for _, key := range sortedKeys(object) {
+				if sel.filter.test(root, object[key]) {
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// elementIndexes returns the indexes of array elements the segment's selectors select
+func (s segment) elementIndexes(root interface{}, array []interface{}) []int {
+	var indexes []int
+	addIndex := func(i int) {
+		if i < 0 {
+			i += len(array)
+		}
+		if i >= 0 && i < len(array) {
+			indexes = append(indexes, i)
+		}
+	}
+	for _, sel := range s.selectors {
+		switch sel.kind {
+		case selectName:
+			if i, err := strconv.Atoi(sel.name); err == nil {
+				addIndex(i)
+			}
+		case selectIndex:
+			addIndex(sel.index)
+		case selectWildcard:
+			for i := range array {
+				indexes = append(indexes, i)
+			}
+		case selectSlice:
+			indexes = append(indexes, sliceIndexes(len(array), sel.slice)...)
+		case selectFilter:
+			for i, item := range array {
+				if sel.filter.test(root, item) {
+					indexes = append(indexes, i)
+				}
+			}
+		}
+	}
+	return indexes
+}
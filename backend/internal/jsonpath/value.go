@@ -0,0 +1,209 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// generic converts v into the representation JSONPath navigates: objects as
+// map[string]interface{}, arrays as []interface{}. Structs and typed maps or
+// slices are viewed through their JSON encoding, so field names match json tags.
+func generic(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, map[string]interface{}, []interface{}, string, bool, float64, int, int64, json.Number:
+		return v
+	case json.RawMessage:
+		var decoded interface{}
+		if json.Unmarshal(v.(json.RawMessage), &decoded) != nil {
+			return nil
+		}
+		return decoded
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return generic(rv.Elem().Interface())
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return roundTrip(v)
+		}
+		result := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			result[iter.Key().String()] = iter.Value().Interface()
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return roundTrip(v)
+		}
+		result := make([]interface{}, rv.Len())
+		for i := range result {
+			result[i] = rv.Index(i).Interface()
+		}
+		return result
+	case reflect.Struct:
+		return roundTrip(v)
+	}
+	return v
+}
+
+// Normalize returns v as plain JSON values (float64 numbers, generic objects
+// and arrays), the form it takes after being stored and loaded again
+func Normalize(v interface{}) interface{} {
+	return roundTrip(v)
+}
+
+// roundTrip converts v through its JSON encoding
+func roundTrip(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var decoded interface{}
+	if json.Unmarshal(data, &decoded) != nil {
+		return nil
+	}
+	return decoded
+}
+
+// member returns the value of an object member. Numeric names also index
+// arrays, so dotted paths like "items.0.name" keep working.
+func member(v interface{}, name string) (interface{}, bool) {
+	switch node := generic(v).(type) {
+	case map[string]interface{}:
+		value, ok := node[name]
+		return value, ok
+	case []interface{}:
+		if i, err := strconv.Atoi(name); err == nil {
+			return element(node, i)
+		}
+	}
+	return nil, false
+}
+
+// element returns an array element; negative indexes count from the end
+func element(v interface{}, i int) (interface{}, bool) {
+	array, ok := generic(v).([]interface{})
+	if !ok {
+		return nil, false
+	}
+	if i < 0 {
+		i += len(array)
+	}
+	if i < 0 || i >= len(array) {
+		return nil, false
+	}
+	return array[i], true
+}
+
+// children returns the members of an object (in key order) or the elements of an array
+func children(v interface{}) []interface{} {
+	switch node := generic(v).(type) {
+	case map[string]interface{}:
+		keys := sortedKeys(node)
+		result := make([]interface{}, len(keys))
+		for i, k := range keys {
+			result[i] = node[k]
+		}
+		return result
+	case []interface{}:
+		return node
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toNumber converts any Go or JSON number to float64
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Equal reports whether two JSON values are equal. Numbers compare by value
+// regardless of Go type (1 == 1.0), objects and arrays compare deeply.
+func Equal(a, b interface{}) bool {
+	if an, ok := toNumber(a); ok {
+		bn, ok := toNumber(b)
+		return ok && (an == bn || (math.IsNaN(an) && math.IsNaN(bn)))
+	}
+
+	a, b = generic(a), generic(b)
+	switch av := a.(type) {
+	case nil:
+		return b == nil
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			other, exists := bv[k]
+			if !exists || !Equal(v, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !Equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -75,6 +75,11 @@ type ActionTemplate struct {
 	//     "name": "userId",
 	//     "path": "response.body.user.id",
 	//     "description": "Authenticated user ID"
+	//   },
+	//   {
+	//     "name": "sessionKey",
+	//     "path": "xpath://LoginResponse/SessionKey",
+	//     "description": "SOAP session key (xpath:, css: and regex: paths read the response body)"
 	//   }
 	// ]
 	Outputs JSONArray `gorm:"type:text" json:"outputs"`
@@ -8,17 +8,19 @@ import (
 // Environment represents a test environment (Dev/Staging/Prod)
 // Stores environment configuration and can be activated for test execution
 type Environment struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	EnvID       string         `gorm:"uniqueIndex;size:50;not null" json:"envId"`
-	TenantID    string         `gorm:"index;size:100" json:"tenantId,omitempty"`    // 租户ID
-	ProjectID   string         `gorm:"index;size:100" json:"projectId,omitempty"`   // 项目ID
-	Name        string         `gorm:"size:255;not null" json:"name"`
-	Description string         `gorm:"type:text" json:"description,omitempty"`
-	IsActive    bool           `gorm:"default:false;index" json:"isActive"`
-	Variables   JSONB          `gorm:"type:text;column:variables" json:"variables,omitempty"` // Using existing JSONB type
-	CreatedAt   time.Time      `json:"createdAt"`
-	UpdatedAt   time.Time      `json:"updatedAt"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	EnvID        string         `gorm:"uniqueIndex;size:50;not null" json:"envId"`
+	TenantID     string         `gorm:"index;size:100" json:"tenantId,omitempty"`  // 租户ID
+	ProjectID    string         `gorm:"index;size:100" json:"projectId,omitempty"` // 项目ID
+	Name         string         `gorm:"size:255;not null" json:"name"`
+	Description  string         `gorm:"type:text" json:"description,omitempty"`
+	IsActive     bool           `gorm:"default:false;index" json:"isActive"`
+	Variables    JSONB          `gorm:"type:text;column:variables" json:"variables,omitempty"` // Using existing JSONB type
+	OpenAPISpec  string         `gorm:"size:255" json:"openapiSpec,omitempty"`                 // Stored openapi document that HTTP tests are validated against
+	ContractMode string         `gorm:"size:20" json:"contractMode,omitempty"`                 // strict (default), warn, off
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Associations
 	EnvironmentVariables []EnvironmentVariable `gorm:"foreignKey:EnvID;references:EnvID" json:"environmentVariables,omitempty"`
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Schema document types
+const (
+	SchemaTypeJSONSchema = "json_schema"
+	SchemaTypeOpenAPI    = "openapi"
+)
+
+// SchemaDocument 项目中存储的 JSON Schema 或 OpenAPI 文档
+// 断言通过 schemaRef 按名称引用，OpenAPI 文档可用 "name#/components/schemas/Pet" 引用其中的 schema
+type SchemaDocument struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	TenantID    string         `gorm:"index;size:100" json:"tenantId,omitempty"`         // 租户ID
+	ProjectID   string         `gorm:"index;size:100" json:"projectId,omitempty"`        // 项目ID
+	Name        string         `gorm:"index;size:255;not null" json:"name"`              // 项目内唯一
+	Type        string         `gorm:"size:20;not null;default:json_schema" json:"type"` // json_schema, openapi
+	Description string         `gorm:"type:text" json:"description,omitempty"`
+	Content     JSONB          `gorm:"type:text" json:"content"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name for SchemaDocument model
+func (SchemaDocument) TableName() string {
+	return "schema_documents"
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Snapshot statuses
+const (
+	SnapshotStatusApproved = "approved" // 已批准的基准版本
+	SnapshotStatusPending  = "pending"  // 与基准不一致，等待审核
+	SnapshotStatusRejected = "rejected" // 审核拒绝
+)
+
+// Snapshot 测试用例的响应快照版本
+// 首次运行自动记录为已批准的版本 1；之后的运行与最新已批准版本比较，
+// 不一致时记录为待审核版本，审核通过后成为新的基准。所有版本都会保留。
+type Snapshot struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	TenantID    string     `gorm:"index;size:100" json:"tenantId,omitempty"`  // 租户ID
+	ProjectID   string     `gorm:"index;size:100" json:"projectId,omitempty"` // 项目ID
+	TestID      string     `gorm:"size:255;not null;index" json:"testId"`
+	Name        string     `gorm:"size:255;not null;default:default" json:"name"` // 同一用例内的快照名
+	Version     int        `gorm:"not null" json:"version"`
+	Status      string     `gorm:"size:20;not null;index" json:"status"` // approved, pending, rejected
+	Content     JSONValue  `gorm:"type:text" json:"content"`             // 规范化后的响应
+	Diff        JSONArray  `gorm:"type:text" json:"diff,omitempty"`      // 待审核版本与基准的差异
+	BaseVersion int        `json:"baseVersion,omitempty"`                // 计算差异时的基准版本
+	ApprovedBy  string     `gorm:"size:100" json:"approvedBy,omitempty"` // 审核人，首次自动记录时为空
+	ApprovedAt  *time.Time `json:"approvedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// TableName specifies the table name for Snapshot model
+func (Snapshot) TableName() string {
+	return "snapshots"
+}
+
+// JSONValue 任意 JSON 值（对象、数组或标量）
+type JSONValue struct {
+	Data interface{}
+}
+
+func (j JSONValue) Value() (driver.Value, error) {
+	return json.Marshal(j.Data)
+}
+
+func (j *JSONValue) Scan(value interface{}) error {
+	if value == nil {
+		j.Data = nil
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("failed to unmarshal JSONValue value: unsupported type %T", value)
+	}
+
+	return json.Unmarshal(bytes, &j.Data)
+}
+
+func (j JSONValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Data)
+}
+
+func (j *JSONValue) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &j.Data)
+}
@@ -126,6 +126,7 @@ type AssertionResult struct {
 	Name     string      `json:"name,omitempty"`
 	Type     string      `json:"type"`              // equals, notEquals, contains, exists, etc.
 	Target   string      `json:"target"`            // Expression being checked
+	Path     string      `json:"path,omitempty"`    // JSONPath into the target, if any
 	Expected interface{} `json:"expected,omitempty"` // Expected value
 	Actual   interface{} `json:"actual,omitempty"`   // Actual value
 	Passed   bool        `json:"passed"`
@@ -21,6 +21,12 @@ type TestCase struct {
 	Status          string         `gorm:"size:50;default:'active';index" json:"status"` // active, inactive
 	Objective       string         `gorm:"type:text" json:"objective,omitempty"`
 	Timeout         int            `gorm:"default:300" json:"timeout,omitempty"` // seconds
+	Serial          bool           `gorm:"default:false" json:"serial,omitempty"` // 与其他用例共享状态, 分组执行时单独串行运行
+
+	// 依赖: 批量执行时依赖的用例先执行, 依赖未通过时本用例记为跳过
+	DependsOn JSONArray `gorm:"type:text" json:"dependsOn,omitempty"` // 依赖的用例ID
+	Outputs   JSONB     `gorm:"type:text" json:"outputs,omitempty"`   // 输出变量: 变量名 -> 响应中的 JSONPath, 供依赖本用例的用例使用
+	Inputs    JSONArray `gorm:"type:text" json:"inputs,omitempty"`    // 需要依赖提供的变量, 缺少时本用例记为跳过
 
 	// Workflow integration support
 	WorkflowID      string         `gorm:"size:255;index" json:"workflowId,omitempty"`       // Mode 1: Reference workflow ID
@@ -36,6 +42,7 @@ type TestCase struct {
 	DatabaseConfig    JSONB      `gorm:"type:text;column:database_config" json:"database,omitempty"`
 	SecurityConfig    JSONB      `gorm:"type:text;column:security_config" json:"security,omitempty"`
 	GRPCConfig        JSONB      `gorm:"type:text;column:grpc_config" json:"grpc,omitempty"`
+	GraphQLConfig     JSONB      `gorm:"type:text;column:graphql_config" json:"graphql,omitempty"`
 	WebSocketConfig   JSONB      `gorm:"type:text;column:websocket_config" json:"websocket,omitempty"`
 	E2EConfig         JSONB      `gorm:"type:text;column:e2e_config" json:"e2e,omitempty"`
 	Assertions        JSONArray  `gorm:"type:text" json:"assertions,omitempty"`
@@ -46,6 +53,9 @@ type TestCase struct {
 	SetupHooks    JSONArray `gorm:"type:text;column:setup_hooks" json:"setupHooks,omitempty"`
 	TeardownHooks JSONArray `gorm:"type:text;column:teardown_hooks" json:"teardownHooks,omitempty"`
 
+	// Named HTTP sessions shared by hooks and steps
+	Sessions JSONArray `gorm:"type:text;column:sessions" json:"sessions,omitempty"`
+
 	// Value scoring columns
 	CoverageScore       int `gorm:"default:0;index" json:"coverageScore,omitempty"`
 	StabilityScore      int `gorm:"default:0;index" json:"stabilityScore,omitempty"`
@@ -68,6 +78,14 @@ type TestCase struct {
 	FlakyScore          int  `gorm:"default:0" json:"flakyScore,omitempty"` // 0-100, higher = more flaky
 	ConsecutiveFailures int  `gorm:"default:0" json:"consecutiveFailures,omitempty"`
 
+	// Quarantine: 隔离的用例照常执行, 但结果不计入批次的通过/失败统计
+	Quarantined         bool       `gorm:"default:false;index" json:"quarantined,omitempty"`
+	QuarantineReason    string     `gorm:"type:text" json:"quarantineReason,omitempty"`
+	QuarantineOwner     string     `gorm:"size:100" json:"quarantineOwner,omitempty"`
+	QuarantinedAt       *time.Time `json:"quarantinedAt,omitempty"`
+	QuarantineExpiresAt *time.Time `json:"quarantineExpiresAt,omitempty"` // 到期后自动解除
+	QuarantinePasses    int        `gorm:"default:0" json:"quarantinePasses,omitempty"` // 隔离期间连续通过的次数, 达到阈值后自动解除
+
 	// Ownership and maintenance
 	OwnerID        string `gorm:"size:100;index" json:"ownerId,omitempty"`
 	LastModifiedBy string `gorm:"size:100" json:"lastModifiedBy,omitempty"`
@@ -89,21 +107,25 @@ func (TestCase) TableName() string {
 
 // TestResult 测试执行结果模型
 type TestResult struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	TestID    string    `gorm:"size:255;not null;index" json:"testId"`
-	TenantID  string    `gorm:"index;size:100" json:"tenantId,omitempty"`    // 租户ID
-	ProjectID string    `gorm:"index;size:100" json:"projectId,omitempty"`   // 项目ID
-	RunID     string    `gorm:"size:255;index" json:"runId,omitempty"`
-	Status    string    `gorm:"size:50;not null;index" json:"status"` // passed, failed, error, skipped
-	StartTime time.Time `gorm:"not null;index" json:"startTime"`
-	EndTime   time.Time `json:"endTime,omitempty"`
-	Duration  int       `json:"duration,omitempty"` // milliseconds
-	Error     string    `gorm:"type:text" json:"error,omitempty"`
-	Failures  JSONArray `gorm:"type:text" json:"failures,omitempty"`
-	Metrics   JSONB     `gorm:"type:text" json:"metrics,omitempty"`
-	Artifacts JSONArray `gorm:"type:text" json:"artifacts,omitempty"`
-	Logs      JSONArray `gorm:"type:text" json:"logs,omitempty"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	TestID     string    `gorm:"size:255;not null;index" json:"testId"`
+	TenantID   string    `gorm:"index;size:100" json:"tenantId,omitempty"`    // 租户ID
+	ProjectID  string    `gorm:"index;size:100" json:"projectId,omitempty"`   // 项目ID
+	RunID      string    `gorm:"size:255;index" json:"runId,omitempty"`
+	RetryOf    uint      `gorm:"index" json:"retryOf,omitempty"` // 重跑时为原批次中该用例结果的 ID
+	Quarantined bool     `gorm:"default:false" json:"quarantined,omitempty"` // 执行时用例处于隔离状态, 不计入批次统计
+	Status     string    `gorm:"size:50;not null;index" json:"status"` // passed, failed, error, skipped
+	StartTime  time.Time `gorm:"not null;index" json:"startTime"`
+	EndTime    time.Time `json:"endTime,omitempty"`
+	Duration   int       `json:"duration,omitempty"` // milliseconds
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	Failures   JSONArray `gorm:"type:text" json:"failures,omitempty"`
+	Assertions JSONArray `gorm:"type:text" json:"assertions,omitempty"` // 每条断言的结构化结果 (AssertionResult)
+	Warnings   JSONArray `gorm:"type:text" json:"warnings,omitempty"`   // 契约 warn 模式下的违规信息
+	Metrics    JSONB     `gorm:"type:text" json:"metrics,omitempty"`
+	Artifacts  JSONArray `gorm:"type:text" json:"artifacts,omitempty"`
+	Logs       JSONArray `gorm:"type:text" json:"logs,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
 
 	// 关联
 	TestCase *TestCase `gorm:"foreignKey:TestID;references:TestID" json:"-"`
@@ -121,15 +143,33 @@ type TestRun struct {
 	TenantID  string    `gorm:"index;size:100" json:"tenantId,omitempty"`    // 租户ID
 	ProjectID string    `gorm:"index;size:100" json:"projectId,omitempty"`   // 项目ID
 	Name      string    `gorm:"size:255" json:"name,omitempty"`
+	GroupID   string    `gorm:"size:255;index" json:"groupId,omitempty"` // 执行的测试分组
 	Total     int       `gorm:"default:0" json:"total"`
 	Passed    int       `gorm:"default:0" json:"passed"`
 	Failed    int       `gorm:"default:0" json:"failed"`
 	Errors    int       `gorm:"default:0" json:"errors"`
 	Skipped   int       `gorm:"default:0" json:"skipped"`
+	Quarantined int     `gorm:"default:0" json:"quarantined"` // 隔离用例的结果数, 不计入以上计数
 	StartTime time.Time `gorm:"index" json:"startTime,omitempty"`
 	EndTime   time.Time `json:"endTime,omitempty"`
 	Duration  int       `json:"duration,omitempty"` // milliseconds
-	Status    string    `gorm:"size:50;default:'running';index" json:"status"` // running, completed, cancelled
+	Status    string    `gorm:"size:50;default:'running';index" json:"status"` // running, completed, cancelled, timeout
+
+	// 分组执行选项
+	Concurrency int  `json:"concurrency,omitempty"` // 并发执行的用例数
+	FailFast    bool `json:"failFast,omitempty"`    // 首个失败后不再调度剩余用例
+	MaxDuration int  `json:"maxDuration,omitempty"` // 最长执行时间（秒），超时未执行的用例记为跳过
+	Recursive   bool `json:"recursive,omitempty"`   // 递归执行子分组
+
+	// 重跑来源: 由重跑创建的批次指向被重跑的批次
+	ParentRunID string `gorm:"size:255;index" json:"parentRunId,omitempty"`
+
+	// 按选择条件执行的批次 (测试计划): 选择条件、环境和变量覆盖, 重跑时沿用
+	PlanID        string `gorm:"size:100;index" json:"planId,omitempty"`
+	Selection     JSONB  `gorm:"type:text" json:"selection,omitempty"`
+	EnvironmentID string `gorm:"size:50" json:"environmentId,omitempty"`
+	Variables     JSONB  `gorm:"type:text" json:"variables,omitempty"`
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 
@@ -15,6 +15,7 @@ type TestGroup struct {
 	Name        string         `gorm:"size:255;not null" json:"name"`
 	Description string         `gorm:"type:text" json:"description,omitempty"`
 	TargetHost  string         `gorm:"size:512" json:"targetHost,omitempty"` // 测试目标服务地址
+	Concurrency int            `gorm:"default:0" json:"concurrency,omitempty"` // 分组执行的并发数, 0 使用服务默认值
 
 	// Folder type for organization: service or module
 	FolderType  string         `gorm:"size:50;default:'folder'" json:"folderType,omitempty"` // service, module, folder
@@ -23,6 +24,10 @@ type TestGroup struct {
 	SetupHooks    JSONArray `gorm:"type:text;column:setup_hooks" json:"setupHooks,omitempty"`
 	TeardownHooks JSONArray `gorm:"type:text;column:teardown_hooks" json:"teardownHooks,omitempty"`
 
+	// OpenAPI 契约: 组内 HTTP 测试按关联文档校验, 子分组继承父分组的契约
+	OpenAPISpec  string `gorm:"size:255" json:"openapiSpec,omitempty"`  // 存储的 openapi 文档名称
+	ContractMode string `gorm:"size:20" json:"contractMode,omitempty"` // strict(默认), warn, off
+
 	CreatedAt   time.Time      `json:"createdAt"`
 	UpdatedAt   time.Time      `json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
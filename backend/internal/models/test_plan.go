@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Test plan notification triggers
+const (
+	NotifyAlways  = "always"  // 每次执行结束都通知
+	NotifyFailure = "failure" // 最终结果未通过时通知（默认）
+	NotifyNever   = "never"
+)
+
+// TestPlan 测试计划: 保存的用例选择条件及执行选项, 可按 ID 执行或定时执行,
+// 例如 "smoke"、"nightly regression"、"P0 release gate"
+type TestPlan struct {
+	ID          uint   `gorm:"primaryKey" json:"-"`
+	PlanID      string `gorm:"uniqueIndex;size:100;not null" json:"planId"`
+	TenantID    string `gorm:"index;size:100" json:"tenantId,omitempty"`  // 租户ID
+	ProjectID   string `gorm:"index;size:100" json:"projectId,omitempty"` // 项目ID
+	Name        string `gorm:"size:255;not null" json:"name"`
+	Description string `gorm:"type:text" json:"description,omitempty"`
+
+	// 用例选择: 显式指定的用例总是执行; 分组中的用例按过滤条件筛选;
+	// 既没有用例也没有分组时, 过滤条件作用于项目的所有用例
+	TestIDs    JSONArray `gorm:"type:text" json:"testIds,omitempty"`
+	GroupIDs   JSONArray `gorm:"type:text" json:"groupIds,omitempty"`
+	Recursive  bool      `json:"recursive,omitempty"`                   // 包含分组的子分组
+	Tags       JSONArray `gorm:"type:text" json:"tags,omitempty"`       // 必须包含所有标签
+	Priorities JSONArray `gorm:"type:text" json:"priorities,omitempty"` // 任一优先级, 如 P0
+	Types      JSONArray `gorm:"type:text" json:"types,omitempty"`      // 任一类型, 如 http
+
+	// 执行选项
+	EnvironmentID string `gorm:"size:50" json:"environmentId,omitempty"` // 为空使用激活的环境
+	Variables     JSONB  `gorm:"type:text" json:"variables,omitempty"`   // 覆盖环境变量
+	Concurrency   int    `json:"concurrency,omitempty"`                  // 0 使用分组或服务的默认值
+	FailFast      bool   `json:"failFast,omitempty"`
+	MaxDuration   int    `json:"maxDuration,omitempty"` // 最长执行时间（秒）
+
+	// 重试策略: 执行结束后自动重跑失败的用例, 最多 MaxRetries 次
+	MaxRetries int    `json:"maxRetries,omitempty"`
+	RetryOn    string `gorm:"size:20" json:"retryOn,omitempty"` // 重跑哪些用例: failed(默认), errored
+
+	// 通知: 最终结果（包括重试）以 JSON POST 到 webhook
+	NotifyWebhook string `gorm:"size:500" json:"notifyWebhook,omitempty"`
+	NotifyOn      string `gorm:"size:20" json:"notifyOn,omitempty"` // always, failure(默认), never
+
+	// 定时执行
+	Schedule  string     `gorm:"size:100" json:"schedule,omitempty"` // cron 表达式, 为空不定时执行
+	NextRunAt *time.Time `gorm:"index" json:"nextRunAt,omitempty"`
+
+	// 最近一次执行
+	LastRunID  string     `gorm:"size:255" json:"lastRunId,omitempty"`
+	LastRunAt  *time.Time `json:"lastRunAt,omitempty"`
+	LastStatus string     `gorm:"size:20" json:"lastStatus,omitempty"` // passed, failed (含重试后的最终结果)
+
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name for TestPlan model
+func (TestPlan) TableName() string {
+	return "test_plans"
+}
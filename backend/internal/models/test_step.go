@@ -94,6 +94,7 @@ type TestStepArray []TestStep
 // StepType constants define the available step types
 const (
 	StepTypeHTTP    = "http"    // HTTP request step
+	StepTypeGraphQL = "graphql" // GraphQL request step
 	StepTypeCommand = "command" // Shell command step
 	StepTypeAssert  = "assert"  // Assertion step
 	StepTypeBranch  = "branch"  // Conditional branching step
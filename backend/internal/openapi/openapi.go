@@ -0,0 +1,616 @@
+// Package openapi checks HTTP exchanges against the operations of an OpenAPI
+// 3.x document: path, method, parameters, status code, headers and body
+// schemas. Schemas are validated with the schema package, so $refs inside
+// the document and to other stored documents resolve as they do for
+// json_schema assertions.
+package openapi
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"test-management-service/internal/jsonpath"
+	"test-management-service/internal/schema"
+)
+
+// Strictness modes of a contract
+const (
+	ModeStrict = "strict" // violations fail the test
+	ModeWarn   = "warn"   // violations are reported as warnings
+	ModeOff    = "off"    // no validation
+)
+
+// ValidMode reports whether mode is a known strictness mode (empty means strict)
+func ValidMode(mode string) bool {
+	switch mode {
+	case "", ModeStrict, ModeWarn, ModeOff:
+		return true
+	}
+	return false
+}
+
+// Request is the request half of an HTTP exchange
+type Request struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   interface{} // decoded body, nil when the request has none
+}
+
+// Response is the response half of an HTTP exchange
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       interface{} // decoded body, nil or "" when empty
+}
+
+// Violation is one way an exchange breaks the contract
+type Violation struct {
+	Location string `json:"location"` // request.path, request.query.limit, request.body/name, response.status, response.header.X-Id, response.body/id, ...
+	Message  string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return v.Location + ": " + v.Message
+}
+
+// Operation is the operation an exchange was matched to
+type Operation struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"` // path template, e.g. /pets/{id}
+	OperationID string `json:"operationId,omitempty"`
+
+	pointer    string // JSON pointer of the operation object
+	item       map[string]interface{}
+	op         map[string]interface{}
+	pathParams map[string]string
+}
+
+func (o *Operation) String() string {
+	return o.Method + " " + o.Path
+}
+
+// Validator validates exchanges against one OpenAPI document
+type Validator struct {
+	name      string // stored document name, used to reference schemas inside it
+	doc       map[string]interface{}
+	load      schema.DocumentLoader
+	basePaths []string
+}
+
+// NewValidator creates a validator for the document stored under name. load
+// resolves references to other stored documents and may be nil.
+func NewValidator(name string, doc interface{}, load schema.DocumentLoader) (*Validator, error) {
+	root, ok := jsonpath.Normalize(doc).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("openapi document '%s' is not an object", name)
+	}
+	version, _ := root["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("openapi document '%s' is not OpenAPI 3.x", name)
+	}
+	v := &Validator{name: name, doc: root, load: load}
+	v.basePaths = serverBasePaths(root)
+	return v, nil
+}
+
+// Validate matches the request to an operation and checks both halves of the
+// exchange. The operation is nil when no operation matches. An error means
+// the document itself is broken.
+func (v *Validator) Validate(req Request, resp *Response) (*Operation, []Violation, error) {
+	op, violations := v.FindOperation(req.Method, req.URL.Path)
+	if op == nil {
+		return nil, violations, nil
+	}
+
+	requestViolations, err := v.validateRequest(op, req)
+	if err != nil {
+		return op, nil, err
+	}
+	violations = append(violations, requestViolations...)
+
+	if resp != nil {
+		responseViolations, err := v.validateResponse(op, resp)
+		if err != nil {
+			return op, nil, err
+		}
+		violations = append(violations, responseViolations...)
+	}
+	return op, violations, nil
+}
+
+// FindOperation finds the operation for a method and request path. Literal
+// path segments win over templated ones, so /pets/mine beats /pets/{id}.
+func (v *Validator) FindOperation(method, requestPath string) (*Operation, []Violation) {
+	paths, _ := v.doc["paths"].(map[string]interface{})
+
+	var best string
+	var bestParams map[string]string
+	bestScore := -1
+	for _, candidate := range v.candidatePaths(requestPath) {
+		for template := range paths {
+			params, score, ok := matchPath(template, candidate)
+			if ok && (score > bestScore || (score == bestScore && template < best)) {
+				best, bestParams, bestScore = template, params, score
+			}
+		}
+	}
+	if bestScore < 0 {
+		return nil, []Violation{{Location: "request.path", Message: fmt.Sprintf("no operation matches %s %s", strings.ToUpper(method), requestPath)}}
+	}
+
+	item, itemPointer := v.resolveAt(paths[best], pointer("paths", best))
+	lower := strings.ToLower(method)
+	op, ok := item[lower].(map[string]interface{})
+	if !ok {
+		return nil, []Violation{{Location: "request.method", Message: fmt.Sprintf("method %s is not allowed for %s (allowed: %s)",
+			strings.ToUpper(method), best, strings.Join(allowedMethods(item), ", "))}}
+	}
+
+	operationID, _ := op["operationId"].(string)
+	return &Operation{
+		Method:      strings.ToUpper(method),
+		Path:        best,
+		OperationID: operationID,
+		pointer:     itemPointer + "/" + escape(lower),
+		item:        item,
+		op:          op,
+		pathParams:  bestParams,
+	}, nil
+}
+
+// candidatePaths returns the request path relative to each server base path
+func (v *Validator) candidatePaths(requestPath string) []string {
+	candidates := []string{requestPath}
+	for _, base := range v.basePaths {
+		if rest := strings.TrimPrefix(requestPath, base); rest != requestPath && (rest == "" || rest[0] == '/') {
+			if rest == "" {
+				rest = "/"
+			}
+			candidates = append(candidates, rest)
+		}
+	}
+	return candidates
+}
+
+var templateParam = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// matchPath matches a request path against a path template and returns the
+// path parameters and the number of literal segments
+func matchPath(template, requestPath string) (map[string]string, int, bool) {
+	templateSegments := strings.Split(strings.Trim(template, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(requestPath, "/"), "/")
+	if len(templateSegments) != len(pathSegments) {
+		return nil, 0, false
+	}
+
+	params := make(map[string]string)
+	score := 0
+	for i, segment := range templateSegments {
+		value, err := url.PathUnescape(pathSegments[i])
+		if err != nil {
+			value = pathSegments[i]
+		}
+		if !strings.Contains(segment, "{") {
+			if segment != value {
+				return nil, 0, false
+			}
+			score++
+			continue
+		}
+
+		names := templateParam.FindAllStringSubmatch(segment, -1)
+		pattern := "^" + templateParam.ReplaceAllString(quoteLiterals(segment), "([^/]+?)") + "$"
+		match := regexp.MustCompile(pattern).FindStringSubmatch(value)
+		if match == nil {
+			return nil, 0, false
+		}
+		for j, name := range names {
+			params[name[1]] = match[j+1]
+		}
+	}
+	return params, score, true
+}
+
+// quoteLiterals escapes the regexp metacharacters of a template segment
+// outside its {param} placeholders
+func quoteLiterals(segment string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range templateParam.FindAllStringIndex(segment, -1) {
+		b.WriteString(regexp.QuoteMeta(segment[last:loc[0]]))
+		b.WriteString(segment[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(segment[last:]))
+	return b.String()
+}
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+func allowedMethods(item map[string]interface{}) []string {
+	var allowed []string
+	for _, m := range methods {
+		if _, ok := item[m]; ok {
+			allowed = append(allowed, strings.ToUpper(m))
+		}
+	}
+	return allowed
+}
+
+// serverBasePaths returns the path prefixes of the document's servers
+func serverBasePaths(doc map[string]interface{}) []string {
+	servers, _ := doc["servers"].([]interface{})
+	var bases []string
+	for _, s := range servers {
+		server, _ := s.(map[string]interface{})
+		raw, _ := server["url"].(string)
+		// Substitute server variables with their defaults
+		variables, _ := server["variables"].(map[string]interface{})
+		raw = templateParam.ReplaceAllStringFunc(raw, func(m string) string {
+			variable, _ := variables[m[1:len(m)-1]].(map[string]interface{})
+			if def, ok := variable["default"].(string); ok {
+				return def
+			}
+			return m
+		})
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		if base := strings.TrimRight(u.Path, "/"); base != "" {
+			bases = append(bases, base)
+		}
+	}
+	return bases
+}
+
+// ===== Request =====
+
+func (v *Validator) validateRequest(op *Operation, req Request) ([]Violation, error) {
+	var violations []Violation
+
+	for _, param := range v.parameters(op) {
+		name, _ := param.value["name"].(string)
+		in, _ := param.value["in"].(string)
+		required, _ := param.value["required"].(bool)
+
+		var raw string
+		var present bool
+		switch in {
+		case "path":
+			raw, present = op.pathParams[name]
+			required = true
+		case "query":
+			values, ok := req.URL.Query()[name]
+			present = ok
+			raw = strings.Join(values, ",")
+		case "header":
+			if strings.EqualFold(name, "Content-Type") || strings.EqualFold(name, "Accept") || strings.EqualFold(name, "Authorization") {
+				continue // described by the operation, not by parameters
+			}
+			present = req.Header.Get(name) != ""
+			raw = req.Header.Get(name)
+		default:
+			continue
+		}
+
+		location := "request." + in + "." + name
+		if !present {
+			if required {
+				violations = append(violations, Violation{Location: location, Message: fmt.Sprintf("required %s parameter is missing", in)})
+			}
+			continue
+		}
+		if _, ok := param.value["schema"]; ok {
+			found, err := v.validateValue(location, param.pointer+"/schema", v.coerce(param.value["schema"], raw))
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, found...)
+		}
+	}
+
+	body, bodyPointer := v.resolveAt(op.op["requestBody"], op.pointer+"/requestBody")
+	if body == nil {
+		return violations, nil
+	}
+	if isEmpty(req.Body) {
+		if required, _ := body["required"].(bool); required {
+			violations = append(violations, Violation{Location: "request.body", Message: "required request body is missing"})
+		}
+		return violations, nil
+	}
+	found, err := v.validateContent("request.body", body, bodyPointer, req.Header.Get("Content-Type"), req.Body)
+	if err != nil {
+		return nil, err
+	}
+	return append(violations, found...), nil
+}
+
+type parameter struct {
+	value   map[string]interface{}
+	pointer string
+}
+
+// parameters merges path-level and operation-level parameters; operation
+// parameters override path parameters with the same name and location
+func (v *Validator) parameters(op *Operation) []parameter {
+	var params []parameter
+	index := make(map[string]int)
+	add := func(list interface{}, listPointer string) {
+		items, _ := list.([]interface{})
+		for i, item := range items {
+			value, p := v.resolveAt(item, listPointer+"/"+strconv.Itoa(i))
+			if value == nil {
+				continue
+			}
+			key := fmt.Sprintf("%v:%v", value["in"], value["name"])
+			if at, ok := index[key]; ok {
+				params[at] = parameter{value, p}
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, parameter{value, p})
+		}
+	}
+	itemPointer := strings.TrimSuffix(op.pointer, "/"+escape(strings.ToLower(op.Method)))
+	add(op.item["parameters"], itemPointer+"/parameters")
+	add(op.op["parameters"], op.pointer+"/parameters")
+	return params
+}
+
+// coerce converts a raw parameter string to the JSON type its schema expects
+func (v *Validator) coerce(paramSchema interface{}, raw string) interface{} {
+	s, _ := v.resolveAt(paramSchema, "")
+	switch s["type"] {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(raw, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	case "array":
+		parts := strings.Split(raw, ",")
+		items := make([]interface{}, len(parts))
+		for i, part := range parts {
+			items[i] = v.coerce(s["items"], part)
+		}
+		return items
+	}
+	return raw
+}
+
+// ===== Response =====
+
+func (v *Validator) validateResponse(op *Operation, resp *Response) ([]Violation, error) {
+	responses, _ := op.op["responses"].(map[string]interface{})
+	code := strconv.Itoa(resp.StatusCode)
+
+	key := ""
+	for _, candidate := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if _, ok := responses[candidate]; ok {
+			key = candidate
+			break
+		}
+	}
+	if key == "" {
+		documented := make([]string, 0, len(responses))
+		for k := range responses {
+			documented = append(documented, k)
+		}
+		sort.Strings(documented)
+		return []Violation{{Location: "response.status", Message: fmt.Sprintf("status %d is not documented for %s (documented: %s)",
+			resp.StatusCode, op, strings.Join(documented, ", "))}}, nil
+	}
+
+	response, responsePointer := v.resolveAt(responses[key], op.pointer+"/responses/"+escape(key))
+	if response == nil {
+		return nil, nil
+	}
+
+	var violations []Violation
+	headers, _ := response["headers"].(map[string]interface{})
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+		header, headerPointer := v.resolveAt(headers[name], responsePointer+"/headers/"+escape(name))
+		if header == nil {
+			continue
+		}
+		location := "response.header." + name
+		value := resp.Header.Get(name)
+		if value == "" {
+			if required, _ := header["required"].(bool); required {
+				violations = append(violations, Violation{Location: location, Message: "required header is missing"})
+			}
+			continue
+		}
+		if _, ok := header["schema"]; ok {
+			found, err := v.validateValue(location, headerPointer+"/schema", v.coerce(header["schema"], value))
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, found...)
+		}
+	}
+
+	if isEmpty(resp.Body) {
+		return violations, nil
+	}
+	found, err := v.validateContent("response.body", response, responsePointer, resp.Header.Get("Content-Type"), resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return append(violations, found...), nil
+}
+
+// ===== Bodies and schemas =====
+
+// validateContent checks the media type of a body against the declared
+// content and validates structured bodies against the media type schema
+func (v *Validator) validateContent(location string, owner map[string]interface{}, ownerPointer, contentType string, body interface{}) ([]Violation, error) {
+	content, _ := owner["content"].(map[string]interface{})
+	if len(content) == 0 {
+		return nil, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	key := matchMediaType(content, mediaType)
+	if key == "" {
+		declared := make([]string, 0, len(content))
+		for k := range content {
+			declared = append(declared, k)
+		}
+		sort.Strings(declared)
+		return []Violation{{Location: location, Message: fmt.Sprintf("content type %q is not declared (declared: %s)",
+			mediaType, strings.Join(declared, ", "))}}, nil
+	}
+
+	media, _ := content[key].(map[string]interface{})
+	if _, ok := media["schema"]; !ok || !isStructured(mediaType) {
+		return nil, nil
+	}
+	return v.validateValue(location, ownerPointer+"/content/"+escape(key)+"/schema", body)
+}
+
+// validateValue validates value against the schema at pointer in the document
+func (v *Validator) validateValue(location, schemaPointer string, value interface{}) ([]Violation, error) {
+	found, err := schema.Validate(schema.Spec{Ref: v.name + "#" + fragment(schemaPointer)}, value, v.loadDocument)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", location, err)
+	}
+	violations := make([]Violation, len(found))
+	for i, f := range found {
+		violations[i] = Violation{Location: location + f.InstancePath, Message: f.Message}
+	}
+	return violations, nil
+}
+
+// loadDocument serves this document by name and other stored documents
+// through the loader
+func (v *Validator) loadDocument(name string) (interface{}, error) {
+	if name == v.name {
+		return v.doc, nil
+	}
+	if v.load == nil {
+		return nil, fmt.Errorf("schema '%s' not found", name)
+	}
+	return v.load(name)
+}
+
+// matchMediaType finds the declared media type for mediaType, preferring
+// exact matches over type/* and */* ranges
+func matchMediaType(content map[string]interface{}, mediaType string) string {
+	for k := range content {
+		if strings.EqualFold(k, mediaType) {
+			return k
+		}
+	}
+	major, _, _ := strings.Cut(mediaType, "/")
+	for k := range content {
+		if strings.EqualFold(k, major+"/*") {
+			return k
+		}
+	}
+	if _, ok := content["*/*"]; ok {
+		return "*/*"
+	}
+	return ""
+}
+
+// isStructured reports whether bodies of the media type are decoded values
+// that can be validated against a schema
+func isStructured(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/x-www-form-urlencoded" || mediaType == ""
+}
+
+func isEmpty(body interface{}) bool {
+	if body == nil {
+		return true
+	}
+	s, ok := body.(string)
+	return ok && s == ""
+}
+
+// resolveAt returns the object at node, following local $refs, with its pointer
+func (v *Validator) resolveAt(node interface{}, at string) (map[string]interface{}, string) {
+	for depth := 0; depth < 32; depth++ {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, at
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return obj, at
+		}
+		at = ref[1:]
+		node = v.lookup(at)
+	}
+	return nil, at
+}
+
+// lookup returns the value at a JSON pointer in the document
+func (v *Validator) lookup(p string) interface{} {
+	var node interface{} = v.doc
+	for _, token := range strings.Split(strings.TrimPrefix(p, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		if decoded, err := url.PathUnescape(token); err == nil {
+			token = decoded
+		}
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node = n[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil
+			}
+			node = n[i]
+		default:
+			return nil
+		}
+	}
+	return node
+}
+
+// pointer builds a JSON pointer from unescaped tokens
+func pointer(tokens ...string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString("/")
+		b.WriteString(escape(t))
+	}
+	return b.String()
+}
+
+func escape(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// fragment percent-encodes a JSON pointer for use as a URI fragment
+func fragment(p string) string {
+	tokens := strings.Split(p, "/")
+	for i, t := range tokens {
+		tokens[i] = url.PathEscape(t)
+	}
+	return strings.Join(tokens, "/")
+}
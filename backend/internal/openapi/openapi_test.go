@@ -0,0 +1,176 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petstore = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Petstore", "version": "1.0"},
+  "servers": [{"url": "https://{host}/api/v1", "variables": {"host": {"default": "pets.example.com"}}}],
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "listPets",
+        "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer", "maximum": 100}}],
+        "responses": {
+          "200": {
+            "description": "pets",
+            "headers": {"X-Total": {"required": true, "schema": {"type": "integer"}}},
+            "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}}
+          }
+        }
+      },
+      "post": {
+        "operationId": "createPet",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+        "responses": {"201": {"description": "created"}, "4XX": {"$ref": "#/components/responses/Error"}}
+      }
+    },
+    "/pets/{petId}": {
+      "parameters": [{"$ref": "#/components/parameters/PetId"}],
+      "get": {
+        "operationId": "getPet",
+        "parameters": [{"name": "X-Request-Id", "in": "header", "required": true, "schema": {"type": "string"}}],
+        "responses": {"200": {"description": "pet", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}
+      }
+    },
+    "/pets/mine": {
+      "get": {"operationId": "myPets", "responses": {"default": {"description": "any"}}}
+    }
+  },
+  "components": {
+    "parameters": {"PetId": {"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}},
+    "schemas": {
+      "Pet": {
+        "type": "object",
+        "required": ["id", "name"],
+        "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}
+      }
+    },
+    "responses": {
+      "Error": {"description": "error", "content": {"application/json": {"schema": {"type": "object", "required": ["message"]}}}}
+    }
+  }
+}`
+
+func newPetstore(t *testing.T) *Validator {
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(petstore), &doc))
+	v, err := NewValidator("petstore", doc, nil)
+	require.NoError(t, err)
+	return v
+}
+
+func request(method, rawURL string, header http.Header, body interface{}) Request {
+	u, _ := url.Parse(rawURL)
+	if header == nil {
+		header = http.Header{}
+	}
+	return Request{Method: method, URL: u, Header: header, Body: body}
+}
+
+func jsonHeader(pairs ...string) http.Header {
+	h := http.Header{"Content-Type": []string{"application/json"}}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		h.Set(pairs[i], pairs[i+1])
+	}
+	return h
+}
+
+func locations(violations []Violation) []string {
+	var result []string
+	for _, v := range violations {
+		result = append(result, v.Location)
+	}
+	return result
+}
+
+func TestFindOperation(t *testing.T) {
+	v := newPetstore(t)
+
+	op, violations := v.FindOperation("GET", "/api/v1/pets/mine")
+	require.Empty(t, violations)
+	assert.Equal(t, "myPets", op.OperationID, "literal segments win over templates")
+
+	op, _ = v.FindOperation("get", "/pets/42")
+	require.NotNil(t, op)
+	assert.Equal(t, "GET /pets/{petId}", op.String())
+	assert.Equal(t, "42", op.pathParams["petId"])
+
+	op, violations = v.FindOperation("DELETE", "/pets")
+	assert.Nil(t, op)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "request.method", violations[0].Location)
+	assert.Contains(t, violations[0].Message, "allowed: GET, POST")
+
+	op, violations = v.FindOperation("GET", "/owners")
+	assert.Nil(t, op)
+	assert.Equal(t, []string{"request.path"}, locations(violations))
+}
+
+func TestValidateExchange(t *testing.T) {
+	v := newPetstore(t)
+
+	op, violations, err := v.Validate(
+		request("GET", "https://pets.example.com/api/v1/pets?limit=10", nil, nil),
+		&Response{StatusCode: 200, Header: jsonHeader("X-Total", "1"), Body: []interface{}{map[string]interface{}{"id": 1, "name": "rex"}}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "listPets", op.OperationID)
+	assert.Empty(t, violations)
+
+	_, violations, err = v.Validate(
+		request("GET", "/pets?limit=500", nil, nil),
+		&Response{StatusCode: 200, Header: jsonHeader(), Body: []interface{}{map[string]interface{}{"id": "1"}}},
+	)
+	require.NoError(t, err)
+	for _, location := range []string{"request.query.limit", "response.header.X-Total", "response.body/0", "response.body/0/id"} {
+		assert.Contains(t, locations(violations), location)
+	}
+
+	_, violations, err = v.Validate(request("GET", "/pets/abc", nil, nil), &Response{StatusCode: 500, Header: http.Header{}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"request.path.petId", "request.header.X-Request-Id", "response.status"}, locations(violations))
+	assert.Contains(t, violations[2].Message, "status 500 is not documented for GET /pets/{petId}")
+}
+
+func TestValidateBodies(t *testing.T) {
+	v := newPetstore(t)
+
+	_, violations, err := v.Validate(request("POST", "/pets", nil, nil), &Response{StatusCode: 201, Header: http.Header{}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"request.body"}, locations(violations))
+	assert.Equal(t, "required request body is missing", violations[0].Message)
+
+	_, violations, err = v.Validate(
+		request("POST", "/pets", http.Header{"Content-Type": []string{"text/plain"}}, "rex"),
+		&Response{StatusCode: 201, Header: http.Header{}},
+	)
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Contains(t, violations[0].Message, `content type "text/plain" is not declared`)
+
+	// 4XX resolves through a $ref to components/responses
+	_, violations, err = v.Validate(
+		request("POST", "/pets", jsonHeader(), map[string]interface{}{"id": 1, "name": "rex"}),
+		&Response{StatusCode: 409, Header: jsonHeader(), Body: map[string]interface{}{"error": "exists"}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"response.body"}, locations(violations))
+	assert.True(t, strings.Contains(violations[0].Message, "message"), violations[0].Message)
+}
+
+func TestNewValidatorRejectsSwagger2(t *testing.T) {
+	_, err := NewValidator("old", map[string]interface{}{"swagger": "2.0"}, nil)
+	assert.Error(t, err)
+	assert.True(t, ValidMode("warn"))
+	assert.False(t, ValidMode("loud"))
+}
@@ -165,10 +165,11 @@ func (r *environmentRepository) UpdateWithTenant(ctx context.Context, env *model
 		return fmt.Errorf("tenant_id and project_id are required")
 	}
 
+	// All columns are written so fields can be cleared
 	result := r.db.WithContext(ctx).
 		Where("env_id = ? AND tenant_id = ? AND project_id = ?",
 			env.EnvID, env.TenantID, env.ProjectID).
-		Updates(env)
+		Select("*").Omit("created_at").Updates(env)
 
 	if result.Error != nil {
 		return fmt.Errorf("failed to update environment: %w", result.Error)
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"test-management-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SchemaDocumentRepository defines data access for project schema documents
+type SchemaDocumentRepository interface {
+	Create(ctx context.Context, doc *models.SchemaDocument) error
+	Update(ctx context.Context, doc *models.SchemaDocument) error
+	Delete(ctx context.Context, tenantID, projectID, name string) error
+	FindByName(ctx context.Context, tenantID, projectID, name string) (*models.SchemaDocument, error)
+	List(ctx context.Context, tenantID, projectID, docType string) ([]models.SchemaDocument, error)
+}
+
+type schemaDocumentRepository struct {
+	db *gorm.DB
+}
+
+// NewSchemaDocumentRepository creates a new SchemaDocumentRepository
+func NewSchemaDocumentRepository(db *gorm.DB) SchemaDocumentRepository {
+	return &schemaDocumentRepository{db: db}
+}
+
+func (r *schemaDocumentRepository) Create(ctx context.Context, doc *models.SchemaDocument) error {
+	return r.db.WithContext(ctx).Create(doc).Error
+}
+
+func (r *schemaDocumentRepository) Update(ctx context.Context, doc *models.SchemaDocument) error {
+	return r.db.WithContext(ctx).Save(doc).Error
+}
+
+func (r *schemaDocumentRepository) Delete(ctx context.Context, tenantID, projectID, name string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND project_id = ? AND name = ?", tenantID, projectID, name).
+		Delete(&models.SchemaDocument{}).Error
+}
+
+// FindByName returns nil when the document does not exist
+func (r *schemaDocumentRepository) FindByName(ctx context.Context, tenantID, projectID, name string) (*models.SchemaDocument, error) {
+	var doc models.SchemaDocument
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND project_id = ? AND name = ?", tenantID, projectID, name).
+		First(&doc).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *schemaDocumentRepository) List(ctx context.Context, tenantID, projectID, docType string) ([]models.SchemaDocument, error) {
+	var docs []models.SchemaDocument
+	query := r.db.WithContext(ctx).Where("tenant_id = ? AND project_id = ?", tenantID, projectID)
+	if docType != "" {
+		query = query.Where("type = ?", docType)
+	}
+	err := query.Order("name").Find(&docs).Error
+	return docs, err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"test-management-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SnapshotFilter selects snapshot versions; empty fields match everything
+type SnapshotFilter struct {
+	TenantID  string
+	ProjectID string
+	TestID    string
+	Name      string
+	Status    string
+}
+
+// SnapshotRepository defines data access for snapshot versions
+type SnapshotRepository interface {
+	Create(ctx context.Context, snapshot *models.Snapshot) error
+	Update(ctx context.Context, snapshot *models.Snapshot) error
+	FindByID(ctx context.Context, tenantID, projectID string, id uint) (*models.Snapshot, error)
+	FindLatest(ctx context.Context, filter SnapshotFilter) (*models.Snapshot, error)
+	MaxVersion(ctx context.Context, tenantID, projectID, testID, name string) (int, error)
+	List(ctx context.Context, filter SnapshotFilter) ([]models.Snapshot, error)
+}
+
+type snapshotRepository struct {
+	db *gorm.DB
+}
+
+// NewSnapshotRepository creates a new SnapshotRepository
+func NewSnapshotRepository(db *gorm.DB) SnapshotRepository {
+	return &snapshotRepository{db: db}
+}
+
+func (r *snapshotRepository) Create(ctx context.Context, snapshot *models.Snapshot) error {
+	return r.db.WithContext(ctx).Create(snapshot).Error
+}
+
+func (r *snapshotRepository) Update(ctx context.Context, snapshot *models.Snapshot) error {
+	return r.db.WithContext(ctx).Save(snapshot).Error
+}
+
+// FindByID returns nil when the snapshot does not exist
+func (r *snapshotRepository) FindByID(ctx context.Context, tenantID, projectID string, id uint) (*models.Snapshot, error) {
+	var snapshot models.Snapshot
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND project_id = ? AND id = ?", tenantID, projectID, id).
+		First(&snapshot).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// FindLatest returns the highest version matching the filter, or nil
+func (r *snapshotRepository) FindLatest(ctx context.Context, filter SnapshotFilter) (*models.Snapshot, error) {
+	var snapshot models.Snapshot
+	err := r.filtered(ctx, filter).Order("version DESC").First(&snapshot).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (r *snapshotRepository) MaxVersion(ctx context.Context, tenantID, projectID, testID, name string) (int, error) {
+	var version *int
+	err := r.db.WithContext(ctx).Model(&models.Snapshot{}).
+		Where("tenant_id = ? AND project_id = ? AND test_id = ? AND name = ?", tenantID, projectID, testID, name).
+		Select("MAX(version)").Scan(&version).Error
+	if err != nil || version == nil {
+		return 0, err
+	}
+	return *version, nil
+}
+
+func (r *snapshotRepository) List(ctx context.Context, filter SnapshotFilter) ([]models.Snapshot, error) {
+	var snapshots []models.Snapshot
+	err := r.filtered(ctx, filter).Order("test_id, name, version DESC").Find(&snapshots).Error
+	return snapshots, err
+}
+
+func (r *snapshotRepository) filtered(ctx context.Context, filter SnapshotFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Where("tenant_id = ? AND project_id = ?", filter.TenantID, filter.ProjectID)
+	if filter.TestID != "" {
+		query = query.Where("test_id = ?", filter.TestID)
+	}
+	if filter.Name != "" {
+		query = query.Where("name = ?", filter.Name)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	return query
+}
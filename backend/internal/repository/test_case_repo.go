@@ -27,12 +27,22 @@ type TestCaseRepository interface {
 	// Multi-tenant methods
 	CreateWithTenant(ctx context.Context, testCase *models.TestCase) error
 	UpdateWithTenant(ctx context.Context, testCase *models.TestCase) error
+	UpdateStatsWithTenant(ctx context.Context, testCase *models.TestCase) error
+	UpdateQuarantineWithTenant(ctx context.Context, testCase *models.TestCase) error
+	UpdateScoresWithTenant(ctx context.Context, testCase *models.TestCase) error
 	DeleteWithTenant(ctx context.Context, testID, tenantID, projectID string) error
 	FindByIDWithTenant(ctx context.Context, testID, tenantID, projectID string) (*models.TestCase, error)
 	FindByGroupIDWithTenant(ctx context.Context, groupID, tenantID, projectID string) ([]models.TestCase, error)
 	FindAllWithTenant(ctx context.Context, tenantID, projectID string, limit, offset int) ([]models.TestCase, int64, error)
 	FindByTypeWithTenant(ctx context.Context, testType, tenantID, projectID string) ([]models.TestCase, error)
 	SearchWithTenant(ctx context.Context, tenantID, projectID, query string) ([]models.TestCase, error)
+	FindProjectScopes(ctx context.Context) ([]ProjectScope, error)
+}
+
+// ProjectScope 拥有测试用例的租户项目
+type ProjectScope struct {
+	TenantID  string
+	ProjectID string
 }
 
 // testCaseRepository 实现
@@ -153,6 +163,18 @@ func (r *testCaseRepository) CreateWithTenant(ctx context.Context, testCase *mod
 	return nil
 }
 
+// testCaseDefinitionColumns are the columns written by UpdateWithTenant.
+// Statistics, flakiness, quarantine and scores have their own updates, so an
+// edit never overwrites what a concurrent run recorded.
+var testCaseDefinitionColumns = []string{
+	"group_id", "name", "type", "priority", "status", "objective", "timeout", "serial",
+	"depends_on", "outputs", "inputs", "workflow_id", "workflow_def",
+	"preconditions", "steps", "http_config", "command_config", "integration_config",
+	"performance_config", "database_config", "security_config", "grpc_config",
+	"graphql_config", "websocket_config", "e2e_config", "assertions", "tags", "custom_config",
+	"setup_hooks", "teardown_hooks", "sessions", "owner_id", "last_modified_by", "updated_at",
+}
+
 // UpdateWithTenant updates a test case with tenant isolation
 func (r *testCaseRepository) UpdateWithTenant(ctx context.Context, testCase *models.TestCase) error {
 	// Validate tenant and project match
@@ -160,11 +182,12 @@ func (r *testCaseRepository) UpdateWithTenant(ctx context.Context, testCase *mod
 		return fmt.Errorf("tenant_id and project_id are required")
 	}
 
-	// Update only if tenant and project match
+	// Update only if tenant and project match; the definition columns are
+	// written even when empty so flags and lists can be cleared
 	result := r.db.WithContext(ctx).
 		Where("test_id = ? AND tenant_id = ? AND project_id = ?",
 			testCase.TestID, testCase.TenantID, testCase.ProjectID).
-		Updates(testCase)
+		Select(testCaseDefinitionColumns).Updates(testCase)
 
 	if result.Error != nil {
 		return fmt.Errorf("failed to update test case: %w", result.Error)
@@ -177,6 +200,89 @@ func (r *testCaseRepository) UpdateWithTenant(ctx context.Context, testCase *mod
 	return nil
 }
 
+// UpdateStatsWithTenant writes the execution statistics and flakiness of a
+// test case with tenant isolation. The definition and updated_at are left
+// untouched, so results never overwrite a concurrent edit.
+func (r *testCaseRepository) UpdateStatsWithTenant(ctx context.Context, testCase *models.TestCase) error {
+	result := r.db.WithContext(ctx).Model(&models.TestCase{}).
+		Where("test_id = ? AND tenant_id = ? AND project_id = ?",
+			testCase.TestID, testCase.TenantID, testCase.ProjectID).
+		UpdateColumns(map[string]interface{}{
+			"execution_count":      testCase.ExecutionCount,
+			"success_count":        testCase.SuccessCount,
+			"failure_count":        testCase.FailureCount,
+			"avg_duration":         testCase.AvgDuration,
+			"success_rate":         testCase.SuccessRate,
+			"last_run_at":          testCase.LastRunAt,
+			"last_success_at":      testCase.LastSuccessAt,
+			"last_failure_at":      testCase.LastFailureAt,
+			"is_flaky":             testCase.IsFlaky,
+			"flaky_score":          testCase.FlakyScore,
+			"consecutive_failures": testCase.ConsecutiveFailures,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update test case statistics: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("test case not found or access denied")
+	}
+
+	return nil
+}
+
+// UpdateQuarantineWithTenant writes the quarantine state of a test case with
+// tenant isolation, leaving the rest of it untouched
+func (r *testCaseRepository) UpdateQuarantineWithTenant(ctx context.Context, testCase *models.TestCase) error {
+	result := r.db.WithContext(ctx).Model(&models.TestCase{}).
+		Where("test_id = ? AND tenant_id = ? AND project_id = ?",
+			testCase.TestID, testCase.TenantID, testCase.ProjectID).
+		UpdateColumns(map[string]interface{}{
+			"quarantined":           testCase.Quarantined,
+			"quarantine_reason":     testCase.QuarantineReason,
+			"quarantine_owner":      testCase.QuarantineOwner,
+			"quarantined_at":        testCase.QuarantinedAt,
+			"quarantine_expires_at": testCase.QuarantineExpiresAt,
+			"quarantine_passes":     testCase.QuarantinePasses,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update test case quarantine: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("test case not found or access denied")
+	}
+
+	return nil
+}
+
+// UpdateScoresWithTenant writes the value scores of a test case with tenant
+// isolation, leaving the rest of it untouched
+func (r *testCaseRepository) UpdateScoresWithTenant(ctx context.Context, testCase *models.TestCase) error {
+	result := r.db.WithContext(ctx).Model(&models.TestCase{}).
+		Where("test_id = ? AND tenant_id = ? AND project_id = ?",
+			testCase.TestID, testCase.TenantID, testCase.ProjectID).
+		UpdateColumns(map[string]interface{}{
+			"coverage_score":        testCase.CoverageScore,
+			"stability_score":       testCase.StabilityScore,
+			"efficiency_score":      testCase.EfficiencyScore,
+			"maintainability_score": testCase.MaintainabilityScore,
+			"overall_score":         testCase.OverallScore,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update test case scores: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("test case not found or access denied")
+	}
+
+	return nil
+}
+
 // DeleteWithTenant soft deletes a test case with tenant isolation
 func (r *testCaseRepository) DeleteWithTenant(ctx context.Context, testID, tenantID, projectID string) error {
 	result := r.db.WithContext(ctx).
@@ -288,3 +394,16 @@ func (r *testCaseRepository) SearchWithTenant(ctx context.Context, query, tenant
 
 	return testCases, nil
 }
+
+// FindProjectScopes returns the tenant projects that have test cases
+func (r *testCaseRepository) FindProjectScopes(ctx context.Context) ([]ProjectScope, error) {
+	var scopes []ProjectScope
+	err := r.db.WithContext(ctx).Model(&models.TestCase{}).
+		Distinct("tenant_id", "project_id").
+		Where("deleted_at IS NULL").
+		Scan(&scopes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test case projects: %w", err)
+	}
+	return scopes, nil
+}
@@ -14,6 +14,7 @@ type TestCaseFilter struct {
 	Priorities     []string
 	Statuses       []string
 	Tags           []string
+	Types          []string
 	SuccessRateMin *int
 	SuccessRateMax *int
 	OwnerID        string
@@ -229,6 +230,11 @@ func (r *WorkflowTestCaseRepository) AdvancedSearch(ctx context.Context, tenantI
 		query = query.Where("status IN ?", filter.Statuses)
 	}
 
+	// Type filter
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+
 	// Tags filter (JSON array contains - use LIKE for SQLite)
 	if len(filter.Tags) > 0 {
 		for _, tag := range filter.Tags {
@@ -153,11 +153,12 @@ func (r *testGroupRepository) UpdateWithTenant(ctx context.Context, group *model
 		return fmt.Errorf("tenant_id and project_id are required")
 	}
 
-	// Update only if tenant and project match
+	// Update only if tenant and project match; all columns are written so
+	// fields can be cleared
 	result := r.db.WithContext(ctx).
 		Where("group_id = ? AND tenant_id = ? AND project_id = ?",
 			group.GroupID, group.TenantID, group.ProjectID).
-		Updates(group)
+		Select("*").Omit("created_at").Updates(group)
 
 	if result.Error != nil {
 		return fmt.Errorf("failed to update test group: %w", result.Error)
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"test-management-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TestPlanRepository defines data access for test plans
+type TestPlanRepository interface {
+	Create(ctx context.Context, plan *models.TestPlan) error
+	Update(ctx context.Context, plan *models.TestPlan) error
+	Delete(ctx context.Context, tenantID, projectID, planID string) error
+	FindByID(ctx context.Context, tenantID, projectID, planID string) (*models.TestPlan, error)
+	List(ctx context.Context, tenantID, projectID string) ([]models.TestPlan, error)
+	// FindDue returns the scheduled plans of every project due at now
+	FindDue(ctx context.Context, now time.Time) ([]models.TestPlan, error)
+}
+
+type testPlanRepository struct {
+	db *gorm.DB
+}
+
+// NewTestPlanRepository creates a new TestPlanRepository
+func NewTestPlanRepository(db *gorm.DB) TestPlanRepository {
+	return &testPlanRepository{db: db}
+}
+
+func (r *testPlanRepository) Create(ctx context.Context, plan *models.TestPlan) error {
+	return r.db.WithContext(ctx).Create(plan).Error
+}
+
+func (r *testPlanRepository) Update(ctx context.Context, plan *models.TestPlan) error {
+	return r.db.WithContext(ctx).Save(plan).Error
+}
+
+func (r *testPlanRepository) Delete(ctx context.Context, tenantID, projectID, planID string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND project_id = ? AND plan_id = ?", tenantID, projectID, planID).
+		Delete(&models.TestPlan{}).Error
+}
+
+// FindByID returns nil when the plan does not exist
+func (r *testPlanRepository) FindByID(ctx context.Context, tenantID, projectID, planID string) (*models.TestPlan, error) {
+	var plan models.TestPlan
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND project_id = ? AND plan_id = ?", tenantID, projectID, planID).
+		First(&plan).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *testPlanRepository) List(ctx context.Context, tenantID, projectID string) ([]models.TestPlan, error) {
+	var plans []models.TestPlan
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND project_id = ?", tenantID, projectID).
+		Order("plan_id").Find(&plans).Error
+	return plans, err
+}
+
+func (r *testPlanRepository) FindDue(ctx context.Context, now time.Time) ([]models.TestPlan, error) {
+	var plans []models.TestPlan
+	err := r.db.WithContext(ctx).
+		Where("schedule <> '' AND next_run_at IS NOT NULL AND next_run_at <= ?", now).
+		Order("next_run_at").Find(&plans).Error
+	return plans, err
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	apierrors "test-management-service/internal/errors"
 	"test-management-service/internal/models"
 	"time"
 
@@ -79,6 +80,7 @@ type TestRunRepository interface {
 	UpdateWithTenant(ctx context.Context, run *models.TestRun) error
 	FindByIDWithTenant(ctx context.Context, runID, tenantID, projectID string) (*models.TestRun, error)
 	FindAllWithTenant(ctx context.Context, tenantID, projectID string, limit, offset int) ([]models.TestRun, int64, error)
+	FindByPlanIDWithTenant(ctx context.Context, planID, tenantID, projectID string, limit, offset int) ([]models.TestRun, int64, error)
 }
 
 type testRunRepository struct {
@@ -224,7 +226,7 @@ func (r *testRunRepository) FindByIDWithTenant(ctx context.Context, runID, tenan
 
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("test run not found: %s", runID)
+			return nil, fmt.Errorf("test run not found: %s: %w", runID, apierrors.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to query test run: %w", err)
 	}
@@ -258,3 +260,23 @@ func (r *testRunRepository) FindAllWithTenant(ctx context.Context, tenantID, pro
 
 	return runs, total, nil
 }
+
+// FindByPlanIDWithTenant retrieves the runs of a test plan, newest first,
+// including the reruns made by its retry policy
+func (r *testRunRepository) FindByPlanIDWithTenant(ctx context.Context, planID, tenantID, projectID string, limit, offset int) ([]models.TestRun, int64, error) {
+	var runs []models.TestRun
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.TestRun{}).
+		Where("plan_id = ? AND tenant_id = ? AND project_id = ?", planID, tenantID, projectID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count test runs: %w", err)
+	}
+
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&runs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list test runs: %w", err)
+	}
+
+	return runs, total, nil
+}
@@ -0,0 +1,51 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// ResolvePath returns the absolute path of path, with symbolic links
+// resolved, and verifies that it is inside one of roots. An empty roots
+// list allows any path. A path that does not exist yet is resolved through
+// its parent directory, so it can be created.
+func ResolvePath(path string, roots []string) (string, error) {
+	resolved, err := evalPath(path)
+	if err != nil {
+		return "", err
+	}
+	if len(roots) == 0 {
+		return resolved, nil
+	}
+	for _, root := range roots {
+		rootResolved, err := evalPath(root)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(rootResolved, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("filesystem access denied: %s", path)
+}
+
+// evalPath makes path absolute and resolves its symbolic links, keeping a
+// missing final element as is
+func evalPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if errors.Is(err, fs.ErrNotExist) {
+		dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, filepath.Base(abs)), nil
+	}
+	return resolved, err
+}
@@ -0,0 +1,307 @@
+// Package sandbox runs external processes with resource limits, a scrubbed
+// environment and a per-run working directory.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default limits, used when a Config field is zero
+const (
+	DefaultMaxMemoryMB    = 1024
+	DefaultMaxCPUSeconds  = 60
+	DefaultMaxOutputBytes = 1 << 20
+	DefaultTimeout        = 60 * time.Second
+
+	defaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// ErrNetworkIsolationUnsupported is returned when network isolation is
+// requested on a platform that cannot provide it
+var ErrNetworkIsolationUnsupported = errors.New("network isolation is not supported on this platform")
+
+// Config describes the limits applied to a sandboxed process.
+// Zero values use the defaults; negative values disable a limit.
+//
+// MaxProcesses is not set by default: RLIMIT_NPROC counts every process and
+// thread of the user, not only those of the sandboxed process. Set it only
+// when the server runs as a dedicated user, or forks fail with EAGAIN as soon
+// as the user has more processes than the limit.
+type Config struct {
+	MaxMemoryMB    int      `json:"maxMemoryMB,omitempty"`    // address space limit (RLIMIT_AS)
+	MaxCPUSeconds  int      `json:"maxCpuSeconds,omitempty"`  // CPU time limit (RLIMIT_CPU)
+	MaxProcesses   int      `json:"maxProcesses,omitempty"`   // process limit of the whole run user (RLIMIT_NPROC), unset by default
+	MaxOutputBytes int      `json:"maxOutputBytes,omitempty"` // per stream, extra output is discarded
+	AllowedEnv     []string `json:"allowedEnv,omitempty"`     // server variables passed through, default PATH, HOME, LANG, TZ
+	IsolateNetwork bool     `json:"isolateNetwork,omitempty"` // run in a new network namespace (linux)
+	WorkDirRoot    string   `json:"workDirRoot,omitempty"`    // parent of per-run directories, default os.TempDir()
+}
+
+// DefaultConfig returns the configuration used when no tenant config exists
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+// Spec describes a process to run
+type Spec struct {
+	Path    string
+	Args    []string
+	Dir     string            // working directory, defaults to the per-run directory
+	Env     map[string]string // additional environment variables
+	Files   map[string][]byte // files written into the per-run directory before start
+	Timeout time.Duration     // wall clock limit, default 60s
+}
+
+// Result is the outcome of a sandboxed run
+type Result struct {
+	ExitCode  int
+	Stdout    string
+	Stderr    string
+	TimedOut  bool
+	Truncated bool // stdout or stderr exceeded MaxOutputBytes
+	Duration  time.Duration
+}
+
+// Run executes spec inside the sandbox described by cfg. A non-zero exit code
+// or timeout is reported in the Result; an error means the process could not
+// be started.
+func Run(ctx context.Context, cfg *Config, spec *Spec) (*Result, error) {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	if spec.Path == "" {
+		return nil, fmt.Errorf("command is required")
+	}
+
+	workDir, err := os.MkdirTemp(cfg.WorkDirRoot, "sandbox-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	for name, content := range spec.Files {
+		path := filepath.Join(workDir, filepath.Clean("/"+name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if err := os.WriteFile(path, content, 0755); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	timeout := spec.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dir := workDir
+	if spec.Dir != "" {
+		dir = spec.Dir
+	}
+	env := buildEnv(cfg, workDir, spec.Env)
+	executable, err := lookPath(spec.Path, dir, env)
+	if err != nil {
+		return nil, err
+	}
+
+	path, args := wrapWithLimits(cfg, executable, spec.Args)
+	cmd := exec.Command(path, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+
+	maxOutput := limitOrDefault(cfg.MaxOutputBytes, DefaultMaxOutputBytes)
+	stdout := &limitedBuffer{limit: maxOutput}
+	stderr := &limitedBuffer{limit: maxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Don't wait forever on pipes held open by leftover children
+	cmd.WaitDelay = time.Second
+
+	if err := configureProcess(cmd, cfg); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	result := &Result{}
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		killProcess(cmd)
+		err = <-done
+		result.TimedOut = true
+	}
+	// Reap background children left in the process group
+	killProcess(cmd)
+
+	result.Duration = time.Since(start)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.Truncated = stdout.truncated || stderr.truncated
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("command failed: %w", err)
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+	return result, nil
+}
+
+// wrapWithLimits runs the command through /bin/sh so rlimits are set in the
+// child before exec. Limits are skipped where no POSIX shell is available.
+func wrapWithLimits(cfg *Config, path string, args []string) (string, []string) {
+	if runtime.GOOS == "windows" {
+		return path, args
+	}
+
+	var limits []string
+	if mem := limitOrDefault(cfg.MaxMemoryMB, DefaultMaxMemoryMB); mem > 0 {
+		limits = append(limits, "ulimit -v "+strconv.Itoa(mem*1024))
+	}
+	if cpu := limitOrDefault(cfg.MaxCPUSeconds, DefaultMaxCPUSeconds); cpu > 0 {
+		limits = append(limits, "ulimit -t "+strconv.Itoa(cpu))
+	}
+	if cfg.MaxProcesses > 0 {
+		// bash uses -u, dash and busybox use -p
+		n := strconv.Itoa(cfg.MaxProcesses)
+		limits = append(limits, "{ ulimit -u "+n+" || ulimit -p "+n+"; } 2>/dev/null")
+	}
+	if len(limits) == 0 {
+		return path, args
+	}
+
+	script := strings.Join(limits, " && ") + ` && exec "$@"`
+	return "/bin/sh", append([]string{"-c", script, "sandbox", path}, args...)
+}
+
+// buildEnv returns a scrubbed environment: allowed server variables, the
+// per-run directory as TMPDIR, and the spec variables
+func buildEnv(cfg *Config, workDir string, extra map[string]string) []string {
+	allowed := cfg.AllowedEnv
+	if len(allowed) == 0 {
+		allowed = []string{"PATH", "HOME", "LANG", "TZ"}
+	}
+
+	env := make(map[string]string)
+	for _, name := range allowed {
+		if value, ok := os.LookupEnv(name); ok {
+			env[name] = value
+		}
+	}
+	if env["PATH"] == "" {
+		env["PATH"] = defaultPath
+	}
+	if env["HOME"] == "" {
+		env["HOME"] = workDir
+	}
+	env["TMPDIR"] = workDir
+	for k, v := range extra {
+		env[k] = v
+	}
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]string, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, k+"="+env[k])
+	}
+	return result
+}
+
+// lookPath resolves a bare command name against the sandbox PATH, so a missing
+// executable is reported as a start failure rather than a shell exit code
+func lookPath(name, dir string, env []string) (string, error) {
+	if runtime.GOOS == "windows" {
+		return name, nil
+	}
+	if strings.ContainsRune(name, filepath.Separator) {
+		candidate := name
+		if !filepath.IsAbs(candidate) {
+			candidate = filepath.Join(dir, candidate)
+		}
+		if !isExecutable(candidate) {
+			return "", fmt.Errorf("executable file not found: %s", name)
+		}
+		return name, nil
+	}
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, "PATH=") {
+			continue
+		}
+		for _, pathDir := range filepath.SplitList(strings.TrimPrefix(kv, "PATH=")) {
+			candidate := filepath.Join(pathDir, name)
+			if isExecutable(candidate) {
+				return candidate, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("executable file not found in $PATH: %s", name)
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
+
+func limitOrDefault(value, def int) int {
+	if value == 0 {
+		return def
+	}
+	return value
+}
+
+// limitedBuffer keeps the first limit bytes and discards the rest, so a
+// chatty process never blocks on a full pipe
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit < 0 {
+		return b.buf.Write(p)
+	}
+	if remaining := b.limit - b.buf.Len(); remaining < len(p) {
+		if remaining > 0 {
+			b.buf.Write(p[:remaining])
+		}
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,35 @@
+package sandbox
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// configureProcess puts the process in its own process group and, when
+// requested, in a new network namespace. Unprivileged servers also need a
+// user namespace that maps their own uid.
+func configureProcess(cmd *exec.Cmd, cfg *Config) error {
+	attr := &syscall.SysProcAttr{Setpgid: true}
+	if cfg.IsolateNetwork {
+		attr.Cloneflags = syscall.CLONE_NEWNET
+		if uid := os.Getuid(); uid != 0 {
+			gid := os.Getgid()
+			attr.Cloneflags |= syscall.CLONE_NEWUSER
+			attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+			attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+		}
+	}
+	cmd.SysProcAttr = attr
+	return nil
+}
+
+// killProcess kills the whole process group
+func killProcess(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package sandbox
+
+import "os/exec"
+
+// configureProcess only supports network isolation on linux
+func configureProcess(cmd *exec.Cmd, cfg *Config) error {
+	if cfg.IsolateNetwork {
+		return ErrNetworkIsolationUnsupported
+	}
+	return nil
+}
+
+func killProcess(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
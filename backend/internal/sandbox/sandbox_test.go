@@ -0,0 +1,111 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_ScrubbedEnvironment(t *testing.T) {
+	t.Setenv("SANDBOX_TEST_SECRET", "s3cret")
+
+	result, err := Run(context.Background(), nil, &Spec{
+		Path: "sh",
+		Args: []string{"-c", `echo "secret=$SANDBOX_TEST_SECRET extra=$EXTRA tmp=$TMPDIR"; pwd`},
+		Env:  map[string]string{"EXTRA": "x"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+
+	lines := strings.Split(strings.TrimSpace(result.Stdout), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "secret= extra=x")
+	// The per-run directory is the working directory and TMPDIR, and is removed afterwards
+	assert.Contains(t, lines[0], "tmp="+lines[1])
+	_, statErr := os.Stat(lines[1])
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestRun_FilesAndExitCode(t *testing.T) {
+	result, err := Run(context.Background(), nil, &Spec{
+		Path:  "sh",
+		Args:  []string{"script.sh"},
+		Files: map[string][]byte{"script.sh": []byte("echo hi; echo oops >&2; exit 3")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, "hi\n", result.Stdout)
+	assert.Equal(t, "oops\n", result.Stderr)
+}
+
+func TestRun_Timeout(t *testing.T) {
+	result, err := Run(context.Background(), nil, &Spec{
+		Path:    "sh",
+		Args:    []string{"-c", "sleep 10"},
+		Timeout: 200 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.True(t, result.TimedOut)
+	assert.Less(t, result.Duration, 5*time.Second)
+}
+
+func TestRun_OutputLimit(t *testing.T) {
+	result, err := Run(context.Background(), &Config{MaxOutputBytes: 10}, &Spec{
+		Path: "sh",
+		Args: []string{"-c", "yes | head -c 100000"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, result.Stdout, 10)
+	assert.True(t, result.Truncated)
+}
+
+func TestRun_MemoryLimit(t *testing.T) {
+	// The address space limit is applied in the child before exec
+	result, err := Run(context.Background(), &Config{MaxMemoryMB: 64}, &Spec{
+		Path: "sh",
+		Args: []string{"-c", "ulimit -v"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "65536", strings.TrimSpace(result.Stdout))
+}
+
+func TestRun_ProcessLimitIsOptIn(t *testing.T) {
+	spec := &Spec{Path: "sh", Args: []string{"-c", "ulimit -u 2>/dev/null || ulimit -p"}}
+	unset, err := Run(context.Background(), nil, spec)
+	require.NoError(t, err)
+	server, err := exec.Command(spec.Path, spec.Args...).Output()
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(server)), strings.TrimSpace(unset.Stdout), "the server's limit is kept")
+
+	limited, err := Run(context.Background(), &Config{MaxProcesses: 64}, spec)
+	require.NoError(t, err)
+	assert.Equal(t, "64", strings.TrimSpace(limited.Stdout))
+}
+
+func TestRun_NetworkIsolation(t *testing.T) {
+	result, err := Run(context.Background(), &Config{IsolateNetwork: true}, &Spec{
+		Path: "cat",
+		Args: []string{"/proc/net/dev"},
+	})
+	if err != nil {
+		t.Skipf("network namespaces unavailable: %v", err)
+	}
+	// Only the loopback interface exists in a fresh namespace
+	lines := strings.Split(strings.TrimSpace(result.Stdout), "\n")
+	require.Len(t, lines, 3, result.Stdout)
+	assert.Contains(t, lines[2], "lo:")
+}
+
+func TestRun_MissingExecutable(t *testing.T) {
+	_, err := Run(context.Background(), nil, &Spec{Path: "nonexistent_command_12345"})
+	assert.Error(t, err)
+
+	_, err = Run(context.Background(), nil, &Spec{Path: "/nonexistent/command"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,184 @@
+// Package schema validates JSON values against JSON Schema (Draft 7 and
+// 2020-12), inline or stored as project documents.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Supported drafts
+const (
+	Draft7    = "7"
+	Draft2020 = "2020-12"
+)
+
+// documentScheme is the URL scheme of stored documents, so schemas can
+// reference each other with {"$ref": "schema://common#/$defs/id"}
+const documentScheme = "schema://"
+
+// DocumentLoader loads a stored schema or OpenAPI document by name
+type DocumentLoader func(name string) (interface{}, error)
+
+// Spec selects the schema to validate against
+type Spec struct {
+	Schema interface{} // inline schema
+	Ref    string      // stored document name, optionally with a JSON pointer: "petstore#/components/schemas/Pet"
+	Draft  string      // 7 or 2020-12; default from $schema, else 2020-12
+}
+
+// Violation describes one failed keyword
+type Violation struct {
+	InstancePath string `json:"instancePath"`
+	KeywordPath  string `json:"keywordPath"`
+	Message      string `json:"message"`
+}
+
+func (v Violation) String() string {
+	path := v.InstancePath
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s (keyword %s)", path, v.Message, v.KeywordPath)
+}
+
+// Validate validates instance against spec and returns the violations. An
+// error means the schema itself could not be loaded or compiled.
+func Validate(spec Spec, instance interface{}, load DocumentLoader) ([]Violation, error) {
+	compiled, err := compile(spec, load)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := normalize(instance)
+	if err != nil {
+		return nil, fmt.Errorf("instance is not valid JSON: %w", err)
+	}
+
+	err = compiled.Validate(value)
+	if err == nil {
+		return nil, nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+	return violations(validationErr), nil
+}
+
+// Check compiles spec without validating anything, to reject broken schemas
+// when they are stored
+func Check(spec Spec, load DocumentLoader) error {
+	_, err := compile(spec, load)
+	return err
+}
+
+func compile(spec Spec, load DocumentLoader) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	switch spec.Draft {
+	case "", Draft2020, "2020":
+		compiler.DefaultDraft(jsonschema.Draft2020)
+	case Draft7, "draft7", "draft-07":
+		compiler.DefaultDraft(jsonschema.Draft7)
+	default:
+		return nil, fmt.Errorf("unsupported schema draft: %s (supported: %s, %s)", spec.Draft, Draft7, Draft2020)
+	}
+	// Only stored documents may be referenced; never files or the network
+	compiler.UseLoader(documentLoader{load: load})
+
+	if spec.Schema != nil {
+		doc, err := normalize(spec.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema: %w", err)
+		}
+		const inline = documentScheme + "inline"
+		if err := compiler.AddResource(inline, doc); err != nil {
+			return nil, fmt.Errorf("invalid schema: %w", err)
+		}
+		return compileLocation(compiler, inline)
+	}
+
+	if spec.Ref == "" {
+		return nil, fmt.Errorf("either schema or schemaRef is required")
+	}
+	name, pointer, _ := strings.Cut(spec.Ref, "#")
+	location := documentScheme + url.PathEscape(name)
+	if pointer != "" {
+		location += "#" + pointer
+	}
+	return compileLocation(compiler, location)
+}
+
+func compileLocation(compiler *jsonschema.Compiler, location string) (*jsonschema.Schema, error) {
+	compiled, err := compiler.Compile(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// documentLoader resolves schema:// URLs through the DocumentLoader
+type documentLoader struct {
+	load DocumentLoader
+}
+
+func (l documentLoader) Load(location string) (interface{}, error) {
+	if !strings.HasPrefix(location, documentScheme) {
+		return nil, fmt.Errorf("schema reference %q is not allowed, only %s documents can be referenced", location, documentScheme)
+	}
+	if l.load == nil {
+		return nil, fmt.Errorf("stored schemas are not available")
+	}
+	name, err := url.PathUnescape(strings.TrimPrefix(location, documentScheme))
+	if err != nil {
+		return nil, err
+	}
+	doc, err := l.load(name)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(doc)
+}
+
+// normalize converts Go values into the representation used by the
+// validator (json.Number for numbers)
+func normalize(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(data))
+}
+
+// violations flattens the validation error into leaf keyword failures
+func violations(err *jsonschema.ValidationError) []Violation {
+	output := err.BasicOutput()
+	var result []Violation
+	for _, unit := range output.Errors {
+		if unit.Error == nil {
+			continue
+		}
+		result = append(result, Violation{
+			InstancePath: unit.InstanceLocation,
+			KeywordPath:  unit.KeywordLocation,
+			Message:      unit.Error.String(),
+		})
+	}
+	if len(result) == 0 && output.Error != nil {
+		result = append(result, Violation{
+			InstancePath: output.InstanceLocation,
+			KeywordPath:  output.KeywordLocation,
+			Message:      output.Error.String(),
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].InstancePath < result[j].InstancePath
+	})
+	return result
+}
@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var userSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []interface{}{"id", "email"},
+	"properties": map[string]interface{}{
+		"id":    map[string]interface{}{"type": "integer"},
+		"email": map[string]interface{}{"type": "string"},
+	},
+}
+
+func TestValidate_Inline(t *testing.T) {
+	violations, err := Validate(Spec{Schema: userSchema}, map[string]interface{}{"id": 1, "email": "a@b.c"}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	violations, err = Validate(Spec{Schema: userSchema}, map[string]interface{}{"id": "abc"}, nil)
+	require.NoError(t, err)
+	require.Len(t, violations, 2)
+	assert.Equal(t, "", violations[0].InstancePath)
+	assert.Equal(t, "/required", violations[0].KeywordPath)
+	assert.Equal(t, "/id", violations[1].InstancePath)
+	assert.Equal(t, "/properties/id/type", violations[1].KeywordPath)
+	assert.Equal(t, "/id: got string, want integer (keyword /properties/id/type)", violations[1].String())
+}
+
+func TestValidate_Drafts(t *testing.T) {
+	// prefixItems only exists in 2020-12; draft 7 ignores it
+	tuple := map[string]interface{}{
+		"type":        "array",
+		"prefixItems": []interface{}{map[string]interface{}{"type": "string"}},
+	}
+	instance := []interface{}{1}
+
+	violations, err := Validate(Spec{Schema: tuple, Draft: Draft2020}, instance, nil)
+	require.NoError(t, err)
+	assert.Len(t, violations, 1)
+
+	violations, err = Validate(Spec{Schema: tuple, Draft: Draft7}, instance, nil)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	_, err = Validate(Spec{Schema: tuple, Draft: "4"}, instance, nil)
+	assert.Error(t, err)
+}
+
+func TestValidate_StoredDocuments(t *testing.T) {
+	docs := map[string]interface{}{
+		"user": userSchema,
+		"petstore": map[string]interface{}{
+			"openapi": "3.0.0",
+			"components": map[string]interface{}{
+				"schemas": map[string]interface{}{
+					"Pet": map[string]interface{}{
+						"type":     "object",
+						"required": []interface{}{"name"},
+						"properties": map[string]interface{}{
+							"name":  map[string]interface{}{"type": "string"},
+							"owner": map[string]interface{}{"$ref": "#/components/schemas/Owner"},
+						},
+					},
+					"Owner": map[string]interface{}{
+						"type":     "object",
+						"required": []interface{}{"id"},
+					},
+				},
+			},
+		},
+	}
+	load := func(name string) (interface{}, error) {
+		doc, ok := docs[name]
+		if !ok {
+			return nil, fmt.Errorf("schema not found: %s", name)
+		}
+		return doc, nil
+	}
+
+	violations, err := Validate(Spec{Ref: "user"}, map[string]interface{}{"id": 1}, load)
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "/required", violations[0].KeywordPath)
+
+	violations, err = Validate(Spec{Ref: "petstore#/components/schemas/Pet"},
+		map[string]interface{}{"name": "rex", "owner": map[string]interface{}{}}, load)
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "/owner", violations[0].InstancePath)
+
+	inline := map[string]interface{}{"$ref": "schema://user"}
+	violations, err = Validate(Spec{Schema: inline}, map[string]interface{}{"id": 1, "email": "x"}, load)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	_, err = Validate(Spec{Ref: "missing"}, map[string]interface{}{}, load)
+	assert.Error(t, err)
+}
+
+func TestValidate_RejectsExternalRefs(t *testing.T) {
+	for _, ref := range []string{"file:///etc/passwd", "http://example.com/schema.json"} {
+		_, err := Validate(Spec{Schema: map[string]interface{}{"$ref": ref}}, 1, nil)
+		assert.Error(t, err, ref)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check(Spec{Schema: userSchema}, nil))
+	assert.Error(t, Check(Spec{Schema: map[string]interface{}{"type": 5}}, nil))
+	assert.Error(t, Check(Spec{}, nil))
+}
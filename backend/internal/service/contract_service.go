@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/openapi"
+	"test-management-service/internal/repository"
+	"test-management-service/internal/testcase"
+)
+
+// maxGroupDepth 防止分组父子关系成环时无限向上查找
+const maxGroupDepth = 32
+
+// ContractResolver 解析 HTTP 测试要校验的 OpenAPI 契约
+type ContractResolver interface {
+	// ResolveContract 依次查找测试分组及其祖先分组、当前激活环境上关联的契约；
+	// 均未关联时返回 nil。groupID 为空时只查找环境
+	ResolveContract(ctx context.Context, tenantID, projectID, groupID string) (*testcase.Contract, error)
+}
+
+type contractResolver struct {
+	groupRepo repository.TestGroupRepository
+	envRepo   repository.EnvironmentRepository
+}
+
+// NewContractResolver 创建契约解析器
+func NewContractResolver(groupRepo repository.TestGroupRepository, envRepo repository.EnvironmentRepository) ContractResolver {
+	return &contractResolver{groupRepo: groupRepo, envRepo: envRepo}
+}
+
+func (r *contractResolver) ResolveContract(ctx context.Context, tenantID, projectID, groupID string) (*testcase.Contract, error) {
+	// 子分组的契约优先于父分组
+	for depth := 0; groupID != "" && depth < maxGroupDepth; depth++ {
+		group, err := r.groupRepo.FindByIDWithTenant(ctx, groupID, tenantID, projectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find test group: %w", err)
+		}
+		if group == nil {
+			break
+		}
+		if group.OpenAPISpec != "" {
+			return &testcase.Contract{Spec: group.OpenAPISpec, Mode: group.ContractMode}, nil
+		}
+		groupID = group.ParentID
+	}
+
+	env, err := r.envRepo.FindActiveWithTenant(ctx, tenantID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find active environment: %w", err)
+	}
+	if env != nil && env.OpenAPISpec != "" {
+		return &testcase.Contract{Spec: env.OpenAPISpec, Mode: env.ContractMode}, nil
+	}
+	return nil, nil
+}
+
+// resolveContract 解析测试分组的契约；未配置解析器时不校验
+func (s *testService) resolveContract(ctx context.Context, tenantID, projectID, groupID string) (*testcase.Contract, error) {
+	if s.contracts == nil {
+		return nil, nil
+	}
+	return s.contracts.ResolveContract(ctx, tenantID, projectID, groupID)
+}
+
+// validateContractMode 校验契约严格程度
+func validateContractMode(mode string) error {
+	if !openapi.ValidMode(mode) {
+		return fmt.Errorf("invalid contract mode '%s' (supported: %s, %s, %s): %w",
+			mode, openapi.ModeStrict, openapi.ModeWarn, openapi.ModeOff, apierrors.ErrInvalidInput)
+	}
+	return nil
+}
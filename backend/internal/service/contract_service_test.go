@@ -0,0 +1,65 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/repository"
+	"test-management-service/internal/service"
+	"test-management-service/internal/testcase"
+	"test-management-service/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractResolver(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	groupRepo := repository.NewTestGroupRepository(db)
+	envRepo := repository.NewEnvironmentRepository(db)
+	tests := service.NewTestService(repository.NewTestCaseRepository(db), groupRepo,
+		repository.NewTestResultRepository(db), repository.NewTestRunRepository(db), testcase.NewExecutor(""))
+	envs := service.NewEnvironmentService(envRepo, repository.NewEnvironmentVariableRepository(db))
+	resolver := service.NewContractResolver(groupRepo, envRepo)
+	ctx := context.Background()
+	const tenant, project = "default", "default"
+
+	_, err := tests.CreateTestGroup(ctx, tenant, project, &service.CreateTestGroupRequest{
+		GroupID: "users", Name: "Users", OpenAPISpec: "users-api", ContractMode: "warn",
+	})
+	require.NoError(t, err)
+	_, err = tests.CreateTestGroup(ctx, tenant, project, &service.CreateTestGroupRequest{
+		GroupID: "users-admin", Name: "Admin", ParentID: "users",
+	})
+	require.NoError(t, err)
+	_, err = tests.CreateTestGroup(ctx, tenant, project, &service.CreateTestGroupRequest{
+		GroupID: "bad", Name: "Bad", ContractMode: "loud",
+	})
+	assert.ErrorIs(t, err, apierrors.ErrInvalidInput)
+
+	// Child groups inherit the contract of their parent
+	contract, err := resolver.ResolveContract(ctx, tenant, project, "users-admin")
+	require.NoError(t, err)
+	assert.Equal(t, &testcase.Contract{Spec: "users-api", Mode: "warn"}, contract)
+
+	// Without a group contract the active environment's contract applies
+	_, err = envs.CreateEnvironment(ctx, tenant, project, &service.CreateEnvironmentRequest{
+		EnvID: "staging", Name: "Staging", OpenAPISpec: "platform-api",
+	})
+	require.NoError(t, err)
+	require.NoError(t, envs.ActivateEnvironment(ctx, "staging", tenant, project))
+
+	empty := ""
+	_, err = tests.UpdateTestGroup(ctx, "users", tenant, project, &service.UpdateTestGroupRequest{OpenAPISpec: &empty})
+	require.NoError(t, err)
+	contract, err = resolver.ResolveContract(ctx, tenant, project, "users-admin")
+	require.NoError(t, err)
+	assert.Equal(t, &testcase.Contract{Spec: "platform-api"}, contract)
+
+	contract, err = resolver.ResolveContract(ctx, "other", project, "")
+	require.NoError(t, err)
+	assert.Nil(t, contract)
+}
@@ -48,16 +48,20 @@ func NewEnvironmentService(
 // ===== Request/Response DTOs =====
 
 type CreateEnvironmentRequest struct {
-	EnvID       string                 `json:"envId" binding:"required"`
-	Name        string                 `json:"name" binding:"required"`
-	Description string                 `json:"description"`
-	Variables   map[string]interface{} `json:"variables"`
+	EnvID        string                 `json:"envId" binding:"required"`
+	Name         string                 `json:"name" binding:"required"`
+	Description  string                 `json:"description"`
+	Variables    map[string]interface{} `json:"variables"`
+	OpenAPISpec  string                 `json:"openapiSpec"`  // 关联的 openapi 文档名称
+	ContractMode string                 `json:"contractMode"` // strict(默认), warn, off
 }
 
 type UpdateEnvironmentRequest struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Variables   map[string]interface{} `json:"variables"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	Variables    map[string]interface{} `json:"variables"`
+	OpenAPISpec  *string                `json:"openapiSpec"`  // nil 保持不变, 空字符串解除关联
+	ContractMode *string                `json:"contractMode"` // strict(默认), warn, off
 }
 
 type SetVariableRequest struct {
@@ -72,15 +76,20 @@ func (s *environmentService) CreateEnvironment(ctx context.Context, tenantID, pr
 	if existing != nil {
 		return nil, fmt.Errorf("environment with envId '%s' already exists", req.EnvID)
 	}
+	if err := validateContractMode(req.ContractMode); err != nil {
+		return nil, err
+	}
 
 	env := &models.Environment{
-		EnvID:       req.EnvID,
-		TenantID:    tenantID,
-		ProjectID:   projectID,
-		Name:        req.Name,
-		Description: req.Description,
-		IsActive:    false, // 新环境默认不激活
-		Variables:   models.JSONB(req.Variables),
+		EnvID:        req.EnvID,
+		TenantID:     tenantID,
+		ProjectID:    projectID,
+		Name:         req.Name,
+		Description:  req.Description,
+		IsActive:     false, // 新环境默认不激活
+		Variables:    models.JSONB(req.Variables),
+		OpenAPISpec:  req.OpenAPISpec,
+		ContractMode: req.ContractMode,
 	}
 
 	if err := s.envRepo.CreateWithTenant(ctx, env); err != nil {
@@ -108,6 +117,15 @@ func (s *environmentService) UpdateEnvironment(ctx context.Context, envID, tenan
 	if req.Variables != nil {
 		env.Variables = models.JSONB(req.Variables)
 	}
+	if req.OpenAPISpec != nil {
+		env.OpenAPISpec = *req.OpenAPISpec
+	}
+	if req.ContractMode != nil {
+		if err := validateContractMode(*req.ContractMode); err != nil {
+			return nil, err
+		}
+		env.ContractMode = *req.ContractMode
+	}
 
 	if err := s.envRepo.UpdateWithTenant(ctx, env); err != nil {
 		return nil, fmt.Errorf("failed to update environment: %w", err)
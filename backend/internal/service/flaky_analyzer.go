@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/models"
+	"test-management-service/internal/repository"
+)
+
+const (
+	// flakyWindow is the number of recent results a flakiness score covers
+	flakyWindow = 20
+	// flakyMinResults is the number of results below which a test is not scored
+	flakyMinResults = 5
+	// flakyScoreThreshold is the score from which a test is marked flaky
+	flakyScoreThreshold = 30
+
+	// Weights of the signals in the score; they add up to 100
+	flakyFlipWeight     = 50
+	flakyRetryWeight    = 30
+	flakyVarianceWeight = 20
+)
+
+// FlakinessReport 用例的不稳定性评分及其依据
+type FlakinessReport struct {
+	TestID         string `json:"testId"`
+	Score          int    `json:"score"` // 0-100, 越高越不稳定
+	IsFlaky        bool   `json:"isFlaky"`
+	Threshold      int    `json:"threshold"` // 评分达到该值即标记为不稳定
+	Window         int    `json:"window"`    // 窗口内的结果数（跳过的结果不计）
+	MinResults     int    `json:"minResults"`
+	Passes         int    `json:"passes"`
+	Failures       int    `json:"failures"` // 失败和错误
+	Flips          int    `json:"flips"`    // 相邻结果在通过与未通过之间切换的次数
+	Retries        int    `json:"retries"`  // 重跑原本失败用例的次数
+	RetryPasses    int    `json:"retryPasses"`
+	DistinctErrors int    `json:"distinctErrors"` // 失败中不同错误信息的数量
+
+	FlipRate      float64 `json:"flipRate"`      // flips / (window - 1)
+	RetryPassRate float64 `json:"retryPassRate"` // retryPasses / retries
+	ErrorVariance float64 `json:"errorVariance"` // (distinctErrors - 1) / (failures - 1)
+
+	History []FlakinessSample `json:"history"` // 窗口内的结果, 由新到旧
+}
+
+// FlakinessSample 评分窗口中的一次执行
+type FlakinessSample struct {
+	ResultID      uint      `json:"resultId"`
+	RunID         string    `json:"runId,omitempty"`
+	Status        string    `json:"status"`
+	StartTime     time.Time `json:"startTime"`
+	Duration      int       `json:"duration"`
+	Error         string    `json:"error,omitempty"` // 失败时归一化后的错误信息
+	Flipped       bool      `json:"flipped"`         // 与前一次执行的结果不同
+	RetryOf       uint      `json:"retryOf,omitempty"`
+	PassedOnRetry bool      `json:"passedOnRetry"`
+}
+
+// flakyAnalyzer keeps the execution statistics and flakiness score of test
+// cases up to date as their results are saved
+type flakyAnalyzer struct {
+	caseRepo   repository.TestCaseRepository
+	resultRepo repository.TestResultRepository
+	// releasePasses is the number of consecutive passes that lift a quarantine
+	releasePasses int
+
+	// mu serializes updates, which read and write the statistics of a test
+	mu sync.Mutex
+}
+
+func newFlakyAnalyzer(caseRepo repository.TestCaseRepository, resultRepo repository.TestResultRepository) *flakyAnalyzer {
+	return &flakyAnalyzer{caseRepo: caseRepo, resultRepo: resultRepo, releasePasses: defaultQuarantineReleasePasses}
+}
+
+// RecordResult updates the statistics of the test of a saved result, rescores
+// its flakiness and releases it from quarantine once it has passed
+// releasePasses times in a row or its quarantine has expired. Skipped results
+// did not execute and are ignored.
+func (a *flakyAnalyzer) RecordResult(ctx context.Context, result *models.TestResult) error {
+	if result.Status == "skipped" {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	tc, err := a.caseRepo.FindByIDWithTenant(ctx, result.TestID, result.TenantID, result.ProjectID)
+	if err != nil {
+		return err
+	}
+	if tc == nil {
+		return nil // deleted while it ran
+	}
+
+	// Running averages over every execution
+	tc.ExecutionCount++
+	tc.AvgDuration += (result.Duration - tc.AvgDuration) / tc.ExecutionCount
+	ranAt := result.StartTime
+	tc.LastRunAt = &ranAt
+	if result.Status == "passed" {
+		tc.SuccessCount++
+		tc.ConsecutiveFailures = 0
+		tc.LastSuccessAt = &ranAt
+	} else {
+		tc.FailureCount++
+		tc.ConsecutiveFailures++
+		tc.LastFailureAt = &ranAt
+	}
+	tc.SuccessRate = tc.SuccessCount * 100 / tc.ExecutionCount
+
+	report, err := a.analyze(ctx, tc.TestID, tc.TenantID, tc.ProjectID)
+	if err != nil {
+		return err
+	}
+	tc.FlakyScore = report.Score
+	tc.IsFlaky = report.IsFlaky
+
+	if err := a.caseRepo.UpdateStatsWithTenant(ctx, tc); err != nil {
+		return err
+	}
+	if !tc.Quarantined {
+		return nil
+	}
+	switch {
+	case !quarantineActive(tc, time.Now()):
+		liftQuarantine(tc)
+	case result.Status == "passed":
+		tc.QuarantinePasses++
+		if tc.QuarantinePasses >= a.releasePasses {
+			liftQuarantine(tc)
+		}
+	default:
+		tc.QuarantinePasses = 0
+	}
+	return a.caseRepo.UpdateQuarantineWithTenant(ctx, tc)
+}
+
+// Report returns the flakiness score of a test with the results behind it
+func (a *flakyAnalyzer) Report(ctx context.Context, testID, tenantID, projectID string) (*FlakinessReport, error) {
+	tc, err := a.caseRepo.FindByIDWithTenant(ctx, testID, tenantID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if tc == nil {
+		return nil, fmt.Errorf("test case %s: %w", testID, apierrors.ErrNotFound)
+	}
+	return a.analyze(ctx, testID, tenantID, projectID)
+}
+
+// analyze scores the flakiness of a test over its recent results from three
+// signals: how often the outcome flips between consecutive executions, how
+// often failures pass when rerun, and how much the errors of its failures
+// vary. A test that breaks and stays broken flips once and fails the same way,
+// so it scores low; one failing at random scores high.
+func (a *flakyAnalyzer) analyze(ctx context.Context, testID, tenantID, projectID string) (*FlakinessReport, error) {
+	results, err := a.resultRepo.FindByTestIDWithTenant(ctx, testID, tenantID, projectID, flakyWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &FlakinessReport{
+		TestID:     testID,
+		Threshold:  flakyScoreThreshold,
+		MinResults: flakyMinResults,
+		History:    []FlakinessSample{},
+	}
+	statusByID := make(map[uint]string, len(results))
+	for _, r := range results {
+		statusByID[r.ID] = r.Status
+	}
+
+	errorsSeen := make(map[string]bool)
+	for _, r := range results { // newest first
+		if r.Status == "skipped" {
+			continue
+		}
+		sample := FlakinessSample{
+			ResultID:  r.ID,
+			RunID:     r.RunID,
+			Status:    r.Status,
+			StartTime: r.StartTime,
+			Duration:  r.Duration,
+			RetryOf:   r.RetryOf,
+		}
+		passed := r.Status == "passed"
+		if passed {
+			report.Passes++
+		} else {
+			report.Failures++
+			sample.Error = normalizeFailure(&r)
+			errorsSeen[sample.Error] = true
+		}
+
+		// Only a retry of a failure says something about flakiness
+		if original, ok := statusByID[r.RetryOf]; ok && original != "passed" {
+			report.Retries++
+			if passed {
+				report.RetryPasses++
+				sample.PassedOnRetry = true
+			}
+		}
+
+		// The older neighbour is the next sample; mark the newer one
+		if n := len(report.History); n > 0 && (report.History[n-1].Status == "passed") != passed {
+			report.Flips++
+			report.History[n-1].Flipped = true
+		}
+		report.History = append(report.History, sample)
+	}
+	report.Window = len(report.History)
+	report.DistinctErrors = len(errorsSeen)
+
+	if report.Window < flakyMinResults || report.Failures == 0 {
+		return report, nil
+	}
+	report.FlipRate = float64(report.Flips) / float64(report.Window-1)
+	if report.Retries > 0 {
+		report.RetryPassRate = float64(report.RetryPasses) / float64(report.Retries)
+	}
+	if report.Failures > 1 {
+		report.ErrorVariance = float64(report.DistinctErrors-1) / float64(report.Failures-1)
+	}
+
+	score := flakyFlipWeight*report.FlipRate + flakyRetryWeight*report.RetryPassRate +
+		flakyVarianceWeight*report.ErrorVariance
+	report.Score = int(math.Round(math.Min(score, 100)))
+	report.IsFlaky = report.Score >= flakyScoreThreshold
+	return report, nil
+}
+
+// volatileText matches the parts of an error message that differ between
+// otherwise identical failures: numbers, durations, IDs and addresses
+var volatileText = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F-]{27}|0x[0-9a-fA-F]+|\d+(\.\d+)?`)
+
+// normalizeFailure returns the error of a failed result with its volatile
+// parts masked, so only failures that differ in kind count as distinct
+func normalizeFailure(r *models.TestResult) string {
+	msg := r.Error
+	if msg == "" {
+		parts := make([]string, 0, len(r.Failures))
+		for _, f := range r.Failures {
+			parts = append(parts, fmt.Sprint(f))
+		}
+		msg = strings.Join(parts, "; ")
+	}
+	return volatileText.ReplaceAllString(msg, "#")
+}
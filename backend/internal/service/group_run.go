@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"test-management-service/internal/models"
+	"test-management-service/internal/testcase"
+)
+
+// TestRunPublisher streams the lifecycle events of test runs to live
+// subscribers, keyed by run ID. The WebSocket hub implements it.
+type TestRunPublisher interface {
+	Broadcast(runID string, msgType string, payload interface{})
+}
+
+// TestRunListener is notified when a group run finishes, after its final
+// state is saved. The test plan service implements it.
+type TestRunListener interface {
+	RunFinished(ctx context.Context, run *models.TestRun)
+}
+
+// Test run event types
+const (
+	eventRunStart     = "run_start"
+	eventTestStart    = "test_start"
+	eventTestComplete = "test_complete"
+	eventRunProgress  = "run_progress"
+	eventRunComplete  = "run_complete"
+)
+
+// groupNode is a test group of a group run: its tests and, in recursive runs,
+// its subgroups, with the executor and contract they run with
+type groupNode struct {
+	group       *models.TestGroup // nil when the group does not exist
+	executor    *testcase.UnifiedTestExecutor
+	contract    *testcase.Contract
+	concurrency int
+	tests       []models.TestCase
+	children    []*groupNode
+	// childrenFirst is the number of children, at the start of children,
+	// holding prerequisites of the node's own tests; they run before them
+	childrenFirst int
+	// variables are seeded into the hooks and tests of the node, below the
+	// responses saved by the hooks of the enclosing groups
+	variables map[string]interface{}
+}
+
+// total returns the number of tests in the node and its subgroups
+func (n *groupNode) total() int {
+	total := len(n.tests)
+	for _, child := range n.children {
+		total += child.total()
+	}
+	return total
+}
+
+// walk calls fn for every test of the node and its subgroups
+func (n *groupNode) walk(fn func(tc *models.TestCase)) {
+	for i := range n.tests {
+		fn(&n.tests[i])
+	}
+	for _, child := range n.children {
+		child.walk(fn)
+	}
+}
+
+// loadGroupNode loads a group with its tests, and its subgroups when the run
+// is recursive. Subgroups inherit the target host of their parent.
+func (s *testService) loadGroupNode(ctx context.Context, groupID, tenantID, projectID string, executor *testcase.UnifiedTestExecutor, req *ExecuteTestGroupRequest, visited map[string]bool) (*groupNode, error) {
+	visited[groupID] = true
+
+	// Get all tests in group with tenant isolation
+	tests, err := s.caseRepo.FindByGroupIDWithTenant(ctx, groupID, tenantID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tests in group: %w", err)
+	}
+	node := &groupNode{tests: tests, executor: executor, concurrency: req.Concurrency}
+
+	// Get the test group to check for custom target host and concurrency
+	group, err := s.groupRepo.FindByIDWithTenant(ctx, groupID, tenantID, projectID)
+	if err == nil && group != nil {
+		node.group = group
+		if group.TargetHost != "" {
+			// Use group-specific target host
+			node.executor = executor.WithBaseURL(group.TargetHost)
+		}
+		if node.concurrency == 0 {
+			node.concurrency = group.Concurrency
+		}
+	}
+	if node.concurrency <= 0 {
+		node.concurrency = s.groupConcurrency
+	}
+	if node.contract, err = s.resolveContract(ctx, tenantID, projectID, groupID); err != nil {
+		return nil, err
+	}
+
+	if !req.Recursive {
+		return node, nil
+	}
+	children, err := s.groupRepo.FindByParentIDWithTenant(ctx, groupID, tenantID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subgroups of %s: %w", groupID, err)
+	}
+	for _, child := range children {
+		if visited[child.GroupID] {
+			continue
+		}
+		childNode, err := s.loadGroupNode(ctx, child.GroupID, tenantID, projectID, node.executor, req, visited)
+		if err != nil {
+			return nil, err
+		}
+		node.children = append(node.children, childNode)
+	}
+	return node, nil
+}
+
+// groupRun is the state shared by the workers of a group run
+type groupRun struct {
+	ctx     context.Context    // saves results; outlives scheduling
+	execCtx context.Context    // done when the maximum duration is exceeded; interrupts running tests
+	runCtx  context.Context    // done on fail-fast or when the maximum duration is exceeded
+	stop    context.CancelFunc // stops scheduling on fail-fast
+
+	runID, tenantID, projectID string
+	failFast                   bool
+	retryOf                    map[string]uint // result each test reruns, by test ID
+
+	// run is only touched under mu once workers start; gorm writes to it on
+	// update, so progress is saved from a copy. saveMu orders those saves.
+	mu          sync.Mutex
+	saveMu      sync.Mutex
+	run         *models.TestRun
+	finished    int
+	interrupted bool                    // a running test was stopped at the maximum duration
+	outcomes    map[string]*testOutcome // by test ID, for the tests that depend on them
+}
+
+// runGroup executes a group run. Each group runs its setup hooks once, then
+// its tests on a pool of workers, then its subgroups one after another, and
+// finally its teardown hooks. Subgroups holding prerequisites of the group's
+// own tests run before them, and those holding prerequisites of a sibling's
+// tests run before the sibling. Tests not started because of fail-fast or the maximum
+// duration are counted as skipped, like the tests still running when the
+// maximum duration is exceeded, which are interrupted.
+func (s *testService) runGroup(ctx context.Context, run *models.TestRun, root *groupNode, retryOf map[string]uint) {
+	gr := &groupRun{
+		ctx:       ctx,
+		runID:     run.RunID,
+		tenantID:  run.TenantID,
+		projectID: run.ProjectID,
+		failFast:  run.FailFast,
+		retryOf:   retryOf,
+		run:       run,
+		outcomes:  newOutcomes(root),
+	}
+	orderGroups(root)
+	gr.execCtx = ctx
+	if run.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		gr.execCtx, cancel = context.WithTimeout(ctx, time.Duration(run.MaxDuration)*time.Second)
+		defer cancel()
+	}
+	gr.runCtx, gr.stop = context.WithCancel(gr.execCtx)
+	defer gr.stop()
+
+	gr.mu.Lock()
+	s.publish(gr, eventRunStart, map[string]interface{}{
+		"groupId":     run.GroupID,
+		"parentRunId": run.ParentRunID,
+		"status":      run.Status,
+		"total":       run.Total,
+		"concurrency": run.Concurrency,
+		"startTime":   run.StartTime,
+	})
+	gr.mu.Unlock()
+
+	s.runGroupNode(gr, root, nil)
+
+	// Update run status
+	gr.mu.Lock()
+	run.EndTime = time.Now()
+	run.Duration = int(run.EndTime.Sub(run.StartTime).Milliseconds())
+	run.Status = "completed"
+	notRun := run.Total - gr.finished
+	run.Skipped += notRun
+	switch {
+	case (notRun > 0 || gr.interrupted) && errors.Is(gr.runCtx.Err(), context.DeadlineExceeded):
+		run.Status = "timeout"
+	case notRun > 0:
+		run.Status = "cancelled"
+	}
+
+	if err := s.runRepo.UpdateWithTenant(ctx, run); err != nil {
+		log.Printf("failed to update test run %s: %v", gr.runID, err)
+	}
+
+	payload := runCounters(run, gr.finished)
+	payload["status"] = run.Status
+	payload["duration"] = run.Duration
+	s.publish(gr, eventRunComplete, payload)
+	finished := *run
+	gr.mu.Unlock()
+
+	if s.listener != nil {
+		s.listener.RunFinished(ctx, &finished)
+	}
+}
+
+// runGroupNode runs one group of a group run. vars holds the responses saved
+// by the hooks of the enclosing groups; the group's own hooks add to a copy.
+func (s *testService) runGroupNode(gr *groupRun, node *groupNode, vars map[string]interface{}) {
+	if gr.runCtx.Err() != nil {
+		return
+	}
+
+	scoped := make(map[string]interface{}, len(node.variables)+len(vars))
+	for k, v := range node.variables {
+		scoped[k] = v
+	}
+	for k, v := range vars {
+		scoped[k] = v
+	}
+
+	if node.group != nil && len(node.group.TeardownHooks) > 0 {
+		defer func() {
+			hooks := convertHooks(node.group.TeardownHooks)
+			if err := node.executor.RunGroupHooks("teardown", hooks, scoped); err != nil {
+				log.Printf("group %s: %v", node.group.GroupID, err)
+			}
+		}()
+	}
+	if node.group != nil && len(node.group.SetupHooks) > 0 {
+		hooks := convertHooks(node.group.SetupHooks)
+		if err := node.executor.RunGroupHooks("setup", hooks, scoped); err != nil {
+			// Nothing beneath the group can run without its setup
+			s.failGroupNode(gr, node, fmt.Sprintf("group %s: %v", node.group.GroupID, err))
+			return
+		}
+	}
+
+	for _, child := range node.children[:node.childrenFirst] {
+		s.runGroupNode(gr, child, scoped)
+	}
+	s.runGroupTests(gr, node, scoped)
+	for _, child := range node.children[node.childrenFirst:] {
+		s.runGroupNode(gr, child, scoped)
+	}
+}
+
+// runGroupTests runs the tests of a group on a pool of node.concurrency
+// workers. A test starts once its prerequisites have finished, so independent
+// chains run side by side, and is skipped when one of them did not pass.
+// Serial tests run one at a time when no other test runs, so they never
+// overlap another test.
+func (s *testService) runGroupTests(gr *groupRun, node *groupNode, vars map[string]interface{}) {
+	execute := func(tc *models.TestCase, inputs map[string]interface{}) {
+		execTC := s.convertToExecutorTestCase(tc)
+		execTC.Contract = node.contract
+		executor := node.executor.WithContext(gr.execCtx)
+		if len(vars)+len(inputs) > 0 {
+			// Variables saved by the group hooks and the outputs of the
+			// prerequisites are injected like run variables
+			execTC.Variables = make(map[string]interface{}, len(vars)+len(inputs))
+			for k, v := range vars {
+				execTC.Variables[k] = v
+			}
+			for k, v := range inputs {
+				execTC.Variables[k] = v
+			}
+			executor = executor.WithVariables(execTC.Variables)
+		}
+		gr.mu.Lock()
+		s.publish(gr, eventTestStart, map[string]interface{}{
+			"testId": tc.TestID,
+			"name":   tc.Name,
+		})
+		gr.mu.Unlock()
+		result := executor.Execute(execTC)
+		if result.Status != "passed" && gr.execCtx.Err() != nil {
+			// Stopped at the maximum duration, like the tests not started
+			result.Status = "skipped"
+			result.Error = "interrupted: the run exceeded its maximum duration"
+			gr.mu.Lock()
+			gr.interrupted = true
+			gr.mu.Unlock()
+		}
+		s.recordGroupResult(gr, tc, result)
+	}
+
+	pending := make([]*models.TestCase, 0, len(node.tests))
+	for i := range node.tests {
+		pending = append(pending, &node.tests[i])
+	}
+
+	// Create semaphore for concurrency control
+	semaphore := make(chan struct{}, node.concurrency)
+	finished := make(chan struct{}, len(pending))
+	running := 0
+	start := func(tc *models.TestCase, inputs map[string]interface{}) {
+		select {
+		case semaphore <- struct{}{}: // Acquire semaphore
+		case <-gr.runCtx.Done():
+		}
+		if gr.runCtx.Err() != nil {
+			return
+		}
+		running++
+		go func() {
+			defer func() { finished <- struct{}{} }()
+			defer func() { <-semaphore }() // Release semaphore
+			execute(tc, inputs)
+		}()
+	}
+
+	for len(pending) > 0 && gr.runCtx.Err() == nil {
+		var waiting, serial []*models.TestCase
+		progress := false
+		for _, tc := range pending {
+			ready, skip, inputs := gr.prerequisites(tc)
+			switch {
+			case !ready:
+				waiting = append(waiting, tc)
+			case skip != "":
+				s.skipTest(gr, tc, skip)
+				progress = true
+			case tc.Serial:
+				serial = append(serial, tc)
+			default:
+				start(tc, inputs)
+				progress = true
+			}
+		}
+		pending = append(waiting, serial...)
+
+		switch {
+		case progress:
+		case running > 0:
+			<-finished
+			running--
+		case len(serial) > 0:
+			_, _, inputs := gr.prerequisites(serial[0])
+			execute(serial[0], inputs)
+			pending = append(waiting, serial[1:]...)
+		default:
+			pending = s.skipBlocked(gr, pending)
+		}
+	}
+	for ; running > 0; running-- {
+		<-finished
+	}
+}
+
+// failGroupNode records an error result for every test of a group and its
+// subgroups
+func (s *testService) failGroupNode(gr *groupRun, node *groupNode, reason string) {
+	now := time.Now()
+	for i := range node.tests {
+		tc := &node.tests[i]
+		s.recordGroupResult(gr, tc, &testcase.TestResult{
+			TestID:    tc.TestID,
+			Name:      tc.Name,
+			Status:    "error",
+			Error:     reason,
+			StartTime: now,
+			EndTime:   now,
+		})
+	}
+	for _, child := range node.children {
+		s.failGroupNode(gr, child, reason)
+	}
+}
+
+// recordGroupResult saves the result of a test and updates the run's counters.
+// Only the counters and the outcome are updated under gr.mu; the result is
+// saved, scored and published after releasing it, so workers and the
+// scheduler do not wait on each other's database writes.
+func (s *testService) recordGroupResult(gr *groupRun, tc *models.TestCase, result *testcase.TestResult) {
+	quarantined := quarantineActive(tc, time.Now())
+
+	gr.mu.Lock()
+	gr.finished++
+	gr.recordOutcome(tc, result)
+	// Update run statistics; quarantined tests run but never decide the
+	// outcome of the run
+	run := gr.run
+	if quarantined {
+		run.Quarantined++
+	} else {
+		switch result.Status {
+		case "passed":
+			run.Passed++
+		case "failed":
+			run.Failed++
+		case "error":
+			run.Errors++
+		case "skipped":
+			run.Skipped++
+		}
+		if gr.failFast && (result.Status == "failed" || result.Status == "error") {
+			gr.stop()
+		}
+	}
+	gr.mu.Unlock()
+
+	dbResult := s.convertToModelResult(result)
+	dbResult.RunID = gr.runID
+	dbResult.RetryOf = gr.retryOf[tc.TestID]
+	dbResult.Quarantined = quarantined
+	dbResult.TenantID = gr.tenantID
+	dbResult.ProjectID = gr.projectID
+
+	if err := s.resultRepo.CreateWithTenant(gr.ctx, dbResult); err != nil {
+		log.Printf("failed to save result for test %s: %v", tc.TestID, err)
+	} else {
+		if err := s.flaky.RecordResult(gr.ctx, dbResult); err != nil {
+			log.Printf("failed to update statistics of test %s: %v", tc.TestID, err)
+		}
+		s.publish(gr, eventTestComplete, map[string]interface{}{
+			"testId":      tc.TestID,
+			"name":        tc.Name,
+			"status":      result.Status,
+			"duration":    dbResult.Duration,
+			"failures":    result.Failures,
+			"error":       result.Error,
+			"quarantined": dbResult.Quarantined,
+		})
+	}
+	s.saveProgress(gr)
+}
+
+// saveProgress saves the counters of a run and publishes them. Saves are
+// serialized by gr.saveMu and each one takes the latest counters, so a
+// worker that saves late never overwrites newer counters.
+func (s *testService) saveProgress(gr *groupRun) {
+	gr.saveMu.Lock()
+	defer gr.saveMu.Unlock()
+
+	gr.mu.Lock()
+	run := *gr.run
+	finished := gr.finished
+	gr.mu.Unlock()
+
+	if err := s.runRepo.UpdateWithTenant(gr.ctx, &run); err != nil {
+		log.Printf("failed to update test run %s: %v", gr.runID, err)
+	}
+	s.publish(gr, eventRunProgress, runCounters(&run, finished))
+}
+
+// publish streams an event of a group run when a publisher is set
+func (s *testService) publish(gr *groupRun, eventType string, payload map[string]interface{}) {
+	if s.publisher == nil {
+		return
+	}
+	s.publisher.Broadcast(gr.runID, eventType, payload)
+}
+
+// runCounters returns the counters of a run for progress events
+func runCounters(run *models.TestRun, finished int) map[string]interface{} {
+	return map[string]interface{}{
+		"total":       run.Total,
+		"finished":    finished,
+		"passed":      run.Passed,
+		"failed":      run.Failed,
+		"errors":      run.Errors,
+		"skipped":     run.Skipped,
+		"quarantined": run.Quarantined,
+	}
+}
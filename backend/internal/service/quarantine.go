@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/models"
+)
+
+// defaultQuarantineReleasePasses 隔离用例连续通过该次数后自动解除隔离
+const defaultQuarantineReleasePasses = 5
+
+// QuarantineTestCaseRequest 隔离用例的请求
+type QuarantineTestCaseRequest struct {
+	Reason    string     `json:"reason" binding:"required"`
+	Owner     string     `json:"owner"`     // 负责修复的人, 默认为用例负责人
+	ExpiresAt *time.Time `json:"expiresAt"` // 到期自动解除; 为空则直到连续通过
+}
+
+// QuarantineTestCase puts a test in quarantine: it keeps running in group
+// runs, but its results no longer count towards the run's verdict
+func (s *testService) QuarantineTestCase(ctx context.Context, testID, tenantID, projectID string, req *QuarantineTestCaseRequest) (*models.TestCase, error) {
+	now := time.Now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("quarantine expiry %s is in the past: %w", req.ExpiresAt.Format(time.RFC3339), apierrors.ErrInvalidInput)
+	}
+
+	tc, err := s.caseRepo.FindByIDWithTenant(ctx, testID, tenantID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find test case: %w", err)
+	}
+	if tc == nil {
+		return nil, fmt.Errorf("test case %s: %w", testID, apierrors.ErrNotFound)
+	}
+
+	tc.Quarantined = true
+	tc.QuarantineReason = req.Reason
+	tc.QuarantineOwner = req.Owner
+	if tc.QuarantineOwner == "" {
+		tc.QuarantineOwner = tc.OwnerID
+	}
+	tc.QuarantinedAt = &now
+	tc.QuarantineExpiresAt = req.ExpiresAt
+	tc.QuarantinePasses = 0
+
+	if err := s.caseRepo.UpdateQuarantineWithTenant(ctx, tc); err != nil {
+		return nil, fmt.Errorf("failed to quarantine test case: %w", err)
+	}
+	return tc, nil
+}
+
+// ReleaseTestCase lifts the quarantine of a test
+func (s *testService) ReleaseTestCase(ctx context.Context, testID, tenantID, projectID string) (*models.TestCase, error) {
+	tc, err := s.caseRepo.FindByIDWithTenant(ctx, testID, tenantID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find test case: %w", err)
+	}
+	if tc == nil {
+		return nil, fmt.Errorf("test case %s: %w", testID, apierrors.ErrNotFound)
+	}
+
+	liftQuarantine(tc)
+	if err := s.caseRepo.UpdateQuarantineWithTenant(ctx, tc); err != nil {
+		return nil, fmt.Errorf("failed to release test case: %w", err)
+	}
+	return tc, nil
+}
+
+// quarantineActive reports whether a test is in quarantine at now
+func quarantineActive(tc *models.TestCase, now time.Time) bool {
+	return tc.Quarantined && (tc.QuarantineExpiresAt == nil || now.Before(*tc.QuarantineExpiresAt))
+}
+
+// liftQuarantine clears the quarantine state of a test
+func liftQuarantine(tc *models.TestCase) {
+	tc.Quarantined = false
+	tc.QuarantineReason = ""
+	tc.QuarantineOwner = ""
+	tc.QuarantinedAt = nil
+	tc.QuarantineExpiresAt = nil
+	tc.QuarantinePasses = 0
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/models"
+	"test-management-service/internal/testcase"
+)
+
+// RunSelection 按条件选择执行的用例, 过滤条件的含义与 repository.TestCaseFilter 相同
+// 显式指定的用例总是执行; 分组中的用例按过滤条件筛选;
+// 既没有用例也没有分组时, 过滤条件作用于项目的所有用例; 所选用例依赖的用例也会执行
+type RunSelection struct {
+	TestIDs    []string `json:"testIds,omitempty"`
+	GroupIDs   []string `json:"groupIds,omitempty"`
+	Tags       []string `json:"tags,omitempty"`       // 必须包含所有标签
+	Priorities []string `json:"priorities,omitempty"` // 任一优先级
+	Types      []string `json:"types,omitempty"`      // 任一类型
+}
+
+// ExecuteSelectionRequest 按选择条件执行的选项; Recursive 表示包含所选分组的子分组
+type ExecuteSelectionRequest struct {
+	ExecuteTestGroupRequest
+	Selection     RunSelection           `json:"selection"`
+	Name          string                 `json:"name"`
+	PlanID        string                 `json:"planId"`        // 由测试计划发起时的计划
+	EnvironmentID string                 `json:"environmentId"` // 为空使用激活的环境
+	Variables     map[string]interface{} `json:"variables"`     // 覆盖环境变量
+}
+
+// matches reports whether a test passes the tag, priority and type filters
+func (sel *RunSelection) matches(tc *models.TestCase) bool {
+	if len(sel.Priorities) > 0 && !slices.Contains(sel.Priorities, tc.Priority) {
+		return false
+	}
+	if len(sel.Types) > 0 && !slices.Contains(sel.Types, tc.Type) {
+		return false
+	}
+	for _, tag := range sel.Tags {
+		if !slices.Contains([]interface{}(tc.Tags), interface{}(tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExecuteSelection runs the tests picked by a selection as one group run.
+// The tests run within their groups, with the setup hooks, target host and
+// contract of each group, like in a group run.
+func (s *testService) ExecuteSelection(ctx context.Context, tenantID, projectID string, req *ExecuteSelectionRequest) (*models.TestRun, error) {
+	if req.Concurrency < 0 || req.MaxDuration < 0 {
+		return nil, fmt.Errorf("concurrency and maxDuration must not be negative: %w", apierrors.ErrInvalidInput)
+	}
+
+	root, err := s.loadSelection(ctx, tenantID, projectID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	selection, err := toJSONB(req.Selection)
+	if err != nil {
+		return nil, err
+	}
+	return s.startGroupRun(ctx, root, &models.TestRun{
+		TenantID:      tenantID,
+		ProjectID:     projectID,
+		Name:          req.Name,
+		FailFast:      req.FailFast,
+		MaxDuration:   req.MaxDuration,
+		Recursive:     req.Recursive,
+		PlanID:        req.PlanID,
+		Selection:     selection,
+		EnvironmentID: req.EnvironmentID,
+		Variables:     req.Variables,
+	}, nil)
+}
+
+// loadSelection builds the tree of a selection run: a root without tests
+// whose children are the selected groups, followed by the groups of the
+// other selected tests, each pruned to the selected tests
+func (s *testService) loadSelection(ctx context.Context, tenantID, projectID string, req *ExecuteSelectionRequest) (*groupNode, error) {
+	sel := &req.Selection
+	executor := s.executor.WithExecutionParams(&testcase.ExecutionParams{
+		TenantID:      tenantID,
+		ProjectID:     projectID,
+		EnvironmentID: req.EnvironmentID,
+		Variables:     req.Variables,
+	})
+	root := &groupNode{executor: executor, concurrency: req.Concurrency, variables: req.Variables}
+	if root.concurrency == 0 {
+		root.concurrency = s.groupConcurrency
+	}
+
+	keep := make(map[string]bool)
+	for _, testID := range sel.TestIDs {
+		keep[testID] = true
+	}
+
+	visited := make(map[string]bool)
+	for _, groupID := range sel.GroupIDs {
+		if visited[groupID] {
+			continue
+		}
+		node, err := s.loadGroupNode(ctx, groupID, tenantID, projectID, executor, &req.ExecuteTestGroupRequest, visited)
+		if err != nil {
+			return nil, err
+		}
+		node.walk(func(tc *models.TestCase) {
+			if sel.matches(tc) {
+				keep[tc.TestID] = true
+			}
+		})
+		root.children = append(root.children, node)
+	}
+
+	// The remaining tests run in their own groups, without subgroups
+	tests, _, err := s.caseRepo.FindAllWithTenant(ctx, tenantID, projectID, -1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tests: %w", err)
+	}
+	if len(sel.TestIDs) == 0 && len(sel.GroupIDs) == 0 {
+		for i := range tests {
+			if sel.matches(&tests[i]) {
+				keep[tests[i].TestID] = true
+			}
+		}
+	}
+	// Prerequisites run too, even when the selection does not pick them
+	addPrerequisites(tests, keep)
+
+	flat := req.ExecuteTestGroupRequest
+	flat.Recursive = false
+	for i := range tests {
+		tc := &tests[i]
+		if !keep[tc.TestID] || visited[tc.GroupID] {
+			continue
+		}
+		node, err := s.loadGroupNode(ctx, tc.GroupID, tenantID, projectID, executor, &flat, visited)
+		if err != nil {
+			return nil, err
+		}
+		root.children = append(root.children, node)
+	}
+
+	if !root.keepTests(keep) {
+		return nil, fmt.Errorf("the selection matches no tests: %w", apierrors.ErrInvalidInput)
+	}
+	return root, nil
+}
+
+// selectionRequest rebuilds the request a selection run was started with
+func selectionRequest(run *models.TestRun) (*ExecuteSelectionRequest, error) {
+	req := &ExecuteSelectionRequest{
+		ExecuteTestGroupRequest: ExecuteTestGroupRequest{
+			Concurrency: run.Concurrency,
+			FailFast:    run.FailFast,
+			MaxDuration: run.MaxDuration,
+			Recursive:   run.Recursive,
+		},
+		Name:          run.Name,
+		PlanID:        run.PlanID,
+		EnvironmentID: run.EnvironmentID,
+		Variables:     run.Variables,
+	}
+	data, err := json.Marshal(run.Selection)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &req.Selection); err != nil {
+		return nil, fmt.Errorf("invalid selection of run %s: %w", run.RunID, err)
+	}
+	return req, nil
+}
+
+// toJSONB converts a struct to its JSON object form
+func toJSONB(v interface{}) (models.JSONB, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m models.JSONB
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
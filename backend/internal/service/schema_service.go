@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"test-management-service/internal/models"
+	"test-management-service/internal/repository"
+	"test-management-service/internal/schema"
+
+	apierrors "test-management-service/internal/errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaService 项目 Schema 文档管理服务接口
+type SchemaService interface {
+	CreateSchema(ctx context.Context, tenantID, projectID string, req *CreateSchemaRequest) (*models.SchemaDocument, error)
+	UpdateSchema(ctx context.Context, tenantID, projectID, name string, req *UpdateSchemaRequest) (*models.SchemaDocument, error)
+	DeleteSchema(ctx context.Context, tenantID, projectID, name string) error
+	GetSchema(ctx context.Context, tenantID, projectID, name string) (*models.SchemaDocument, error)
+	ListSchemas(ctx context.Context, tenantID, projectID, docType string) ([]models.SchemaDocument, error)
+
+	// LoadSchemaDocument 返回文档内容，供 json_schema 断言解析 schemaRef
+	LoadSchemaDocument(ctx context.Context, tenantID, projectID, name string) (interface{}, error)
+}
+
+type schemaService struct {
+	repo repository.SchemaDocumentRepository
+}
+
+// NewSchemaService 创建 Schema 文档服务
+func NewSchemaService(repo repository.SchemaDocumentRepository) SchemaService {
+	return &schemaService{repo: repo}
+}
+
+// ===== Request/Response DTOs =====
+
+// CreateSchemaRequest Content 可以是 JSON 对象，也可以是 JSON/YAML 字符串（便于上传 OpenAPI 文档）
+type CreateSchemaRequest struct {
+	Name        string      `json:"name" binding:"required"`
+	Type        string      `json:"type"` // json_schema (默认), openapi
+	Description string      `json:"description"`
+	Content     interface{} `json:"content" binding:"required"`
+}
+
+type UpdateSchemaRequest struct {
+	Description *string     `json:"description"`
+	Content     interface{} `json:"content"`
+}
+
+// ===== Implementation =====
+
+func (s *schemaService) CreateSchema(ctx context.Context, tenantID, projectID string, req *CreateSchemaRequest) (*models.SchemaDocument, error) {
+	existing, err := s.repo.FindByName(ctx, tenantID, projectID, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("schema '%s': %w", req.Name, apierrors.ErrAlreadyExists)
+	}
+
+	docType := req.Type
+	if docType == "" {
+		docType = models.SchemaTypeJSONSchema
+	}
+	content, err := s.parseContent(ctx, tenantID, projectID, docType, req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &models.SchemaDocument{
+		TenantID:    tenantID,
+		ProjectID:   projectID,
+		Name:        req.Name,
+		Type:        docType,
+		Description: req.Description,
+		Content:     content,
+	}
+	if err := s.repo.Create(ctx, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *schemaService) UpdateSchema(ctx context.Context, tenantID, projectID, name string, req *UpdateSchemaRequest) (*models.SchemaDocument, error) {
+	doc, err := s.GetSchema(ctx, tenantID, projectID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		doc.Description = *req.Description
+	}
+	if req.Content != nil {
+		content, err := s.parseContent(ctx, tenantID, projectID, doc.Type, req.Content)
+		if err != nil {
+			return nil, err
+		}
+		doc.Content = content
+	}
+
+	if err := s.repo.Update(ctx, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *schemaService) DeleteSchema(ctx context.Context, tenantID, projectID, name string) error {
+	if _, err := s.GetSchema(ctx, tenantID, projectID, name); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, tenantID, projectID, name)
+}
+
+func (s *schemaService) GetSchema(ctx context.Context, tenantID, projectID, name string) (*models.SchemaDocument, error) {
+	doc, err := s.repo.FindByName(ctx, tenantID, projectID, name)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("schema '%s': %w", name, apierrors.ErrNotFound)
+	}
+	return doc, nil
+}
+
+func (s *schemaService) ListSchemas(ctx context.Context, tenantID, projectID, docType string) ([]models.SchemaDocument, error) {
+	return s.repo.List(ctx, tenantID, projectID, docType)
+}
+
+func (s *schemaService) LoadSchemaDocument(ctx context.Context, tenantID, projectID, name string) (interface{}, error) {
+	doc, err := s.GetSchema(ctx, tenantID, projectID, name)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}(doc.Content), nil
+}
+
+// parseContent 解析并校验文档内容
+func (s *schemaService) parseContent(ctx context.Context, tenantID, projectID, docType string, raw interface{}) (models.JSONB, error) {
+	var content map[string]interface{}
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		content = v
+	case string:
+		// YAML is a superset of JSON
+		if err := yaml.Unmarshal([]byte(v), &content); err != nil {
+			return nil, fmt.Errorf("content is not valid JSON or YAML: %v: %w", err, apierrors.ErrInvalidInput)
+		}
+	default:
+		return nil, fmt.Errorf("content must be an object or a JSON/YAML string: %w", apierrors.ErrInvalidInput)
+	}
+
+	switch docType {
+	case models.SchemaTypeJSONSchema:
+		load := func(name string) (interface{}, error) {
+			return s.LoadSchemaDocument(ctx, tenantID, projectID, name)
+		}
+		if err := schema.Check(schema.Spec{Schema: content}, load); err != nil {
+			return nil, fmt.Errorf("%v: %w", err, apierrors.ErrInvalidInput)
+		}
+	case models.SchemaTypeOpenAPI:
+		if content["openapi"] == nil && content["swagger"] == nil {
+			return nil, fmt.Errorf("openapi document must have an 'openapi' or 'swagger' field: %w", apierrors.ErrInvalidInput)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported schema type '%s': %w", docType, apierrors.ErrInvalidInput)
+	}
+	return models.JSONB(content), nil
+}
@@ -0,0 +1,496 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/models"
+	"test-management-service/internal/repository"
+)
+
+// ScoreWeightsSettingsKey 项目 Settings 中评分权重的配置键
+const ScoreWeightsSettingsKey = "scoreWeights"
+
+const (
+	// stabilityWindow is the number of recent results stability is scored on
+	stabilityWindow = 50
+	// efficiencyTargetRate is the assertions per second of a fully efficient test
+	efficiencyTargetRate = 1.0
+)
+
+// ScoringService 用例价值评分服务接口
+type ScoringService interface {
+	// RecomputeScores 重新计算项目下所有用例的评分并保存，返回评分的用例数
+	RecomputeScores(ctx context.Context, tenantID, projectID string) (int, error)
+	// GetTestScore 计算单个用例的评分，并说明每项评分的依据
+	GetTestScore(ctx context.Context, testID, tenantID, projectID string) (*TestScoreBreakdown, error)
+	// Start 每隔 interval 重新计算所有项目的评分，直到 ctx 结束
+	Start(ctx context.Context, interval time.Duration)
+}
+
+// ScoreWeights 各项评分在综合评分中的权重, 按比例折算
+type ScoreWeights struct {
+	Coverage        float64 `json:"coverage"`
+	Stability       float64 `json:"stability"`
+	Efficiency      float64 `json:"efficiency"`
+	Maintainability float64 `json:"maintainability"`
+}
+
+// DefaultScoreWeights 项目未配置权重时使用的权重
+func DefaultScoreWeights() ScoreWeights {
+	return ScoreWeights{Coverage: 1, Stability: 1, Efficiency: 1, Maintainability: 1}
+}
+
+// TestScoreBreakdown 用例的各项评分及依据
+type TestScoreBreakdown struct {
+	TestID          string       `json:"testId"`
+	Overall         int          `json:"overall"` // 各项评分按权重的加权平均
+	Weights         ScoreWeights `json:"weights"`
+	Coverage        ScoreDetail  `json:"coverage"`
+	Stability       ScoreDetail  `json:"stability"`
+	Efficiency      ScoreDetail  `json:"efficiency"`
+	Maintainability ScoreDetail  `json:"maintainability"`
+}
+
+// ScoreDetail 单项评分及其计算依据
+type ScoreDetail struct {
+	Score       int                    `json:"score"` // 0-100
+	Explanation string                 `json:"explanation"`
+	Inputs      map[string]interface{} `json:"inputs"` // 参与计算的数据
+}
+
+type scoringService struct {
+	caseRepo    repository.TestCaseRepository
+	resultRepo  repository.TestResultRepository
+	projectRepo repository.ProjectRepository
+}
+
+// NewScoringService 创建用例价值评分服务
+func NewScoringService(
+	caseRepo repository.TestCaseRepository,
+	resultRepo repository.TestResultRepository,
+	projectRepo repository.ProjectRepository,
+) ScoringService {
+	return &scoringService{caseRepo: caseRepo, resultRepo: resultRepo, projectRepo: projectRepo}
+}
+
+func (s *scoringService) RecomputeScores(ctx context.Context, tenantID, projectID string) (int, error) {
+	weights, err := s.projectWeights(projectID)
+	if err != nil {
+		return 0, err
+	}
+	tests, endpoints, err := s.loadProject(ctx, tenantID, projectID)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range tests {
+		tc := &tests[i]
+		breakdown, err := s.score(ctx, tc, weights, endpoints)
+		if err != nil {
+			return i, err
+		}
+		tc.CoverageScore = breakdown.Coverage.Score
+		tc.StabilityScore = breakdown.Stability.Score
+		tc.EfficiencyScore = breakdown.Efficiency.Score
+		tc.MaintainabilityScore = breakdown.Maintainability.Score
+		tc.OverallScore = breakdown.Overall
+		if err := s.caseRepo.UpdateScoresWithTenant(ctx, tc); err != nil {
+			return i, err
+		}
+	}
+	return len(tests), nil
+}
+
+func (s *scoringService) GetTestScore(ctx context.Context, testID, tenantID, projectID string) (*TestScoreBreakdown, error) {
+	weights, err := s.projectWeights(projectID)
+	if err != nil {
+		return nil, err
+	}
+	tests, endpoints, err := s.loadProject(ctx, tenantID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tests {
+		if tests[i].TestID == testID {
+			return s.score(ctx, &tests[i], weights, endpoints)
+		}
+	}
+	return nil, fmt.Errorf("test case %s: %w", testID, apierrors.ErrNotFound)
+}
+
+func (s *scoringService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.recomputeAll(ctx)
+			}
+		}
+	}()
+}
+
+// recomputeAll rescores the tests of every project, logging the projects that fail
+func (s *scoringService) recomputeAll(ctx context.Context) {
+	scopes, err := s.caseRepo.FindProjectScopes(ctx)
+	if err != nil {
+		log.Printf("scoring: %v", err)
+		return
+	}
+	for _, scope := range scopes {
+		if _, err := s.RecomputeScores(ctx, scope.TenantID, scope.ProjectID); err != nil {
+			log.Printf("scoring: project %s/%s: %v", scope.TenantID, scope.ProjectID, err)
+		}
+	}
+}
+
+// projectWeights returns the score weights configured on a project, or the
+// defaults when there is no project record or it sets none
+func (s *scoringService) projectWeights(projectID string) (ScoreWeights, error) {
+	weights := DefaultScoreWeights()
+	project, err := s.projectRepo.FindByID(projectID)
+	if err != nil {
+		return weights, fmt.Errorf("failed to load project: %w", err)
+	}
+	if project == nil {
+		return weights, nil
+	}
+	if err := decodeSetting(project.Settings, ScoreWeightsSettingsKey, &weights); err != nil {
+		return weights, fmt.Errorf("invalid score weights: %w", err)
+	}
+	if weights.Coverage < 0 || weights.Stability < 0 || weights.Efficiency < 0 || weights.Maintainability < 0 ||
+		weights.Coverage+weights.Stability+weights.Efficiency+weights.Maintainability == 0 {
+		return weights, fmt.Errorf("score weights must not be negative and at least one must be positive: %w", apierrors.ErrInvalidInput)
+	}
+	return weights, nil
+}
+
+// loadProject loads the tests of a project and counts how many of them
+// exercise each endpoint
+func (s *scoringService) loadProject(ctx context.Context, tenantID, projectID string) ([]models.TestCase, map[string]int, error) {
+	tests, _, err := s.caseRepo.FindAllWithTenant(ctx, tenantID, projectID, -1, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	endpoints := make(map[string]int)
+	for i := range tests {
+		for _, endpoint := range testEndpoints(&tests[i]) {
+			endpoints[endpoint]++
+		}
+	}
+	return tests, endpoints, nil
+}
+
+// score computes the scores of a test; endpoints counts the tests of the
+// project exercising each endpoint
+func (s *scoringService) score(ctx context.Context, tc *models.TestCase, weights ScoreWeights, endpoints map[string]int) (*TestScoreBreakdown, error) {
+	stability, err := s.stabilityScore(ctx, tc)
+	if err != nil {
+		return nil, err
+	}
+	b := &TestScoreBreakdown{
+		TestID:          tc.TestID,
+		Weights:         weights,
+		Coverage:        coverageScore(tc, endpoints),
+		Stability:       stability,
+		Efficiency:      efficiencyScore(tc),
+		Maintainability: maintainabilityScore(tc),
+	}
+
+	total := weights.Coverage + weights.Stability + weights.Efficiency + weights.Maintainability
+	overall := (weights.Coverage*float64(b.Coverage.Score) +
+		weights.Stability*float64(b.Stability.Score) +
+		weights.Efficiency*float64(b.Efficiency.Score) +
+		weights.Maintainability*float64(b.Maintainability.Score)) / total
+	b.Overall = int(math.Round(overall))
+	return b, nil
+}
+
+// stabilityScore is the pass rate of the recent results, discounted by the
+// flakiness of the test
+func (s *scoringService) stabilityScore(ctx context.Context, tc *models.TestCase) (ScoreDetail, error) {
+	results, err := s.resultRepo.FindByTestIDWithTenant(ctx, tc.TestID, tc.TenantID, tc.ProjectID, stabilityWindow)
+	if err != nil {
+		return ScoreDetail{}, err
+	}
+	executed, passed := 0, 0
+	for _, r := range results {
+		if r.Status == "skipped" {
+			continue
+		}
+		executed++
+		if r.Status == "passed" {
+			passed++
+		}
+	}
+
+	detail := ScoreDetail{Inputs: map[string]interface{}{
+		"results":    executed,
+		"passed":     passed,
+		"flakyScore": tc.FlakyScore,
+	}}
+	if executed == 0 {
+		detail.Explanation = "never run"
+		return detail, nil
+	}
+	passRate := float64(passed) / float64(executed)
+	detail.Inputs["passRate"] = passRate
+	detail.Score = percent(passRate * (1 - float64(tc.FlakyScore)/100))
+	detail.Explanation = fmt.Sprintf("%d of the last %d results passed, discounted by a flaky score of %d", passed, executed, tc.FlakyScore)
+	return detail, nil
+}
+
+// efficiencyScore compares the assertions of a test with the time it takes to
+// run; a test checking at least efficiencyTargetRate assertions per second
+// scores 100. Tests under a second count as taking a second.
+func efficiencyScore(tc *models.TestCase) ScoreDetail {
+	assertions := len(testAssertions(tc))
+	detail := ScoreDetail{Inputs: map[string]interface{}{
+		"assertions":  assertions,
+		"avgDuration": tc.AvgDuration,
+	}}
+	switch {
+	case assertions == 0:
+		detail.Explanation = "no assertions"
+		return detail
+	case tc.ExecutionCount == 0:
+		detail.Explanation = "never run"
+		return detail
+	}
+	seconds := math.Max(float64(tc.AvgDuration)/1000, 1)
+	rate := float64(assertions) / seconds
+	detail.Inputs["assertionsPerSecond"] = rate
+	detail.Score = percent(math.Min(rate/efficiencyTargetRate, 1))
+	detail.Explanation = fmt.Sprintf("%d assertions in %dms on average", assertions, tc.AvgDuration)
+	return detail
+}
+
+var (
+	absoluteURL = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+	ipAddress   = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}\b`)
+	// sensitiveKey matches configuration keys whose values belong in variables
+	sensitiveKey = regexp.MustCompile(`(?i)token|password|secret|api[-_]?key|authorization|cookie`)
+)
+
+// maintainabilityScore is the share of environment-specific values of a test
+// taken from variables and templates rather than hardcoded: absolute URLs, IP
+// addresses and credentials. A reference to a workflow or action template
+// counts as a templated value.
+func maintainabilityScore(tc *models.TestCase) ScoreDetail {
+	templated, hardcoded := 0, 0
+	var samples []string
+	var walk func(key string, v interface{})
+	walk = func(key string, v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, child := range val {
+				walk(k, child)
+			}
+		case []interface{}:
+			for _, child := range val {
+				walk(key, child)
+			}
+		case string:
+			switch {
+			case val == "":
+			case strings.Contains(val, "{{"):
+				templated++
+			case key == "workflowId" || key == "actionTemplateId":
+				templated++
+			case absoluteURL.MatchString(val) || ipAddress.MatchString(val) || sensitiveKey.MatchString(key):
+				hardcoded++
+				if len(samples) < 5 {
+					samples = append(samples, key+"="+val)
+				}
+			}
+		}
+	}
+	if tc.WorkflowID != "" {
+		templated++
+	}
+	for _, config := range []interface{}{
+		map[string]interface{}(tc.HTTPConfig), map[string]interface{}(tc.CommandConfig),
+		map[string]interface{}(tc.GraphQLConfig), map[string]interface{}(tc.WebSocketConfig),
+		map[string]interface{}(tc.GRPCConfig), map[string]interface{}(tc.DatabaseConfig),
+		map[string]interface{}(tc.IntegrationConfig), map[string]interface{}(tc.E2EConfig),
+		map[string]interface{}(tc.WorkflowDef), []interface{}(tc.Steps), []interface{}(tc.Sessions),
+		[]interface{}(tc.SetupHooks), []interface{}(tc.TeardownHooks),
+	} {
+		walk("", config)
+	}
+
+	sort.Strings(samples)
+	detail := ScoreDetail{Inputs: map[string]interface{}{
+		"templatedValues": templated,
+		"hardcodedValues": hardcoded,
+		"hardcoded":       samples,
+	}}
+	if templated+hardcoded == 0 {
+		detail.Score = 100
+		detail.Explanation = "no environment-specific values"
+		return detail
+	}
+	detail.Score = percent(float64(templated) / float64(templated+hardcoded))
+	detail.Explanation = fmt.Sprintf("%d values from variables or templates, %d hardcoded URLs, addresses or credentials", templated, hardcoded)
+	return detail
+}
+
+// assertionKinds groups assertion types by what they check
+var assertionKinds = map[string]string{
+	"status_code": "status",
+	"exit_code":   "status",
+	"json_schema": "structure",
+	"snapshot":    "structure",
+}
+
+// coverageScore rates the endpoints a test exercises by how few other tests
+// exercise them, and its assertions by how many kinds of checks (status,
+// content, structure) they make. Tests without HTTP endpoints are scored on
+// their assertions alone.
+func coverageScore(tc *models.TestCase, endpoints map[string]int) ScoreDetail {
+	kinds := make(map[string]bool)
+	for _, a := range testAssertions(tc) {
+		kind, ok := assertionKinds[a]
+		if !ok {
+			kind = "content"
+		}
+		kinds[kind] = true
+	}
+	kindList := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		kindList = append(kindList, kind)
+	}
+	sort.Strings(kindList)
+	breadth := float64(len(kinds)) / 3
+
+	own := testEndpoints(tc)
+	detail := ScoreDetail{Inputs: map[string]interface{}{
+		"endpoints":      own,
+		"assertionKinds": kindList,
+	}}
+	if len(own) == 0 {
+		detail.Score = percent(breadth)
+		detail.Explanation = fmt.Sprintf("no HTTP endpoints; assertions check %d of 3 kinds", len(kinds))
+		return detail
+	}
+
+	uniqueness := 0.0
+	for _, endpoint := range own {
+		uniqueness += 1 / float64(endpoints[endpoint])
+	}
+	uniqueness /= float64(len(own))
+	detail.Inputs["endpointUniqueness"] = uniqueness
+	detail.Score = percent((uniqueness + breadth) / 2)
+	detail.Explanation = fmt.Sprintf("%d endpoints, %.0f%% unique to this test on average; assertions check %d of 3 kinds",
+		len(own), uniqueness*100, len(kinds))
+	return detail
+}
+
+var (
+	numericSegment = regexp.MustCompile(`/\d+(/|$)`)
+	placeholder    = regexp.MustCompile(`\{\{[^}]*\}\}`)
+)
+
+// testEndpoints returns the distinct HTTP endpoints ("GET /users/{}") a test
+// calls, from its HTTP config and its HTTP steps
+func testEndpoints(tc *models.TestCase) []string {
+	seen := make(map[string]bool)
+	add := func(config map[string]interface{}) {
+		path, _ := config["path"].(string)
+		if path == "" {
+			return
+		}
+		method, _ := config["method"].(string)
+		if method == "" {
+			method = "GET"
+		}
+		if i := strings.IndexAny(path, "?#"); i >= 0 {
+			path = path[:i]
+		}
+		path = placeholder.ReplaceAllString(path, "{}")
+		for numericSegment.MatchString(path) {
+			path = numericSegment.ReplaceAllString(path, "/{}$1")
+		}
+		seen[strings.ToUpper(method)+" "+path] = true
+	}
+
+	if tc.HTTPConfig != nil {
+		add(tc.HTTPConfig)
+	}
+	walkSteps(tc.Steps, func(step map[string]interface{}) {
+		if step["type"] == "http" {
+			if config, ok := step["config"].(map[string]interface{}); ok {
+				add(config)
+			}
+		}
+	})
+
+	endpoints := make([]string, 0, len(seen))
+	for endpoint := range seen {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
+
+// testAssertions returns the types of the assertions of a test and its steps
+func testAssertions(tc *models.TestCase) []string {
+	var types []string
+	collect := func(assertions interface{}) {
+		list, _ := assertions.([]interface{})
+		for _, a := range list {
+			if m, ok := a.(map[string]interface{}); ok {
+				t, _ := m["type"].(string)
+				types = append(types, t)
+			}
+		}
+	}
+	collect([]interface{}(tc.Assertions))
+	walkSteps(tc.Steps, func(step map[string]interface{}) {
+		collect(step["assertions"])
+		if config, ok := step["config"].(map[string]interface{}); ok {
+			collect(config["assertions"])
+		}
+	})
+	return types
+}
+
+// walkSteps calls fn for every step, including the children of loops and
+// branches
+func walkSteps(steps []interface{}, fn func(step map[string]interface{})) {
+	for _, s := range steps {
+		step, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fn(step)
+		if children, ok := step["children"].([]interface{}); ok {
+			walkSteps(children, fn)
+		}
+		if branches, ok := step["branches"].([]interface{}); ok {
+			for _, b := range branches {
+				if branch, ok := b.(map[string]interface{}); ok {
+					if children, ok := branch["children"].([]interface{}); ok {
+						walkSteps(children, fn)
+					}
+				}
+			}
+		}
+	}
+}
+
+// percent converts a ratio in [0, 1] to a score in [0, 100]
+func percent(ratio float64) int {
+	return int(math.Round(math.Max(0, math.Min(ratio, 1)) * 100))
+}
@@ -0,0 +1,152 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/models"
+	"test-management-service/internal/repository"
+	"test-management-service/internal/service"
+	"test-management-service/internal/testcase"
+	"test-management-service/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoringService(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	caseRepo := repository.NewTestCaseRepository(db)
+	resultRepo := repository.NewTestResultRepository(db)
+	projectRepo := repository.NewProjectRepository(db)
+	svc := service.NewTestService(caseRepo, repository.NewTestGroupRepository(db),
+		resultRepo, repository.NewTestRunRepository(db), testcase.NewExecutor(""))
+	scoring := service.NewScoringService(caseRepo, resultRepo, projectRepo)
+	ctx := context.Background()
+	const tenant = "default"
+
+	createTest := func(project, testID string, http map[string]interface{}, assertions ...string) {
+		list := make([]interface{}, 0, len(assertions))
+		for _, a := range assertions {
+			list = append(list, map[string]interface{}{"type": a})
+		}
+		_, err := svc.CreateTestCase(ctx, tenant, project, &service.CreateTestCaseRequest{
+			TestID: testID, Name: testID, Type: "http", HTTP: http, Assertions: list,
+		})
+		require.NoError(t, err)
+	}
+	addResults := func(project, testID string, statuses ...string) {
+		start := time.Now().Add(-time.Hour)
+		for i, status := range statuses {
+			require.NoError(t, resultRepo.Create(&models.TestResult{
+				TestID: testID, TenantID: tenant, ProjectID: project, Status: status,
+				StartTime: start.Add(time.Duration(i) * time.Minute),
+			}))
+		}
+	}
+	setStats := func(project, testID string, executions, avgDuration, flakyScore int) {
+		tc, err := caseRepo.FindByIDWithTenant(ctx, testID, tenant, project)
+		require.NoError(t, err)
+		tc.ExecutionCount, tc.AvgDuration, tc.FlakyScore = executions, avgDuration, flakyScore
+		require.NoError(t, caseRepo.UpdateStatsWithTenant(ctx, tc))
+	}
+
+	// Two tests share the same endpoint; the first makes two kinds of checks
+	// and hardcodes a credential next to a templated base URL
+	createTest("default", "score-a", map[string]interface{}{
+		"method": "GET", "path": "/users/1", "baseUrl": "{{baseUrl}}",
+		"headers": map[string]interface{}{"Authorization": "Bearer abc"},
+	}, "status_code", "json_path")
+	createTest("default", "score-b", map[string]interface{}{
+		"method": "GET", "path": "/users/2",
+		"headers": map[string]interface{}{"Authorization": "{{token}}"},
+	}, "status_code")
+	addResults("default", "score-a", "passed", "failed", "passed", "skipped", "passed")
+	setStats("default", "score-a", 4, 4000, 20)
+
+	t.Run("explains each score", func(t *testing.T) {
+		b, err := scoring.GetTestScore(ctx, "score-a", tenant, "default")
+		require.NoError(t, err)
+		assert.Equal(t, service.DefaultScoreWeights(), b.Weights)
+
+		// (1/2 endpoint uniqueness + 2/3 assertion kinds) / 2
+		assert.Equal(t, 58, b.Coverage.Score)
+		assert.Equal(t, []string{"GET /users/{}"}, b.Coverage.Inputs["endpoints"])
+		assert.Equal(t, []string{"content", "status"}, b.Coverage.Inputs["assertionKinds"])
+		// 3 of 4 executed results passed, discounted by a flaky score of 20
+		assert.Equal(t, 60, b.Stability.Score)
+		assert.Equal(t, 4, b.Stability.Inputs["results"])
+		// 2 assertions in 4 seconds
+		assert.Equal(t, 50, b.Efficiency.Score)
+		// one templated base URL, one hardcoded credential
+		assert.Equal(t, 50, b.Maintainability.Score)
+		assert.Equal(t, []string{"Authorization=Bearer abc"}, b.Maintainability.Inputs["hardcoded"])
+		assert.Equal(t, 55, b.Overall)
+		assert.NotEmpty(t, b.Coverage.Explanation)
+	})
+
+	t.Run("tests never run score zero stability and efficiency", func(t *testing.T) {
+		b, err := scoring.GetTestScore(ctx, "score-b", tenant, "default")
+		require.NoError(t, err)
+		assert.Equal(t, 42, b.Coverage.Score)
+		assert.Equal(t, 0, b.Stability.Score)
+		assert.Equal(t, "never run", b.Stability.Explanation)
+		assert.Equal(t, 0, b.Efficiency.Score)
+		assert.Equal(t, 100, b.Maintainability.Score)
+	})
+
+	t.Run("recompute saves the scores", func(t *testing.T) {
+		scored, err := scoring.RecomputeScores(ctx, tenant, "default")
+		require.NoError(t, err)
+		assert.Equal(t, 2, scored)
+
+		tc, err := svc.GetTestCase(ctx, "score-a", tenant, "default")
+		require.NoError(t, err)
+		assert.Equal(t, 58, tc.CoverageScore)
+		assert.Equal(t, 60, tc.StabilityScore)
+		assert.Equal(t, 50, tc.EfficiencyScore)
+		assert.Equal(t, 50, tc.MaintainabilityScore)
+		assert.Equal(t, 55, tc.OverallScore)
+		assert.Equal(t, 20, tc.FlakyScore, "statistics are left alone")
+	})
+
+	t.Run("weights come from the project settings", func(t *testing.T) {
+		require.NoError(t, projectRepo.Create(&models.Project{
+			ProjectID: "weighted", TenantID: tenant, Name: "weighted", Status: models.ProjectStatusActive,
+			Settings: models.JSONB{service.ScoreWeightsSettingsKey: map[string]interface{}{
+				"coverage": 0, "stability": 3, "efficiency": 0, "maintainability": 1,
+			}},
+		}))
+		createTest("weighted", "score-weighted", map[string]interface{}{"method": "GET", "path": "/orders"}, "status_code")
+		addResults("weighted", "score-weighted", "passed", "failed")
+
+		b, err := scoring.GetTestScore(ctx, "score-weighted", tenant, "weighted")
+		require.NoError(t, err)
+		assert.Equal(t, service.ScoreWeights{Stability: 3, Maintainability: 1}, b.Weights)
+		assert.Equal(t, 50, b.Stability.Score)
+		assert.Equal(t, 100, b.Maintainability.Score)
+		// (3*50 + 1*100) / 4
+		assert.Equal(t, 63, b.Overall)
+	})
+
+	t.Run("invalid weights are rejected", func(t *testing.T) {
+		require.NoError(t, projectRepo.Create(&models.Project{
+			ProjectID: "unweighted", TenantID: tenant, Name: "unweighted", Status: models.ProjectStatusActive,
+			Settings: models.JSONB{service.ScoreWeightsSettingsKey: map[string]interface{}{
+				"coverage": 0, "stability": 0, "efficiency": 0, "maintainability": 0,
+			}},
+		}))
+
+		_, err := scoring.RecomputeScores(ctx, tenant, "unweighted")
+		assert.ErrorIs(t, err, apierrors.ErrInvalidInput)
+	})
+
+	t.Run("unknown test", func(t *testing.T) {
+		_, err := scoring.GetTestScore(ctx, "missing", tenant, "default")
+		assert.ErrorIs(t, err, apierrors.ErrNotFound)
+	})
+}
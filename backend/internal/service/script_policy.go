@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"test-management-service/internal/repository"
+	"test-management-service/internal/sandbox"
+	"test-management-service/internal/workflow/actions"
+)
+
+// 租户 Settings 中的配置键
+const (
+	ScriptPolicySettingsKey = "scriptPolicy" // 嵌入式脚本策略
+	SandboxSettingsKey      = "sandbox"      // 外部进程沙箱
+)
+
+// ScriptPolicyService 从租户配置解析脚本执行策略和进程沙箱配置
+type ScriptPolicyService struct {
+	tenantRepo repository.TenantRepository
+}
+
+// NewScriptPolicyService 创建脚本策略服务
+func NewScriptPolicyService(tenantRepo repository.TenantRepository) *ScriptPolicyService {
+	return &ScriptPolicyService{tenantRepo: tenantRepo}
+}
+
+// GetScriptPolicy 获取租户的脚本策略，未配置时返回默认策略（禁止文件系统和网络访问）
+func (s *ScriptPolicyService) GetScriptPolicy(ctx context.Context, tenantID string) (*actions.ScriptPolicy, error) {
+	settings, err := s.tenantSettings(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &actions.ScriptPolicy{}
+	if err := decodeSetting(settings, ScriptPolicySettingsKey, policy); err != nil {
+		return nil, fmt.Errorf("invalid script policy: %w", err)
+	}
+	policy.Sandbox = &sandbox.Config{}
+	if err := decodeSetting(settings, SandboxSettingsKey, policy.Sandbox); err != nil {
+		return nil, fmt.Errorf("invalid sandbox config: %w", err)
+	}
+	return policy, nil
+}
+
+// GetSandboxConfig 获取租户的进程沙箱配置，未配置时使用默认限制。
+// 进程数限制（maxProcesses）默认不设置：它作用于服务运行用户的全部进程，
+// 仅在服务以专用用户运行时配置
+func (s *ScriptPolicyService) GetSandboxConfig(ctx context.Context, tenantID string) (*sandbox.Config, error) {
+	settings, err := s.tenantSettings(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := sandbox.DefaultConfig()
+	if err := decodeSetting(settings, SandboxSettingsKey, cfg); err != nil {
+		return nil, fmt.Errorf("invalid sandbox config: %w", err)
+	}
+	return cfg, nil
+}
+
+// tenantSettings 加载租户配置，租户不存在时返回 nil
+func (s *ScriptPolicyService) tenantSettings(tenantID string) (map[string]interface{}, error) {
+	if tenantID == "" {
+		return nil, nil
+	}
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenant: %w", err)
+	}
+	if tenant == nil {
+		return nil, nil
+	}
+	return tenant.Settings, nil
+}
+
+// decodeSetting 将 settings[key] 解码到 target，未配置时保持 target 不变
+func decodeSetting(settings map[string]interface{}, key string, target interface{}) error {
+	raw, ok := settings[key]
+	if !ok || raw == nil {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
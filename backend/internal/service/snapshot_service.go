@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/jsonpath"
+	"test-management-service/internal/models"
+	"test-management-service/internal/repository"
+	"test-management-service/internal/snapshot"
+)
+
+// SnapshotService 快照断言的记录、比较与审核服务接口
+type SnapshotService interface {
+	// MatchSnapshot 将规范化后的响应与最新已批准版本比较；首次运行时记录为版本 1
+	MatchSnapshot(ctx context.Context, key snapshot.Key, actual interface{}) (*snapshot.Result, error)
+
+	ListSnapshots(ctx context.Context, filter repository.SnapshotFilter) ([]models.Snapshot, error)
+	GetSnapshot(ctx context.Context, tenantID, projectID string, id uint) (*models.Snapshot, error)
+	ApproveSnapshot(ctx context.Context, tenantID, projectID string, id uint, approvedBy string) (*models.Snapshot, error)
+	RejectSnapshot(ctx context.Context, tenantID, projectID string, id uint) (*models.Snapshot, error)
+}
+
+type snapshotService struct {
+	repo repository.SnapshotRepository
+	mu   sync.Mutex // 串行化版本号分配
+}
+
+// NewSnapshotService 创建快照服务
+func NewSnapshotService(repo repository.SnapshotRepository) SnapshotService {
+	return &snapshotService{repo: repo}
+}
+
+func (s *snapshotService) MatchSnapshot(ctx context.Context, key snapshot.Key, actual interface{}) (*snapshot.Result, error) {
+	if key.Name == "" {
+		key.Name = snapshot.DefaultName
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	filter := repository.SnapshotFilter{
+		TenantID:  key.TenantID,
+		ProjectID: key.ProjectID,
+		TestID:    key.TestID,
+		Name:      key.Name,
+	}
+
+	approvedFilter := filter
+	approvedFilter.Status = models.SnapshotStatusApproved
+	approved, err := s.repo.FindLatest(ctx, approvedFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	// 首次运行：记录为已批准的基准版本
+	if approved == nil {
+		version, err := s.nextVersion(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		recorded := &models.Snapshot{
+			TenantID:   key.TenantID,
+			ProjectID:  key.ProjectID,
+			TestID:     key.TestID,
+			Name:       key.Name,
+			Version:    version,
+			Status:     models.SnapshotStatusApproved,
+			Content:    models.JSONValue{Data: actual},
+			ApprovedAt: &now,
+		}
+		if err := s.repo.Create(ctx, recorded); err != nil {
+			return nil, fmt.Errorf("failed to record snapshot: %w", err)
+		}
+		return &snapshot.Result{Name: key.Name, Status: snapshot.StatusRecorded, Version: version}, nil
+	}
+
+	changes := snapshot.Diff(approved.Content.Data, actual)
+	if len(changes) == 0 {
+		return &snapshot.Result{Name: key.Name, Status: snapshot.StatusMatched, Version: approved.Version}, nil
+	}
+
+	// 不一致：更新（或创建）待审核版本
+	pendingFilter := filter
+	pendingFilter.Status = models.SnapshotStatusPending
+	pending, err := s.repo.FindLatest(ctx, pendingFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	if pending == nil {
+		version, err := s.nextVersion(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		pending = &models.Snapshot{
+			TenantID:  key.TenantID,
+			ProjectID: key.ProjectID,
+			TestID:    key.TestID,
+			Name:      key.Name,
+			Version:   version,
+			Status:    models.SnapshotStatusPending,
+		}
+	}
+	pending.Content = models.JSONValue{Data: actual}
+	pending.Diff, _ = jsonpath.Normalize(changes).([]interface{})
+	pending.BaseVersion = approved.Version
+
+	if pending.ID == 0 {
+		err = s.repo.Create(ctx, pending)
+	} else {
+		err = s.repo.Update(ctx, pending)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record snapshot: %w", err)
+	}
+
+	return &snapshot.Result{
+		Name:           key.Name,
+		Status:         snapshot.StatusChanged,
+		Version:        approved.Version,
+		PendingVersion: pending.Version,
+		Diff:           changes,
+	}, nil
+}
+
+func (s *snapshotService) nextVersion(ctx context.Context, key snapshot.Key) (int, error) {
+	version, err := s.repo.MaxVersion(ctx, key.TenantID, key.ProjectID, key.TestID, key.Name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load snapshot versions: %w", err)
+	}
+	return version + 1, nil
+}
+
+func (s *snapshotService) ListSnapshots(ctx context.Context, filter repository.SnapshotFilter) ([]models.Snapshot, error) {
+	return s.repo.List(ctx, filter)
+}
+
+func (s *snapshotService) GetSnapshot(ctx context.Context, tenantID, projectID string, id uint) (*models.Snapshot, error) {
+	snap, err := s.repo.FindByID(ctx, tenantID, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return nil, fmt.Errorf("snapshot %d: %w", id, apierrors.ErrNotFound)
+	}
+	return snap, nil
+}
+
+// ApproveSnapshot 批准待审核版本，使其成为新的基准
+func (s *snapshotService) ApproveSnapshot(ctx context.Context, tenantID, projectID string, id uint, approvedBy string) (*models.Snapshot, error) {
+	if approvedBy == "" {
+		return nil, fmt.Errorf("approvedBy is required: %w", apierrors.ErrInvalidInput)
+	}
+	return s.review(ctx, tenantID, projectID, id, func(snap *models.Snapshot) {
+		now := time.Now()
+		snap.Status = models.SnapshotStatusApproved
+		snap.ApprovedBy = approvedBy
+		snap.ApprovedAt = &now
+	})
+}
+
+// RejectSnapshot 拒绝待审核版本，基准保持不变
+func (s *snapshotService) RejectSnapshot(ctx context.Context, tenantID, projectID string, id uint) (*models.Snapshot, error) {
+	return s.review(ctx, tenantID, projectID, id, func(snap *models.Snapshot) {
+		snap.Status = models.SnapshotStatusRejected
+	})
+}
+
+func (s *snapshotService) review(ctx context.Context, tenantID, projectID string, id uint, apply func(*models.Snapshot)) (*models.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap, err := s.GetSnapshot(ctx, tenantID, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+	if snap.Status != models.SnapshotStatusPending {
+		return nil, fmt.Errorf("snapshot %d is %s, only pending snapshots can be reviewed: %w", id, snap.Status, apierrors.ErrConflict)
+	}
+
+	apply(snap)
+	if err := s.repo.Update(ctx, snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
@@ -0,0 +1,81 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/models"
+	"test-management-service/internal/repository"
+	"test-management-service/internal/service"
+	"test-management-service/internal/snapshot"
+	"test-management-service/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotService_ApprovalWorkflow(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	svc := service.NewSnapshotService(repository.NewSnapshotRepository(db))
+	ctx := context.Background()
+	key := snapshot.Key{TenantID: "default", ProjectID: "default", TestID: "t-1"}
+	v1 := map[string]interface{}{"name": "a", "count": float64(1)}
+	v2 := map[string]interface{}{"name": "b", "count": float64(1)}
+
+	// First run records version 1 as the approved baseline
+	result, err := svc.MatchSnapshot(ctx, key, v1)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.StatusRecorded, result.Status)
+	assert.Equal(t, 1, result.Version)
+
+	result, err = svc.MatchSnapshot(ctx, key, v1)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.StatusMatched, result.Status)
+
+	// Repeated changes update a single pending version
+	result, err = svc.MatchSnapshot(ctx, key, v2)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.StatusChanged, result.Status)
+	assert.Equal(t, 2, result.PendingVersion)
+	assert.Equal(t, []snapshot.Change{{Path: "$.name", Op: snapshot.OpChanged, Expected: "a", Actual: "b"}}, result.Diff)
+
+	result, err = svc.MatchSnapshot(ctx, key, v2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.PendingVersion)
+
+	pending, err := svc.ListSnapshots(ctx, repository.SnapshotFilter{TenantID: "default", ProjectID: "default", TestID: "t-1", Status: models.SnapshotStatusPending})
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1, pending[0].BaseVersion)
+	assert.Len(t, pending[0].Diff, 1)
+
+	// Approval requires a reviewer and makes the pending version the baseline
+	_, err = svc.ApproveSnapshot(ctx, "default", "default", pending[0].ID, "")
+	assert.ErrorIs(t, err, apierrors.ErrInvalidInput)
+
+	approved, err := svc.ApproveSnapshot(ctx, "default", "default", pending[0].ID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, models.SnapshotStatusApproved, approved.Status)
+	assert.Equal(t, "alice", approved.ApprovedBy)
+	assert.NotNil(t, approved.ApprovedAt)
+
+	result, err = svc.MatchSnapshot(ctx, key, v2)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.StatusMatched, result.Status)
+	assert.Equal(t, 2, result.Version)
+
+	// Reviewed versions are kept and cannot be reviewed again
+	_, err = svc.RejectSnapshot(ctx, "default", "default", pending[0].ID)
+	assert.ErrorIs(t, err, apierrors.ErrConflict)
+
+	all, err := svc.ListSnapshots(ctx, repository.SnapshotFilter{TenantID: "default", ProjectID: "default", TestID: "t-1"})
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	// Snapshots are isolated per project
+	_, err = svc.GetSnapshot(ctx, "default", "other", pending[0].ID)
+	assert.ErrorIs(t, err, apierrors.ErrNotFound)
+}
@@ -0,0 +1,393 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/jsonpath"
+	"test-management-service/internal/models"
+	"test-management-service/internal/testcase"
+)
+
+// testOutcome is the outcome of a test of a group run, awaited by the tests
+// that depend on it
+type testOutcome struct {
+	done    bool
+	status  string
+	outputs map[string]interface{}
+}
+
+// dependencyIDs returns the IDs of the prerequisites of a test
+func dependencyIDs(tc *models.TestCase) []string {
+	return stringList(tc.DependsOn)
+}
+
+// setDependencies applies the dependency fields of a create or update
+// request; nil leaves a field unchanged and an empty value clears it
+func setDependencies(tc *models.TestCase, dependsOn []string, outputs map[string]string, inputs []string) {
+	if dependsOn != nil {
+		tc.DependsOn = jsonList(dependsOn)
+	}
+	if outputs != nil {
+		tc.Outputs = nil
+		if len(outputs) > 0 {
+			tc.Outputs = make(models.JSONB, len(outputs))
+			for name, path := range outputs {
+				tc.Outputs[name] = path
+			}
+		}
+	}
+	if inputs != nil {
+		tc.Inputs = jsonList(inputs)
+	}
+}
+
+// validateDependencies checks that the outputs of a test are valid JSONPaths
+// and that its prerequisites exist and do not depend on it
+func (s *testService) validateDependencies(ctx context.Context, tc *models.TestCase) error {
+	for name, path := range tc.Outputs {
+		expr, ok := path.(string)
+		if name == "" || !ok || expr == "" {
+			return fmt.Errorf("output %q must map a variable name to a JSONPath: %w", name, apierrors.ErrInvalidInput)
+		}
+		if _, err := jsonpath.Compile(expr); err != nil {
+			return fmt.Errorf("output %s: %v: %w", name, err, apierrors.ErrInvalidInput)
+		}
+	}
+	for _, input := range stringList(tc.Inputs) {
+		if input == "" {
+			return fmt.Errorf("inputs must be variable names: %w", apierrors.ErrInvalidInput)
+		}
+	}
+
+	dependsOn := dependencyIDs(tc)
+	if len(dependsOn) == 0 {
+		return nil
+	}
+	tests, _, err := s.caseRepo.FindAllWithTenant(ctx, tc.TenantID, tc.ProjectID, -1, 0)
+	if err != nil {
+		return fmt.Errorf("failed to find tests: %w", err)
+	}
+	prerequisites := make(map[string][]string, len(tests)+1)
+	for i := range tests {
+		prerequisites[tests[i].TestID] = dependencyIDs(&tests[i])
+	}
+	prerequisites[tc.TestID] = dependsOn
+
+	for _, id := range dependsOn {
+		if id == tc.TestID {
+			return fmt.Errorf("test %s cannot depend on itself: %w", tc.TestID, apierrors.ErrInvalidInput)
+		}
+		if _, ok := prerequisites[id]; !ok {
+			return fmt.Errorf("prerequisite %s not found: %w", id, apierrors.ErrInvalidInput)
+		}
+	}
+	if cycle := dependencyCycle(tc.TestID, prerequisites); cycle != nil {
+		return fmt.Errorf("dependency cycle: %s: %w", strings.Join(cycle, " -> "), apierrors.ErrInvalidInput)
+	}
+	return nil
+}
+
+// dependencyCycle returns a chain of prerequisites leading from testID back
+// to itself, or nil when there is none
+func dependencyCycle(testID string, prerequisites map[string][]string) []string {
+	visited := make(map[string]bool)
+	var path []string
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		path = append(path, id)
+		for _, dep := range prerequisites[id] {
+			if dep == testID {
+				path = append(path, dep)
+				return true
+			}
+			if !visited[dep] {
+				visited[dep] = true
+				if visit(dep) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if visit(testID) {
+		return path
+	}
+	return nil
+}
+
+// newOutcomes registers the tests of a run, whose dependents wait for them.
+// Prerequisites outside the run do not hold their dependents back.
+func newOutcomes(root *groupNode) map[string]*testOutcome {
+	outcomes := make(map[string]*testOutcome)
+	root.walk(func(tc *models.TestCase) {
+		outcomes[tc.TestID] = &testOutcome{}
+	})
+	return outcomes
+}
+
+// orderGroups orders the subgroups of every group of a run so that a
+// subgroup holding prerequisites of the tests of a sibling runs before it,
+// and moves the subgroups holding prerequisites of the group's own tests,
+// with the siblings they need, in front of them (see groupNode.childrenFirst).
+// Siblings that depend on each other keep their order.
+func orderGroups(node *groupNode) {
+	for _, child := range node.children {
+		orderGroups(child)
+	}
+	n := len(node.children)
+	if n == 0 {
+		return
+	}
+
+	owner := make(map[string]int)
+	for i, child := range node.children {
+		child.walk(func(tc *models.TestCase) {
+			owner[tc.TestID] = i
+		})
+	}
+	needs := make([]map[int]bool, n)
+	for i, child := range node.children {
+		needs[i] = make(map[int]bool)
+		child.walk(func(tc *models.TestCase) {
+			for _, dep := range dependencyIDs(tc) {
+				if j, ok := owner[dep]; ok && j != i {
+					needs[i][j] = true
+				}
+			}
+		})
+	}
+
+	first := make([]bool, n)
+	var runFirst func(i int)
+	runFirst = func(i int) {
+		if first[i] {
+			return
+		}
+		first[i] = true
+		for j := range needs[i] {
+			runFirst(j)
+		}
+	}
+	for i := range node.tests {
+		for _, dep := range dependencyIDs(&node.tests[i]) {
+			if j, ok := owner[dep]; ok {
+				runFirst(j)
+			}
+		}
+	}
+
+	// Pick the first sibling, in the original order, whose prerequisites
+	// already run before it; the ones running before the group's tests go
+	// first
+	ordered := make([]*groupNode, 0, n)
+	placed := make([]bool, n)
+	ready := func(i int) bool {
+		for j := range needs[i] {
+			if !placed[j] {
+				return false
+			}
+		}
+		return true
+	}
+	place := func(candidate func(i int) bool) {
+		for {
+			next := -1
+			for i := 0; i < n && next < 0; i++ {
+				if !placed[i] && candidate(i) && ready(i) {
+					next = i
+				}
+			}
+			for i := 0; i < n && next < 0; i++ {
+				if !placed[i] && candidate(i) {
+					next = i
+				}
+			}
+			if next < 0 {
+				return
+			}
+			placed[next] = true
+			ordered = append(ordered, node.children[next])
+		}
+	}
+	place(func(i int) bool { return first[i] })
+	node.childrenFirst = len(ordered)
+	place(func(int) bool { return true })
+	node.children = ordered
+}
+
+// addPrerequisites adds the prerequisites of the kept tests to keep,
+// recursively
+func addPrerequisites(tests []models.TestCase, keep map[string]bool) {
+	byID := make(map[string]*models.TestCase, len(tests))
+	for i := range tests {
+		byID[tests[i].TestID] = &tests[i]
+	}
+	queue := make([]string, 0, len(keep))
+	for id := range keep {
+		queue = append(queue, id)
+	}
+	for len(queue) > 0 {
+		tc := byID[queue[0]]
+		queue = queue[1:]
+		if tc == nil {
+			continue
+		}
+		for _, dep := range dependencyIDs(tc) {
+			if !keep[dep] {
+				keep[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+}
+
+// rerunScope adds to the tests of a rerun their prerequisites, so they run
+// again first, and the tests skipped in the original run because of them
+func rerunScope(root *groupNode, testIDs, skipped map[string]bool) {
+	for changed := true; changed; {
+		changed = false
+		root.walk(func(tc *models.TestCase) {
+			deps := dependencyIDs(tc)
+			if testIDs[tc.TestID] {
+				for _, dep := range deps {
+					if !testIDs[dep] {
+						testIDs[dep] = true
+						changed = true
+					}
+				}
+				return
+			}
+			if !skipped[tc.TestID] {
+				return
+			}
+			for _, dep := range deps {
+				if testIDs[dep] {
+					testIDs[tc.TestID] = true
+					changed = true
+					return
+				}
+			}
+		})
+	}
+}
+
+// prerequisites reports whether the prerequisites of a test that are part of
+// the run have finished. When they have, it returns the reason to skip the
+// test if one did not pass or an input is missing, and otherwise the outputs
+// they provide.
+func (gr *groupRun) prerequisites(tc *models.TestCase) (ready bool, skip string, inputs map[string]interface{}) {
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+
+	ready = true
+	inputs = make(map[string]interface{})
+	for _, id := range dependencyIDs(tc) {
+		outcome, ok := gr.outcomes[id]
+		switch {
+		case !ok:
+			continue
+		case !outcome.done:
+			ready = false
+		case outcome.status != "passed":
+			// No need to wait for the others
+			return true, fmt.Sprintf("prerequisite %s did not pass (%s)", id, outcome.status), nil
+		}
+		for name, value := range outcome.outputs {
+			inputs[name] = value
+		}
+	}
+	if !ready {
+		return false, "", nil
+	}
+	for _, name := range stringList(tc.Inputs) {
+		if _, ok := inputs[name]; !ok {
+			return true, fmt.Sprintf("input %s is not provided by the prerequisites", name), nil
+		}
+	}
+	return true, "", inputs
+}
+
+// pendingPrerequisite returns the first prerequisite of a test that is part
+// of the run and has not finished
+func (gr *groupRun) pendingPrerequisite(tc *models.TestCase) string {
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+	for _, id := range dependencyIDs(tc) {
+		if outcome, ok := gr.outcomes[id]; ok && !outcome.done {
+			return id
+		}
+	}
+	return ""
+}
+
+// recordOutcome records the outcome of a test for its dependents, with the
+// outputs it provides when it passed. Outputs missing from the response are
+// added to the result's warnings. Callers hold gr.mu.
+func (gr *groupRun) recordOutcome(tc *models.TestCase, result *testcase.TestResult) {
+	outcome := gr.outcomes[tc.TestID]
+	if outcome == nil {
+		return
+	}
+	outcome.done = true
+	outcome.status = result.Status
+	if result.Status != "passed" || len(tc.Outputs) == 0 {
+		return
+	}
+	outcome.outputs = make(map[string]interface{}, len(tc.Outputs))
+	for name, path := range tc.Outputs {
+		expr, _ := path.(string)
+		value, found, err := jsonpath.Lookup(result.Response, expr)
+		if err != nil || !found {
+			warning := fmt.Sprintf("output %s not found at %s", name, expr)
+			log.Printf("test %s: %s", tc.TestID, warning)
+			result.Warnings = append(result.Warnings, warning)
+			continue
+		}
+		outcome.outputs[name] = value
+	}
+}
+
+// skipBlocked skips the tests of a group whose prerequisites cannot finish
+// before them because they run later in the run, and returns the others. When
+// every test waits on another test of the group, they depend on each other
+// and are all skipped.
+func (s *testService) skipBlocked(gr *groupRun, blocked []*models.TestCase) []*models.TestCase {
+	inGroup := make(map[string]bool, len(blocked))
+	for _, tc := range blocked {
+		inGroup[tc.TestID] = true
+	}
+	var waiting []*models.TestCase
+	for _, tc := range blocked {
+		if id := gr.pendingPrerequisite(tc); !inGroup[id] {
+			s.skipTest(gr, tc, fmt.Sprintf("prerequisite %s runs later in the run", id))
+		} else {
+			waiting = append(waiting, tc)
+		}
+	}
+	if len(waiting) < len(blocked) {
+		return waiting
+	}
+	for _, tc := range waiting {
+		s.skipTest(gr, tc, "dependency cycle")
+	}
+	return nil
+}
+
+// skipTest records a skipped result for a test of a group run
+func (s *testService) skipTest(gr *groupRun, tc *models.TestCase, reason string) {
+	now := time.Now()
+	s.recordGroupResult(gr, tc, &testcase.TestResult{
+		TestID:    tc.TestID,
+		Name:      tc.Name,
+		Status:    "skipped",
+		Error:     reason,
+		StartTime: now,
+		EndTime:   now,
+	})
+}
@@ -0,0 +1,245 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/models"
+	"test-management-service/internal/repository"
+	"test-management-service/internal/service"
+	"test-management-service/internal/testcase"
+	"test-management-service/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestDependencies(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	// POST /users creates user 42, which GET /users/42 finds; /slow records
+	// how many requests it serves at once
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/users":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id": 42}`))
+		case r.URL.Path == "/users/42":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/slow":
+			mu.Lock()
+			active++
+			maxActive = max(maxActive, active)
+			mu.Unlock()
+			time.Sleep(50 * time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	envs := service.NewEnvironmentService(repository.NewEnvironmentRepository(db), repository.NewEnvironmentVariableRepository(db))
+	executor := testcase.NewExecutorWithInjector("", nil, nil, nil, service.NewVariableInjector(envs))
+	svc := service.NewTestService(repository.NewTestCaseRepository(db), repository.NewTestGroupRepository(db),
+		repository.NewTestResultRepository(db), repository.NewTestRunRepository(db), executor)
+	ctx := context.Background()
+	const tenant, project = "default", "default"
+
+	for _, group := range []service.CreateTestGroupRequest{
+		{GroupID: "deps", Name: "deps", TargetHost: server.URL},
+		{GroupID: "deps-api", Name: "api", ParentID: "deps", TargetHost: server.URL},
+		{GroupID: "deps-setup", Name: "setup", ParentID: "deps", TargetHost: server.URL},
+		{GroupID: "chains", Name: "chains", TargetHost: server.URL, Concurrency: 4},
+	} {
+		_, err := svc.CreateTestGroup(ctx, tenant, project, &group)
+		require.NoError(t, err)
+	}
+	createTest := func(req service.CreateTestCaseRequest) {
+		req.Name, req.Type = req.TestID, "http"
+		req.Assertions = []interface{}{map[string]interface{}{"type": "status_code", "expected": 200}}
+		_, err := svc.CreateTestCase(ctx, tenant, project, &req)
+		require.NoError(t, err)
+	}
+	get := func(path string) map[string]interface{} {
+		return map[string]interface{}{"method": "GET", "path": path}
+	}
+
+	// get-user is created before its prerequisite, in a group that runs first
+	createTest(service.CreateTestCaseRequest{TestID: "get-user", GroupID: "deps-api", HTTP: get("/users/{{userId}}")})
+	createTest(service.CreateTestCaseRequest{TestID: "broken", GroupID: "deps-api", HTTP: get("/fail")})
+	createTest(service.CreateTestCaseRequest{TestID: "after-broken", GroupID: "deps-api", HTTP: get("/users/42"), DependsOn: []string{"broken"}})
+	createTest(service.CreateTestCaseRequest{TestID: "after-after", GroupID: "deps-api", HTTP: get("/users/42"), DependsOn: []string{"after-broken"}})
+	createTest(service.CreateTestCaseRequest{
+		TestID: "create-user", GroupID: "deps-setup",
+		HTTP:    map[string]interface{}{"method": "POST", "path": "/users"},
+		Outputs: map[string]string{"userId": "$.body.id"},
+	})
+	_, err := svc.UpdateTestCase(ctx, "get-user", tenant, project, &service.UpdateTestCaseRequest{
+		DependsOn: []string{"create-user"}, Inputs: []string{"userId"},
+	})
+	require.NoError(t, err)
+
+	wait := func(run *models.TestRun) *models.TestRun {
+		var done *models.TestRun
+		require.Eventually(t, func() bool {
+			got, err := svc.GetTestRun(ctx, run.RunID, tenant, project)
+			require.NoError(t, err)
+			done = got
+			return got.Status != "running"
+		}, 10*time.Second, 20*time.Millisecond)
+		return done
+	}
+	results := func(run *models.TestRun) map[string]models.TestResult {
+		byID := make(map[string]models.TestResult)
+		for _, result := range run.Results {
+			byID[result.TestID] = result
+		}
+		return byID
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		for name, req := range map[string]service.UpdateTestCaseRequest{
+			"self":           {DependsOn: []string{"create-user"}},
+			"unknown":        {DependsOn: []string{"missing"}},
+			"cycle":          {DependsOn: []string{"get-user"}},
+			"bad output":     {Outputs: map[string]string{"userId": "$["}},
+			"empty output":   {Outputs: map[string]string{"userId": ""}},
+			"empty input":    {Inputs: []string{""}},
+			"unnamed output": {Outputs: map[string]string{"": "$.body.id"}},
+		} {
+			_, err := svc.UpdateTestCase(ctx, "create-user", tenant, project, &req)
+			assert.ErrorIs(t, err, apierrors.ErrInvalidInput, name)
+		}
+		_, err := svc.UpdateTestCase(ctx, "get-user", tenant, project, &service.UpdateTestCaseRequest{DependsOn: []string{"after-after"}})
+		assert.NoError(t, err, "no cycle")
+		_, err = svc.UpdateTestCase(ctx, "get-user", tenant, project, &service.UpdateTestCaseRequest{DependsOn: []string{"create-user"}})
+		require.NoError(t, err)
+	})
+
+	t.Run("prerequisites run first and share their outputs", func(t *testing.T) {
+		run, err := svc.ExecuteTestGroup(ctx, "deps", tenant, project, &service.ExecuteTestGroupRequest{Recursive: true})
+		require.NoError(t, err)
+		run = wait(run)
+		assert.Equal(t, "completed", run.Status)
+		assert.Equal(t, 2, run.Passed)
+		assert.Equal(t, 1, run.Failed)
+		assert.Equal(t, 2, run.Skipped)
+
+		byID := results(run)
+		assert.Equal(t, "passed", byID["create-user"].Status)
+		assert.Equal(t, "passed", byID["get-user"].Status, "the subgroup of the prerequisite runs first")
+		assert.Equal(t, "skipped", byID["after-broken"].Status)
+		assert.Equal(t, "prerequisite broken did not pass (failed)", byID["after-broken"].Error)
+		assert.Equal(t, "prerequisite after-broken did not pass (skipped)", byID["after-after"].Error)
+	})
+
+	t.Run("missing inputs skip the test", func(t *testing.T) {
+		// create-user is not part of a run of deps-api alone
+		run, err := svc.ExecuteTestGroup(ctx, "deps-api", tenant, project, nil)
+		require.NoError(t, err)
+		byID := results(wait(run))
+		assert.Equal(t, "skipped", byID["get-user"].Status)
+		assert.Equal(t, "input userId is not provided by the prerequisites", byID["get-user"].Error)
+	})
+
+	t.Run("missing outputs are reported as warnings", func(t *testing.T) {
+		createTest(service.CreateTestCaseRequest{
+			TestID: "create-nameless", GroupID: "deps-setup",
+			HTTP:    map[string]interface{}{"method": "POST", "path": "/users"},
+			Outputs: map[string]string{"userName": "$.body.name"},
+		})
+		createTest(service.CreateTestCaseRequest{TestID: "get-nameless", GroupID: "deps-setup", HTTP: get("/users/42"),
+			DependsOn: []string{"create-nameless"}, Inputs: []string{"userName"}})
+
+		run, err := svc.ExecuteSelection(ctx, tenant, project, &service.ExecuteSelectionRequest{
+			Selection: service.RunSelection{TestIDs: []string{"get-nameless"}},
+		})
+		require.NoError(t, err)
+		byID := results(wait(run))
+		assert.Equal(t, "passed", byID["create-nameless"].Status)
+		assert.Equal(t, models.JSONArray{"output userName not found at $.body.name"}, byID["create-nameless"].Warnings)
+		assert.Equal(t, "skipped", byID["get-nameless"].Status)
+	})
+
+	t.Run("selections include prerequisites", func(t *testing.T) {
+		run, err := svc.ExecuteSelection(ctx, tenant, project, &service.ExecuteSelectionRequest{
+			Selection: service.RunSelection{TestIDs: []string{"get-user"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, run.Total)
+		run = wait(run)
+		assert.Equal(t, 2, run.Passed)
+	})
+
+	t.Run("reruns include the dependents skipped by the failures", func(t *testing.T) {
+		run, err := svc.ExecuteTestGroup(ctx, "deps", tenant, project, &service.ExecuteTestGroupRequest{Recursive: true})
+		require.NoError(t, err)
+		run = wait(run)
+
+		rerun, err := svc.RerunTestRun(ctx, run.RunID, tenant, project, service.RerunFailed)
+		require.NoError(t, err)
+		assert.Equal(t, 3, rerun.Total, "broken and the tests it held back")
+		rerun = wait(rerun)
+		assert.Equal(t, 1, rerun.Failed)
+		assert.Equal(t, 2, rerun.Skipped)
+	})
+
+	t.Run("independent chains run in parallel", func(t *testing.T) {
+		createTest(service.CreateTestCaseRequest{TestID: "chain-a1", GroupID: "chains", HTTP: get("/slow")})
+		createTest(service.CreateTestCaseRequest{TestID: "chain-a2", GroupID: "chains", HTTP: get("/slow"), DependsOn: []string{"chain-a1"}})
+		createTest(service.CreateTestCaseRequest{TestID: "chain-b1", GroupID: "chains", HTTP: get("/slow")})
+		createTest(service.CreateTestCaseRequest{TestID: "chain-b2", GroupID: "chains", HTTP: get("/slow"), DependsOn: []string{"chain-b1"}})
+
+		run, err := svc.ExecuteTestGroup(ctx, "chains", tenant, project, nil)
+		require.NoError(t, err)
+		run = wait(run)
+		assert.Equal(t, 4, run.Passed)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, 2, maxActive, "one test of each chain at a time")
+
+		byID := results(run)
+		assert.False(t, byID["chain-a2"].StartTime.Before(byID["chain-a1"].EndTime))
+		assert.False(t, byID["chain-b2"].StartTime.Before(byID["chain-b1"].EndTime))
+	})
+
+	t.Run("subgroups holding prerequisites run before the group's tests", func(t *testing.T) {
+		for _, group := range []service.CreateTestGroupRequest{
+			{GroupID: "nested", Name: "nested", TargetHost: server.URL},
+			{GroupID: "nested-later", Name: "later", ParentID: "nested", TargetHost: server.URL},
+			{GroupID: "nested-setup", Name: "setup", ParentID: "nested", TargetHost: server.URL},
+		} {
+			_, err := svc.CreateTestGroup(ctx, tenant, project, &group)
+			require.NoError(t, err)
+		}
+		createTest(service.CreateTestCaseRequest{
+			TestID: "nested-create", GroupID: "nested-setup",
+			HTTP:    map[string]interface{}{"method": "POST", "path": "/users"},
+			Outputs: map[string]string{"userId": "$.body.id"},
+		})
+		createTest(service.CreateTestCaseRequest{TestID: "nested-get", GroupID: "nested", HTTP: get("/users/{{userId}}"),
+			DependsOn: []string{"nested-create"}, Inputs: []string{"userId"}})
+		createTest(service.CreateTestCaseRequest{TestID: "nested-other", GroupID: "nested-later", HTTP: get("/users/42")})
+
+		run, err := svc.ExecuteTestGroup(ctx, "nested", tenant, project, &service.ExecuteTestGroupRequest{Recursive: true})
+		require.NoError(t, err)
+		run = wait(run)
+		assert.Equal(t, 3, run.Passed)
+
+		byID := results(run)
+		assert.Equal(t, "passed", byID["nested-get"].Status, byID["nested-get"].Error)
+		assert.False(t, byID["nested-get"].StartTime.Before(byID["nested-create"].EndTime))
+		assert.False(t, byID["nested-other"].StartTime.Before(byID["nested-get"].EndTime), "other subgroups still run after the group's tests")
+	})
+}
@@ -0,0 +1,521 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"test-management-service/internal/cron"
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/models"
+	"test-management-service/internal/repository"
+)
+
+// Test plan verdicts, including retries
+const (
+	PlanStatusRunning = "running"
+	PlanStatusPassed  = "passed"
+	PlanStatusFailed  = "failed"
+)
+
+// TestPlanService 测试计划服务接口
+type TestPlanService interface {
+	CreateTestPlan(ctx context.Context, tenantID, projectID string, req *CreateTestPlanRequest) (*models.TestPlan, error)
+	UpdateTestPlan(ctx context.Context, planID, tenantID, projectID string, req *TestPlanRequest) (*models.TestPlan, error)
+	DeleteTestPlan(ctx context.Context, planID, tenantID, projectID string) error
+	GetTestPlan(ctx context.Context, planID, tenantID, projectID string) (*models.TestPlan, error)
+	ListTestPlans(ctx context.Context, tenantID, projectID string) ([]models.TestPlan, error)
+
+	// ExecuteTestPlan 执行计划, 返回首个批次; 重试批次在前一批次结束后自动创建
+	ExecuteTestPlan(ctx context.Context, planID, tenantID, projectID string) (*models.TestRun, error)
+	// GetTestPlanRuns 计划的执行历史, 由新到旧, 包括重试批次
+	GetTestPlanRuns(ctx context.Context, planID, tenantID, projectID string, limit, offset int) ([]models.TestRun, int64, error)
+
+	// RunFinished 计划的批次结束时按重试策略重跑, 得出最终结果后发送通知
+	TestRunListener
+	// Start 每隔 interval 执行到期的定时计划, 直到 ctx 结束
+	Start(ctx context.Context, interval time.Duration)
+}
+
+// TestPlanRequest 测试计划的内容, 更新时整体替换
+type TestPlanRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+
+	TestIDs    []string `json:"testIds"`
+	GroupIDs   []string `json:"groupIds"`
+	Recursive  bool     `json:"recursive"`
+	Tags       []string `json:"tags"`
+	Priorities []string `json:"priorities"`
+	Types      []string `json:"types"`
+
+	EnvironmentID string                 `json:"environmentId"`
+	Variables     map[string]interface{} `json:"variables"`
+	Concurrency   int                    `json:"concurrency"`
+	FailFast      bool                   `json:"failFast"`
+	MaxDuration   int                    `json:"maxDuration"`
+
+	MaxRetries int    `json:"maxRetries"`
+	RetryOn    string `json:"retryOn"` // failed(默认), errored
+
+	NotifyWebhook string `json:"notifyWebhook"`
+	NotifyOn      string `json:"notifyOn"` // always, failure(默认), never
+
+	Schedule string `json:"schedule"` // cron 表达式, 如 "0 2 * * *"
+}
+
+// CreateTestPlanRequest 创建测试计划的请求
+type CreateTestPlanRequest struct {
+	PlanID string `json:"planId" binding:"required"`
+	TestPlanRequest
+}
+
+// TestPlanNotification 计划执行结束后发送到 webhook 的内容
+type TestPlanNotification struct {
+	Event         string    `json:"event"` // test_plan.finished
+	PlanID        string    `json:"planId"`
+	Name          string    `json:"name"`
+	Status        string    `json:"status"`    // passed, failed
+	RunID         string    `json:"runId"`     // 首个批次
+	LastRunID     string    `json:"lastRunId"` // 最后一次重试的批次
+	Retries       int       `json:"retries"`
+	Total         int       `json:"total"`
+	Passed        int       `json:"passed"` // 以下计数按重试后的最终状态统计
+	Failed        int       `json:"failed"`
+	Errors        int       `json:"errors"`
+	Skipped       int       `json:"skipped"`
+	Quarantined   int       `json:"quarantined"`
+	PassedOnRetry int       `json:"passedOnRetry"`
+	FinishedAt    time.Time `json:"finishedAt"`
+}
+
+type testPlanService struct {
+	planRepo   repository.TestPlanRepository
+	runRepo    repository.TestRunRepository
+	tests      TestService
+	envService EnvironmentService
+	client     *http.Client
+
+	// mu serializes the updates of plans, which runs finishing in the
+	// background make as well
+	mu sync.Mutex
+}
+
+// NewTestPlanService 创建测试计划服务; 计划的批次结束后需要通知它,
+// 见 TestService 的 SetRunListener
+func NewTestPlanService(
+	planRepo repository.TestPlanRepository,
+	runRepo repository.TestRunRepository,
+	tests TestService,
+	envService EnvironmentService,
+) TestPlanService {
+	return &testPlanService{
+		planRepo:   planRepo,
+		runRepo:    runRepo,
+		tests:      tests,
+		envService: envService,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *testPlanService) CreateTestPlan(ctx context.Context, tenantID, projectID string, req *CreateTestPlanRequest) (*models.TestPlan, error) {
+	existing, err := s.planRepo.FindByID(ctx, tenantID, projectID, req.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find test plan: %w", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("test plan %s already exists: %w", req.PlanID, apierrors.ErrConflict)
+	}
+
+	plan := &models.TestPlan{PlanID: req.PlanID, TenantID: tenantID, ProjectID: projectID}
+	if err := s.apply(ctx, plan, &req.TestPlanRequest); err != nil {
+		return nil, err
+	}
+	if err := s.planRepo.Create(ctx, plan); err != nil {
+		return nil, fmt.Errorf("failed to create test plan: %w", err)
+	}
+	return plan, nil
+}
+
+func (s *testPlanService) UpdateTestPlan(ctx context.Context, planID, tenantID, projectID string, req *TestPlanRequest) (*models.TestPlan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plan, err := s.findPlan(ctx, planID, tenantID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(ctx, plan, req); err != nil {
+		return nil, err
+	}
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		return nil, fmt.Errorf("failed to update test plan: %w", err)
+	}
+	return plan, nil
+}
+
+func (s *testPlanService) DeleteTestPlan(ctx context.Context, planID, tenantID, projectID string) error {
+	if _, err := s.findPlan(ctx, planID, tenantID, projectID); err != nil {
+		return err
+	}
+	return s.planRepo.Delete(ctx, tenantID, projectID, planID)
+}
+
+func (s *testPlanService) GetTestPlan(ctx context.Context, planID, tenantID, projectID string) (*models.TestPlan, error) {
+	return s.findPlan(ctx, planID, tenantID, projectID)
+}
+
+func (s *testPlanService) ListTestPlans(ctx context.Context, tenantID, projectID string) ([]models.TestPlan, error) {
+	return s.planRepo.List(ctx, tenantID, projectID)
+}
+
+func (s *testPlanService) ExecuteTestPlan(ctx context.Context, planID, tenantID, projectID string) (*models.TestRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plan, err := s.findPlan(ctx, planID, tenantID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return s.execute(ctx, plan)
+}
+
+func (s *testPlanService) GetTestPlanRuns(ctx context.Context, planID, tenantID, projectID string, limit, offset int) ([]models.TestRun, int64, error) {
+	if _, err := s.findPlan(ctx, planID, tenantID, projectID); err != nil {
+		return nil, 0, err
+	}
+	return s.runRepo.FindByPlanIDWithTenant(ctx, planID, tenantID, projectID, limit, offset)
+}
+
+func (s *testPlanService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.runDue(ctx, now)
+			}
+		}
+	}()
+}
+
+// runDue executes the scheduled plans due at now and schedules their next
+// execution, even when this one could not start
+func (s *testPlanService) runDue(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plans, err := s.planRepo.FindDue(ctx, now)
+	if err != nil {
+		log.Printf("test plans: %v", err)
+		return
+	}
+	for i := range plans {
+		plan := &plans[i]
+		if _, err := s.execute(ctx, plan); err != nil {
+			log.Printf("test plans: plan %s/%s/%s: %v", plan.TenantID, plan.ProjectID, plan.PlanID, err)
+		}
+		plan.NextRunAt = nextRun(plan.Schedule, now)
+		if err := s.planRepo.Update(ctx, plan); err != nil {
+			log.Printf("test plans: plan %s: %v", plan.PlanID, err)
+		}
+	}
+}
+
+// execute starts a run of the tests a plan selects and records it as the
+// plan's latest run. Callers hold s.mu.
+func (s *testPlanService) execute(ctx context.Context, plan *models.TestPlan) (*models.TestRun, error) {
+	run, err := s.tests.ExecuteSelection(ctx, plan.TenantID, plan.ProjectID, &ExecuteSelectionRequest{
+		ExecuteTestGroupRequest: ExecuteTestGroupRequest{
+			Concurrency: plan.Concurrency,
+			FailFast:    plan.FailFast,
+			MaxDuration: plan.MaxDuration,
+			Recursive:   plan.Recursive,
+		},
+		Selection: RunSelection{
+			TestIDs:    stringList(plan.TestIDs),
+			GroupIDs:   stringList(plan.GroupIDs),
+			Tags:       stringList(plan.Tags),
+			Priorities: stringList(plan.Priorities),
+			Types:      stringList(plan.Types),
+		},
+		Name:          plan.Name,
+		PlanID:        plan.PlanID,
+		EnvironmentID: plan.EnvironmentID,
+		Variables:     plan.Variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plan.LastRunID = run.RunID
+	plan.LastRunAt = &run.StartTime
+	plan.LastStatus = PlanStatusRunning
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		return nil, fmt.Errorf("failed to update test plan: %w", err)
+	}
+	return run, nil
+}
+
+// RunFinished reruns the failures of a finished plan run as the plan's retry
+// policy allows; once no retry is left, it records the verdict of the plan
+// over the run and its retries and sends the notification
+func (s *testPlanService) RunFinished(ctx context.Context, run *models.TestRun) {
+	if run.PlanID == "" {
+		return
+	}
+	plan, notification := s.finish(ctx, run)
+	if notification != nil {
+		s.notify(ctx, plan, notification)
+	}
+}
+
+// finish handles a finished plan run, returning the plan and its notification
+// when the plan reached a verdict
+func (s *testPlanService) finish(ctx context.Context, run *models.TestRun) (*models.TestPlan, *TestPlanNotification) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plan, err := s.planRepo.FindByID(ctx, run.TenantID, run.ProjectID, run.PlanID)
+	if err != nil || plan == nil {
+		return nil, nil // deleted while it ran
+	}
+
+	// Walk back to the run the plan execution started with
+	retries, complete := 0, run.Status == "completed"
+	original := run
+	for original.ParentRunID != "" {
+		if original, err = s.runRepo.FindByIDWithTenant(ctx, original.ParentRunID, run.TenantID, run.ProjectID); err != nil {
+			log.Printf("test plans: plan %s: %v", plan.PlanID, err)
+			return nil, nil
+		}
+		retries++
+		complete = complete && original.Status == "completed"
+	}
+	if original.RunID != plan.LastRunID {
+		return nil, nil // superseded by a later execution
+	}
+
+	if retries < plan.MaxRetries {
+		filter := plan.RetryOn
+		if filter == "" {
+			filter = RerunFailed
+		}
+		if (filter == RerunFailed && run.Failed > 0) || (filter == RerunErrored && run.Errors > 0) {
+			_, err := s.tests.RerunTestRun(ctx, run.RunID, run.TenantID, run.ProjectID, filter)
+			if err == nil {
+				return nil, nil
+			}
+			log.Printf("test plans: plan %s: failed to retry run %s: %v", plan.PlanID, run.RunID, err)
+		}
+	}
+
+	merged, err := s.tests.GetMergedTestRun(ctx, run.RunID, run.TenantID, run.ProjectID)
+	if err != nil {
+		log.Printf("test plans: plan %s: %v", plan.PlanID, err)
+		return nil, nil
+	}
+	plan.LastStatus = PlanStatusPassed
+	if !complete || merged.Failed > 0 || merged.Errors > 0 {
+		plan.LastStatus = PlanStatusFailed
+	}
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		log.Printf("test plans: plan %s: %v", plan.PlanID, err)
+	}
+
+	return plan, &TestPlanNotification{
+		Event:         "test_plan.finished",
+		PlanID:        plan.PlanID,
+		Name:          plan.Name,
+		Status:        plan.LastStatus,
+		RunID:         original.RunID,
+		LastRunID:     run.RunID,
+		Retries:       retries,
+		Total:         merged.Total,
+		Passed:        merged.Passed,
+		Failed:        merged.Failed,
+		Errors:        merged.Errors,
+		Skipped:       merged.Skipped,
+		Quarantined:   merged.Quarantined,
+		PassedOnRetry: merged.PassedOnRetry,
+		FinishedAt:    run.EndTime,
+	}
+}
+
+// notify posts the verdict of a plan to its webhook when its notification
+// settings ask for it
+func (s *testPlanService) notify(ctx context.Context, plan *models.TestPlan, notification *TestPlanNotification) {
+	if plan.NotifyWebhook == "" {
+		return
+	}
+	switch plan.NotifyOn {
+	case models.NotifyNever:
+		return
+	case models.NotifyAlways:
+	default:
+		if notification.Status == PlanStatusPassed {
+			return
+		}
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		log.Printf("test plans: plan %s: %v", plan.PlanID, err)
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, plan.NotifyWebhook, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("test plans: plan %s: %v", plan.PlanID, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Printf("test plans: plan %s: failed to notify: %v", plan.PlanID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("test plans: plan %s: webhook answered %s", plan.PlanID, resp.Status)
+	}
+}
+
+// apply validates a plan request and copies it onto plan
+func (s *testPlanService) apply(ctx context.Context, plan *models.TestPlan, req *TestPlanRequest) error {
+	if req.Concurrency < 0 || req.MaxDuration < 0 || req.MaxRetries < 0 {
+		return fmt.Errorf("concurrency, maxDuration and maxRetries must not be negative: %w", apierrors.ErrInvalidInput)
+	}
+	switch req.RetryOn {
+	case "", RerunFailed, RerunErrored:
+	default:
+		return fmt.Errorf("unknown retryOn %q, expected failed or errored: %w", req.RetryOn, apierrors.ErrInvalidInput)
+	}
+	switch req.NotifyOn {
+	case "", models.NotifyAlways, models.NotifyFailure, models.NotifyNever:
+	default:
+		return fmt.Errorf("unknown notifyOn %q, expected always, failure or never: %w", req.NotifyOn, apierrors.ErrInvalidInput)
+	}
+	if req.NotifyWebhook != "" {
+		u, err := url.Parse(req.NotifyWebhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("notifyWebhook must be an http or https URL: %w", apierrors.ErrInvalidInput)
+		}
+	}
+	if req.Schedule != "" {
+		if _, err := cron.Parse(req.Schedule); err != nil {
+			return fmt.Errorf("%v: %w", err, apierrors.ErrInvalidInput)
+		}
+		if nextRun(req.Schedule, time.Now()) == nil {
+			return fmt.Errorf("schedule %q never fires: %w", req.Schedule, apierrors.ErrInvalidInput)
+		}
+	}
+
+	for _, testID := range req.TestIDs {
+		tc, err := s.tests.GetTestCase(ctx, testID, plan.TenantID, plan.ProjectID)
+		if err != nil {
+			return fmt.Errorf("failed to find test case: %w", err)
+		}
+		if tc == nil {
+			return fmt.Errorf("test case %s does not exist: %w", testID, apierrors.ErrInvalidInput)
+		}
+	}
+	for _, groupID := range req.GroupIDs {
+		group, err := s.tests.GetTestGroup(ctx, groupID, plan.TenantID, plan.ProjectID)
+		if err != nil {
+			return fmt.Errorf("failed to find test group: %w", err)
+		}
+		if group == nil {
+			return fmt.Errorf("test group %s does not exist: %w", groupID, apierrors.ErrInvalidInput)
+		}
+	}
+	if req.EnvironmentID != "" {
+		env, err := s.envService.GetEnvironment(ctx, req.EnvironmentID, plan.TenantID, plan.ProjectID)
+		if err != nil {
+			return fmt.Errorf("failed to find environment: %w", err)
+		}
+		if env == nil {
+			return fmt.Errorf("environment %s does not exist: %w", req.EnvironmentID, apierrors.ErrInvalidInput)
+		}
+	}
+
+	plan.Name = req.Name
+	plan.Description = req.Description
+	plan.TestIDs = jsonList(req.TestIDs)
+	plan.GroupIDs = jsonList(req.GroupIDs)
+	plan.Recursive = req.Recursive
+	plan.Tags = jsonList(req.Tags)
+	plan.Priorities = jsonList(req.Priorities)
+	plan.Types = jsonList(req.Types)
+	plan.EnvironmentID = req.EnvironmentID
+	plan.Variables = req.Variables
+	plan.Concurrency = req.Concurrency
+	plan.FailFast = req.FailFast
+	plan.MaxDuration = req.MaxDuration
+	plan.MaxRetries = req.MaxRetries
+	plan.RetryOn = req.RetryOn
+	plan.NotifyWebhook = req.NotifyWebhook
+	plan.NotifyOn = req.NotifyOn
+	if req.Schedule != plan.Schedule || plan.NextRunAt == nil {
+		plan.NextRunAt = nextRun(req.Schedule, time.Now())
+	}
+	plan.Schedule = req.Schedule
+	return nil
+}
+
+func (s *testPlanService) findPlan(ctx context.Context, planID, tenantID, projectID string) (*models.TestPlan, error) {
+	plan, err := s.planRepo.FindByID(ctx, tenantID, projectID, planID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find test plan: %w", err)
+	}
+	if plan == nil {
+		return nil, fmt.Errorf("test plan %s: %w", planID, apierrors.ErrNotFound)
+	}
+	return plan, nil
+}
+
+// nextRun returns when a schedule next fires after now, or nil when the plan
+// is not scheduled
+func nextRun(schedule string, now time.Time) *time.Time {
+	if schedule == "" {
+		return nil
+	}
+	s, err := cron.Parse(schedule)
+	if err != nil {
+		return nil
+	}
+	next := s.Next(now)
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+func jsonList(values []string) models.JSONArray {
+	if len(values) == 0 {
+		return nil
+	}
+	list := make(models.JSONArray, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+	return list
+}
+
+func stringList(values models.JSONArray) []string {
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
@@ -0,0 +1,281 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/models"
+	"test-management-service/internal/repository"
+	"test-management-service/internal/service"
+	"test-management-service/internal/testcase"
+	"test-management-service/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestPlanService(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	// /flaky fails on its first request only; /vars passes when the plan
+	// overrides the env variable
+	var flakyCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/fail", r.URL.Path == "/flaky" && flakyCalls.Add(1) == 1,
+			r.URL.Path == "/vars" && r.Header.Get("X-Env") != "plan":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var notifications []service.TestPlanNotification
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n service.TestPlanNotification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+		mu.Lock()
+		notifications = append(notifications, n)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+	received := func() []service.TestPlanNotification {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]service.TestPlanNotification(nil), notifications...)
+	}
+
+	runRepo := repository.NewTestRunRepository(db)
+	planRepo := repository.NewTestPlanRepository(db)
+	envs := service.NewEnvironmentService(repository.NewEnvironmentRepository(db), repository.NewEnvironmentVariableRepository(db))
+	executor := testcase.NewExecutorWithInjector("", nil, nil, nil, service.NewVariableInjector(envs))
+	svc := service.NewTestService(repository.NewTestCaseRepository(db), repository.NewTestGroupRepository(db),
+		repository.NewTestResultRepository(db), runRepo, executor)
+	plans := service.NewTestPlanService(planRepo, runRepo, svc, envs)
+	if ts, ok := svc.(interface{ SetRunListener(service.TestRunListener) }); ok {
+		ts.SetRunListener(plans)
+	}
+	ctx := context.Background()
+	const tenant, project = "default", "default"
+
+	_, err := envs.CreateEnvironment(ctx, tenant, project, &service.CreateEnvironmentRequest{
+		EnvID: "qa", Name: "QA", Variables: map[string]interface{}{"env": "qa"},
+	})
+	require.NoError(t, err)
+	for _, group := range []service.CreateTestGroupRequest{
+		{GroupID: "plan-api", Name: "api", TargetHost: server.URL},
+		{GroupID: "plan-api-child", Name: "child", ParentID: "plan-api"},
+		{GroupID: "plan-other", Name: "other", TargetHost: server.URL},
+	} {
+		_, err := svc.CreateTestGroup(ctx, tenant, project, &group)
+		require.NoError(t, err)
+	}
+	for _, tc := range []struct {
+		id, group, path, priority string
+		tags                      []interface{}
+	}{
+		{"plan-ok", "plan-api", "/ok", "P0", []interface{}{"smoke"}},
+		{"plan-flaky", "plan-api", "/flaky", "P0", []interface{}{"smoke"}},
+		{"plan-fail", "plan-api", "/fail", "P1", nil},
+		{"plan-vars", "plan-api", "/vars", "P2", nil},
+		{"plan-child", "plan-api-child", "/ok", "P0", []interface{}{"smoke"}},
+		{"plan-other", "plan-other", "/ok", "P1", []interface{}{"smoke"}},
+	} {
+		_, err := svc.CreateTestCase(ctx, tenant, project, &service.CreateTestCaseRequest{
+			TestID: tc.id, GroupID: tc.group, Name: tc.id, Type: "http", Priority: tc.priority, Tags: tc.tags,
+			HTTP: map[string]interface{}{
+				"method": "GET", "path": tc.path,
+				"headers": map[string]interface{}{"X-Env": "{{env}}"},
+			},
+			Assertions: []interface{}{map[string]interface{}{"type": "status_code", "expected": 200}},
+		})
+		require.NoError(t, err)
+	}
+
+	// waitPlan waits for the verdict of a plan, retries included
+	waitPlan := func(planID string) *models.TestPlan {
+		var plan *models.TestPlan
+		require.Eventually(t, func() bool {
+			got, err := plans.GetTestPlan(ctx, planID, tenant, project)
+			require.NoError(t, err)
+			plan = got
+			return got.LastStatus != "" && got.LastStatus != service.PlanStatusRunning
+		}, 10*time.Second, 20*time.Millisecond)
+		return plan
+	}
+	testIDs := func(run *models.TestRun) []string {
+		full, err := svc.GetTestRun(ctx, run.RunID, tenant, project)
+		require.NoError(t, err)
+		var ids []string
+		for _, r := range full.Results {
+			ids = append(ids, r.TestID)
+		}
+		return ids
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		for name, req := range map[string]service.TestPlanRequest{
+			"unknown test":   {Name: "x", TestIDs: []string{"missing"}},
+			"unknown group":  {Name: "x", GroupIDs: []string{"missing"}},
+			"unknown env":    {Name: "x", EnvironmentID: "missing"},
+			"bad schedule":   {Name: "x", Schedule: "every night"},
+			"never fires":    {Name: "x", Schedule: "0 0 30 2 *"},
+			"bad webhook":    {Name: "x", NotifyWebhook: "slack://channel"},
+			"bad retryOn":    {Name: "x", RetryOn: "all"},
+			"bad notifyOn":   {Name: "x", NotifyOn: "sometimes"},
+			"negative retry": {Name: "x", MaxRetries: -1},
+		} {
+			_, err := plans.CreateTestPlan(ctx, tenant, project, &service.CreateTestPlanRequest{PlanID: "invalid", TestPlanRequest: req})
+			assert.ErrorIs(t, err, apierrors.ErrInvalidInput, name)
+		}
+
+		_, err := plans.GetTestPlan(ctx, "missing", tenant, project)
+		assert.ErrorIs(t, err, apierrors.ErrNotFound)
+		_, err = plans.ExecuteTestPlan(ctx, "missing", tenant, project)
+		assert.ErrorIs(t, err, apierrors.ErrNotFound)
+	})
+
+	t.Run("smoke plan retries its failures", func(t *testing.T) {
+		_, err := plans.CreateTestPlan(ctx, tenant, project, &service.CreateTestPlanRequest{
+			PlanID: "smoke",
+			TestPlanRequest: service.TestPlanRequest{
+				Name: "Smoke", GroupIDs: []string{"plan-api"}, Recursive: true, Tags: []string{"smoke"},
+				MaxRetries: 2, NotifyWebhook: webhook.URL, NotifyOn: models.NotifyAlways,
+			},
+		})
+		require.NoError(t, err)
+		_, err = plans.CreateTestPlan(ctx, tenant, project, &service.CreateTestPlanRequest{
+			PlanID: "smoke", TestPlanRequest: service.TestPlanRequest{Name: "Smoke"},
+		})
+		assert.ErrorIs(t, err, apierrors.ErrConflict)
+
+		run, err := plans.ExecuteTestPlan(ctx, "smoke", tenant, project)
+		require.NoError(t, err)
+		assert.Equal(t, "smoke", run.PlanID)
+		assert.Equal(t, "Smoke", run.Name)
+		assert.Equal(t, 3, run.Total, "tagged tests of the group and its subgroup")
+
+		plan := waitPlan("smoke")
+		assert.Equal(t, service.PlanStatusPassed, plan.LastStatus)
+		assert.Equal(t, run.RunID, plan.LastRunID)
+
+		runs, total, err := plans.GetTestPlanRuns(ctx, "smoke", tenant, project, 10, 0)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), total, "one retry fixes the flaky test")
+		assert.Equal(t, run.RunID, runs[0].ParentRunID, "newest first")
+		assert.Equal(t, []string{"plan-flaky"}, testIDs(&runs[0]))
+
+		require.Eventually(t, func() bool { return len(received()) == 1 }, 5*time.Second, 20*time.Millisecond)
+		n := received()[0]
+		assert.Equal(t, "test_plan.finished", n.Event)
+		assert.Equal(t, "smoke", n.PlanID)
+		assert.Equal(t, service.PlanStatusPassed, n.Status)
+		assert.Equal(t, run.RunID, n.RunID)
+		assert.Equal(t, runs[0].RunID, n.LastRunID)
+		assert.Equal(t, 1, n.Retries)
+		assert.Equal(t, 3, n.Passed)
+		assert.Equal(t, 1, n.PassedOnRetry)
+	})
+
+	t.Run("release gate selects by priority across groups", func(t *testing.T) {
+		_, err := plans.CreateTestPlan(ctx, tenant, project, &service.CreateTestPlanRequest{
+			PlanID: "gate",
+			TestPlanRequest: service.TestPlanRequest{
+				Name: "P0 release gate", Priorities: []string{"P0", "P1"}, Types: []string{"http"},
+				Variables: map[string]interface{}{"env": "plan"}, NotifyWebhook: webhook.URL,
+			},
+		})
+		require.NoError(t, err)
+
+		run, err := plans.ExecuteTestPlan(ctx, "gate", tenant, project)
+		require.NoError(t, err)
+		assert.Equal(t, 5, run.Total)
+		plan := waitPlan("gate")
+		assert.Equal(t, service.PlanStatusFailed, plan.LastStatus, "plan-fail is P1")
+		assert.ElementsMatch(t, []string{"plan-ok", "plan-flaky", "plan-fail", "plan-child", "plan-other"}, testIDs(run))
+
+		// Explicit tests always run, in the plan's environment with its
+		// variables on top
+		_, err = plans.UpdateTestPlan(ctx, "gate", tenant, project, &service.TestPlanRequest{
+			Name: "P0 release gate", TestIDs: []string{"plan-vars"}, Priorities: []string{"P0"},
+			EnvironmentID: "qa", Variables: map[string]interface{}{"env": "plan"}, NotifyWebhook: webhook.URL,
+		})
+		require.NoError(t, err)
+		run, err = plans.ExecuteTestPlan(ctx, "gate", tenant, project)
+		require.NoError(t, err)
+		plan = waitPlan("gate")
+		assert.Equal(t, service.PlanStatusPassed, plan.LastStatus)
+		assert.Equal(t, []string{"plan-vars"}, testIDs(run), "filters only narrow groups and the whole project")
+
+		require.Eventually(t, func() bool { return len(received()) == 2 }, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, "gate", received()[1].PlanID, "only the failure is notified by default")
+		assert.Equal(t, service.PlanStatusFailed, received()[1].Status)
+	})
+
+	t.Run("failures exhaust the retries", func(t *testing.T) {
+		_, err := plans.CreateTestPlan(ctx, tenant, project, &service.CreateTestPlanRequest{
+			PlanID:          "broken",
+			TestPlanRequest: service.TestPlanRequest{Name: "Broken", TestIDs: []string{"plan-fail", "plan-ok"}, MaxRetries: 2},
+		})
+		require.NoError(t, err)
+
+		_, err = plans.ExecuteTestPlan(ctx, "broken", tenant, project)
+		require.NoError(t, err)
+		plan := waitPlan("broken")
+		assert.Equal(t, service.PlanStatusFailed, plan.LastStatus)
+
+		runs, total, err := plans.GetTestPlanRuns(ctx, "broken", tenant, project, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, []string{"plan-fail"}, testIDs(&runs[0]))
+	})
+
+	t.Run("scheduled plans run when due", func(t *testing.T) {
+		plan, err := plans.CreateTestPlan(ctx, tenant, project, &service.CreateTestPlanRequest{
+			PlanID:          "nightly",
+			TestPlanRequest: service.TestPlanRequest{Name: "Nightly", GroupIDs: []string{"plan-other"}, Schedule: "0 2 * * *"},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, plan.NextRunAt)
+		assert.Equal(t, 2, plan.NextRunAt.Hour())
+		assert.True(t, plan.NextRunAt.After(time.Now()))
+
+		due := time.Now().Add(-time.Minute)
+		plan.NextRunAt = &due
+		require.NoError(t, planRepo.Update(ctx, plan))
+
+		schedulerCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		plans.Start(schedulerCtx, 20*time.Millisecond)
+
+		plan = waitPlan("nightly")
+		cancel()
+		assert.Equal(t, service.PlanStatusPassed, plan.LastStatus)
+		require.NotNil(t, plan.NextRunAt)
+		assert.True(t, plan.NextRunAt.After(time.Now()), "next night")
+		_, total, err := plans.GetTestPlanRuns(ctx, "nightly", tenant, project, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, plans.DeleteTestPlan(ctx, "nightly", tenant, project))
+		_, err := plans.GetTestPlan(ctx, "nightly", tenant, project)
+		assert.ErrorIs(t, err, apierrors.ErrNotFound)
+		assert.ErrorIs(t, plans.DeleteTestPlan(ctx, "nightly", tenant, project), apierrors.ErrNotFound)
+
+		list, err := plans.ListTestPlans(ctx, tenant, project)
+		require.NoError(t, err)
+		assert.Len(t, list, 3)
+	})
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/models"
+	"test-management-service/internal/testcase"
+)
+
+// Rerun filters select the results of a run that a rerun executes again
+const (
+	RerunFailed  = "failed"  // failed tests (the default)
+	RerunErrored = "errored" // tests that ended in an error
+	RerunAll     = "all"     // every test of the run
+)
+
+// MergedTestRun 合并视图: 原始批次的结果依次叠加每次重跑的结果
+type MergedTestRun struct {
+	RunID         string             `json:"runId"`         // 查询的批次
+	Status        string             `json:"status"`        // 查询批次的状态
+	OriginalRunID string             `json:"originalRunId"` // 重跑链最初的批次
+	Reruns        []string           `json:"reruns"`        // 原始批次之后的重跑批次, 按先后顺序
+	Total         int                `json:"total"`
+	Passed        int                `json:"passed"` // 以下计数按最终状态统计
+	Failed        int                `json:"failed"`
+	Errors        int                `json:"errors"`
+	Skipped       int                `json:"skipped"`
+	Quarantined   int                `json:"quarantined"`   // 最后一次执行时处于隔离状态的用例, 不计入以上计数
+	PassedOnRetry int                `json:"passedOnRetry"` // 首次失败或错误、重跑后通过的用例数
+	Results       []MergedTestResult `json:"results"`
+}
+
+// MergedTestResult 合并视图中单个用例的结果
+type MergedTestResult struct {
+	TestID         string           `json:"testId"`
+	OriginalStatus string           `json:"originalStatus"` // 原始批次中的状态
+	Status         string           `json:"status"`         // 最后一次执行的状态
+	Attempts       int              `json:"attempts"`       // 执行次数
+	PassedOnRetry  bool             `json:"passedOnRetry"`
+	ResultID       uint             `json:"resultId"` // 最后一次执行的结果
+	Quarantined    bool             `json:"quarantined,omitempty"`
+	Error          string           `json:"error,omitempty"`
+	Failures       models.JSONArray `json:"failures,omitempty"`
+}
+
+// RerunTestRun starts a new run of the tests of a group or selection run picked by
+// filter, linked to it through ParentRunID. The tests run with the options
+// of the original run and, like any group run, with the setup hooks, target
+// host and contract of their groups. The prerequisites of the tests run again
+// with them, as do the tests the original run skipped because of them.
+func (s *testService) RerunTestRun(ctx context.Context, runID, tenantID, projectID, filter string) (*models.TestRun, error) {
+	var selected func(status string) bool
+	switch filter {
+	case "", RerunFailed:
+		filter = RerunFailed
+		selected = func(status string) bool { return status == "failed" }
+	case RerunErrored:
+		selected = func(status string) bool { return status == "error" }
+	case RerunAll:
+		selected = func(string) bool { return true }
+	default:
+		return nil, fmt.Errorf("unknown rerun filter %q, expected failed, errored or all: %w", filter, apierrors.ErrInvalidInput)
+	}
+
+	parent, err := s.runRepo.FindByIDWithTenant(ctx, runID, tenantID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if parent.GroupID == "" && parent.Selection == nil {
+		return nil, fmt.Errorf("run %s is not a group or selection run: %w", runID, apierrors.ErrInvalidInput)
+	}
+	if parent.Status == "running" {
+		return nil, fmt.Errorf("run %s is still running: %w", runID, apierrors.ErrConflict)
+	}
+
+	testIDs := make(map[string]bool)
+	skipped := make(map[string]bool)
+	retryOf := make(map[string]uint)
+	for _, result := range parent.Results {
+		if selected(result.Status) {
+			testIDs[result.TestID] = true
+			retryOf[result.TestID] = result.ID
+		} else if result.Status == "skipped" {
+			skipped[result.TestID] = true
+		}
+	}
+	if len(testIDs) == 0 {
+		return nil, fmt.Errorf("run %s has no %s tests to rerun: %w", runID, filter, apierrors.ErrInvalidInput)
+	}
+
+	var root *groupNode
+	if parent.GroupID != "" {
+		req := &ExecuteTestGroupRequest{
+			Concurrency: parent.Concurrency,
+			FailFast:    parent.FailFast,
+			MaxDuration: parent.MaxDuration,
+			Recursive:   parent.Recursive,
+		}
+		executor := s.executor.WithExecutionParams(&testcase.ExecutionParams{TenantID: tenantID, ProjectID: projectID})
+		root, err = s.loadGroupNode(ctx, parent.GroupID, tenantID, projectID, executor, req, map[string]bool{})
+	} else {
+		// Selection runs rerun with their environment and variables
+		var req *ExecuteSelectionRequest
+		if req, err = selectionRequest(parent); err != nil {
+			return nil, err
+		}
+		root, err = s.loadSelection(ctx, tenantID, projectID, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Prerequisites run again first, followed by the dependents they held back
+	rerunScope(root, testIDs, skipped)
+	// Tests deleted since the original run are not rerun
+	if !root.keepTests(testIDs) {
+		return nil, fmt.Errorf("the %s tests of run %s no longer exist: %w", filter, runID, apierrors.ErrInvalidInput)
+	}
+
+	return s.startGroupRun(ctx, root, &models.TestRun{
+		TenantID:      tenantID,
+		ProjectID:     projectID,
+		Name:          parent.Name,
+		GroupID:       parent.GroupID,
+		FailFast:      parent.FailFast,
+		MaxDuration:   parent.MaxDuration,
+		Recursive:     parent.Recursive,
+		ParentRunID:   parent.RunID,
+		PlanID:        parent.PlanID,
+		Selection:     parent.Selection,
+		EnvironmentID: parent.EnvironmentID,
+		Variables:     parent.Variables,
+	}, retryOf)
+}
+
+// keepTests removes the tests not in testIDs from the node and its subgroups,
+// and the subgroups left without tests so their hooks do not run. It reports
+// whether any test is left.
+func (n *groupNode) keepTests(testIDs map[string]bool) bool {
+	tests := n.tests[:0]
+	for _, tc := range n.tests {
+		if testIDs[tc.TestID] {
+			tests = append(tests, tc)
+		}
+	}
+	n.tests = tests
+
+	children := n.children[:0]
+	for _, child := range n.children {
+		if child.keepTests(testIDs) {
+			children = append(children, child)
+		}
+	}
+	n.children = children
+
+	return len(n.tests) > 0 || len(n.children) > 0
+}
+
+// GetMergedTestRun returns the results of the run a rerun chain started with,
+// each replaced by the result of its latest rerun up to runID
+func (s *testService) GetMergedTestRun(ctx context.Context, runID, tenantID, projectID string) (*MergedTestRun, error) {
+	// Walk up to the original run, then merge from it down to runID
+	var chain []*models.TestRun
+	visited := make(map[string]bool)
+	for id := runID; id != "" && !visited[id]; {
+		visited[id] = true
+		run, err := s.runRepo.FindByIDWithTenant(ctx, id, tenantID, projectID)
+		if err != nil {
+			return nil, err
+		}
+		chain = append([]*models.TestRun{run}, chain...)
+		id = run.ParentRunID
+	}
+
+	merged := &MergedTestRun{
+		RunID:         runID,
+		Status:        chain[len(chain)-1].Status,
+		OriginalRunID: chain[0].RunID,
+		Reruns:        []string{},
+		Results:       []MergedTestResult{},
+	}
+	index := make(map[string]int)
+	for i, run := range chain {
+		if i > 0 {
+			merged.Reruns = append(merged.Reruns, run.RunID)
+		}
+		for _, result := range run.Results {
+			pos, ok := index[result.TestID]
+			if !ok {
+				pos = len(merged.Results)
+				index[result.TestID] = pos
+				merged.Results = append(merged.Results, MergedTestResult{
+					TestID:         result.TestID,
+					OriginalStatus: result.Status,
+				})
+			}
+			entry := &merged.Results[pos]
+			entry.Status = result.Status
+			entry.Attempts++
+			entry.ResultID = result.ID
+			entry.Error = result.Error
+			entry.Failures = result.Failures
+			entry.Quarantined = result.Quarantined
+		}
+	}
+
+	for i := range merged.Results {
+		entry := &merged.Results[i]
+		entry.PassedOnRetry = entry.Attempts > 1 && entry.Status == "passed" &&
+			(entry.OriginalStatus == "failed" || entry.OriginalStatus == "error")
+		if entry.PassedOnRetry {
+			merged.PassedOnRetry++
+		}
+		if entry.Quarantined {
+			merged.Quarantined++
+			continue
+		}
+		switch entry.Status {
+		case "passed":
+			merged.Passed++
+		case "failed":
+			merged.Failed++
+		case "error":
+			merged.Errors++
+		case "skipped":
+			merged.Skipped++
+		}
+	}
+	merged.Total = len(merged.Results)
+	return merged, nil
+}
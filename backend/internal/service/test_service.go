@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"time"
 
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/jsonpath"
 	"test-management-service/internal/models"
 	"test-management-service/internal/repository"
 	"test-management-service/internal/testcase"
+
+	"github.com/google/uuid"
 )
 
 // TestService 测试服务接口
@@ -20,6 +25,8 @@ type TestService interface {
 	GetTestCase(ctx context.Context, testID, tenantID, projectID string) (*models.TestCase, error)
 	ListTestCases(ctx context.Context, tenantID, projectID string, limit, offset int) ([]models.TestCase, int64, error)
 	SearchTestCases(ctx context.Context, tenantID, projectID, query string) ([]models.TestCase, error)
+	QuarantineTestCase(ctx context.Context, testID, tenantID, projectID string, req *QuarantineTestCaseRequest) (*models.TestCase, error)
+	ReleaseTestCase(ctx context.Context, testID, tenantID, projectID string) (*models.TestCase, error)
 
 	// Test Group operations
 	CreateTestGroup(ctx context.Context, tenantID, projectID string, req *CreateTestGroupRequest) (*models.TestGroup, error)
@@ -30,7 +37,8 @@ type TestService interface {
 
 	// Test execution
 	ExecuteTest(ctx context.Context, testID, tenantID, projectID string) (*models.TestResult, error)
-	ExecuteTestGroup(ctx context.Context, groupID, tenantID, projectID string) (*models.TestRun, error)
+	ExecuteTestGroup(ctx context.Context, groupID, tenantID, projectID string, req *ExecuteTestGroupRequest) (*models.TestRun, error)
+	ExecuteSelection(ctx context.Context, tenantID, projectID string, req *ExecuteSelectionRequest) (*models.TestRun, error)
 
 	// Test results
 	GetTestResult(ctx context.Context, id uint, tenantID, projectID string) (*models.TestResult, error)
@@ -39,11 +47,14 @@ type TestService interface {
 	// Test runs
 	GetTestRun(ctx context.Context, runID, tenantID, projectID string) (*models.TestRun, error)
 	ListTestRuns(ctx context.Context, tenantID, projectID string, limit, offset int) ([]models.TestRun, int64, error)
+	RerunTestRun(ctx context.Context, runID, tenantID, projectID, filter string) (*models.TestRun, error)
+	GetMergedTestRun(ctx context.Context, runID, tenantID, projectID string) (*MergedTestRun, error)
 
 	// Advanced search and analytics
 	AdvancedSearch(ctx context.Context, tenantID string, filter repository.TestCaseFilter) ([]*models.TestCase, int64, error)
 	GetStatistics(ctx context.Context, tenantID string, currentUserID string) (*repository.TestStatistics, error)
 	GetFlakyTests(ctx context.Context, tenantID string) ([]*models.TestCase, error)
+	GetTestFlakiness(ctx context.Context, testID, tenantID, projectID string) (*FlakinessReport, error)
 }
 
 type testService struct {
@@ -55,8 +66,22 @@ type testService struct {
 	// workflowCaseRepo provides access to advanced workflow test case methods
 	// This is optional and can be nil if not needed
 	workflowCaseRepo *repository.WorkflowTestCaseRepository
+	// contracts resolves the OpenAPI contract of a test group; optional
+	contracts ContractResolver
+	// groupConcurrency is the worker pool size of group runs when neither
+	// the run nor the group sets one
+	groupConcurrency int
+	// publisher streams the progress of group runs; optional
+	publisher TestRunPublisher
+	// listener is told when group runs finish; optional
+	listener TestRunListener
+	// flaky updates the statistics and flakiness of tests as results are saved
+	flaky *flakyAnalyzer
 }
 
+// defaultGroupConcurrency 分组执行的默认并发数
+const defaultGroupConcurrency = 4
+
 // NewTestService creates a new test service
 func NewTestService(
 	caseRepo repository.TestCaseRepository,
@@ -72,6 +97,8 @@ func NewTestService(
 		runRepo:    runRepo,
 		executor:   executor,
 		// workflowCaseRepo is nil by default, can be set via SetWorkflowCaseRepo
+		groupConcurrency: defaultGroupConcurrency,
+		flaky:            newFlakyAnalyzer(caseRepo, resultRepo),
 	}
 }
 
@@ -81,6 +108,38 @@ func (s *testService) SetWorkflowCaseRepo(repo *repository.WorkflowTestCaseRepos
 	s.workflowCaseRepo = repo
 }
 
+// SetContractResolver sets the resolver for OpenAPI contracts linked to test
+// groups and environments. Without it HTTP tests only run their own contracts.
+func (s *testService) SetContractResolver(resolver ContractResolver) {
+	s.contracts = resolver
+}
+
+// SetRunPublisher sets the publisher that streams the progress of group runs
+func (s *testService) SetRunPublisher(publisher TestRunPublisher) {
+	s.publisher = publisher
+}
+
+// SetRunListener sets the listener told when group runs finish
+func (s *testService) SetRunListener(listener TestRunListener) {
+	s.listener = listener
+}
+
+// SetQuarantineReleasePasses sets the number of consecutive passes after
+// which a quarantined test is released
+func (s *testService) SetQuarantineReleasePasses(passes int) {
+	if passes > 0 {
+		s.flaky.releasePasses = passes
+	}
+}
+
+// SetGroupConcurrency sets the default number of tests a group run executes
+// at once; groups and individual runs can override it
+func (s *testService) SetGroupConcurrency(concurrency int) {
+	if concurrency > 0 {
+		s.groupConcurrency = concurrency
+	}
+}
+
 // ===== Request/Response DTOs =====
 
 type CreateTestCaseRequest struct {
@@ -92,6 +151,13 @@ type CreateTestCaseRequest struct {
 	Status        string                 `json:"status"`
 	Objective     string                 `json:"objective"`
 	Timeout       int                    `json:"timeout"`
+	Serial        bool                   `json:"serial"`                  // Run alone in group runs (shares state with other tests)
+
+	// Dependencies: prerequisites run first in batch runs; their outputs
+	// (variable name -> JSONPath into the response) become variables of dependents
+	DependsOn     []string               `json:"dependsOn"`
+	Outputs       map[string]string      `json:"outputs"`
+	Inputs        []string               `json:"inputs"`                  // Variables the prerequisites must provide
 
 	// Workflow integration (NEW)
 	WorkflowID    string                 `json:"workflowId,omitempty"`    // Mode 1: Reference workflow
@@ -103,11 +169,13 @@ type CreateTestCaseRequest struct {
 	// Existing fields
 	HTTP          map[string]interface{} `json:"http"`
 	Command       map[string]interface{} `json:"command"`
+	GraphQL       map[string]interface{} `json:"graphql"`
 	Integration   map[string]interface{} `json:"integration"`
 	Assertions    []interface{}          `json:"assertions"`
 	Tags          []interface{}          `json:"tags"`
 	SetupHooks    []interface{}          `json:"setupHooks"`
 	TeardownHooks []interface{}          `json:"teardownHooks"`
+	Sessions      []interface{}          `json:"sessions"`                // Named HTTP sessions (cookie jar, headers, base URL)
 }
 
 type UpdateTestCaseRequest struct {
@@ -116,6 +184,12 @@ type UpdateTestCaseRequest struct {
 	Status        string                 `json:"status"`
 	Objective     string                 `json:"objective"`
 	Timeout       int                    `json:"timeout"`
+	Serial        *bool                  `json:"serial"`                  // nil keeps the current setting
+
+	// Dependencies; nil keeps the current setting, empty clears it
+	DependsOn     []string               `json:"dependsOn"`
+	Outputs       map[string]string      `json:"outputs"`
+	Inputs        []string               `json:"inputs"`
 
 	// Workflow integration (NEW)
 	WorkflowID    string                 `json:"workflowId,omitempty"`
@@ -126,10 +200,12 @@ type UpdateTestCaseRequest struct {
 
 	HTTP          map[string]interface{} `json:"http"`
 	Command       map[string]interface{} `json:"command"`
+	GraphQL       map[string]interface{} `json:"graphql"`
 	Assertions    []interface{}          `json:"assertions"`
 	Tags          []interface{}          `json:"tags"`
 	SetupHooks    []interface{}          `json:"setupHooks"`
 	TeardownHooks []interface{}          `json:"teardownHooks"`
+	Sessions      []interface{}          `json:"sessions"`                // Named HTTP sessions (cookie jar, headers, base URL)
 }
 
 type CreateTestGroupRequest struct {
@@ -138,12 +214,35 @@ type CreateTestGroupRequest struct {
 	ParentID    string `json:"parentId"`
 	Description string `json:"description"`
 	TargetHost  string `json:"targetHost"` // 测试目标服务地址
+	Concurrency int    `json:"concurrency"` // 分组执行的并发数, 0 使用服务默认值
+
+	// 分组执行时在组内用例和子分组之前/之后各运行一次
+	SetupHooks    []interface{} `json:"setupHooks"`
+	TeardownHooks []interface{} `json:"teardownHooks"`
+
+	OpenAPISpec  string `json:"openapiSpec"`  // 关联的 openapi 文档名称
+	ContractMode string `json:"contractMode"` // strict(默认), warn, off
 }
 
 type UpdateTestGroupRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	TargetHost  string `json:"targetHost"` // 测试目标服务地址
+	Concurrency *int   `json:"concurrency"` // nil 保持不变, 0 使用服务默认值
+
+	SetupHooks    []interface{} `json:"setupHooks"`    // nil 保持不变
+	TeardownHooks []interface{} `json:"teardownHooks"` // nil 保持不变
+
+	OpenAPISpec  *string `json:"openapiSpec"`  // nil 保持不变, 空字符串解除关联
+	ContractMode *string `json:"contractMode"` // strict(默认), warn, off
+}
+
+// ExecuteTestGroupRequest 分组执行选项, 零值使用分组或服务的默认值
+type ExecuteTestGroupRequest struct {
+	Concurrency int  `json:"concurrency"` // 并发数, 覆盖分组的设置
+	FailFast    bool `json:"failFast"`    // 首个失败或错误后不再调度剩余用例
+	MaxDuration int  `json:"maxDuration"` // 最长执行时间（秒）, 超时后剩余用例记为跳过
+	Recursive   bool `json:"recursive"`   // 递归执行所有子分组
 }
 
 // ===== Test Case Operations =====
@@ -170,6 +269,7 @@ func (s *testService) CreateTestCase(ctx context.Context, tenantID, projectID st
 		Status:    req.Status,
 		Objective: req.Objective,
 		Timeout:   req.Timeout,
+		Serial:    req.Serial,
 	}
 
 	// Test steps with control flow
@@ -183,6 +283,9 @@ func (s *testService) CreateTestCase(ctx context.Context, tenantID, projectID st
 	if req.Command != nil {
 		tc.CommandConfig = req.Command
 	}
+	if req.GraphQL != nil {
+		tc.GraphQLConfig = req.GraphQL
+	}
 	if req.Integration != nil {
 		tc.IntegrationConfig = req.Integration
 	}
@@ -198,6 +301,13 @@ func (s *testService) CreateTestCase(ctx context.Context, tenantID, projectID st
 	if req.TeardownHooks != nil {
 		tc.TeardownHooks = req.TeardownHooks
 	}
+	if req.Sessions != nil {
+		tc.Sessions = req.Sessions
+	}
+	setDependencies(tc, req.DependsOn, req.Outputs, req.Inputs)
+	if err := s.validateDependencies(ctx, tc); err != nil {
+		return nil, err
+	}
 
 	// Workflow integration
 	if req.WorkflowID != "" {
@@ -238,6 +348,9 @@ func (s *testService) UpdateTestCase(ctx context.Context, testID, tenantID, proj
 	if req.Timeout > 0 {
 		tc.Timeout = req.Timeout
 	}
+	if req.Serial != nil {
+		tc.Serial = *req.Serial
+	}
 	// Test steps with control flow
 	if req.Steps != nil {
 		tc.Steps = req.Steps
@@ -248,6 +361,9 @@ func (s *testService) UpdateTestCase(ctx context.Context, testID, tenantID, proj
 	if req.Command != nil {
 		tc.CommandConfig = req.Command
 	}
+	if req.GraphQL != nil {
+		tc.GraphQLConfig = req.GraphQL
+	}
 	if req.Assertions != nil {
 		tc.Assertions = req.Assertions
 	}
@@ -260,6 +376,13 @@ func (s *testService) UpdateTestCase(ctx context.Context, testID, tenantID, proj
 	if req.TeardownHooks != nil {
 		tc.TeardownHooks = req.TeardownHooks
 	}
+	if req.Sessions != nil {
+		tc.Sessions = req.Sessions
+	}
+	setDependencies(tc, req.DependsOn, req.Outputs, req.Inputs)
+	if err := s.validateDependencies(ctx, tc); err != nil {
+		return nil, err
+	}
 
 	// Workflow integration
 	if req.WorkflowID != "" {
@@ -295,14 +418,30 @@ func (s *testService) SearchTestCases(ctx context.Context, tenantID, projectID,
 // ===== Test Group Operations =====
 
 func (s *testService) CreateTestGroup(ctx context.Context, tenantID, projectID string, req *CreateTestGroupRequest) (*models.TestGroup, error) {
+	if err := validateContractMode(req.ContractMode); err != nil {
+		return nil, err
+	}
+	if req.Concurrency < 0 {
+		return nil, fmt.Errorf("concurrency must not be negative: %w", apierrors.ErrInvalidInput)
+	}
+
 	group := &models.TestGroup{
-		GroupID:     req.GroupID,
-		TenantID:    tenantID,
-		ProjectID:   projectID,
-		Name:        req.Name,
-		ParentID:    req.ParentID,
-		Description: req.Description,
-		TargetHost:  req.TargetHost,
+		GroupID:      req.GroupID,
+		TenantID:     tenantID,
+		ProjectID:    projectID,
+		Name:         req.Name,
+		ParentID:     req.ParentID,
+		Description:  req.Description,
+		TargetHost:   req.TargetHost,
+		Concurrency:  req.Concurrency,
+		OpenAPISpec:  req.OpenAPISpec,
+		ContractMode: req.ContractMode,
+	}
+	if req.SetupHooks != nil {
+		group.SetupHooks = req.SetupHooks
+	}
+	if req.TeardownHooks != nil {
+		group.TeardownHooks = req.TeardownHooks
 	}
 
 	if err := s.groupRepo.CreateWithTenant(ctx, group); err != nil {
@@ -329,6 +468,27 @@ func (s *testService) UpdateTestGroup(ctx context.Context, groupID, tenantID, pr
 	}
 	// Allow clearing targetHost by setting to empty string
 	group.TargetHost = req.TargetHost
+	if req.Concurrency != nil {
+		if *req.Concurrency < 0 {
+			return nil, fmt.Errorf("concurrency must not be negative: %w", apierrors.ErrInvalidInput)
+		}
+		group.Concurrency = *req.Concurrency
+	}
+	if req.SetupHooks != nil {
+		group.SetupHooks = req.SetupHooks
+	}
+	if req.TeardownHooks != nil {
+		group.TeardownHooks = req.TeardownHooks
+	}
+	if req.OpenAPISpec != nil {
+		group.OpenAPISpec = *req.OpenAPISpec
+	}
+	if req.ContractMode != nil {
+		if err := validateContractMode(*req.ContractMode); err != nil {
+			return nil, err
+		}
+		group.ContractMode = *req.ContractMode
+	}
 
 	if err := s.groupRepo.UpdateWithTenant(ctx, group); err != nil {
 		return nil, fmt.Errorf("failed to update test group: %w", err)
@@ -367,12 +527,16 @@ func (s *testService) ExecuteTest(ctx context.Context, testID, tenantID, project
 		group, err := s.groupRepo.FindByIDWithTenant(ctx, tc.GroupID, tenantID, projectID)
 		if err == nil && group != nil && group.TargetHost != "" {
 			// Use group-specific target host
-			executor = testcase.NewExecutor(group.TargetHost)
+			executor = executor.WithBaseURL(group.TargetHost)
 		}
 	}
+	executor = executor.WithExecutionParams(&testcase.ExecutionParams{TenantID: tenantID, ProjectID: projectID})
 
 	// Convert to executor format
 	execTC := s.convertToExecutorTestCase(tc)
+	if execTC.Contract, err = s.resolveContract(ctx, tenantID, projectID, tc.GroupID); err != nil {
+		return nil, err
+	}
 
 	// Execute test
 	result := executor.Execute(execTC)
@@ -381,79 +545,66 @@ func (s *testService) ExecuteTest(ctx context.Context, testID, tenantID, project
 	dbResult := s.convertToModelResult(result)
 	dbResult.TenantID = tenantID
 	dbResult.ProjectID = projectID
+	dbResult.Quarantined = quarantineActive(tc, time.Now())
 	if err := s.resultRepo.CreateWithTenant(ctx, dbResult); err != nil {
 		return nil, fmt.Errorf("failed to save test result: %w", err)
 	}
+	if err := s.flaky.RecordResult(ctx, dbResult); err != nil {
+		log.Printf("failed to update statistics of test %s: %v", testID, err)
+	}
 
 	return dbResult, nil
 }
 
-func (s *testService) ExecuteTestGroup(ctx context.Context, groupID, tenantID, projectID string) (*models.TestRun, error) {
-	// Get all tests in group with tenant isolation
-	tests, err := s.caseRepo.FindByGroupIDWithTenant(ctx, groupID, tenantID, projectID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find tests in group: %w", err)
+// ExecuteTestGroup starts a run of all tests in a group, and with Recursive of
+// all its subgroups, and returns it right away. The tests execute in the
+// background (see runGroup) and the run's counters are updated as results arrive.
+func (s *testService) ExecuteTestGroup(ctx context.Context, groupID, tenantID, projectID string, req *ExecuteTestGroupRequest) (*models.TestRun, error) {
+	if req == nil {
+		req = &ExecuteTestGroupRequest{}
+	}
+	if req.Concurrency < 0 || req.MaxDuration < 0 {
+		return nil, fmt.Errorf("concurrency and maxDuration must not be negative: %w", apierrors.ErrInvalidInput)
 	}
 
-	// Get the test group to check for custom target host
-	executor := s.executor
-	group, err := s.groupRepo.FindByIDWithTenant(ctx, groupID, tenantID, projectID)
-	if err == nil && group != nil && group.TargetHost != "" {
-		// Use group-specific target host
-		executor = testcase.NewExecutor(group.TargetHost)
+	executor := s.executor.WithExecutionParams(&testcase.ExecutionParams{TenantID: tenantID, ProjectID: projectID})
+	root, err := s.loadGroupNode(ctx, groupID, tenantID, projectID, executor, req, map[string]bool{})
+	if err != nil {
+		return nil, err
 	}
 
-	// Create test run
-	runID := fmt.Sprintf("run-%d", time.Now().Unix())
-	run := &models.TestRun{
-		RunID:     runID,
-		TenantID:  tenantID,
-		ProjectID: projectID,
-		Total:     len(tests),
-		StartTime: time.Now(),
-		Status:    "running",
+	return s.startGroupRun(ctx, root, &models.TestRun{
+		TenantID:    tenantID,
+		ProjectID:   projectID,
+		GroupID:     groupID,
+		FailFast:    req.FailFast,
+		MaxDuration: req.MaxDuration,
+		Recursive:   req.Recursive,
+	}, nil)
+}
+
+// startGroupRun saves run for the tests of root and executes them in the
+// background. run carries the group and the options of the run; retryOf links
+// the results of a rerun to those they retry.
+func (s *testService) startGroupRun(ctx context.Context, root *groupNode, run *models.TestRun, retryOf map[string]uint) (*models.TestRun, error) {
+	run.RunID = "run-" + uuid.New().String()
+	run.Total = root.total()
+	run.StartTime = time.Now()
+	run.Status = "running"
+	run.Concurrency = root.concurrency
+	if root.group != nil {
+		run.Name = root.group.Name
 	}
 
 	if err := s.runRepo.CreateWithTenant(ctx, run); err != nil {
 		return nil, fmt.Errorf("failed to create test run: %w", err)
 	}
 
-	// Execute each test
-	for _, tc := range tests {
-		execTC := s.convertToExecutorTestCase(&tc)
-		result := executor.Execute(execTC)
-
-		dbResult := s.convertToModelResult(result)
-		dbResult.RunID = runID
-		dbResult.TenantID = tenantID
-		dbResult.ProjectID = projectID
-
-		if err := s.resultRepo.CreateWithTenant(ctx, dbResult); err != nil {
-			fmt.Printf("failed to save result for test %s: %v\n", tc.TestID, err)
-			continue
-		}
-
-		// Update run statistics
-		switch result.Status {
-		case "passed":
-			run.Passed++
-		case "failed":
-			run.Failed++
-		case "error":
-			run.Errors++
-		}
-	}
-
-	// Update run status
-	run.EndTime = time.Now()
-	run.Duration = int(run.EndTime.Sub(run.StartTime).Milliseconds())
-	run.Status = "completed"
+	// The run outlives the request, so it must not be cancelled with it
+	created := *run
+	go s.runGroup(context.WithoutCancel(ctx), run, root, retryOf)
 
-	if err := s.runRepo.UpdateWithTenant(ctx, run); err != nil {
-		return nil, fmt.Errorf("failed to update test run: %w", err)
-	}
-
-	return run, nil
+	return &created, nil
 }
 
 // ===== Test Results =====
@@ -510,27 +661,24 @@ func (s *testService) convertToExecutorTestCase(tc *models.TestCase) *testcase.T
 	}
 
 	if httpConfig != nil {
-		execTC.HTTP = &testcase.HTTPTest{}
-		if method, ok := httpConfig["method"].(string); ok {
-			execTC.HTTP.Method = method
-		}
-		// Support both "path" (old format) and "url" (new format)
-		if path, ok := httpConfig["path"].(string); ok {
-			execTC.HTTP.Path = path
-		} else if url, ok := httpConfig["url"].(string); ok {
-			execTC.HTTP.Path = url
-		}
-		if headers, ok := httpConfig["headers"].(map[string]interface{}); ok {
-			execTC.HTTP.Headers = make(map[string]string)
-			for k, v := range headers {
-				if str, ok := v.(string); ok {
-					execTC.HTTP.Headers[k] = str
+		execTC.HTTP = convertHTTPConfig(httpConfig)
+	}
+
+	// Convert GraphQL config - supports both GraphQLConfig and Steps[0].Config
+	graphqlConfig := tc.GraphQLConfig
+	if graphqlConfig == nil && len(tc.Steps) > 0 {
+		if stepMap, ok := tc.Steps[0].(map[string]interface{}); ok {
+			stepType, _ := stepMap["type"].(string)
+			if stepType == "graphql" {
+				if config, ok := stepMap["config"].(map[string]interface{}); ok {
+					graphqlConfig = config
 				}
 			}
 		}
-		if body, ok := httpConfig["body"].(map[string]interface{}); ok {
-			execTC.HTTP.Body = body
-		}
+	}
+
+	if graphqlConfig != nil {
+		execTC.GraphQL = convertGraphQLConfig(graphqlConfig)
 	}
 
 	// Convert Command config - supports both old format (CommandConfig) and new format (Steps[0].Config)
@@ -582,144 +730,164 @@ func (s *testService) convertToExecutorTestCase(tc *models.TestCase) *testcase.T
 				if operator, ok := assertMap["operator"].(string); ok {
 					assertion.Operator = operator
 				}
-				execTC.Assertions = append(execTC.Assertions, assertion)
-			}
-		}
-	}
-
-	// Convert Setup Hooks
-	if tc.SetupHooks != nil {
-		for _, h := range tc.SetupHooks {
-			if hookMap, ok := h.(map[string]interface{}); ok {
-				hook := testcase.Hook{}
-				if hType, ok := hookMap["type"].(string); ok {
-					hook.Type = hType
+				if schemaDoc := assertMap["schema"]; schemaDoc != nil {
+					assertion.Schema = schemaDoc
 				}
-				if name, ok := hookMap["name"].(string); ok {
-					hook.Name = name
+				if schemaRef, ok := assertMap["schemaRef"].(string); ok {
+					assertion.SchemaRef = schemaRef
 				}
-				if saveResponse, ok := hookMap["saveResponse"].(string); ok {
-					hook.SaveResponse = saveResponse
+				if draft, ok := assertMap["draft"].(string); ok {
+					assertion.Draft = draft
 				}
-				if runOnFailure, ok := hookMap["runOnFailure"].(bool); ok {
-					hook.RunOnFailure = runOnFailure
+				if name, ok := assertMap["snapshot"].(string); ok {
+					assertion.Snapshot = name
 				}
-				if continueOnError, ok := hookMap["continueOnError"].(bool); ok {
-					hook.ContinueOnError = continueOnError
+				if expression, ok := assertMap["expression"].(string); ok {
+					assertion.Expression = expression
 				}
-
-				// Convert HTTP config for hook
-				if httpConfig, ok := hookMap["http"].(map[string]interface{}); ok {
-					hook.HTTP = &testcase.HTTPTest{}
-					if method, ok := httpConfig["method"].(string); ok {
-						hook.HTTP.Method = method
-					}
-					if path, ok := httpConfig["path"].(string); ok {
-						hook.HTTP.Path = path
-					}
-					if headers, ok := httpConfig["headers"].(map[string]interface{}); ok {
-						hook.HTTP.Headers = make(map[string]string)
-						for k, v := range headers {
-							if str, ok := v.(string); ok {
-								hook.HTTP.Headers[k] = str
-							}
+				if ignore, ok := assertMap["ignore"].([]interface{}); ok {
+					for _, path := range ignore {
+						if p, ok := path.(string); ok {
+							assertion.Ignore = append(assertion.Ignore, p)
 						}
 					}
-					if body, ok := httpConfig["body"].(map[string]interface{}); ok {
-						hook.HTTP.Body = body
-					}
 				}
+				execTC.Assertions = append(execTC.Assertions, assertion)
+			}
+		}
+	}
 
-				// Convert Command config for hook
-				if cmdConfig, ok := hookMap["command"].(map[string]interface{}); ok {
-					hook.Command = &testcase.CommandTest{}
-					if cmd, ok := cmdConfig["cmd"].(string); ok {
-						hook.Command.Cmd = cmd
-					}
-					if args, ok := cmdConfig["args"].([]interface{}); ok {
-						for _, arg := range args {
-							if str, ok := arg.(string); ok {
-								hook.Command.Args = append(hook.Command.Args, str)
-							}
-						}
-					}
-					if timeout, ok := cmdConfig["timeout"].(float64); ok {
-						hook.Command.Timeout = int(timeout)
-					}
-				}
+	// Convert Setup / Teardown Hooks
+	execTC.SetupHooks = convertHooks(tc.SetupHooks)
+	execTC.TeardownHooks = convertHooks(tc.TeardownHooks)
 
-				execTC.SetupHooks = append(execTC.SetupHooks, hook)
-			}
+	// Convert named HTTP sessions
+	if tc.Sessions != nil {
+		if data, err := json.Marshal(tc.Sessions); err == nil {
+			_ = json.Unmarshal(data, &execTC.Sessions)
 		}
 	}
 
-	// Convert Teardown Hooks
-	if tc.TeardownHooks != nil {
-		for _, h := range tc.TeardownHooks {
-			if hookMap, ok := h.(map[string]interface{}); ok {
-				hook := testcase.Hook{}
-				if hType, ok := hookMap["type"].(string); ok {
-					hook.Type = hType
-				}
-				if name, ok := hookMap["name"].(string); ok {
-					hook.Name = name
-				}
-				if saveResponse, ok := hookMap["saveResponse"].(string); ok {
-					hook.SaveResponse = saveResponse
-				}
-				if runOnFailure, ok := hookMap["runOnFailure"].(bool); ok {
-					hook.RunOnFailure = runOnFailure
-				}
-				if continueOnError, ok := hookMap["continueOnError"].(bool); ok {
-					hook.ContinueOnError = continueOnError
-				}
+	return execTC
+}
 
-				// Convert HTTP config for hook
-				if httpConfig, ok := hookMap["http"].(map[string]interface{}); ok {
-					hook.HTTP = &testcase.HTTPTest{}
-					if method, ok := httpConfig["method"].(string); ok {
-						hook.HTTP.Method = method
-					}
-					if path, ok := httpConfig["path"].(string); ok {
-						hook.HTTP.Path = path
-					}
-					if headers, ok := httpConfig["headers"].(map[string]interface{}); ok {
-						hook.HTTP.Headers = make(map[string]string)
-						for k, v := range headers {
-							if str, ok := v.(string); ok {
-								hook.HTTP.Headers[k] = str
-							}
-						}
-					}
-					if body, ok := httpConfig["body"].(map[string]interface{}); ok {
-						hook.HTTP.Body = body
-					}
-				}
+// convertHooks converts stored hook definitions into executor hooks
+func convertHooks(hooks models.JSONArray) []testcase.Hook {
+	var result []testcase.Hook
+	for _, h := range hooks {
+		hookMap, ok := h.(map[string]interface{})
+		if !ok {
+			continue
+		}
 
-				// Convert Command config for hook
-				if cmdConfig, ok := hookMap["command"].(map[string]interface{}); ok {
-					hook.Command = &testcase.CommandTest{}
-					if cmd, ok := cmdConfig["cmd"].(string); ok {
-						hook.Command.Cmd = cmd
-					}
-					if args, ok := cmdConfig["args"].([]interface{}); ok {
-						for _, arg := range args {
-							if str, ok := arg.(string); ok {
-								hook.Command.Args = append(hook.Command.Args, str)
-							}
-						}
-					}
-					if timeout, ok := cmdConfig["timeout"].(float64); ok {
-						hook.Command.Timeout = int(timeout)
+		hook := testcase.Hook{}
+		if hType, ok := hookMap["type"].(string); ok {
+			hook.Type = hType
+		}
+		if name, ok := hookMap["name"].(string); ok {
+			hook.Name = name
+		}
+		if saveResponse, ok := hookMap["saveResponse"].(string); ok {
+			hook.SaveResponse = saveResponse
+		}
+		if savePath, ok := hookMap["saveResponsePath"].(string); ok {
+			hook.SaveResponsePath = savePath
+		}
+		if runOnFailure, ok := hookMap["runOnFailure"].(bool); ok {
+			hook.RunOnFailure = runOnFailure
+		}
+		if continueOnError, ok := hookMap["continueOnError"].(bool); ok {
+			hook.ContinueOnError = continueOnError
+		}
+
+		// Convert HTTP config for hook
+		if httpConfig, ok := hookMap["http"].(map[string]interface{}); ok {
+			hook.HTTP = convertHTTPConfig(httpConfig)
+		}
+
+		// Convert Command config for hook
+		if cmdConfig, ok := hookMap["command"].(map[string]interface{}); ok {
+			hook.Command = &testcase.CommandTest{}
+			if cmd, ok := cmdConfig["cmd"].(string); ok {
+				hook.Command.Cmd = cmd
+			}
+			if args, ok := cmdConfig["args"].([]interface{}); ok {
+				for _, arg := range args {
+					if str, ok := arg.(string); ok {
+						hook.Command.Args = append(hook.Command.Args, str)
 					}
 				}
+			}
+			if timeout, ok := cmdConfig["timeout"].(float64); ok {
+				hook.Command.Timeout = int(timeout)
+			}
+		}
+
+		result = append(result, hook)
+	}
+	return result
+}
+
+// convertGraphQLConfig converts a stored GraphQL config map into an executor GraphQLTest.
+// Transport options are decoded like HTTP configs.
+func convertGraphQLConfig(graphqlConfig map[string]interface{}) *testcase.GraphQLTest {
+	graphqlTest := &testcase.GraphQLTest{}
+	if data, err := json.Marshal(graphqlConfig); err == nil {
+		_ = json.Unmarshal(data, graphqlTest)
+	}
 
-				execTC.TeardownHooks = append(execTC.TeardownHooks, hook)
+	// The "query" key holds the GraphQL document, so it is not passed on as URL query parameters
+	transport := make(map[string]interface{}, len(graphqlConfig))
+	for k, v := range graphqlConfig {
+		if k != "query" && k != "variables" && k != "body" {
+			transport[k] = v
+		}
+	}
+	graphqlTest.HTTPTest = *convertHTTPConfig(transport)
+	return graphqlTest
+}
+
+// convertHTTPConfig converts a stored HTTP config map into an executor HTTPTest.
+// Body, transport and TLS options use the same JSON field names as testcase.HTTPTest.
+func convertHTTPConfig(httpConfig map[string]interface{}) *testcase.HTTPTest {
+	httpTest := &testcase.HTTPTest{}
+
+	// Decode the extended options (query, form, multipart, timeout, tls, proxy...)
+	// on a best-effort basis; fields with unexpected types are skipped.
+	if data, err := json.Marshal(httpConfig); err == nil {
+		_ = json.Unmarshal(data, httpTest)
+	}
+
+	// Support both "path" (old format) and "url" (new format); both are relative to the target host
+	httpTest.URL = ""
+	if path, ok := httpConfig["path"].(string); ok {
+		httpTest.Path = path
+	} else if url, ok := httpConfig["url"].(string); ok {
+		httpTest.Path = url
+	}
+
+	httpTest.Headers = nil
+	if headers, ok := httpConfig["headers"].(map[string]interface{}); ok {
+		httpTest.Headers = make(map[string]string)
+		for k, v := range headers {
+			if str, ok := v.(string); ok {
+				httpTest.Headers[k] = str
 			}
 		}
 	}
 
-	return execTC
+	// A string body is sent as-is
+	httpTest.Body = nil
+	switch body := httpConfig["body"].(type) {
+	case map[string]interface{}:
+		httpTest.Body = body
+	case string:
+		if httpTest.RawBody == "" {
+			httpTest.RawBody = body
+		}
+	}
+
+	return httpTest
 }
 
 func (s *testService) convertToModelResult(result *testcase.TestResult) *models.TestResult {
@@ -739,6 +907,24 @@ func (s *testService) convertToModelResult(result *testcase.TestResult) *models.
 		}
 	}
 
+	// Structured assertion results (expected, actual, path, message)
+	if len(result.Assertions) > 0 {
+		dbResult.Assertions, _ = jsonpath.Normalize(result.Assertions).([]interface{})
+	}
+
+	// Contract violations reported in warn mode
+	for _, w := range result.Warnings {
+		dbResult.Warnings = append(dbResult.Warnings, w)
+	}
+
+	// Snapshot outcomes (including structured diffs) are kept as artifacts
+	for _, snap := range result.Snapshots {
+		dbResult.Artifacts = append(dbResult.Artifacts, map[string]interface{}{
+			"type":     "snapshot",
+			"snapshot": jsonpath.Normalize(snap),
+		})
+	}
+
 	// Store request/response as JSON
 	if result.Request != nil {
 		if data, err := json.Marshal(result.Request); err == nil {
@@ -780,10 +966,15 @@ func (s *testService) GetStatistics(ctx context.Context, tenantID string, curren
 func (s *testService) GetFlakyTests(ctx context.Context, tenantID string) ([]*models.TestCase, error) {
 	// Check if workflowCaseRepo is available
 	if s.workflowCaseRepo != nil {
-		// Default flaky score threshold: 70
-		return s.workflowCaseRepo.GetFlakyTests(ctx, tenantID, 70)
+		return s.workflowCaseRepo.GetFlakyTests(ctx, tenantID, flakyScoreThreshold)
 	}
 
 	// Fallback: this should not happen in practice
 	return nil, fmt.Errorf("flaky test detection not supported by current repository implementation")
 }
+
+// GetTestFlakiness returns the flakiness score of a test with the recent
+// results it is computed from
+func (s *testService) GetTestFlakiness(ctx context.Context, testID, tenantID, projectID string) (*FlakinessReport, error) {
+	return s.flaky.Report(ctx, testID, tenantID, projectID)
+}
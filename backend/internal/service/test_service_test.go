@@ -0,0 +1,737 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apierrors "test-management-service/internal/errors"
+	"test-management-service/internal/models"
+	"test-management-service/internal/repository"
+	"test-management-service/internal/service"
+	"test-management-service/internal/testcase"
+	"test-management-service/internal/testutil"
+	"test-management-service/internal/websocket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyServer answers /ok and /serial after a delay, /fail with a 500
+// and /hang once the client gives up, recording the most requests it served
+// at once
+type concurrencyServer struct {
+	mu            sync.Mutex
+	active        int
+	maxActive     int
+	serialOverlap bool
+}
+
+func (s *concurrencyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.active++
+	if s.active > s.maxActive {
+		s.maxActive = s.active
+	}
+	if r.URL.Path == "/serial" && s.active > 1 {
+		s.serialOverlap = true
+	}
+	s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/fail":
+	case "/hang":
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	default:
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+
+	if r.URL.Path == "/fail" {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *concurrencyServer) stats() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxActive, s.serialOverlap
+}
+
+func (s *concurrencyServer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxActive, s.serialOverlap = 0, false
+}
+
+func TestExecuteTestGroup(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	target := &concurrencyServer{}
+	server := httptest.NewServer(target)
+	defer server.Close()
+
+	svc := service.NewTestService(repository.NewTestCaseRepository(db), repository.NewTestGroupRepository(db),
+		repository.NewTestResultRepository(db), repository.NewTestRunRepository(db), testcase.NewExecutor(""))
+	ctx := context.Background()
+	const tenant, project = "default", "default"
+
+	createGroup := func(groupID string, concurrency int, paths ...string) {
+		_, err := svc.CreateTestGroup(ctx, tenant, project, &service.CreateTestGroupRequest{
+			GroupID: groupID, Name: groupID, TargetHost: server.URL, Concurrency: concurrency,
+		})
+		require.NoError(t, err)
+		for i, path := range paths {
+			_, err := svc.CreateTestCase(ctx, tenant, project, &service.CreateTestCaseRequest{
+				TestID: fmt.Sprintf("%s-%d", groupID, i), GroupID: groupID, Name: path, Type: "http",
+				Serial:     path == "/serial",
+				HTTP:       map[string]interface{}{"method": "GET", "path": path},
+				Assertions: []interface{}{map[string]interface{}{"type": "status_code", "expected": 200}},
+			})
+			require.NoError(t, err)
+		}
+	}
+	wait := func(run *models.TestRun) *models.TestRun {
+		var done *models.TestRun
+		require.Eventually(t, func() bool {
+			got, err := svc.GetTestRun(ctx, run.RunID, tenant, project)
+			require.NoError(t, err)
+			done = got
+			return got.Status != "running"
+		}, 10*time.Second, 20*time.Millisecond)
+		return done
+	}
+
+	t.Run("runs on a bounded pool and serial tests alone", func(t *testing.T) {
+		createGroup("pool", 3, "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/serial")
+
+		run, err := svc.ExecuteTestGroup(ctx, "pool", tenant, project, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "running", run.Status)
+		assert.Equal(t, 7, run.Total)
+		assert.Equal(t, 3, run.Concurrency, "group concurrency applies")
+
+		run = wait(run)
+		assert.Equal(t, "completed", run.Status)
+		assert.Equal(t, 7, run.Passed)
+		assert.Len(t, run.Results, 7)
+		maxActive, serialOverlap := target.stats()
+		assert.Equal(t, 3, maxActive)
+		assert.False(t, serialOverlap, "serial test overlapped another test")
+	})
+
+	t.Run("run concurrency overrides the group", func(t *testing.T) {
+		target.reset()
+		run, err := svc.ExecuteTestGroup(ctx, "pool", tenant, project, &service.ExecuteTestGroupRequest{Concurrency: 1})
+		require.NoError(t, err)
+		run = wait(run)
+		assert.Equal(t, "completed", run.Status)
+		maxActive, _ := target.stats()
+		assert.Equal(t, 1, maxActive)
+	})
+
+	t.Run("fail-fast skips the remaining tests", func(t *testing.T) {
+		createGroup("failing", 1, "/fail", "/fail", "/fail", "/fail")
+
+		run, err := svc.ExecuteTestGroup(ctx, "failing", tenant, project, &service.ExecuteTestGroupRequest{FailFast: true})
+		require.NoError(t, err)
+		run = wait(run)
+		assert.Equal(t, "cancelled", run.Status)
+		assert.Equal(t, 1, run.Failed)
+		assert.Equal(t, 3, run.Skipped)
+	})
+
+	t.Run("max duration stops scheduling", func(t *testing.T) {
+		createGroup("slow", 1, "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok",
+			"/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok", "/ok")
+
+		run, err := svc.ExecuteTestGroup(ctx, "slow", tenant, project, &service.ExecuteTestGroupRequest{MaxDuration: 1})
+		require.NoError(t, err)
+		run = wait(run)
+		assert.Equal(t, "timeout", run.Status)
+		assert.Greater(t, run.Passed, 0)
+		assert.Greater(t, run.Skipped, 0)
+		assert.Equal(t, run.Total, run.Passed+run.Skipped)
+	})
+
+	t.Run("max duration interrupts running tests", func(t *testing.T) {
+		createGroup("hanging", 1, "/hang")
+
+		run, err := svc.ExecuteTestGroup(ctx, "hanging", tenant, project, &service.ExecuteTestGroupRequest{MaxDuration: 1})
+		require.NoError(t, err)
+		run = wait(run)
+		assert.Equal(t, "timeout", run.Status)
+		assert.Equal(t, 1, run.Skipped)
+		assert.Less(t, run.Duration, 5000)
+		require.Len(t, run.Results, 1)
+		assert.Equal(t, "interrupted: the run exceeded its maximum duration", run.Results[0].Error)
+	})
+
+	t.Run("rejects negative options", func(t *testing.T) {
+		_, err := svc.ExecuteTestGroup(ctx, "pool", tenant, project, &service.ExecuteTestGroupRequest{Concurrency: -1})
+		assert.ErrorIs(t, err, apierrors.ErrInvalidInput)
+	})
+}
+
+func TestExecuteTestGroup_Recursive(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	var hits sync.Map // path -> *int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count, _ := hits.LoadOrStore(r.URL.Path, new(int32))
+		atomic.AddInt32(count.(*int32), 1)
+		switch r.URL.Path {
+		case "/login":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"token":"t-1"}`)
+		case "/ok":
+			if r.Header.Get("Authorization") != "Bearer t-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	hitCount := func(path string) int32 {
+		if count, ok := hits.Load(path); ok {
+			return atomic.LoadInt32(count.(*int32))
+		}
+		return 0
+	}
+
+	envs := service.NewEnvironmentService(repository.NewEnvironmentRepository(db), repository.NewEnvironmentVariableRepository(db))
+	executor := testcase.NewExecutorWithInjector("", nil, nil, nil, service.NewVariableInjector(envs))
+	svc := service.NewTestService(repository.NewTestCaseRepository(db), repository.NewTestGroupRepository(db),
+		repository.NewTestResultRepository(db), repository.NewTestRunRepository(db), executor)
+	ctx := context.Background()
+	const tenant, project = "default", "default"
+
+	hook := func(name, path string, save bool) map[string]interface{} {
+		h := map[string]interface{}{"type": "http", "name": name, "http": map[string]interface{}{"method": "GET", "path": path}}
+		if save {
+			h["saveResponse"] = "token"
+			h["saveResponsePath"] = "body.token"
+		}
+		return h
+	}
+	createGroup := func(req *service.CreateTestGroupRequest, tests int) {
+		_, err := svc.CreateTestGroup(ctx, tenant, project, req)
+		require.NoError(t, err)
+		for i := 0; i < tests; i++ {
+			_, err := svc.CreateTestCase(ctx, tenant, project, &service.CreateTestCaseRequest{
+				TestID: fmt.Sprintf("%s-%d", req.GroupID, i), GroupID: req.GroupID, Name: "test", Type: "http",
+				// Variables saved by the hooks of every enclosing group are visible
+				// to requests and assertions
+				HTTP: map[string]interface{}{"method": "GET", "path": "/ok",
+					"headers": map[string]interface{}{"Authorization": "Bearer {{token}}"}},
+				Assertions: []interface{}{
+					map[string]interface{}{"type": "status_code", "expected": 200},
+					map[string]interface{}{"type": "expr", "expression": "token == 't-1'"},
+				},
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	createGroup(&service.CreateTestGroupRequest{
+		GroupID: "suite", Name: "Suite", TargetHost: server.URL,
+		SetupHooks:    []interface{}{hook("login", "/login", true)},
+		TeardownHooks: []interface{}{hook("logout", "/logout", false)},
+	}, 2)
+	createGroup(&service.CreateTestGroupRequest{GroupID: "suite-users", Name: "Users", ParentID: "suite"}, 2)
+	createGroup(&service.CreateTestGroupRequest{GroupID: "suite-users-admin", Name: "Admin", ParentID: "suite-users"}, 1)
+	createGroup(&service.CreateTestGroupRequest{
+		GroupID: "suite-broken", Name: "Broken", ParentID: "suite",
+		SetupHooks:    []interface{}{hook("seed", "/fail", false)},
+		TeardownHooks: []interface{}{hook("cleanup", "/cleanup", false)},
+	}, 2)
+
+	wait := func(run *models.TestRun) *models.TestRun {
+		var done *models.TestRun
+		require.Eventually(t, func() bool {
+			got, err := svc.GetTestRun(ctx, run.RunID, tenant, project)
+			require.NoError(t, err)
+			done = got
+			return got.Status != "running"
+		}, 10*time.Second, 20*time.Millisecond)
+		return done
+	}
+
+	run, err := svc.ExecuteTestGroup(ctx, "suite", tenant, project, &service.ExecuteTestGroupRequest{Recursive: true})
+	require.NoError(t, err)
+	assert.Equal(t, 7, run.Total)
+
+	run = wait(run)
+	assert.Equal(t, "completed", run.Status)
+	assert.Equal(t, 5, run.Passed)
+	assert.Equal(t, 2, run.Errors, "tests under a group whose setup failed")
+	for _, result := range run.Results {
+		if result.Status == "error" {
+			assert.Contains(t, result.Error, "group suite-broken: setup hook 'seed' failed")
+		}
+	}
+
+	// Hooks run once per group, and teardown runs even when setup failed
+	assert.Equal(t, int32(1), hitCount("/login"))
+	assert.Equal(t, int32(1), hitCount("/logout"))
+	assert.Equal(t, int32(1), hitCount("/cleanup"))
+	assert.Equal(t, int32(5), hitCount("/ok"))
+
+	// Without recursion only the group's own tests run, still within its hooks
+	run, err = svc.ExecuteTestGroup(ctx, "suite", tenant, project, nil)
+	require.NoError(t, err)
+	run = wait(run)
+	assert.Equal(t, 2, run.Total)
+	assert.Equal(t, 2, run.Passed)
+	assert.Equal(t, int32(2), hitCount("/login"))
+}
+
+// recordingPublisher records the events published for test runs
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []websocket.Message
+}
+
+func (p *recordingPublisher) Broadcast(runID string, msgType string, payload interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, websocket.Message{RunID: runID, Type: msgType, Payload: payload})
+}
+
+func (p *recordingPublisher) snapshot() []websocket.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]websocket.Message(nil), p.events...)
+}
+
+func TestExecuteTestGroup_Events(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	server := httptest.NewServer(&concurrencyServer{})
+	defer server.Close()
+
+	svc := service.NewTestService(repository.NewTestCaseRepository(db), repository.NewTestGroupRepository(db),
+		repository.NewTestResultRepository(db), repository.NewTestRunRepository(db), testcase.NewExecutor(""))
+	publisher := &recordingPublisher{}
+	if ts, ok := svc.(interface {
+		SetRunPublisher(service.TestRunPublisher)
+	}); ok {
+		ts.SetRunPublisher(publisher)
+	}
+	ctx := context.Background()
+	const tenant, project = "default", "default"
+
+	_, err := svc.CreateTestGroup(ctx, tenant, project, &service.CreateTestGroupRequest{
+		GroupID: "events", Name: "events", TargetHost: server.URL,
+	})
+	require.NoError(t, err)
+	for i, path := range []string{"/ok", "/ok", "/fail"} {
+		_, err := svc.CreateTestCase(ctx, tenant, project, &service.CreateTestCaseRequest{
+			TestID: fmt.Sprintf("events-%d", i), GroupID: "events", Name: path, Type: "http",
+			HTTP:       map[string]interface{}{"method": "GET", "path": path},
+			Assertions: []interface{}{map[string]interface{}{"type": "status_code", "expected": 200}},
+		})
+		require.NoError(t, err)
+	}
+
+	run, err := svc.ExecuteTestGroup(ctx, "events", tenant, project, nil)
+	require.NoError(t, err)
+
+	var events []websocket.Message
+	require.Eventually(t, func() bool {
+		events = publisher.snapshot()
+		return len(events) > 0 && events[len(events)-1].Type == "run_complete"
+	}, 10*time.Second, 20*time.Millisecond)
+
+	counts := map[string]int{}
+	for _, event := range events {
+		assert.Equal(t, run.RunID, event.RunID)
+		counts[event.Type]++
+	}
+	assert.Equal(t, "run_start", events[0].Type)
+	assert.Equal(t, 3, events[0].Payload.(map[string]interface{})["total"])
+	assert.Equal(t, map[string]int{
+		"run_start": 1, "test_start": 3, "test_complete": 3, "run_progress": 3, "run_complete": 1,
+	}, counts)
+
+	var failed map[string]interface{}
+	for _, event := range events {
+		payload := event.Payload.(map[string]interface{})
+		if event.Type == "test_complete" && payload["status"] == "failed" {
+			failed = payload
+		}
+	}
+	require.NotNil(t, failed, "failed test not streamed")
+	assert.Equal(t, "events-2", failed["testId"])
+	assert.NotEmpty(t, failed["failures"])
+
+	progress := events[len(events)-2].Payload.(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"total": 3, "finished": 3, "passed": 2, "failed": 1, "errors": 0, "skipped": 0, "quarantined": 0,
+	}, progress)
+	final := events[len(events)-1].Payload.(map[string]interface{})
+	assert.Equal(t, "completed", final["status"])
+	assert.Equal(t, 2, final["passed"])
+	assert.Equal(t, 1, final["failed"])
+}
+
+func TestRerunTestRun(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	// /flaky fails on its first request only
+	var flakyCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/fail", r.URL.Path == "/flaky" && flakyCalls.Add(1) == 1:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	svc := service.NewTestService(repository.NewTestCaseRepository(db), repository.NewTestGroupRepository(db),
+		repository.NewTestResultRepository(db), repository.NewTestRunRepository(db), testcase.NewExecutor(""))
+	ctx := context.Background()
+	const tenant, project = "default", "default"
+
+	_, err := svc.CreateTestGroup(ctx, tenant, project, &service.CreateTestGroupRequest{
+		GroupID: "rerun", Name: "rerun", TargetHost: server.URL,
+	})
+	require.NoError(t, err)
+	for i, path := range []string{"/ok", "/flaky", "/fail"} {
+		_, err := svc.CreateTestCase(ctx, tenant, project, &service.CreateTestCaseRequest{
+			TestID: fmt.Sprintf("rerun-%d", i), GroupID: "rerun", Name: path, Type: "http",
+			HTTP:       map[string]interface{}{"method": "GET", "path": path},
+			Assertions: []interface{}{map[string]interface{}{"type": "status_code", "expected": 200}},
+		})
+		require.NoError(t, err)
+	}
+	wait := func(run *models.TestRun) *models.TestRun {
+		var done *models.TestRun
+		require.Eventually(t, func() bool {
+			got, err := svc.GetTestRun(ctx, run.RunID, tenant, project)
+			require.NoError(t, err)
+			done = got
+			return got.Status != "running"
+		}, 10*time.Second, 20*time.Millisecond)
+		return done
+	}
+
+	original, err := svc.ExecuteTestGroup(ctx, "rerun", tenant, project, &service.ExecuteTestGroupRequest{Concurrency: 2})
+	require.NoError(t, err)
+	original = wait(original)
+	require.Equal(t, 1, original.Passed)
+	require.Equal(t, 2, original.Failed)
+
+	_, err = svc.RerunTestRun(ctx, original.RunID, tenant, project, "flaky")
+	assert.ErrorIs(t, err, apierrors.ErrInvalidInput, "unknown filter")
+	_, err = svc.RerunTestRun(ctx, original.RunID, tenant, project, service.RerunErrored)
+	assert.ErrorIs(t, err, apierrors.ErrInvalidInput, "no errored tests")
+	_, err = svc.RerunTestRun(ctx, "missing", tenant, project, "")
+	assert.ErrorIs(t, err, apierrors.ErrNotFound)
+	_, err = svc.GetMergedTestRun(ctx, "missing", tenant, project)
+	assert.ErrorIs(t, err, apierrors.ErrNotFound)
+
+	rerun, err := svc.RerunTestRun(ctx, original.RunID, tenant, project, "")
+	require.NoError(t, err)
+	assert.Equal(t, original.RunID, rerun.ParentRunID)
+	assert.Equal(t, 2, rerun.Total, "only the failed tests rerun")
+	assert.Equal(t, 2, rerun.Concurrency, "options of the original run")
+
+	rerun = wait(rerun)
+	assert.Equal(t, 1, rerun.Passed)
+	assert.Equal(t, 1, rerun.Failed)
+
+	merged, err := svc.GetMergedTestRun(ctx, rerun.RunID, tenant, project)
+	require.NoError(t, err)
+	assert.Equal(t, original.RunID, merged.OriginalRunID)
+	assert.Equal(t, []string{rerun.RunID}, merged.Reruns)
+	assert.Equal(t, 3, merged.Total)
+	assert.Equal(t, 2, merged.Passed)
+	assert.Equal(t, 1, merged.Failed)
+	assert.Equal(t, 1, merged.PassedOnRetry)
+
+	byID := map[string]service.MergedTestResult{}
+	for _, result := range merged.Results {
+		byID[result.TestID] = result
+	}
+	assert.Equal(t, 1, byID["rerun-0"].Attempts)
+	assert.False(t, byID["rerun-0"].PassedOnRetry)
+	assert.True(t, byID["rerun-1"].PassedOnRetry)
+	assert.Equal(t, "failed", byID["rerun-1"].OriginalStatus)
+	assert.Equal(t, 2, byID["rerun-2"].Attempts)
+	assert.Equal(t, "failed", byID["rerun-2"].Status)
+	assert.NotEmpty(t, byID["rerun-2"].Failures)
+}
+
+func TestFlakyAnalyzer(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	// Each path answers with the next status of its script
+	scripts := map[string][]int{
+		"/random": {200, 500, 200, 500, 200, 500},
+		"/broken": {200, 200, 200, 500, 500, 500},
+		"/retry":  {500, 200},
+	}
+	var mu sync.Mutex
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		script := scripts[r.URL.Path]
+		status := script[calls[r.URL.Path]%len(script)]
+		calls[r.URL.Path]++
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	svc := service.NewTestService(repository.NewTestCaseRepository(db), repository.NewTestGroupRepository(db),
+		repository.NewTestResultRepository(db), repository.NewTestRunRepository(db), testcase.NewExecutor(""))
+	ctx := context.Background()
+	const tenant, project = "default", "default"
+
+	_, err := svc.CreateTestGroup(ctx, tenant, project, &service.CreateTestGroupRequest{
+		GroupID: "flaky", Name: "flaky", TargetHost: server.URL,
+	})
+	require.NoError(t, err)
+	for _, path := range []string{"/random", "/broken", "/retry"} {
+		_, err := svc.CreateTestCase(ctx, tenant, project, &service.CreateTestCaseRequest{
+			TestID: "flaky" + strings.ReplaceAll(path, "/", "-"), GroupID: "flaky", Name: path, Type: "http",
+			HTTP:       map[string]interface{}{"method": "GET", "path": path},
+			Assertions: []interface{}{map[string]interface{}{"type": "status_code", "expected": 200}},
+		})
+		require.NoError(t, err)
+	}
+	executeTimes := func(testID string, n int) {
+		for i := 0; i < n; i++ {
+			_, err := svc.ExecuteTest(ctx, testID, tenant, project)
+			require.NoError(t, err)
+		}
+	}
+
+	t.Run("alternating results are flaky", func(t *testing.T) {
+		executeTimes("flaky-random", 6)
+
+		tc, err := svc.GetTestCase(ctx, "flaky-random", tenant, project)
+		require.NoError(t, err)
+		assert.Equal(t, 6, tc.ExecutionCount)
+		assert.Equal(t, 3, tc.SuccessCount)
+		assert.Equal(t, 3, tc.FailureCount)
+		assert.Equal(t, 50, tc.SuccessRate)
+		assert.Equal(t, 1, tc.ConsecutiveFailures)
+		assert.NotNil(t, tc.LastRunAt)
+		assert.NotNil(t, tc.LastSuccessAt)
+		assert.NotNil(t, tc.LastFailureAt)
+		assert.True(t, tc.IsFlaky)
+		assert.Equal(t, 50, tc.FlakyScore)
+
+		report, err := svc.GetTestFlakiness(ctx, "flaky-random", tenant, project)
+		require.NoError(t, err)
+		assert.Equal(t, tc.FlakyScore, report.Score)
+		assert.Equal(t, 6, report.Window)
+		assert.Equal(t, 5, report.Flips)
+		assert.Equal(t, 1, report.DistinctErrors, "failures differ only in volatile details")
+		require.Len(t, report.History, 6)
+		assert.Equal(t, "failed", report.History[0].Status, "newest first")
+		assert.True(t, report.History[0].Flipped)
+	})
+
+	t.Run("a test that breaks and stays broken is not flaky", func(t *testing.T) {
+		executeTimes("flaky-broken", 6)
+
+		tc, err := svc.GetTestCase(ctx, "flaky-broken", tenant, project)
+		require.NoError(t, err)
+		assert.Equal(t, 3, tc.ConsecutiveFailures)
+		assert.False(t, tc.IsFlaky)
+		assert.Equal(t, 10, tc.FlakyScore) // one flip in five pairs
+	})
+
+	t.Run("failures passing on retry are reported", func(t *testing.T) {
+		run, err := svc.ExecuteTestGroup(ctx, "flaky", tenant, project, nil)
+		require.NoError(t, err)
+		waitRun := func(runID string) {
+			require.Eventually(t, func() bool {
+				got, err := svc.GetTestRun(ctx, runID, tenant, project)
+				require.NoError(t, err)
+				return got.Status != "running"
+			}, 10*time.Second, 20*time.Millisecond)
+		}
+		waitRun(run.RunID)
+
+		rerun, err := svc.RerunTestRun(ctx, run.RunID, tenant, project, service.RerunFailed)
+		require.NoError(t, err)
+		waitRun(rerun.RunID)
+
+		report, err := svc.GetTestFlakiness(ctx, "flaky-retry", tenant, project)
+		require.NoError(t, err)
+		require.Len(t, report.History, 2)
+		assert.Equal(t, 1, report.Retries)
+		assert.Equal(t, 1, report.RetryPasses)
+		assert.True(t, report.History[0].PassedOnRetry)
+		assert.Equal(t, report.History[1].ResultID, report.History[0].RetryOf)
+		assert.Equal(t, 0, report.Score, "too few results to score")
+	})
+
+	_, err = svc.GetTestFlakiness(ctx, "missing", tenant, project)
+	assert.ErrorIs(t, err, apierrors.ErrNotFound)
+}
+
+func TestQuarantine(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	// /unstable fails twice, then passes
+	var unstableCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unstable" && unstableCalls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	svc := service.NewTestService(repository.NewTestCaseRepository(db), repository.NewTestGroupRepository(db),
+		repository.NewTestResultRepository(db), repository.NewTestRunRepository(db), testcase.NewExecutor(""))
+	if ts, ok := svc.(interface{ SetQuarantineReleasePasses(int) }); ok {
+		ts.SetQuarantineReleasePasses(2)
+	}
+	ctx := context.Background()
+	const tenant, project = "default", "default"
+
+	_, err := svc.CreateTestGroup(ctx, tenant, project, &service.CreateTestGroupRequest{
+		GroupID: "quarantine", Name: "quarantine", TargetHost: server.URL,
+	})
+	require.NoError(t, err)
+	for i, path := range []string{"/ok", "/unstable"} {
+		_, err := svc.CreateTestCase(ctx, tenant, project, &service.CreateTestCaseRequest{
+			TestID: fmt.Sprintf("quarantine-%d", i), GroupID: "quarantine", Name: path, Type: "http",
+			HTTP:       map[string]interface{}{"method": "GET", "path": path},
+			Assertions: []interface{}{map[string]interface{}{"type": "status_code", "expected": 200}},
+		})
+		require.NoError(t, err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	_, err = svc.QuarantineTestCase(ctx, "quarantine-1", tenant, project, &service.QuarantineTestCaseRequest{
+		Reason: "flaky", ExpiresAt: &past,
+	})
+	assert.ErrorIs(t, err, apierrors.ErrInvalidInput, "expiry in the past")
+	_, err = svc.QuarantineTestCase(ctx, "missing", tenant, project, &service.QuarantineTestCaseRequest{Reason: "flaky"})
+	assert.ErrorIs(t, err, apierrors.ErrNotFound)
+
+	tc, err := svc.QuarantineTestCase(ctx, "quarantine-1", tenant, project, &service.QuarantineTestCaseRequest{
+		Reason: "times out under load", Owner: "alice",
+	})
+	require.NoError(t, err)
+	assert.True(t, tc.Quarantined)
+	assert.NotNil(t, tc.QuarantinedAt)
+
+	run, err := svc.ExecuteTestGroup(ctx, "quarantine", tenant, project, &service.ExecuteTestGroupRequest{FailFast: true})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		run, err = svc.GetTestRun(ctx, run.RunID, tenant, project)
+		require.NoError(t, err)
+		return run.Status != "running"
+	}, 10*time.Second, 20*time.Millisecond)
+
+	assert.Equal(t, "completed", run.Status)
+	assert.Equal(t, 1, run.Passed)
+	assert.Equal(t, 0, run.Failed, "quarantined failure does not fail the run")
+	assert.Equal(t, 1, run.Quarantined)
+	for _, result := range run.Results {
+		assert.Equal(t, result.TestID == "quarantine-1", result.Quarantined, result.TestID)
+	}
+
+	// A failure resets the passes; two passes in a row release the test
+	_, err = svc.ExecuteTest(ctx, "quarantine-1", tenant, project)
+	require.NoError(t, err)
+	result, err := svc.ExecuteTest(ctx, "quarantine-1", tenant, project)
+	require.NoError(t, err)
+	assert.True(t, result.Quarantined)
+	tc, err = svc.GetTestCase(ctx, "quarantine-1", tenant, project)
+	require.NoError(t, err)
+	assert.True(t, tc.Quarantined)
+	assert.Equal(t, 1, tc.QuarantinePasses)
+	assert.Equal(t, "alice", tc.QuarantineOwner)
+
+	_, err = svc.ExecuteTest(ctx, "quarantine-1", tenant, project)
+	require.NoError(t, err)
+	tc, err = svc.GetTestCase(ctx, "quarantine-1", tenant, project)
+	require.NoError(t, err)
+	assert.False(t, tc.Quarantined, "released after consecutive passes")
+	assert.Empty(t, tc.QuarantineReason)
+
+	result, err = svc.ExecuteTest(ctx, "quarantine-1", tenant, project)
+	require.NoError(t, err)
+	assert.False(t, result.Quarantined)
+
+	// Manual release
+	_, err = svc.QuarantineTestCase(ctx, "quarantine-0", tenant, project, &service.QuarantineTestCaseRequest{Reason: "flaky"})
+	require.NoError(t, err)
+	tc, err = svc.ReleaseTestCase(ctx, "quarantine-0", tenant, project)
+	require.NoError(t, err)
+	assert.False(t, tc.Quarantined)
+}
+
+func TestUpdateTestCase_KeepsRecordedState(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	caseRepo := repository.NewTestCaseRepository(db)
+	svc := service.NewTestService(caseRepo, repository.NewTestGroupRepository(db),
+		repository.NewTestResultRepository(db), repository.NewTestRunRepository(db), testcase.NewExecutor(server.URL))
+	ctx := context.Background()
+	const tenant, project = "default", "default"
+
+	_, err := svc.CreateTestCase(ctx, tenant, project, &service.CreateTestCaseRequest{
+		TestID: "edited", GroupID: "edits", Name: "edited", Type: "http", Serial: true,
+		HTTP: map[string]interface{}{"method": "GET", "path": "/"},
+	})
+	require.NoError(t, err)
+	_, err = svc.QuarantineTestCase(ctx, "edited", tenant, project, &service.QuarantineTestCaseRequest{Reason: "flaky"})
+	require.NoError(t, err)
+
+	// An edit saved from a copy loaded before a run keeps what the run recorded
+	stale, err := caseRepo.FindByIDWithTenant(ctx, "edited", tenant, project)
+	require.NoError(t, err)
+	_, err = svc.ExecuteTest(ctx, "edited", tenant, project)
+	require.NoError(t, err)
+	stale.Name = "renamed"
+	stale.Serial = false
+	require.NoError(t, caseRepo.UpdateWithTenant(ctx, stale))
+
+	tc, err := svc.GetTestCase(ctx, "edited", tenant, project)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", tc.Name)
+	assert.False(t, tc.Serial, "flags can be cleared")
+	assert.Equal(t, 1, tc.ExecutionCount)
+	assert.Equal(t, 1, tc.QuarantinePasses)
+	assert.True(t, tc.Quarantined)
+}
@@ -4,15 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"regexp"
-	"strconv"
-	"strings"
+
+	"test-management-service/internal/expression"
 	"test-management-service/internal/testcase"
 )
 
 // VariableInjector 变量注入器
 type VariableInjector struct {
 	envService EnvironmentService
+	strict     bool // 严格模式：无法解析的占位符报错而不是原样保留
 }
 
 // NewVariableInjector 创建变量注入器
@@ -22,6 +22,11 @@ func NewVariableInjector(envService EnvironmentService) *VariableInjector {
 	}
 }
 
+// SetStrict 设置严格模式，开启后无法解析的 {{ }} 占位符会导致注入失败
+func (vi *VariableInjector) SetStrict(strict bool) {
+	vi.strict = strict
+}
+
 // GetActiveEnvironmentVariables 获取激活环境的变量
 func (vi *VariableInjector) GetActiveEnvironmentVariables(ctx context.Context, tenantID, projectID string) (map[string]string, error) {
 	activeEnv, err := vi.envService.GetActiveEnvironment(ctx, tenantID, projectID)
@@ -54,7 +59,7 @@ func (vi *VariableInjector) InjectVariables(
 	activeEnv, err := vi.envService.GetActiveEnvironment(ctx, tenantID, projectID)
 	if err != nil {
 		// 如果没有激活环境，使用空变量集
-		return vi.injectWithVars(config, make(map[string]interface{}), workflowVars), nil
+		return vi.injectWithVars(config, make(map[string]interface{}), workflowVars)
 	}
 
 	envVars := activeEnv.Variables
@@ -63,7 +68,38 @@ func (vi *VariableInjector) InjectVariables(
 	}
 
 	// 2. 执行变量注入
-	return vi.injectWithVars(config, envVars, workflowVars), nil
+	return vi.injectWithVars(config, envVars, workflowVars)
+}
+
+// GetExecutionVariables 获取执行使用的变量: 指定环境（未指定时为激活环境）的变量,
+// 被执行参数中的变量覆盖
+func (vi *VariableInjector) GetExecutionVariables(ctx context.Context, params *testcase.ExecutionParams) (map[string]interface{}, error) {
+	var envVars map[string]interface{}
+	if params.EnvironmentID == "" {
+		// 没有激活环境时只使用执行参数中的变量
+		if activeEnv, err := vi.envService.GetActiveEnvironment(ctx, params.TenantID, params.ProjectID); err == nil {
+			envVars = activeEnv.Variables
+		}
+	} else {
+		env, err := vi.envService.GetEnvironment(ctx, params.EnvironmentID, params.TenantID, params.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if env == nil {
+			return nil, fmt.Errorf("environment not found: %s", params.EnvironmentID)
+		}
+		envVars = env.Variables
+	}
+	return vi.mergeVariables(envVars, params.Variables), nil
+}
+
+// injectExecution 按执行参数注入变量
+func (vi *VariableInjector) injectExecution(ctx context.Context, params *testcase.ExecutionParams, config interface{}) (interface{}, error) {
+	vars, err := vi.GetExecutionVariables(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return vi.replaceVariables(config, vars)
 }
 
 // injectWithVars 使用指定的变量集进行注入
@@ -71,7 +107,7 @@ func (vi *VariableInjector) injectWithVars(
 	config interface{},
 	envVars map[string]interface{},
 	workflowVars map[string]interface{},
-) interface{} {
+) (interface{}, error) {
 	// 合并变量（优先级: envVars < workflowVars）
 	mergedVars := vi.mergeVariables(envVars, workflowVars)
 
@@ -102,92 +138,24 @@ func (vi *VariableInjector) mergeVariables(
 }
 
 // replaceVariables 递归替换所有变量占位符
+// 占位符与工作流使用同一表达式引擎：支持嵌套路径 {{user.id}}、运算和 $函数；
+// 整个字符串就是一个占位符时保留值的类型
 func (vi *VariableInjector) replaceVariables(
 	value interface{},
 	vars map[string]interface{},
-) interface{} {
-	switch v := value.(type) {
-	case string:
-		return vi.replaceStringVariables(v, vars)
-
-	case map[string]interface{}:
-		result := make(map[string]interface{})
-		for key, val := range v {
-			result[key] = vi.replaceVariables(val, vars)
-		}
-		return result
-
-	case []interface{}:
-		result := make([]interface{}, len(v))
-		for i, val := range v {
-			result[i] = vi.replaceVariables(val, vars)
-		}
-		return result
-
-	default:
-		return value
-	}
-}
-
-// replaceStringVariables 替换字符串中的变量占位符
-// 支持格式: {{VAR_NAME}}
-func (vi *VariableInjector) replaceStringVariables(
-	str string,
-	vars map[string]interface{},
-) interface{} {
-	// 正则匹配 {{VAR_NAME}}
-	re := regexp.MustCompile(`\{\{([a-zA-Z0-9_]+)\}\}`)
-
-	matches := re.FindAllStringSubmatch(str, -1)
-	if len(matches) == 0 {
-		return str
-	}
-
-	// 如果整个字符串就是一个变量引用，直接返回变量值（保持类型）
-	if len(matches) == 1 && matches[0][0] == str {
-		varName := matches[0][1]
-		if val, exists := vars[varName]; exists {
-			return val
-		}
-		return str // 变量不存在，返回原字符串
-	}
-
-	// 字符串包含多个变量或混合内容，替换为字符串
-	result := str
-	for _, match := range matches {
-		placeholder := match[0] // {{VAR_NAME}}
-		varName := match[1]     // VAR_NAME
-
-		if val, exists := vars[varName]; exists {
-			// 将变量值转换为字符串
-			replacement := vi.valueToString(val)
-			result = strings.ReplaceAll(result, placeholder, replacement)
-		}
-	}
-
-	return result
+) (interface{}, error) {
+	evaluator := expression.NewEvaluator(vars, nil)
+	evaluator.SetStrict(vi.strict)
+	return evaluator.InterpolateValue(value)
 }
 
 // valueToString 将任意值转换为字符串
 func (vi *VariableInjector) valueToString(value interface{}) string {
-	switch v := value.(type) {
-	case string:
-		return v
-	case int, int32, int64:
-		return fmt.Sprintf("%d", v)
-	case float32, float64:
-		return strconv.FormatFloat(v.(float64), 'f', -1, 64)
-	case bool:
-		return strconv.FormatBool(v)
-	default:
-		// 复杂类型转为 JSON 字符串
-		bytes, _ := json.Marshal(v)
-		return string(bytes)
-	}
+	return expression.Text(value)
 }
 
 // InjectHTTPVariables 注入变量到 HTTP 配置
-func (vi *VariableInjector) InjectHTTPVariables(ctx context.Context, tenantID, projectID string, httpConfig *testcase.HTTPTest) error {
+func (vi *VariableInjector) InjectHTTPVariables(ctx context.Context, params *testcase.ExecutionParams, httpConfig *testcase.HTTPTest) error {
 	if httpConfig == nil {
 		return nil
 	}
@@ -195,13 +163,34 @@ func (vi *VariableInjector) InjectHTTPVariables(ctx context.Context, tenantID, p
 	// Convert HTTP config to map
 	configMap := map[string]interface{}{
 		"method":  httpConfig.Method,
+		"url":     httpConfig.URL,
 		"path":    httpConfig.Path,
-		"headers": httpConfig.Headers,
+		"query":   stringMapToInterface(httpConfig.Query),
+		"headers": stringMapToInterface(httpConfig.Headers),
 		"body":    httpConfig.Body,
+		"rawBody": httpConfig.RawBody,
+		"form":    stringMapToInterface(httpConfig.Form),
+		"proxy":   httpConfig.Proxy,
+	}
+	if httpConfig.Multipart != nil {
+		configMap["multipartFields"] = stringMapToInterface(httpConfig.Multipart.Fields)
+	}
+	if httpConfig.Auth != nil {
+		// 认证配置中的密钥通过 {{VAR}} 从环境变量注入
+		authMap, err := structToMap(httpConfig.Auth)
+		if err != nil {
+			return fmt.Errorf("failed to convert auth config: %w", err)
+		}
+		// JWT claims are rendered when the token is signed, where {{now}},
+		// {{exp}} and {{jti}} are defined
+		if jwt, ok := authMap["jwt"].(map[string]interface{}); ok {
+			delete(jwt, "claims")
+		}
+		configMap["auth"] = authMap
 	}
 
 	// Inject variables
-	injected, err := vi.InjectVariables(ctx, tenantID, projectID, configMap, nil)
+	injected, err := vi.injectExecution(ctx, params, configMap)
 	if err != nil {
 		return err
 	}
@@ -211,30 +200,96 @@ func (vi *VariableInjector) InjectHTTPVariables(ctx context.Context, tenantID, p
 	if method, ok := injectedMap["method"].(string); ok {
 		httpConfig.Method = method
 	}
+	if url, ok := injectedMap["url"].(string); ok {
+		httpConfig.URL = url
+	}
 	if path, ok := injectedMap["path"].(string); ok {
 		httpConfig.Path = path
 	}
-	if headers, ok := injectedMap["headers"].(map[string]interface{}); ok {
-		// Convert map[string]interface{} to map[string]string
-		stringHeaders := make(map[string]string)
-		for k, v := range headers {
-			if str, ok := v.(string); ok {
-				stringHeaders[k] = str
-			} else {
-				stringHeaders[k] = vi.valueToString(v)
-			}
-		}
-		httpConfig.Headers = stringHeaders
+	if query, ok := injectedMap["query"].(map[string]interface{}); ok && httpConfig.Query != nil {
+		httpConfig.Query = vi.toStringMap(query)
+	}
+	if headers, ok := injectedMap["headers"].(map[string]interface{}); ok && httpConfig.Headers != nil {
+		httpConfig.Headers = vi.toStringMap(headers)
 	}
 	if body, ok := injectedMap["body"].(map[string]interface{}); ok {
 		httpConfig.Body = body
 	}
+	if rawBody, ok := injectedMap["rawBody"]; ok && httpConfig.RawBody != "" {
+		httpConfig.RawBody = vi.valueToString(rawBody)
+	}
+	if form, ok := injectedMap["form"].(map[string]interface{}); ok && httpConfig.Form != nil {
+		httpConfig.Form = vi.toStringMap(form)
+	}
+	if proxy, ok := injectedMap["proxy"].(string); ok {
+		httpConfig.Proxy = proxy
+	}
+	if fields, ok := injectedMap["multipartFields"].(map[string]interface{}); ok && httpConfig.Multipart.Fields != nil {
+		httpConfig.Multipart.Fields = vi.toStringMap(fields)
+	}
+	if auth, ok := injectedMap["auth"].(map[string]interface{}); ok {
+		var injectedAuth testcase.AuthConfig
+		if err := mapToStruct(auth, &injectedAuth); err != nil {
+			return fmt.Errorf("failed to convert auth config: %w", err)
+		}
+		if injectedAuth.JWT != nil && httpConfig.Auth.JWT != nil {
+			injectedAuth.JWT.Claims = httpConfig.Auth.JWT.Claims
+		}
+		httpConfig.Auth = &injectedAuth
+	}
 
 	return nil
 }
 
+// toStringMap 将 map[string]interface{} 转换为 map[string]string
+func (vi *VariableInjector) toStringMap(m map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		if str, ok := v.(string); ok {
+			result[k] = str
+		} else {
+			result[k] = vi.valueToString(v)
+		}
+	}
+	return result
+}
+
+// structToMap 将结构体通过 JSON 转换为 map，便于递归替换变量
+func structToMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mapToStruct 将 map 通过 JSON 转换回结构体
+func mapToStruct(m map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// stringMapToInterface 将 map[string]string 转换为可递归替换的 map[string]interface{}
+func stringMapToInterface(m map[string]string) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
 // InjectCommandVariables 注入变量到命令配置
-func (vi *VariableInjector) InjectCommandVariables(ctx context.Context, tenantID, projectID string, commandConfig *testcase.CommandTest) error {
+func (vi *VariableInjector) InjectCommandVariables(ctx context.Context, params *testcase.ExecutionParams, commandConfig *testcase.CommandTest) error {
 	if commandConfig == nil {
 		return nil
 	}
@@ -246,7 +301,7 @@ func (vi *VariableInjector) InjectCommandVariables(ctx context.Context, tenantID
 	}
 
 	// Inject variables
-	injected, err := vi.InjectVariables(ctx, tenantID, projectID, configMap, nil)
+	injected, err := vi.injectExecution(ctx, params, configMap)
 	if err != nil {
 		return err
 	}
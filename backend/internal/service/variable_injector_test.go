@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"test-management-service/internal/expression"
+	"test-management-service/internal/repository"
+	"test-management-service/internal/service"
+	"test-management-service/internal/testcase"
+	"test-management-service/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariableInjector(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(db)
+
+	envs := service.NewEnvironmentService(repository.NewEnvironmentRepository(db), repository.NewEnvironmentVariableRepository(db))
+	injector := service.NewVariableInjector(envs)
+	ctx := context.Background()
+	const tenant, project = "default", "default"
+
+	_, err := envs.CreateEnvironment(ctx, tenant, project, &service.CreateEnvironmentRequest{
+		EnvID: "staging", Name: "Staging",
+		Variables: map[string]interface{}{
+			"baseUrl": "https://staging.example.com",
+			"user":    map[string]interface{}{"id": 42, "name": "alice"},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, envs.ActivateEnvironment(ctx, "staging", tenant, project))
+
+	config := map[string]interface{}{
+		"url":     "{{baseUrl}}/users/{{user.id}}",
+		"userId":  "{{user.id}}",
+		"headers": map[string]interface{}{"X-User": "{{$upper(user.name)}}"},
+		"args":    []interface{}{"{{region}}", "--retry={{retries}}"},
+	}
+
+	injected, err := injector.InjectVariables(ctx, tenant, project, config, map[string]interface{}{"region": "eu"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"url":     "https://staging.example.com/users/42",
+		"userId":  float64(42), // whole placeholders keep the value's type
+		"headers": map[string]interface{}{"X-User": "ALICE"},
+		"args":    []interface{}{"eu", "--retry={{retries}}"},
+	}, injected)
+
+	// Strict mode fails on the reference that cannot be resolved
+	injector.SetStrict(true)
+	_, err = injector.InjectVariables(ctx, tenant, project, config, map[string]interface{}{"region": "eu"})
+	var unresolved *expression.UnresolvedError
+	require.True(t, errors.As(err, &unresolved), "error = %v", err)
+	assert.Equal(t, "{{retries}}", unresolved.Placeholder)
+	assert.Contains(t, err.Error(), "args: [1]: unresolved placeholder {{retries}}")
+
+	// A run may pick an environment other than the active one and override
+	// its variables
+	injector.SetStrict(false)
+	_, err = envs.CreateEnvironment(ctx, tenant, project, &service.CreateEnvironmentRequest{
+		EnvID: "prod", Name: "Production",
+		Variables: map[string]interface{}{"baseUrl": "https://example.com", "token": "prod-token"},
+	})
+	require.NoError(t, err)
+	httpConfig := &testcase.HTTPTest{
+		Path:    "{{baseUrl}}/health",
+		Headers: map[string]string{"Authorization": "Bearer {{token}}"},
+	}
+	require.NoError(t, injector.InjectHTTPVariables(ctx, &testcase.ExecutionParams{
+		TenantID: tenant, ProjectID: project, EnvironmentID: "prod",
+		Variables: map[string]interface{}{"token": "plan-token"},
+	}, httpConfig))
+	assert.Equal(t, "https://example.com/health", httpConfig.Path)
+	assert.Equal(t, "Bearer plan-token", httpConfig.Headers["Authorization"])
+
+	err = injector.InjectHTTPVariables(ctx, &testcase.ExecutionParams{
+		TenantID: tenant, ProjectID: project, EnvironmentID: "missing",
+	}, &testcase.HTTPTest{Path: "/"})
+	assert.Error(t, err)
+
+	// JWT claims may use {{now}}, {{exp}} and {{jti}} in strict mode
+	var claims map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(token, ".")
+		if len(parts) == 3 {
+			data, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			_ = json.Unmarshal(data, &claims)
+		}
+	}))
+	defer server.Close()
+
+	injector.SetStrict(true)
+	executor := testcase.NewExecutorWithInjector(server.URL, nil, nil, nil, injector).WithExecutionParams(&testcase.ExecutionParams{
+		TenantID: tenant, ProjectID: project, EnvironmentID: "staging",
+	})
+	executor.SetStrictVariables(true)
+	result := executor.Execute(&testcase.TestCase{ID: "jwt", Type: "http", HTTP: &testcase.HTTPTest{
+		Method: "GET", Path: "/",
+		Auth: &testcase.AuthConfig{Type: testcase.AuthTypeJWT, JWT: &testcase.JWTConfig{
+			Secret: "s3cret",
+			Claims: map[string]interface{}{"sub": "{{user.name}}", "nbf": "{{now}}", "jti": "{{jti}}"},
+		}},
+	}})
+	require.Equal(t, "passed", result.Status, result.Error)
+	assert.Equal(t, "alice", claims["sub"])
+	assert.Equal(t, claims["iat"], claims["nbf"])
+	assert.NotEmpty(t, claims["jti"])
+}
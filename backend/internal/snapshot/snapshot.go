@@ -0,0 +1,178 @@
+// Package snapshot normalizes and diffs recorded response snapshots.
+package snapshot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"test-management-service/internal/jsonpath"
+)
+
+// DefaultName is the snapshot name used when an assertion does not set one
+const DefaultName = "default"
+
+// IgnoredValue replaces values at ignored paths, so volatile fields still
+// have to be present but may change freely
+const IgnoredValue = "<ignored>"
+
+// Outcomes of comparing a run against the approved snapshot
+const (
+	StatusRecorded = "recorded" // first run, stored as the approved version
+	StatusMatched  = "matched"
+	StatusChanged  = "changed" // stored as a pending version awaiting approval
+)
+
+// Change operations
+const (
+	OpAdded   = "added"
+	OpRemoved = "removed"
+	OpChanged = "changed"
+)
+
+// Key identifies a snapshot of a test case
+type Key struct {
+	TenantID  string
+	ProjectID string
+	TestID    string
+	Name      string
+}
+
+// Change is one difference between the approved snapshot and a run
+type Change struct {
+	Path     string      `json:"path"`
+	Op       string      `json:"op"` // added, removed, changed
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Op {
+	case OpAdded:
+		return fmt.Sprintf("%s: added %s", c.Path, format(c.Actual))
+	case OpRemoved:
+		return fmt.Sprintf("%s: removed %s", c.Path, format(c.Expected))
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Path, format(c.Expected), format(c.Actual))
+}
+
+// Result is the outcome of a snapshot assertion
+type Result struct {
+	Name           string   `json:"name"`
+	Status         string   `json:"status"`                   // recorded, matched, changed
+	Version        int      `json:"version"`                  // approved version compared against
+	PendingVersion int      `json:"pendingVersion,omitempty"` // version awaiting approval when changed
+	Diff           []Change `json:"diff,omitempty"`
+}
+
+// Normalize converts body to plain JSON values and masks the ignored paths
+func Normalize(body interface{}, ignore []string) (interface{}, error) {
+	value := jsonpath.Normalize(body)
+	for _, expr := range ignore {
+		path, err := jsonpath.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore path: %w", err)
+		}
+		value = path.Replace(value, func(interface{}) interface{} { return IgnoredValue })
+	}
+	return value, nil
+}
+
+// Diff lists the differences between the expected and actual values in
+// document order, with JSONPath locations
+func Diff(expected, actual interface{}) []Change {
+	var changes []Change
+	diff("$", expected, actual, &changes)
+	return changes
+}
+
+func diff(path string, expected, actual interface{}, changes *[]Change) {
+	switch exp := expected.(type) {
+	case map[string]interface{}:
+		act, ok := actual.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(exp)+len(act))
+		for k := range exp {
+			keys = append(keys, k)
+		}
+		for k := range act {
+			if _, ok := exp[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := memberPath(path, k)
+			e, inExpected := exp[k]
+			a, inActual := act[k]
+			switch {
+			case !inActual:
+				*changes = append(*changes, Change{Path: childPath, Op: OpRemoved, Expected: e})
+			case !inExpected:
+				*changes = append(*changes, Change{Path: childPath, Op: OpAdded, Actual: a})
+			default:
+				diff(childPath, e, a, changes)
+			}
+		}
+		return
+
+	case []interface{}:
+		act, ok := actual.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(exp) || i < len(act); i++ {
+			childPath := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(act):
+				*changes = append(*changes, Change{Path: childPath, Op: OpRemoved, Expected: exp[i]})
+			case i >= len(exp):
+				*changes = append(*changes, Change{Path: childPath, Op: OpAdded, Actual: act[i]})
+			default:
+				diff(childPath, exp[i], act[i], changes)
+			}
+		}
+		return
+	}
+
+	if !jsonpath.Equal(expected, actual) {
+		*changes = append(*changes, Change{Path: path, Op: OpChanged, Expected: expected, Actual: actual})
+	}
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// memberPath appends a member to a JSONPath, quoting names that need it
+func memberPath(path, name string) string {
+	if identifier.MatchString(name) {
+		return path + "." + name
+	}
+	return path + "['" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(name) + "']"
+}
+
+// Summary formats up to max changes for a failure message
+func Summary(changes []Change, max int) string {
+	parts := make([]string, 0, max+1)
+	for i, c := range changes {
+		if i == max {
+			parts = append(parts, fmt.Sprintf("... and %d more", len(changes)-max))
+			break
+		}
+		parts = append(parts, c.String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+func format(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	if v == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,59 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	body := map[string]interface{}{
+		"id":        "u-123",
+		"createdAt": "2024-01-01T00:00:00Z",
+		"items":     []interface{}{map[string]interface{}{"id": 1, "name": "a"}},
+	}
+
+	normalized, err := Normalize(body, []string{"$.createdAt", "$..id"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":        IgnoredValue,
+		"createdAt": IgnoredValue,
+		"items":     []interface{}{map[string]interface{}{"id": IgnoredValue, "name": "a"}},
+	}, normalized)
+
+	// The input is left untouched
+	assert.Equal(t, "u-123", body["id"])
+
+	_, err = Normalize(body, []string{"$.items[?("})
+	assert.Error(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	expected := map[string]interface{}{
+		"name":         "a",
+		"count":        float64(1),
+		"tags":         []interface{}{"x", "y"},
+		"content-type": "json",
+	}
+	actual := map[string]interface{}{
+		"name":         "b",
+		"count":        1,
+		"tags":         []interface{}{"x"},
+		"content-type": "json",
+		"extra":        true,
+	}
+
+	changes := Diff(expected, actual)
+	assert.Equal(t, []Change{
+		{Path: "$.extra", Op: OpAdded, Actual: true},
+		{Path: "$.name", Op: OpChanged, Expected: "a", Actual: "b"},
+		{Path: "$.tags[1]", Op: OpRemoved, Expected: "y"},
+	}, changes)
+	assert.Equal(t, `$.name: "a" -> "b"`, changes[1].String())
+	assert.Equal(t, `$.extra: added true; ... and 2 more`, Summary(changes, 1))
+
+	assert.Empty(t, Diff(expected, expected))
+	assert.Equal(t, []Change{{Path: "$['a b']", Op: OpChanged, Expected: nil, Actual: "x"}},
+		Diff(map[string]interface{}{"a b": nil}, map[string]interface{}{"a b": "x"}))
+}
@@ -0,0 +1,67 @@
+package testcase
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertionResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id":"u-1","email":"a@example.com","roles":["admin"]}`)
+	}))
+	defer server.Close()
+
+	result := NewExecutor(server.URL).Execute(&TestCase{ID: "r1", Type: "http",
+		HTTP: &HTTPTest{Method: "POST", Path: "/users"},
+		Assertions: []Assertion{
+			{Type: "status_code", Operator: "between", Expected: []interface{}{200, 299}},
+			{Type: "json_path", Path: "$.email", Operator: "regex", Expected: `^[^@]+@example\.com$`},
+			{Type: "json_path", Path: "$.id", Operator: "startsWith", Expected: "u-"},
+			{Type: "json_path", Path: "$.roles", Operator: "length", Expected: 2},
+			{Type: "body", Path: "$.roles[0]", Operator: "type", Expected: "string"},
+		},
+	})
+	assert.Equal(t, "failed", result.Status)
+	assert.Equal(t, []string{"JSON path $.roles: should have length 2, got 1"}, result.Failures)
+
+	require.Len(t, result.Assertions, 5)
+	status := result.Assertions[0]
+	assert.Equal(t, "between", status.Type)
+	assert.Equal(t, "status_code", status.Target)
+	assert.Equal(t, 201, status.Actual)
+	assert.True(t, status.Passed)
+
+	length := result.Assertions[3]
+	assert.Equal(t, "$.roles", length.Path)
+	assert.Equal(t, []interface{}{"admin"}, length.Actual)
+	assert.Equal(t, 2, length.Expected)
+	assert.False(t, length.Passed)
+	assert.Equal(t, "should have length 2, got 1", length.Message)
+}
+
+func TestCommandAssertionResults(t *testing.T) {
+	result := NewExecutor("").Execute(&TestCase{ID: "c1", Type: "command",
+		Command: &CommandTest{Cmd: "echo", Args: []string{`{"ok":true,"count":3}`}},
+		Assertions: []Assertion{
+			{Type: "exit_code", Operator: "in", Expected: []interface{}{0, 2}},
+			{Type: "stdout_contains", Expected: `"ok":true`},
+			{Type: "stdout", Path: "$.count", Operator: "gte", Expected: 3},
+			{Type: "stdout", Operator: "endsWith", Expected: "}"},
+		},
+	})
+	assert.Equal(t, "failed", result.Status, "stdout ends with a newline")
+	require.Len(t, result.Assertions, 4)
+	for _, a := range result.Assertions[:3] {
+		assert.True(t, a.Passed, a.Message)
+	}
+	assert.Equal(t, "contains", result.Assertions[1].Type)
+	assert.True(t, strings.HasPrefix(result.Failures[0], "stdout: should end with }"))
+}
@@ -0,0 +1,263 @@
+package testcase
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// Supported auth types
+const (
+	AuthTypeBasic     = "basic"
+	AuthTypeDigest    = "digest"
+	AuthTypeBearer    = "bearer"
+	AuthTypeOAuth2    = "oauth2"
+	AuthTypeJWT       = "jwt"
+	AuthTypeHMAC      = "hmac"
+	AuthTypeAWSSigV4  = "aws_sigv4"
+	defaultAuthHeader = "Authorization"
+)
+
+// AuthConfig describes how an HTTP request is authenticated. Secrets are
+// normally written as {{VAR}} placeholders resolved from the active environment.
+type AuthConfig struct {
+	Type string `json:"type"` // basic, digest, bearer, oauth2, jwt, hmac, aws_sigv4
+
+	// basic / digest
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// bearer
+	Token string `json:"token,omitempty"`
+
+	// Header and prefix used for bearer, oauth2 and jwt tokens
+	// (default "Authorization" and "Bearer")
+	Header string `json:"header,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+
+	OAuth2 *OAuth2Config   `json:"oauth2,omitempty"`
+	JWT    *JWTConfig      `json:"jwt,omitempty"`
+	HMAC   *HMACConfig     `json:"hmac,omitempty"`
+	AWS    *AWSSigV4Config `json:"aws,omitempty"`
+}
+
+// applyAuth authenticates req before it is sent. client is used for token
+// endpoints (OAuth2).
+func (e *UnifiedTestExecutor) applyAuth(req *http.Request, auth *AuthConfig, client *http.Client) error {
+	switch strings.ToLower(auth.Type) {
+	case "", "none":
+		return nil
+
+	case AuthTypeBasic:
+		req.SetBasicAuth(auth.Username, auth.Password)
+		return nil
+
+	case AuthTypeDigest:
+		// Digest needs the server challenge; the Authorization header is set on the 401 retry
+		return nil
+
+	case AuthTypeBearer:
+		setToken(req, auth, auth.Token)
+		return nil
+
+	case AuthTypeOAuth2:
+		if auth.OAuth2 == nil {
+			return fmt.Errorf("oauth2 auth requires an oauth2 block")
+		}
+		token, err := defaultTokenCache.token(client, e.tokenCacheScope(), auth.OAuth2)
+		if err != nil {
+			return err
+		}
+		setToken(req, auth, token)
+		return nil
+
+	case AuthTypeJWT:
+		if auth.JWT == nil {
+			return fmt.Errorf("jwt auth requires a jwt block")
+		}
+		token, err := signJWT(auth.JWT, e.templateVariables(nil), e.strictVariables)
+		if err != nil {
+			return err
+		}
+		setToken(req, auth, token)
+		return nil
+
+	case AuthTypeHMAC:
+		if auth.HMAC == nil {
+			return fmt.Errorf("hmac auth requires an hmac block")
+		}
+		return signHMAC(req, auth.HMAC)
+
+	case AuthTypeAWSSigV4:
+		if auth.AWS == nil {
+			return fmt.Errorf("aws_sigv4 auth requires an aws block")
+		}
+		return signAWSSigV4(req, auth.AWS)
+	}
+
+	return fmt.Errorf("unsupported auth type: %s", auth.Type)
+}
+
+// reauthenticate prepares a retry of a request that was rejected with 401.
+// It returns false when the auth type has nothing to retry with.
+func (e *UnifiedTestExecutor) reauthenticate(req *http.Request, resp *http.Response, auth *AuthConfig, client *http.Client) (bool, error) {
+	switch strings.ToLower(auth.Type) {
+	case AuthTypeDigest:
+		challenge := resp.Header.Get("WWW-Authenticate")
+		if !strings.HasPrefix(strings.ToLower(challenge), "digest ") {
+			return false, nil
+		}
+		header, err := digestAuthorization(req, auth.Username, auth.Password, challenge)
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("Authorization", header)
+		return true, nil
+
+	case AuthTypeOAuth2:
+		// The cached token may have been revoked; fetch a fresh one once
+		defaultTokenCache.invalidate(e.tokenCacheScope(), auth.OAuth2)
+		if err := e.applyAuth(req, auth, client); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// tokenCacheScope scopes cached tokens to the tenant, project and
+// environment being executed
+func (e *UnifiedTestExecutor) tokenCacheScope() string {
+	if e.executionParams == nil {
+		return ""
+	}
+	return e.executionParams.TenantID + "/" + e.executionParams.ProjectID + "/" + e.executionParams.EnvironmentID
+}
+
+func setToken(req *http.Request, auth *AuthConfig, token string) {
+	header := auth.Header
+	if header == "" {
+		header = defaultAuthHeader
+	}
+	prefix := auth.Prefix
+	if prefix == "" && strings.EqualFold(header, defaultAuthHeader) {
+		prefix = "Bearer"
+	}
+	if prefix != "" {
+		token = prefix + " " + token
+	}
+	req.Header.Set(header, token)
+}
+
+// digestAuthorization computes an RFC 7616 Digest Authorization header for the challenge
+func digestAuthorization(req *http.Request, username, password, challenge string) (string, error) {
+	params := parseAuthParams(challenge[len("digest "):])
+	realm, nonce := params["realm"], params["nonce"]
+	if nonce == "" {
+		return "", fmt.Errorf("digest challenge has no nonce")
+	}
+
+	algorithm := strings.ToUpper(params["algorithm"])
+	var newHash func() hash.Hash
+	switch algorithm {
+	case "", "MD5", "MD5-SESS":
+		newHash = md5.New
+	case "SHA-256", "SHA-256-SESS":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm: %s", algorithm)
+	}
+	h := func(s string) string {
+		hasher := newHash()
+		hasher.Write([]byte(s))
+		return hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	cnonce := randomHex(8)
+	nc := "00000001"
+	uri := req.URL.RequestURI()
+
+	ha1 := h(username + ":" + realm + ":" + password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1 + ":" + nonce + ":" + cnonce)
+	}
+
+	qop := ""
+	for _, q := range strings.Split(params["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+		}
+	}
+
+	ha2 := h(req.Method + ":" + uri)
+	var response string
+	if qop != "" {
+		response = h(strings.Join([]string{ha1, nonce, nc, cnonce, qop, ha2}, ":"))
+	} else {
+		response = h(ha1 + ":" + nonce + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, username),
+		fmt.Sprintf(`realm="%s"`, realm),
+		fmt.Sprintf(`nonce="%s"`, nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if algorithm != "" {
+		parts = append(parts, "algorithm="+params["algorithm"])
+	}
+	if qop != "" {
+		parts = append(parts, "qop="+qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	if opaque, ok := params["opaque"]; ok {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, opaque))
+	}
+	return "Digest " + strings.Join(parts, ", "), nil
+}
+
+// parseAuthParams parses comma separated key=value / key="value" auth parameters
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ,")
+		eq := strings.Index(s, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else if comma := strings.Index(s, ","); comma >= 0 {
+			value, s = strings.TrimSpace(s[:comma]), s[comma+1:]
+		} else {
+			value, s = strings.TrimSpace(s), ""
+		}
+		params[key] = value
+	}
+	return params
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func base64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
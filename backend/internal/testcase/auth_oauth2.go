@@ -0,0 +1,216 @@
+package testcase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tokenExpirySkew = 30 * time.Second // refresh tokens slightly before they expire
+	maxCachedTokens = 1024             // bound of the token cache
+)
+
+// OAuth2Config describes an OAuth2 token request
+type OAuth2Config struct {
+	GrantType    string            `json:"grantType"` // client_credentials, password
+	TokenURL     string            `json:"tokenUrl"`
+	ClientID     string            `json:"clientId"`
+	ClientSecret string            `json:"clientSecret,omitempty"`
+	Username     string            `json:"username,omitempty"` // password grant
+	Password     string            `json:"password,omitempty"` // password grant
+	Scopes       []string          `json:"scopes,omitempty"`
+	Audience     string            `json:"audience,omitempty"`
+	ExtraParams  map[string]string `json:"extraParams,omitempty"`
+	ClientAuth   string            `json:"clientAuth,omitempty"` // "body" (default) or "basic"
+}
+
+// oauth2Token is a cached access token
+type oauth2Token struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time // zero means no expiry was reported
+}
+
+func (t *oauth2Token) valid() bool {
+	return t.AccessToken != "" && (t.ExpiresAt.IsZero() || time.Now().Add(tokenExpirySkew).Before(t.ExpiresAt))
+}
+
+// tokenCache caches OAuth2 tokens. Entries are keyed by tenant/project/
+// environment and a hash of the resolved token request, so environments never
+// share a token. Expired entries are evicted when a token is stored, and the
+// cache holds at most maxCachedTokens entries.
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]*oauth2Token
+}
+
+var defaultTokenCache = &tokenCache{tokens: make(map[string]*oauth2Token)}
+
+func (c *tokenCache) key(scope string, cfg *OAuth2Config) string {
+	h := sha256.New()
+	parts := []string{
+		cfg.GrantType, cfg.TokenURL, cfg.ClientID, cfg.ClientSecret, cfg.ClientAuth,
+		cfg.Username, cfg.Password, strings.Join(cfg.Scopes, " "), cfg.Audience,
+	}
+	names := make([]string, 0, len(cfg.ExtraParams))
+	for name := range cfg.ExtraParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, name, cfg.ExtraParams[name])
+	}
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return scope + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// store caches token under key, evicting expired entries and, when the cache
+// is still full, arbitrary ones. Callers hold c.mu.
+func (c *tokenCache) store(key string, token *oauth2Token) {
+	now := time.Now()
+	for k, cached := range c.tokens {
+		if !cached.ExpiresAt.IsZero() && now.After(cached.ExpiresAt) {
+			delete(c.tokens, k)
+		}
+	}
+	for k := range c.tokens {
+		if len(c.tokens) < maxCachedTokens {
+			break
+		}
+		delete(c.tokens, k)
+	}
+	c.tokens[key] = token
+}
+
+// token returns a valid access token, refreshing or re-acquiring it when needed
+func (c *tokenCache) token(client *http.Client, scope string, cfg *OAuth2Config) (string, error) {
+	key := c.key(scope, cfg)
+
+	c.mu.Lock()
+	cached := c.tokens[key]
+	c.mu.Unlock()
+
+	if cached != nil && cached.valid() {
+		return cached.AccessToken, nil
+	}
+
+	var token *oauth2Token
+	var err error
+	if cached != nil && cached.RefreshToken != "" {
+		token, err = requestToken(client, cfg, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {cached.RefreshToken},
+		})
+	}
+	if token == nil || err != nil {
+		token, err = requestToken(client, cfg, grantParams(cfg))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	c.mu.Lock()
+	c.store(key, token)
+	c.mu.Unlock()
+	return token.AccessToken, nil
+}
+
+func (c *tokenCache) invalidate(scope string, cfg *OAuth2Config) {
+	if cfg == nil {
+		return
+	}
+	c.mu.Lock()
+	delete(c.tokens, c.key(scope, cfg))
+	c.mu.Unlock()
+}
+
+func grantParams(cfg *OAuth2Config) url.Values {
+	grantType := cfg.GrantType
+	if grantType == "" {
+		grantType = "client_credentials"
+	}
+	params := url.Values{"grant_type": {grantType}}
+	if grantType == "password" {
+		params.Set("username", cfg.Username)
+		params.Set("password", cfg.Password)
+	}
+	if len(cfg.Scopes) > 0 {
+		params.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+	if cfg.Audience != "" {
+		params.Set("audience", cfg.Audience)
+	}
+	for k, v := range cfg.ExtraParams {
+		params.Set(k, v)
+	}
+	return params
+}
+
+// requestToken calls the token endpoint
+func requestToken(client *http.Client, cfg *OAuth2Config, params url.Values) (*oauth2Token, error) {
+	if cfg.TokenURL == "" {
+		return nil, fmt.Errorf("oauth2 tokenUrl is required")
+	}
+
+	basic := strings.EqualFold(cfg.ClientAuth, "basic")
+	if !basic {
+		params.Set("client_id", cfg.ClientID)
+		if cfg.ClientSecret != "" {
+			params.Set("client_secret", cfg.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cfg.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basic {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	// Token requests must not carry session cookies
+	tokenClient := *client
+	tokenClient.Jar = nil
+	resp, err := tokenClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("token request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var payload struct {
+		AccessToken  string      `json:"access_token"`
+		RefreshToken string      `json:"refresh_token"`
+		ExpiresIn    json.Number `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if payload.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+
+	token := &oauth2Token{AccessToken: payload.AccessToken, RefreshToken: payload.RefreshToken}
+	if seconds, err := payload.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return token, nil
+}
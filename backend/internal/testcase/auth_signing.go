@@ -0,0 +1,408 @@
+package testcase
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-management-service/internal/expression"
+)
+
+// JWTConfig describes a locally signed JWT
+type JWTConfig struct {
+	Algorithm  string                 `json:"algorithm"`            // HS256, HS384, HS512, RS256, RS384, RS512
+	Secret     string                 `json:"secret,omitempty"`     // HS* shared secret
+	PrivateKey string                 `json:"privateKey,omitempty"` // RS* PEM private key (PKCS#1 or PKCS#8)
+	KeyID      string                 `json:"keyId,omitempty"`
+	ExpiresIn  int                    `json:"expiresIn,omitempty"` // seconds, default 300
+	Claims     map[string]interface{} `json:"claims,omitempty"`    // string values may use {{now}}, {{exp}}, {{$uuid}}, ...
+	Headers    map[string]interface{} `json:"headers,omitempty"`   // extra JOSE headers
+}
+
+// HMACConfig describes generic HMAC request signing. StringToSign and
+// HeaderFormat use single-brace placeholders: {method} {path} {query} {host}
+// {body} {bodyHash} {timestamp} {nonce} {keyId} {signature} {header:Name}.
+type HMACConfig struct {
+	Algorithm       string `json:"algorithm,omitempty"` // sha256 (default), sha1, sha512
+	Secret          string `json:"secret"`
+	KeyID           string `json:"keyId,omitempty"`
+	StringToSign    string `json:"stringToSign,omitempty"`    // default "{method}\n{path}\n{timestamp}\n{bodyHash}"
+	Encoding        string `json:"encoding,omitempty"`        // hex (default) or base64
+	SignatureHeader string `json:"signatureHeader,omitempty"` // default "X-Signature"
+	HeaderFormat    string `json:"headerFormat,omitempty"`    // default "{signature}"
+	TimestampHeader string `json:"timestampHeader,omitempty"` // default "X-Timestamp"
+	NonceHeader     string `json:"nonceHeader,omitempty"`     // only sent when set
+	KeyIDHeader     string `json:"keyIdHeader,omitempty"`     // only sent when set
+}
+
+// AWSSigV4Config describes AWS Signature Version 4 signing
+type AWSSigV4Config struct {
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	SessionToken    string `json:"sessionToken,omitempty"`
+	Region          string `json:"region"`
+	Service         string `json:"service"`
+}
+
+// signJWT builds and signs a JWT from cfg. Claims are rendered with
+// variables plus {{now}}, {{exp}} and {{jti}}.
+func signJWT(cfg *JWTConfig, variables map[string]interface{}, strict bool) (string, error) {
+	alg := strings.ToUpper(cfg.Algorithm)
+	if alg == "" {
+		alg = "HS256"
+	}
+
+	now := time.Now()
+	expiresIn := cfg.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = 300
+	}
+	exp := now.Add(time.Duration(expiresIn) * time.Second)
+
+	claimVariables := make(map[string]interface{}, len(variables)+3)
+	for k, v := range variables {
+		claimVariables[k] = v
+	}
+	claimVariables["now"] = now.Unix()
+	claimVariables["exp"] = exp.Unix()
+	claimVariables["jti"] = uuid.New().String()
+	evaluator := expression.NewEvaluator(claimVariables, nil)
+	evaluator.SetStrict(strict)
+	rendered, err := evaluator.InterpolateValue(cfg.Claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to render jwt claims: %w", err)
+	}
+	claims, _ := rendered.(map[string]interface{})
+	if claims == nil {
+		claims = make(map[string]interface{})
+	}
+	if _, ok := claims["iat"]; !ok {
+		claims["iat"] = now.Unix()
+	}
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = exp.Unix()
+	}
+
+	header := map[string]interface{}{"alg": alg, "typ": "JWT"}
+	for k, v := range cfg.Headers {
+		header[k] = v
+	}
+	if cfg.KeyID != "" {
+		header["kid"] = cfg.KeyID
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode jwt header: %w", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode jwt claims: %w", err)
+	}
+	signingInput := base64URL(headerJSON) + "." + base64URL(claimsJSON)
+
+	var signature []byte
+	switch alg {
+	case "HS256", "HS384", "HS512":
+		if cfg.Secret == "" {
+			return "", fmt.Errorf("jwt %s requires a secret", alg)
+		}
+		mac := hmac.New(jwtHash(alg).New, []byte(cfg.Secret))
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+
+	case "RS256", "RS384", "RS512":
+		key, err := parseRSAPrivateKey(cfg.PrivateKey)
+		if err != nil {
+			return "", err
+		}
+		hashFunc := jwtHash(alg)
+		hasher := hashFunc.New()
+		hasher.Write([]byte(signingInput))
+		signature, err = rsa.SignPKCS1v15(nil, key, hashFunc, hasher.Sum(nil))
+		if err != nil {
+			return "", fmt.Errorf("failed to sign jwt: %w", err)
+		}
+
+	default:
+		return "", fmt.Errorf("unsupported jwt algorithm: %s", cfg.Algorithm)
+	}
+
+	return signingInput + "." + base64URL(signature), nil
+}
+
+func jwtHash(alg string) crypto.Hash {
+	switch alg[2:] {
+	case "384":
+		return crypto.SHA384
+	case "512":
+		return crypto.SHA512
+	}
+	return crypto.SHA256
+}
+
+func parseRSAPrivateKey(pemData string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil, fmt.Errorf("jwt privateKey is not valid PEM")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jwt privateKey: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("jwt privateKey is not an RSA key")
+	}
+	return key, nil
+}
+
+// requestBody returns a copy of the request body without consuming it
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// signHMAC signs req with a generic HMAC scheme
+func signHMAC(req *http.Request, cfg *HMACConfig) error {
+	if cfg.Secret == "" {
+		return fmt.Errorf("hmac secret is required")
+	}
+
+	var newHash func() hash.Hash
+	switch strings.ToLower(cfg.Algorithm) {
+	case "", "sha256":
+		newHash = sha256.New
+	case "sha1":
+		newHash = sha1.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return fmt.Errorf("unsupported hmac algorithm: %s", cfg.Algorithm)
+	}
+
+	body, err := requestBody(req)
+	if err != nil {
+		return fmt.Errorf("failed to read body for signing: %w", err)
+	}
+	bodyHash := sha256.Sum256(body)
+
+	values := map[string]string{
+		"method":    req.Method,
+		"path":      req.URL.EscapedPath(),
+		"query":     req.URL.RawQuery,
+		"host":      req.URL.Host,
+		"body":      string(body),
+		"bodyHash":  hex.EncodeToString(bodyHash[:]),
+		"timestamp": strconv.FormatInt(time.Now().Unix(), 10),
+		"nonce":     randomHex(16),
+		"keyId":     cfg.KeyID,
+	}
+
+	stringToSign := cfg.StringToSign
+	if stringToSign == "" {
+		stringToSign = "{method}\n{path}\n{timestamp}\n{bodyHash}"
+	}
+	mac := hmac.New(newHash, []byte(cfg.Secret))
+	mac.Write([]byte(renderSigningTemplate(stringToSign, values, req)))
+	if strings.EqualFold(cfg.Encoding, "base64") {
+		values["signature"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	} else {
+		values["signature"] = hex.EncodeToString(mac.Sum(nil))
+	}
+
+	signatureHeader := cfg.SignatureHeader
+	if signatureHeader == "" {
+		signatureHeader = "X-Signature"
+	}
+	headerFormat := cfg.HeaderFormat
+	if headerFormat == "" {
+		headerFormat = "{signature}"
+	}
+	timestampHeader := cfg.TimestampHeader
+	if timestampHeader == "" {
+		timestampHeader = "X-Timestamp"
+	}
+
+	req.Header.Set(signatureHeader, renderSigningTemplate(headerFormat, values, req))
+	req.Header.Set(timestampHeader, values["timestamp"])
+	if cfg.NonceHeader != "" {
+		req.Header.Set(cfg.NonceHeader, values["nonce"])
+	}
+	if cfg.KeyIDHeader != "" && cfg.KeyID != "" {
+		req.Header.Set(cfg.KeyIDHeader, cfg.KeyID)
+	}
+	return nil
+}
+
+// renderSigningTemplate replaces {name} and {header:Name} placeholders. An
+// escaped \n in the template is a newline; the substituted values are kept
+// as sent.
+func renderSigningTemplate(template string, values map[string]string, req *http.Request) string {
+	template = strings.ReplaceAll(template, `\n`, "\n")
+	var b strings.Builder
+	for {
+		start := strings.Index(template, "{")
+		if start < 0 {
+			b.WriteString(template)
+			break
+		}
+		end := strings.Index(template[start:], "}")
+		if end < 0 {
+			b.WriteString(template)
+			break
+		}
+		end += start
+
+		name := template[start+1 : end]
+		b.WriteString(template[:start])
+		if strings.HasPrefix(name, "header:") {
+			b.WriteString(req.Header.Get(strings.TrimPrefix(name, "header:")))
+		} else if value, ok := values[name]; ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(template[start : end+1])
+		}
+		template = template[end+1:]
+	}
+	return b.String()
+}
+
+// signAWSSigV4 signs req with AWS Signature Version 4
+func signAWSSigV4(req *http.Request, cfg *AWSSigV4Config) error {
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return fmt.Errorf("aws accessKeyId and secretAccessKey are required")
+	}
+	if cfg.Region == "" || cfg.Service == "" {
+		return fmt.Errorf("aws region and service are required")
+	}
+
+	body, err := requestBody(req)
+	if err != nil {
+		return fmt.Errorf("failed to read body for signing: %w", err)
+	}
+	payloadHash := sha256.Sum256(body)
+	payloadHex := hex.EncodeToString(payloadHash[:])
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHex)
+	if cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", cfg.SessionToken)
+	}
+
+	// Canonical headers: host plus every x-amz-* and content-type header
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHex,
+	}, "\n")
+
+	scope := strings.Join([]string{dateStamp, cfg.Region, cfg.Service, "aws4_request"}, "/")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+cfg.SecretAccessKey), dateStamp)
+	signingKey = hmacSHA256(signingKey, cfg.Region)
+	signingKey = hmacSHA256(signingKey, cfg.Service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+func canonicalQueryString(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsURIEncode(k)+"="+awsURIEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsURIEncode encodes per the SigV4 rules (RFC 3986 unreserved characters kept)
+func awsURIEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,179 @@
+package testcase
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth_DigestChallengeRetry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Digest ") {
+			w.Header().Set("WWW-Authenticate", `Digest realm="test", nonce="abc", qop="auth"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		p := parseAuthParams(header[len("Digest "):])
+		h := func(s string) string { sum := md5.Sum([]byte(s)); return hex.EncodeToString(sum[:]) }
+		ha1 := h("alice:test:secret")
+		ha2 := h(r.Method + ":" + p["uri"])
+		expected := h(strings.Join([]string{ha1, p["nonce"], p["nc"], p["cnonce"], p["qop"], ha2}, ":"))
+		if p["response"] != expected {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	result := NewExecutor(server.URL).Execute(&TestCase{ID: "digest", Type: "http",
+		HTTP: &HTTPTest{Method: "GET", Path: "/secure?x=1",
+			Auth: &AuthConfig{Type: AuthTypeDigest, Username: "alice", Password: "secret"}},
+		Assertions: []Assertion{{Type: "status_code", Expected: 200}},
+	})
+	assert.Equal(t, "passed", result.Status, strings.Join(result.Failures, "; "))
+}
+
+func TestAuth_OAuth2ClientCredentialsCachesToken(t *testing.T) {
+	var tokenRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			atomic.AddInt32(&tokenRequests, 1)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+			assert.Equal(t, "cid", r.Form.Get("client_id"))
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"tok-1","expires_in":3600}`)
+		default:
+			if r.Header.Get("Authorization") != "Bearer tok-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(server.URL)
+	executor.SetExecutionParams(&ExecutionParams{TenantID: "t-oauth", ProjectID: "p"})
+	auth := &AuthConfig{Type: AuthTypeOAuth2, OAuth2: &OAuth2Config{
+		TokenURL: server.URL + "/token", ClientID: "cid", ClientSecret: "cs",
+	}}
+
+	for i := 0; i < 2; i++ {
+		result := executor.Execute(&TestCase{ID: "oauth", Type: "http",
+			HTTP:       &HTTPTest{Method: "GET", Path: "/api", Auth: auth},
+			Assertions: []Assertion{{Type: "status_code", Expected: 200}},
+		})
+		assert.Equal(t, "passed", result.Status, strings.Join(result.Failures, "; "))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokenRequests))
+
+	// Another environment with the same credentials gets its own token
+	executor.SetExecutionParams(&ExecutionParams{TenantID: "t-oauth", ProjectID: "p", EnvironmentID: "staging"})
+	result := executor.Execute(&TestCase{ID: "oauth", Type: "http",
+		HTTP:       &HTTPTest{Method: "GET", Path: "/api", Auth: auth},
+		Assertions: []Assertion{{Type: "status_code", Expected: 200}},
+	})
+	assert.Equal(t, "passed", result.Status, strings.Join(result.Failures, "; "))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&tokenRequests))
+}
+
+func TestTokenCache_Eviction(t *testing.T) {
+	cache := &tokenCache{tokens: make(map[string]*oauth2Token)}
+	cache.store("expired", &oauth2Token{AccessToken: "a", ExpiresAt: time.Now().Add(-time.Minute)})
+	cache.store("no-expiry", &oauth2Token{AccessToken: "b"})
+	cache.store("fresh", &oauth2Token{AccessToken: "c", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.NotContains(t, cache.tokens, "expired")
+	assert.Contains(t, cache.tokens, "no-expiry")
+	assert.Contains(t, cache.tokens, "fresh")
+
+	for i := 0; i < maxCachedTokens+10; i++ {
+		cache.store(fmt.Sprintf("key-%d", i), &oauth2Token{AccessToken: "t"})
+	}
+	assert.Len(t, cache.tokens, maxCachedTokens)
+	assert.Contains(t, cache.tokens, fmt.Sprintf("key-%d", maxCachedTokens+9), "the stored token is kept")
+}
+
+func TestSignJWT_HS256WithClaimTemplates(t *testing.T) {
+	token, err := signJWT(&JWTConfig{
+		Algorithm: "HS256",
+		Secret:    "s3cret",
+		KeyID:     "k1",
+		Claims: map[string]interface{}{
+			"sub": "{{user}}",
+			"nbf": "{{now}}",
+			"ctx": map[string]interface{}{"exp": "{{exp}}"},
+		},
+	}, map[string]interface{}{"user": "user-1"}, true)
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), parts[2])
+
+	var header, claims map[string]interface{}
+	data, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, json.Unmarshal(data, &header))
+	data, _ = base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, json.Unmarshal(data, &claims))
+
+	assert.Equal(t, "k1", header["kid"])
+	assert.Equal(t, "user-1", claims["sub"])
+	assert.Equal(t, claims["iat"], claims["nbf"])
+	assert.Equal(t, claims["exp"], claims["ctx"].(map[string]interface{})["exp"])
+}
+
+func TestSignHMAC_DefaultStringToSign(t *testing.T) {
+	req, err := http.NewRequest("POST", "http://example.com/orders", strings.NewReader(`{"a":1}`))
+	require.NoError(t, err)
+	require.NoError(t, signHMAC(req, &HMACConfig{Secret: "k", KeyID: "app", HeaderFormat: "HMAC {keyId}:{signature}"}))
+
+	bodyHash := sha256.Sum256([]byte(`{"a":1}`))
+	mac := hmac.New(sha256.New, []byte("k"))
+	mac.Write([]byte("POST\n/orders\n" + req.Header.Get("X-Timestamp") + "\n" + hex.EncodeToString(bodyHash[:])))
+	assert.Equal(t, "HMAC app:"+hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Signature"))
+}
+
+func TestSignHMAC_BodyIsSignedAsSent(t *testing.T) {
+	body := `{"text":"a\nb"}`
+	req, err := http.NewRequest("POST", "http://example.com/notes", strings.NewReader(body))
+	require.NoError(t, err)
+	require.NoError(t, signHMAC(req, &HMACConfig{Secret: "k", StringToSign: `{method}\n{body}`}))
+
+	mac := hmac.New(sha256.New, []byte("k"))
+	mac.Write([]byte("POST\n" + body))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Signature"))
+}
+
+func TestSignAWSSigV4_SetsAuthorizationHeader(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/?b=2&a=1", nil)
+	require.NoError(t, err)
+	require.NoError(t, signAWSSigV4(req, &AWSSigV4Config{
+		AccessKeyID: "AKID", SecretAccessKey: "SECRET", Region: "us-east-1", Service: "execute-api",
+	}))
+
+	authz := req.Header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(authz, "AWS4-HMAC-SHA256 Credential=AKID/"), authz)
+	assert.Contains(t, authz, "/us-east-1/execute-api/aws4_request")
+	assert.Contains(t, authz, "SignedHeaders=host;x-amz-content-sha256;x-amz-date")
+	assert.NotEmpty(t, req.Header.Get("X-Amz-Date"))
+}
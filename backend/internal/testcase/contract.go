@@ -0,0 +1,90 @@
+package testcase
+
+import (
+	"fmt"
+	"net/http"
+
+	"test-management-service/internal/models"
+	"test-management-service/internal/openapi"
+)
+
+// checkContract validates the HTTP exchange against the operation it matches
+// in the contract's OpenAPI document. In strict mode violations fail the test;
+// in warn mode they are only reported as warnings.
+func (e *UnifiedTestExecutor) checkContract(contract *Contract, req *http.Request, requestBody interface{}, resp *httpResponse, result *TestResult) {
+	if contract == nil || contract.Spec == "" || contract.Mode == openapi.ModeOff {
+		return
+	}
+
+	outcome := models.AssertionResult{Type: "openapi", Target: "contract", Expected: contract.Spec}
+	report := func(messages []string) {
+		if contract.Mode == openapi.ModeWarn {
+			result.Warnings = append(result.Warnings, messages...)
+			return
+		}
+		outcome.Message = messages[0]
+		if len(messages) > 1 {
+			outcome.Message = fmt.Sprintf("%s (and %d more)", messages[0], len(messages)-1)
+		}
+		result.Assertions = append(result.Assertions, outcome)
+		result.Status = "failed"
+		result.Failures = append(result.Failures, messages...)
+	}
+
+	load := contract.Load
+	if load == nil {
+		load = e.loadSchemaDocument
+	}
+	doc, err := load(contract.Spec)
+	if err != nil {
+		report([]string{fmt.Sprintf("openapi %s: %v", contract.Spec, err)})
+		return
+	}
+	validator, err := openapi.NewValidator(contract.Spec, doc, load)
+	if err != nil {
+		report([]string{fmt.Sprintf("openapi: %v", err)})
+		return
+	}
+
+	op, violations, err := validator.Validate(
+		openapi.Request{Method: req.Method, URL: req.URL, Header: req.Header, Body: requestBody},
+		&openapi.Response{StatusCode: resp.StatusCode, Header: resp.Headers, Body: resp.Body},
+	)
+	if err != nil {
+		report([]string{fmt.Sprintf("openapi %s: %v", contract.Spec, err)})
+		return
+	}
+
+	label := "openapi"
+	if op != nil {
+		label = "openapi " + op.String()
+		outcome.Actual = op.String()
+	}
+	if len(violations) > 0 {
+		messages := make([]string, len(violations))
+		for i, v := range violations {
+			messages[i] = fmt.Sprintf("%s: %s", label, v)
+		}
+		report(messages)
+		return
+	}
+
+	outcome.Passed = true
+	result.Assertions = append(result.Assertions, outcome)
+}
+
+// requestBodyForContract returns the request body as the decoded value the
+// contract's request body schema describes
+func requestBodyForContract(cfg *HTTPTest) interface{} {
+	switch {
+	case cfg.Multipart != nil:
+		return cfg.Multipart.Fields
+	case cfg.Form != nil:
+		return cfg.Form
+	case cfg.RawBody != "":
+		return parseJSON(cfg.RawBody)
+	case cfg.Body != nil:
+		return cfg.Body
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package testcase
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const usersContract = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Users", "version": "1.0"},
+  "paths": {
+    "/users/{id}": {
+      "get": {
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {
+          "200": {
+            "description": "user",
+            "content": {"application/json": {"schema": {
+              "type": "object",
+              "required": ["id", "email"],
+              "properties": {"id": {"type": "string"}, "email": {"type": "string"}}
+            }}}
+          },
+          "404": {"description": "not found"}
+        }
+      }
+    }
+  }
+}`
+
+func TestContractValidation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/users/1":
+			io.WriteString(w, `{"id":"1","email":"a@example.com"}`)
+		case "/users/2":
+			io.WriteString(w, `{"id":2}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(usersContract), &doc))
+	executor := NewExecutor(server.URL)
+	executor.SetSchemaLoader(stubSchemaLoader{"users": doc})
+
+	run := func(path, mode string) *TestResult {
+		return executor.Execute(&TestCase{ID: "c1", Type: "http",
+			HTTP:     &HTTPTest{Method: "GET", Path: path},
+			Contract: &Contract{Spec: "users", Mode: mode},
+		})
+	}
+
+	t.Run("conforming exchange passes", func(t *testing.T) {
+		result := run("/users/1", "")
+		assert.Equal(t, "passed", result.Status, result.Failures)
+		require.Len(t, result.Assertions, 1)
+		assert.Equal(t, "openapi", result.Assertions[0].Type)
+		assert.Equal(t, "GET /users/{id}", result.Assertions[0].Actual)
+		assert.True(t, result.Assertions[0].Passed)
+	})
+
+	t.Run("strict mode fails on violations", func(t *testing.T) {
+		result := run("/users/2", "strict")
+		assert.Equal(t, "failed", result.Status)
+		assert.Contains(t, result.Failures, "openapi GET /users/{id}: response.body/id: got number, want string")
+		assert.Contains(t, result.Failures, "openapi GET /users/{id}: response.body: missing property 'email'")
+		require.Len(t, result.Assertions, 1)
+		assert.False(t, result.Assertions[0].Passed)
+		assert.Contains(t, result.Assertions[0].Message, "(and 1 more)")
+	})
+
+	t.Run("warn mode reports warnings", func(t *testing.T) {
+		result := run("/users/3", "warn")
+		assert.Equal(t, "passed", result.Status)
+		assert.Empty(t, result.Failures)
+		assert.Equal(t, []string{"openapi GET /users/{id}: response.status: status 500 is not documented for GET /users/{id} (documented: 200, 404)"}, result.Warnings)
+	})
+
+	t.Run("off mode skips validation", func(t *testing.T) {
+		result := run("/users/3", "off")
+		assert.Equal(t, "passed", result.Status)
+		assert.Empty(t, result.Assertions)
+	})
+
+	t.Run("unknown spec fails the test", func(t *testing.T) {
+		result := executor.Execute(&TestCase{ID: "c2", Type: "http",
+			HTTP:     &HTTPTest{Method: "GET", Path: "/users/1"},
+			Contract: &Contract{Spec: "missing"},
+		})
+		assert.Equal(t, "failed", result.Status)
+		assert.Contains(t, result.Failures[0], "schema 'missing' not found")
+	})
+}
@@ -2,29 +2,52 @@
 package testcase
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"os/exec"
 	"strings"
 	"time"
 
+	"test-management-service/internal/assertion"
+	"test-management-service/internal/expression"
+	"test-management-service/internal/jsonpath"
 	"test-management-service/internal/models"
+	"test-management-service/internal/sandbox"
+	"test-management-service/internal/schema"
+	"test-management-service/internal/snapshot"
 )
 
 // VariableInjector interface for injecting environment variables
 type VariableInjector interface {
-	InjectHTTPVariables(ctx context.Context, tenantID, projectID string, config *HTTPTest) error
-	InjectCommandVariables(ctx context.Context, tenantID, projectID string, config *CommandTest) error
+	InjectHTTPVariables(ctx context.Context, params *ExecutionParams, config *HTTPTest) error
+	InjectCommandVariables(ctx context.Context, params *ExecutionParams, config *CommandTest) error
+}
+
+// SandboxConfigProvider resolves the process sandbox configuration of a tenant
+type SandboxConfigProvider interface {
+	GetSandboxConfig(ctx context.Context, tenantID string) (*sandbox.Config, error)
+}
+
+// SchemaLoader loads project schema documents referenced by json_schema assertions
+type SchemaLoader interface {
+	LoadSchemaDocument(ctx context.Context, tenantID, projectID, name string) (interface{}, error)
+}
+
+// SnapshotStore records snapshot assertions and compares runs against the approved version
+type SnapshotStore interface {
+	MatchSnapshot(ctx context.Context, key snapshot.Key, actual interface{}) (*snapshot.Result, error)
 }
 
 // ExecutionParams contains tenant context for test execution
 type ExecutionParams struct {
 	TenantID  string
 	ProjectID string
+	// EnvironmentID selects the environment whose variables are injected;
+	// empty uses the active environment
+	EnvironmentID string
+	// Variables override those of the environment
+	Variables map[string]interface{}
 }
 
 // UnifiedTestExecutor executes test cases of all types (http, command, workflow, etc.)
@@ -36,6 +59,12 @@ type UnifiedTestExecutor struct {
 	workflowRepo     WorkflowRepository // Repository for workflow data
 	variableInjector VariableInjector   // Injector for environment variables
 	executionParams  *ExecutionParams   // Tenant context for execution
+	sandboxProvider  SandboxConfigProvider // Per-tenant sandbox for spawned processes
+	schemaLoader     SchemaLoader          // Project schema documents for json_schema assertions
+	snapshotStore    SnapshotStore         // Approved snapshots for snapshot assertions
+	strictVariables  bool                  // Fail on {{ }} placeholders that cannot be resolved
+	allowedPaths     []string              // Directories test cases may read files from; empty allows inline content only
+	ctx              context.Context       // Cancels requests and processes of executions, nil for none
 }
 
 // WorkflowExecutor interface for workflow execution
@@ -116,8 +145,98 @@ func (e *UnifiedTestExecutor) SetExecutionParams(params *ExecutionParams) {
 	e.executionParams = params
 }
 
+// WithExecutionParams returns a copy of the executor bound to the given tenant
+// context, so concurrent executions for different projects don't share state
+func (e *UnifiedTestExecutor) WithExecutionParams(params *ExecutionParams) *UnifiedTestExecutor {
+	clone := *e
+	clone.executionParams = params
+	return &clone
+}
+
+// WithVariables returns a copy of the executor whose injected variables are
+// overridden by vars, on top of the variables of its execution params
+func (e *UnifiedTestExecutor) WithVariables(vars map[string]interface{}) *UnifiedTestExecutor {
+	var params ExecutionParams
+	if e.executionParams != nil {
+		params = *e.executionParams
+	}
+	merged := make(map[string]interface{}, len(params.Variables)+len(vars))
+	for k, v := range params.Variables {
+		merged[k] = v
+	}
+	for k, v := range vars {
+		merged[k] = v
+	}
+	params.Variables = merged
+	return e.WithExecutionParams(&params)
+}
+
+// WithBaseURL returns a copy of the executor targeting another base URL
+func (e *UnifiedTestExecutor) WithBaseURL(baseURL string) *UnifiedTestExecutor {
+	clone := *e
+	clone.baseURL = baseURL
+	return &clone
+}
+
+// WithContext returns a copy of the executor whose HTTP requests and
+// processes are cancelled when ctx is done
+func (e *UnifiedTestExecutor) WithContext(ctx context.Context) *UnifiedTestExecutor {
+	clone := *e
+	clone.ctx = ctx
+	return &clone
+}
+
+// execContext returns the context executions run in
+func (e *UnifiedTestExecutor) execContext() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// SetSandboxProvider sets the provider of per-tenant sandbox configuration
+func (e *UnifiedTestExecutor) SetSandboxProvider(provider SandboxConfigProvider) {
+	e.sandboxProvider = provider
+}
+
+// SetSchemaLoader sets the loader for stored schema documents
+func (e *UnifiedTestExecutor) SetSchemaLoader(loader SchemaLoader) {
+	e.schemaLoader = loader
+}
+
+// SetSnapshotStore sets the store for snapshot assertions
+func (e *UnifiedTestExecutor) SetSnapshotStore(store SnapshotStore) {
+	e.snapshotStore = store
+}
+
+// SetStrictVariables makes template interpolation fail on {{ }} placeholders
+// that cannot be resolved instead of leaving them in place
+func (e *UnifiedTestExecutor) SetStrictVariables(strict bool) {
+	e.strictVariables = strict
+}
+
+// SetAllowedPaths sets the directories that multipart files and TLS
+// certificates may be read from. Without any, only inline content is accepted.
+func (e *UnifiedTestExecutor) SetAllowedPaths(roots []string) {
+	e.allowedPaths = roots
+}
+
 // Execute runs a test case with lifecycle hooks (unified entry point)
 func (e *UnifiedTestExecutor) Execute(tc *TestCase) *TestResult {
+	return e.ExecuteWithSessions(tc, nil)
+}
+
+// ExecuteWithSessions executes a test case sharing the given session store, so
+// cookies persist across test cases or workflow steps. A nil store creates a
+// fresh one scoped to this test case.
+func (e *UnifiedTestExecutor) ExecuteWithSessions(tc *TestCase, sessions *SessionStore) *TestResult {
+	if sessions == nil {
+		sessions = NewSessionStore(nil)
+	}
+	for _, cfg := range tc.Sessions {
+		sessions.DefineDefault(cfg)
+	}
+
 	result := &TestResult{
 		TestID:    tc.ID,
 		Name:      tc.Name,
@@ -125,19 +244,23 @@ func (e *UnifiedTestExecutor) Execute(tc *TestCase) *TestResult {
 		Status:    "passed",
 	}
 
-	// Context for storing hook responses
-	ctx := make(map[string]interface{})
+	// Context for storing hook responses, starting from the variables the
+	// test inherits from its workflow or group hooks
+	ctx := make(map[string]interface{}, len(tc.Variables))
+	for k, v := range tc.Variables {
+		ctx[k] = v
+	}
 
 	defer func() {
 		result.EndTime = time.Now()
 		result.Duration = result.EndTime.Sub(result.StartTime)
 
 		// Run teardown hooks (always execute, even on failure)
-		e.executeTeardownHooks(tc, result, ctx)
+		e.executeTeardownHooks(tc, result, ctx, sessions)
 	}()
 
 	// Run setup hooks
-	if !e.executeSetupHooks(tc, result, ctx) {
+	if !e.executeSetupHooks(tc, result, ctx, sessions) {
 		// Setup failed, skip test execution
 		return result
 	}
@@ -145,9 +268,11 @@ func (e *UnifiedTestExecutor) Execute(tc *TestCase) *TestResult {
 	// Execute the main test
 	switch tc.Type {
 	case "http":
-		e.executeHTTP(tc, result)
+		e.executeHTTP(tc, result, sessions, ctx)
 	case "command":
-		e.executeCommand(tc, result)
+		e.executeCommand(tc, result, ctx)
+	case "graphql":
+		e.executeGraphQL(tc, result, sessions, ctx)
 	case "workflow":
 		e.executeWorkflowTest(tc, result)
 	default:
@@ -159,7 +284,7 @@ func (e *UnifiedTestExecutor) Execute(tc *TestCase) *TestResult {
 }
 
 // executeHTTP executes an HTTP test
-func (e *UnifiedTestExecutor) executeHTTP(tc *TestCase, result *TestResult) {
+func (e *UnifiedTestExecutor) executeHTTP(tc *TestCase, result *TestResult, sessions *SessionStore, hookCtx map[string]interface{}) {
 	if tc.HTTP == nil {
 		result.Status = "error"
 		result.Error = "HTTP configuration missing"
@@ -168,79 +293,55 @@ func (e *UnifiedTestExecutor) executeHTTP(tc *TestCase, result *TestResult) {
 
 	// Inject environment variables if variableInjector is available
 	if e.variableInjector != nil && e.executionParams != nil {
-		if err := e.variableInjector.InjectHTTPVariables(context.Background(), e.executionParams.TenantID, e.executionParams.ProjectID, tc.HTTP); err != nil {
+		if err := e.variableInjector.InjectHTTPVariables(context.Background(), e.executionParams, tc.HTTP); err != nil {
 			result.Status = "error"
 			result.Error = fmt.Sprintf("failed to inject variables: %v", err)
 			return
 		}
 	}
 
-	// Prepare request body
-	var bodyReader io.Reader
-	if tc.HTTP.Body != nil {
-		bodyBytes, err := json.Marshal(tc.HTTP.Body)
-		if err != nil {
-			result.Status = "error"
-			result.Error = fmt.Sprintf("failed to marshal body: %v", err)
-			return
-		}
-		bodyReader = bytes.NewReader(bodyBytes)
-	}
-
-	// Build URL - prioritize URL field over Path
-	var url string
-	if tc.HTTP.URL != "" {
-		url = tc.HTTP.URL // Use full URL if provided
-	} else {
-		url = e.baseURL + tc.HTTP.Path // Otherwise append path to baseURL
-	}
-	req, err := http.NewRequest(tc.HTTP.Method, url, bodyReader)
-	if err != nil {
-		result.Status = "error"
-		result.Error = fmt.Sprintf("failed to create request: %v", err)
-		return
-	}
-
-	// Set headers
-	for k, v := range tc.HTTP.Headers {
-		req.Header.Set(k, v)
-	}
+	req, resp, err := e.doHTTP(tc.HTTP, sessions)
 
 	// Store request info
-	result.Request = map[string]interface{}{
-		"method":  tc.HTTP.Method,
-		"url":     url,
-		"headers": tc.HTTP.Headers,
-		"body":    tc.HTTP.Body,
+	if req != nil {
+		result.Request = map[string]interface{}{
+			"method":  req.Method,
+			"url":     req.URL.String(),
+			"headers": tc.HTTP.Headers,
+			"body":    requestBodyForResult(tc.HTTP),
+		}
 	}
 
-	// Execute request
-	resp, err := e.client.Do(req)
 	if err != nil {
 		result.Status = "error"
-		result.Error = fmt.Sprintf("request failed: %v", err)
+		result.Error = err.Error()
 		return
 	}
-	defer resp.Body.Close()
 
-	// Read response body
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	var responseBody map[string]interface{}
-	json.Unmarshal(bodyBytes, &responseBody)
-
-	result.Response = map[string]interface{}{
-		"statusCode": resp.StatusCode,
-		"headers":    resp.Header,
-		"body":       responseBody,
-		"bodyRaw":    string(bodyBytes),
-	}
+	result.Response = resp.toMap()
 
 	// Run assertions
-	e.runHTTPAssertions(tc.Assertions, resp.StatusCode, responseBody, result)
+	e.runHTTPAssertions(tc.Assertions, resp, e.exprEvaluator(tc, hookCtx, result), result)
+
+	// Check the exchange against the linked OpenAPI contract
+	e.checkContract(tc.Contract, req, requestBodyForContract(tc.HTTP), resp, result)
+}
+
+// requestBodyForResult returns the request body representation stored on the result
+func requestBodyForResult(cfg *HTTPTest) interface{} {
+	switch {
+	case cfg.Multipart != nil:
+		return cfg.Multipart
+	case cfg.Form != nil:
+		return cfg.Form
+	case cfg.RawBody != "":
+		return cfg.RawBody
+	}
+	return cfg.Body
 }
 
 // executeCommand executes a command test
-func (e *UnifiedTestExecutor) executeCommand(tc *TestCase, result *TestResult) {
+func (e *UnifiedTestExecutor) executeCommand(tc *TestCase, result *TestResult, hookCtx map[string]interface{}) {
 	if tc.Command == nil {
 		result.Status = "error"
 		result.Error = "Command configuration missing"
@@ -249,60 +350,40 @@ func (e *UnifiedTestExecutor) executeCommand(tc *TestCase, result *TestResult) {
 
 	// Inject environment variables if variableInjector is available
 	if e.variableInjector != nil && e.executionParams != nil {
-		if err := e.variableInjector.InjectCommandVariables(context.Background(), e.executionParams.TenantID, e.executionParams.ProjectID, tc.Command); err != nil {
+		if err := e.variableInjector.InjectCommandVariables(context.Background(), e.executionParams, tc.Command); err != nil {
 			result.Status = "error"
 			result.Error = fmt.Sprintf("failed to inject variables: %v", err)
 			return
 		}
 	}
 
-	cmd := exec.Command(tc.Command.Cmd, tc.Command.Args...)
-	if tc.Command.Cwd != "" {
-		cmd.Dir = tc.Command.Cwd
-	}
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
 	// Set timeout
 	timeout := 60 * time.Second
 	if tc.Command.Timeout > 0 {
 		timeout = time.Duration(tc.Command.Timeout) * time.Second
 	}
 
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Run()
-	}()
-
-	select {
-	case err := <-done:
-		exitCode := 0
-		if err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				exitCode = exitErr.ExitCode()
-			} else {
-				result.Status = "error"
-				result.Error = fmt.Sprintf("command failed: %v", err)
-				return
-			}
-		}
-
-		result.Response = map[string]interface{}{
-			"exitCode": exitCode,
-			"stdout":   stdout.String(),
-			"stderr":   stderr.String(),
-		}
-
-		// Run assertions
-		e.runCommandAssertions(tc.Assertions, exitCode, stdout.String(), result)
-
-	case <-time.After(timeout):
-		cmd.Process.Kill()
+	run, err := sandbox.Run(e.execContext(), e.sandboxConfig(), &sandbox.Spec{
+		Path:    tc.Command.Cmd,
+		Args:    tc.Command.Args,
+		Dir:     tc.Command.Cwd,
+		Timeout: timeout,
+	})
+	if err != nil {
+		result.Status = "error"
+		result.Error = fmt.Sprintf("command failed: %v", err)
+		return
+	}
+	if run.TimedOut {
 		result.Status = "failed"
 		result.Failures = append(result.Failures, fmt.Sprintf("command timeout after %v", timeout))
+		return
 	}
+
+	result.Response = commandResponse(run)
+
+	// Run assertions
+	e.runCommandAssertions(tc.Assertions, run.ExitCode, run.Stdout, e.exprEvaluator(tc, hookCtx, result), result)
 }
 
 // executeWorkflowTest executes a workflow-type test case
@@ -386,129 +467,207 @@ func convertWorkflowStatusToTestStatus(workflowStatus string) string {
 	}
 }
 
-// runHTTPAssertions runs HTTP assertions
-func (e *UnifiedTestExecutor) runHTTPAssertions(assertions []Assertion, statusCode int, body map[string]interface{}, result *TestResult) {
-	for _, assertion := range assertions {
-		switch assertion.Type {
+// runHTTPAssertions runs HTTP assertions; exprs evaluates expr assertions
+func (e *UnifiedTestExecutor) runHTTPAssertions(assertions []Assertion, resp *httpResponse, exprs *expression.Evaluator, result *TestResult) {
+	body := resp.Body
+	for _, a := range assertions {
+		switch a.Type {
 		case "status_code":
-			if !e.checkStatusCode(assertion, statusCode) {
-				result.Status = "failed"
-				result.Failures = append(result.Failures,
-					fmt.Sprintf("status code: expected %v, got %d", assertion.Expected, statusCode))
+			e.evaluate(a, "status_code", "status code", resp.StatusCode, result)
+
+		case "json_path", "body":
+			label := "body"
+			if a.Path != "" {
+				label = "JSON path " + a.Path
 			}
+			e.evaluate(a, "body", label, body, result)
 
-		case "json_path":
-			if !e.checkJSONPath(assertion, body, result) {
-				result.Status = "failed"
+		case "json_schema":
+			e.recordCheck(a, "body", result, func() bool { return e.checkJSONSchema(a, body, result) })
+
+		case "snapshot":
+			e.recordCheck(a, "body", result, func() bool { return e.checkSnapshot(a, body, result) })
+
+		case assertion.ExprType:
+			e.checkExpr(a, "response", exprs, result)
+
+		default:
+			// XML, HTML and text bodies are checked on the raw response
+			if resp.BodyType != BodyTypeBinary {
+				e.runTextAssertion(a, "body", string(resp.Raw), markupType(resp.BodyType), result)
 			}
 		}
 	}
 }
 
-// runCommandAssertions runs command assertions
-func (e *UnifiedTestExecutor) runCommandAssertions(assertions []Assertion, exitCode int, stdout string, result *TestResult) {
-	for _, assertion := range assertions {
-		switch assertion.Type {
+// runCommandAssertions runs command assertions; exprs evaluates expr assertions
+func (e *UnifiedTestExecutor) runCommandAssertions(assertions []Assertion, exitCode int, stdout string, exprs *expression.Evaluator, result *TestResult) {
+	for _, a := range assertions {
+		switch a.Type {
 		case "exit_code":
-			expected, ok := assertion.Expected.(float64)
-			if !ok {
-				expectedInt, ok := assertion.Expected.(int)
-				if ok {
-					expected = float64(expectedInt)
-				}
-			}
-			if exitCode != int(expected) {
-				result.Status = "failed"
-				result.Failures = append(result.Failures,
-					fmt.Sprintf("exit code: expected %v, got %d", assertion.Expected, exitCode))
-			}
+			e.evaluate(a, "exit_code", "exit code", exitCode, result)
 
 		case "stdout_contains":
-			expected, ok := assertion.Expected.(string)
-			if !ok || !strings.Contains(stdout, expected) {
-				result.Status = "failed"
-				result.Failures = append(result.Failures,
-					fmt.Sprintf("stdout should contain: %v", assertion.Expected))
+			a.Operator = "contains"
+			e.evaluate(a, "stdout", "stdout", stdout, result)
+
+		case "stdout":
+			// Structured output is navigated as JSON, anything else checked as text
+			var subject interface{} = stdout
+			if a.Path != "" {
+				subject = parseJSON(stdout)
 			}
+			e.evaluate(a, "stdout", "stdout", subject, result)
+
+		case "json_schema":
+			e.recordCheck(a, "stdout", result, func() bool {
+				var body interface{}
+				if err := json.Unmarshal([]byte(stdout), &body); err != nil {
+					result.Failures = append(result.Failures, fmt.Sprintf("json_schema: stdout is not JSON: %v", err))
+					return false
+				}
+				return e.checkJSONSchema(a, body, result)
+			})
+
+		case "snapshot":
+			// Structured output is compared as JSON, anything else as text
+			e.recordCheck(a, "stdout", result, func() bool { return e.checkSnapshot(a, parseJSON(stdout), result) })
+
+		case assertion.ExprType:
+			e.checkExpr(a, "response", exprs, result)
+
+		default:
+			e.runTextAssertion(a, "stdout", stdout, "", result)
 		}
 	}
 }
 
-// checkStatusCode checks status code assertion
-func (e *UnifiedTestExecutor) checkStatusCode(assertion Assertion, actualCode int) bool {
-	if assertion.Operator == "in" {
-		// Expected is an array of valid status codes
-		if arr, ok := assertion.Expected.([]interface{}); ok {
-			for _, v := range arr {
-				if code, ok := v.(float64); ok && int(code) == actualCode {
-					return true
-				}
-			}
+// parseJSON returns the JSON value of text, or text itself when it isn't JSON
+func parseJSON(text string) interface{} {
+	var parsed interface{}
+	if json.Unmarshal([]byte(text), &parsed) == nil {
+		return parsed
+	}
+	return text
+}
+
+// evaluate runs an operator assertion with the shared assertion engine and
+// records its result; label prefixes the failure message
+func (e *UnifiedTestExecutor) evaluate(a Assertion, target, label string, subject interface{}, result *TestResult) {
+	outcome := assertion.Evaluate(assertion.Assertion{
+		Target:   target,
+		Operator: a.Operator,
+		Path:     a.Path,
+		Expected: a.Expected,
+	}, subject)
+	result.Assertions = append(result.Assertions, outcome)
+	if !outcome.Passed {
+		result.Status = "failed"
+		result.Failures = append(result.Failures, fmt.Sprintf("%s: %s", label, outcome.Message))
+	}
+}
+
+// recordCheck records the result of a check that reports its own failures
+func (e *UnifiedTestExecutor) recordCheck(a Assertion, target string, result *TestResult, check func() bool) {
+	before := len(result.Failures)
+	outcome := models.AssertionResult{Type: a.Type, Target: target, Path: a.Path, Passed: check()}
+	if !outcome.Passed {
+		result.Status = "failed"
+		outcome.Message = strings.Join(result.Failures[before:], "; ")
+	}
+	result.Assertions = append(result.Assertions, outcome)
+}
+
+// checkJSONSchema validates the body (or the value at Path) against a JSON Schema
+func (e *UnifiedTestExecutor) checkJSONSchema(assertion Assertion, body interface{}, result *TestResult) bool {
+	value := body
+	if assertion.Path != "" {
+		var found bool
+		var err error
+		if value, found, err = jsonpath.Lookup(body, assertion.Path); err != nil || !found {
+			result.Failures = append(result.Failures, fmt.Sprintf("json_schema: JSON path %s not found", assertion.Path))
 			return false
 		}
 	}
 
-	// Exact match
-	if expected, ok := assertion.Expected.(float64); ok {
-		return int(expected) == actualCode
+	violations, err := schema.Validate(schema.Spec{
+		Schema: assertion.Schema,
+		Ref:    assertion.SchemaRef,
+		Draft:  assertion.Draft,
+	}, value, e.loadSchemaDocument)
+	if err != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("json_schema: %v", err))
+		return false
 	}
-	if expected, ok := assertion.Expected.(int); ok {
-		return expected == actualCode
+	for _, v := range violations {
+		result.Failures = append(result.Failures, fmt.Sprintf("json_schema %s", v))
 	}
-
-	return false
+	return len(violations) == 0
 }
 
-// checkJSONPath checks JSON path assertion
-func (e *UnifiedTestExecutor) checkJSONPath(assertion Assertion, body map[string]interface{}, result *TestResult) bool {
-	// Simple JSON path implementation (only supports $.field notation)
-	path := strings.TrimPrefix(assertion.Path, "$.")
-
-	var value interface{}
-	if strings.Contains(path, ".") {
-		// Nested path
-		parts := strings.Split(path, ".")
-		current := body
-		for i, part := range parts {
-			if i == len(parts)-1 {
-				value = current[part]
-			} else {
-				if next, ok := current[part].(map[string]interface{}); ok {
-					current = next
-				} else {
-					result.Failures = append(result.Failures,
-						fmt.Sprintf("JSON path %s not found", assertion.Path))
-					return false
-				}
-			}
-		}
-	} else {
-		value = body[path]
+// checkSnapshot compares the body (or the value at Path) with the approved
+// snapshot of the test case. The first run records the snapshot.
+func (e *UnifiedTestExecutor) checkSnapshot(assertion Assertion, body interface{}, result *TestResult) bool {
+	name := assertion.Snapshot
+	if name == "" {
+		name = snapshot.DefaultName
+	}
+	if e.snapshotStore == nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("snapshot %s: snapshot storage is not available", name))
+		return false
 	}
 
-	if assertion.Operator == "exists" {
-		if value == nil {
-			result.Failures = append(result.Failures,
-				fmt.Sprintf("JSON path %s should exist", assertion.Path))
+	value := body
+	if assertion.Path != "" {
+		var found bool
+		var err error
+		if value, found, err = jsonpath.Lookup(body, assertion.Path); err != nil || !found {
+			result.Failures = append(result.Failures, fmt.Sprintf("snapshot %s: JSON path %s not found", name, assertion.Path))
 			return false
 		}
-		return true
 	}
 
-	// Exact match
-	if value != assertion.Expected {
-		result.Failures = append(result.Failures,
-			fmt.Sprintf("JSON path %s: expected %v, got %v", assertion.Path, assertion.Expected, value))
+	normalized, err := snapshot.Normalize(value, assertion.Ignore)
+	if err != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("snapshot %s: %v", name, err))
+		return false
+	}
+
+	key := snapshot.Key{TestID: result.TestID, Name: name}
+	if e.executionParams != nil {
+		key.TenantID, key.ProjectID = e.executionParams.TenantID, e.executionParams.ProjectID
+	}
+	outcome, err := e.snapshotStore.MatchSnapshot(context.Background(), key, normalized)
+	if err != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("snapshot %s: %v", name, err))
 		return false
 	}
+	result.Snapshots = append(result.Snapshots, *outcome)
 
+	if outcome.Status == snapshot.StatusChanged {
+		result.Failures = append(result.Failures, fmt.Sprintf("snapshot %s changed since version %d (version %d awaits approval): %s",
+			name, outcome.Version, outcome.PendingVersion, snapshot.Summary(outcome.Diff, 10)))
+		return false
+	}
 	return true
 }
 
+// loadSchemaDocument loads a stored schema document of the current project
+func (e *UnifiedTestExecutor) loadSchemaDocument(name string) (interface{}, error) {
+	if e.schemaLoader == nil {
+		return nil, fmt.Errorf("schema '%s' not found: stored schemas are not available", name)
+	}
+	tenantID, projectID := "", ""
+	if e.executionParams != nil {
+		tenantID, projectID = e.executionParams.TenantID, e.executionParams.ProjectID
+	}
+	return e.schemaLoader.LoadSchemaDocument(context.Background(), tenantID, projectID, name)
+}
+
 // executeSetupHooks runs setup hooks before test execution
-func (e *UnifiedTestExecutor) executeSetupHooks(tc *TestCase, result *TestResult, ctx map[string]interface{}) bool {
+func (e *UnifiedTestExecutor) executeSetupHooks(tc *TestCase, result *TestResult, ctx map[string]interface{}, sessions *SessionStore) bool {
 	for _, hook := range tc.SetupHooks {
-		if !e.executeHook(&hook, "setup", result, ctx) {
+		if !e.executeHook(&hook, "setup", result, ctx, sessions) {
 			// Setup hook failed
 			if !hook.ContinueOnError {
 				result.Status = "error"
@@ -521,7 +680,7 @@ func (e *UnifiedTestExecutor) executeSetupHooks(tc *TestCase, result *TestResult
 }
 
 // executeTeardownHooks runs teardown hooks after test execution
-func (e *UnifiedTestExecutor) executeTeardownHooks(tc *TestCase, result *TestResult, ctx map[string]interface{}) {
+func (e *UnifiedTestExecutor) executeTeardownHooks(tc *TestCase, result *TestResult, ctx map[string]interface{}, sessions *SessionStore) {
 	for _, hook := range tc.TeardownHooks {
 		// Check if hook should run on failure
 		if result.Status == "failed" || result.Status == "error" {
@@ -530,7 +689,7 @@ func (e *UnifiedTestExecutor) executeTeardownHooks(tc *TestCase, result *TestRes
 			}
 		}
 
-		if !e.executeHook(&hook, "teardown", result, ctx) {
+		if !e.executeHook(&hook, "teardown", result, ctx, sessions) {
 			// Teardown hook failed, but don't fail the test
 			if result.Status == "passed" && !hook.ContinueOnError {
 				result.Status = "failed"
@@ -541,13 +700,35 @@ func (e *UnifiedTestExecutor) executeTeardownHooks(tc *TestCase, result *TestRes
 	}
 }
 
+// RunGroupHooks runs the setup or teardown hooks of a test group once, outside
+// any test case. Responses saved by the hooks are added to vars. Setup stops at
+// the first hook that fails; teardown runs every hook and reports the failures.
+func (e *UnifiedTestExecutor) RunGroupHooks(phase string, hooks []Hook, vars map[string]interface{}) error {
+	sessions := NewSessionStore(nil)
+	result := &TestResult{}
+	var failed []string
+	for _, hook := range hooks {
+		if e.executeHook(&hook, phase, result, vars, sessions) || hook.ContinueOnError {
+			continue
+		}
+		if phase == "setup" {
+			return fmt.Errorf("setup hook '%s' failed", hook.Name)
+		}
+		failed = append(failed, hook.Name)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s hooks failed: %s", phase, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
 // executeHook executes a single hook
-func (e *UnifiedTestExecutor) executeHook(hook *Hook, phase string, result *TestResult, ctx map[string]interface{}) bool {
+func (e *UnifiedTestExecutor) executeHook(hook *Hook, phase string, result *TestResult, ctx map[string]interface{}, sessions *SessionStore) bool {
 	fmt.Printf("[%s hook] Executing: %s (type: %s)\n", phase, hook.Name, hook.Type)
 
 	switch hook.Type {
 	case "http":
-		return e.executeHTTPHook(hook, result, ctx)
+		return e.executeHTTPHook(hook, result, ctx, sessions)
 	case "command":
 		return e.executeCommandHook(hook, result, ctx)
 	default:
@@ -557,57 +738,19 @@ func (e *UnifiedTestExecutor) executeHook(hook *Hook, phase string, result *Test
 }
 
 // executeHTTPHook executes an HTTP hook
-func (e *UnifiedTestExecutor) executeHTTPHook(hook *Hook, result *TestResult, ctx map[string]interface{}) bool {
+func (e *UnifiedTestExecutor) executeHTTPHook(hook *Hook, result *TestResult, ctx map[string]interface{}, sessions *SessionStore) bool {
 	if hook.HTTP == nil {
 		return false
 	}
 
-	// Prepare request body
-	var bodyReader io.Reader
-	if hook.HTTP.Body != nil {
-		bodyBytes, err := json.Marshal(hook.HTTP.Body)
-		if err != nil {
-			fmt.Printf("[HTTP hook] Failed to marshal body: %v\n", err)
-			return false
-		}
-		bodyReader = bytes.NewReader(bodyBytes)
-	}
-
-	// Build URL
-	url := e.baseURL + hook.HTTP.Path
-	req, err := http.NewRequest(hook.HTTP.Method, url, bodyReader)
-	if err != nil {
-		fmt.Printf("[HTTP hook] Failed to create request: %v\n", err)
-		return false
-	}
-
-	// Set headers
-	for k, v := range hook.HTTP.Headers {
-		req.Header.Set(k, v)
-	}
-
-	// Execute request
-	resp, err := e.client.Do(req)
+	_, resp, err := e.doHTTP(hook.HTTP, sessions)
 	if err != nil {
-		fmt.Printf("[HTTP hook] Request failed: %v\n", err)
+		fmt.Printf("[HTTP hook] %v\n", err)
 		return false
 	}
-	defer resp.Body.Close()
-
-	// Read response
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	var responseBody map[string]interface{}
-	json.Unmarshal(bodyBytes, &responseBody)
 
 	// Save response if requested
-	if hook.SaveResponse != "" {
-		ctx[hook.SaveResponse] = map[string]interface{}{
-			"statusCode": resp.StatusCode,
-			"body":       responseBody,
-			"bodyRaw":    string(bodyBytes),
-		}
-		fmt.Printf("[HTTP hook] Saved response to context: %s\n", hook.SaveResponse)
-	}
+	saveHookResponse(hook, resp.toMap(), ctx)
 
 	// Consider 2xx status codes as success
 	success := resp.StatusCode >= 200 && resp.StatusCode < 300
@@ -624,58 +767,87 @@ func (e *UnifiedTestExecutor) executeCommandHook(hook *Hook, result *TestResult,
 		return false
 	}
 
-	cmd := exec.Command(hook.Command.Cmd, hook.Command.Args...)
-	if hook.Command.Cwd != "" {
-		cmd.Dir = hook.Command.Cwd
-	}
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
 	// Set timeout
 	timeout := 60 * time.Second
 	if hook.Command.Timeout > 0 {
 		timeout = time.Duration(hook.Command.Timeout) * time.Second
 	}
 
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Run()
-	}()
+	run, err := sandbox.Run(e.execContext(), e.sandboxConfig(), &sandbox.Spec{
+		Path:    hook.Command.Cmd,
+		Args:    hook.Command.Args,
+		Dir:     hook.Command.Cwd,
+		Timeout: timeout,
+	})
+	if err != nil {
+		fmt.Printf("[Command hook] Failed: %v\n", err)
+		return false
+	}
+	if run.TimedOut {
+		fmt.Printf("[Command hook] Timeout after %v\n", timeout)
+		return false
+	}
 
-	select {
-	case err := <-done:
-		exitCode := 0
-		if err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				exitCode = exitErr.ExitCode()
-			} else {
-				fmt.Printf("[Command hook] Failed: %v\n", err)
-				return false
-			}
-		}
+	// Save response if requested
+	saveHookResponse(hook, commandResponse(run), ctx)
 
-		// Save response if requested
-		if hook.SaveResponse != "" {
-			ctx[hook.SaveResponse] = map[string]interface{}{
-				"exitCode": exitCode,
-				"stdout":   stdout.String(),
-				"stderr":   stderr.String(),
-			}
-			fmt.Printf("[Command hook] Saved response to context: %s\n", hook.SaveResponse)
-		}
+	success := run.ExitCode == 0
+	if !success {
+		fmt.Printf("[Command hook] Failed with exit code: %d\n", run.ExitCode)
+	}
+	return success
+}
+
+// saveHookResponse stores the hook response, or the value selected by
+// SaveResponsePath, in the hook context
+func saveHookResponse(hook *Hook, response map[string]interface{}, ctx map[string]interface{}) {
+	if hook.SaveResponse == "" {
+		return
+	}
 
-		success := exitCode == 0
-		if !success {
-			fmt.Printf("[Command hook] Failed with exit code: %d\n", exitCode)
+	var value interface{} = response
+	if hook.SaveResponsePath != "" {
+		selected, found, err := jsonpath.Lookup(response, hook.SaveResponsePath)
+		if err != nil || !found {
+			fmt.Printf("[%s hook] Response path %s not found\n", hook.Type, hook.SaveResponsePath)
+			return
 		}
-		return success
+		value = selected
+	}
 
-	case <-time.After(timeout):
-		cmd.Process.Kill()
-		fmt.Printf("[Command hook] Timeout after %v\n", timeout)
-		return false
+	ctx[hook.SaveResponse] = value
+	fmt.Printf("[%s hook] Saved response to context: %s\n", hook.Type, hook.SaveResponse)
+}
+
+// commandResponse converts a sandbox run into the command response map
+func commandResponse(run *sandbox.Result) map[string]interface{} {
+	response := map[string]interface{}{
+		"exitCode": run.ExitCode,
+		"stdout":   run.Stdout,
+		"stderr":   run.Stderr,
+	}
+	if run.Truncated {
+		response["outputTruncated"] = true
+	}
+	return response
+}
+
+// sandboxConfig resolves the sandbox configuration for the current tenant
+func (e *UnifiedTestExecutor) sandboxConfig() *sandbox.Config {
+	if e.sandboxProvider == nil {
+		return sandbox.DefaultConfig()
+	}
+	tenantID := ""
+	if e.executionParams != nil {
+		tenantID = e.executionParams.TenantID
+	}
+	cfg, err := e.sandboxProvider.GetSandboxConfig(context.Background(), tenantID)
+	if err != nil || cfg == nil {
+		if err != nil {
+			fmt.Printf("[Sandbox] Failed to load config, using defaults: %v\n", err)
+		}
+		return sandbox.DefaultConfig()
 	}
+	return cfg
 }
 
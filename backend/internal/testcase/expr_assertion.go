@@ -0,0 +1,46 @@
+package testcase
+
+import (
+	"test-management-service/internal/assertion"
+	"test-management-service/internal/expression"
+)
+
+// exprEvaluator returns the evaluator of the test case's expr assertions, or
+// nil when it has none. Expressions see the active environment, the workflow
+// variables and the responses saved by setup hooks as variables, the test's
+// own response as "response", and workflow step outputs by step ID.
+func (e *UnifiedTestExecutor) exprEvaluator(tc *TestCase, hookCtx map[string]interface{}, result *TestResult) *expression.Evaluator {
+	needed := false
+	for _, a := range tc.Assertions {
+		if a.Type == assertion.ExprType {
+			needed = true
+			break
+		}
+	}
+	if !needed {
+		return nil
+	}
+
+	variables := e.templateVariables(nil)
+	for k, v := range tc.Variables {
+		variables[k] = v
+	}
+	for k, v := range hookCtx {
+		variables[k] = v
+	}
+	variables["response"] = result.Response
+	return expression.NewEvaluator(variables, tc.StepOutputs)
+}
+
+// checkExpr runs an expr assertion and records its result
+func (e *UnifiedTestExecutor) checkExpr(a Assertion, target string, evaluator *expression.Evaluator, result *TestResult) {
+	if evaluator == nil {
+		evaluator = expression.NewEvaluator(nil, nil)
+	}
+	outcome := assertion.EvaluateExpr(target, a.Expression, evaluator)
+	result.Assertions = append(result.Assertions, outcome)
+	if !outcome.Passed {
+		result.Status = "failed"
+		result.Failures = append(result.Failures, "expr: "+outcome.Message)
+	}
+}
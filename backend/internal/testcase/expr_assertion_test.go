@@ -0,0 +1,51 @@
+package testcase
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExprAssertion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"total":50,"expected":49,"status":"paid"}`)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(server.URL)
+	run := func(tc *TestCase) *TestResult {
+		tc.ID, tc.Type, tc.HTTP = "e1", "http", &HTTPTest{Method: "GET", Path: "/order"}
+		return executor.Execute(tc)
+	}
+
+	t.Run("passing expression", func(t *testing.T) {
+		result := run(&TestCase{
+			Assertions: []Assertion{{Type: "expr", Expression: "response.statusCode == 200 && response.body.status == wantStatus"}},
+			Variables:  map[string]interface{}{"wantStatus": "paid"},
+		})
+		assert.Equal(t, "passed", result.Status, result.Failures)
+		require.Len(t, result.Assertions, 1)
+		assert.Equal(t, "expr", result.Assertions[0].Type)
+		assert.True(t, result.Assertions[0].Passed)
+	})
+
+	t.Run("failing expression shows sub-expression values", func(t *testing.T) {
+		result := run(&TestCase{
+			Assertions:  []Assertion{{Type: "expr", Expression: "{{response.body.total == login.expected}}"}},
+			StepOutputs: map[string]interface{}{"login": map[string]interface{}{"expected": 49}},
+		})
+		assert.Equal(t, "failed", result.Status)
+		require.Len(t, result.Failures, 1)
+		assert.Equal(t, "expr: expression is false\n"+
+			"response.body.total == login.expected\n"+
+			"|                   |  |\n"+
+			"|                   |  49\n"+
+			"|                   false\n"+
+			"50", result.Failures[0])
+	})
+}
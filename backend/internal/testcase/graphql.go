@@ -0,0 +1,456 @@
+package testcase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"test-management-service/internal/expression"
+)
+
+// schemaCacheTTL controls how long an introspected schema is reused
+const schemaCacheTTL = 5 * time.Minute
+
+// GraphQLTest represents a GraphQL request. Transport options (url, path,
+// headers, auth, session, tls, ...) are shared with HTTPTest.
+type GraphQLTest struct {
+	HTTPTest
+
+	Query          string                 `json:"query"` // GraphQL document
+	OperationName  string                 `json:"operationName,omitempty"`
+	Variables      map[string]interface{} `json:"variables,omitempty"`
+	ValidateSchema bool                   `json:"validateSchema,omitempty"` // Introspect the schema and validate the query before sending
+	SchemaURL      string                 `json:"schemaUrl,omitempty"`      // Introspection endpoint, defaults to the request URL
+}
+
+// environmentVariableProvider is implemented by injectors that can expose the
+// variables of the environment a test executes in (used to interpolate
+// GraphQL documents)
+type environmentVariableProvider interface {
+	GetExecutionVariables(ctx context.Context, params *ExecutionParams) (map[string]interface{}, error)
+}
+
+// executeGraphQL executes a GraphQL test
+func (e *UnifiedTestExecutor) executeGraphQL(tc *TestCase, result *TestResult, sessions *SessionStore, hookCtx map[string]interface{}) {
+	if tc.GraphQL == nil || strings.TrimSpace(tc.GraphQL.Query) == "" {
+		result.Status = "error"
+		result.Error = "GraphQL query missing"
+		return
+	}
+	cfg := tc.GraphQL
+
+	// Inject environment variables into transport options (url, headers, auth...)
+	if e.variableInjector != nil && e.executionParams != nil {
+		if err := e.variableInjector.InjectHTTPVariables(context.Background(), e.executionParams, &cfg.HTTPTest); err != nil {
+			result.Status = "error"
+			result.Error = fmt.Sprintf("failed to inject variables: %v", err)
+			return
+		}
+	}
+
+	// Interpolate the document and variables through the expression evaluator
+	evaluator := expression.NewEvaluator(e.templateVariables(hookCtx), nil)
+	evaluator.SetStrict(e.strictVariables)
+	query, err := evaluator.EvaluateString(cfg.Query)
+	if err != nil {
+		result.Status = "error"
+		result.Error = fmt.Sprintf("failed to interpolate query: %v", err)
+		return
+	}
+	rendered, err := evaluator.InterpolateValue(cfg.Variables)
+	if err != nil {
+		result.Status = "error"
+		result.Error = fmt.Sprintf("failed to interpolate variables: %v", err)
+		return
+	}
+	variables, _ := rendered.(map[string]interface{})
+
+	if cfg.ValidateSchema {
+		schema, err := e.loadGraphQLSchema(cfg, sessions)
+		if err != nil {
+			result.Status = "error"
+			result.Error = fmt.Sprintf("failed to load GraphQL schema: %v", err)
+			return
+		}
+		if _, errs := gqlparser.LoadQuery(schema, query); len(errs) > 0 {
+			result.Status = "failed"
+			for _, gqlErr := range errs {
+				result.Failures = append(result.Failures, fmt.Sprintf("GraphQL validation: %s", gqlErr.Error()))
+			}
+			return
+		}
+	}
+
+	httpCfg := graphQLHTTPRequest(cfg, query, variables)
+	req, resp, err := e.doHTTP(httpCfg, sessions)
+
+	if req != nil {
+		result.Request = map[string]interface{}{
+			"method":        req.Method,
+			"url":           req.URL.String(),
+			"headers":       cfg.Headers,
+			"query":         query,
+			"operationName": cfg.OperationName,
+			"variables":     variables,
+		}
+	}
+
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return
+	}
+
+	result.Response = resp.toMap()
+	data, gqlErrors := splitGraphQLResponse(resp.Body)
+	result.Response["data"] = data
+	result.Response["errors"] = gqlErrors
+
+	e.runHTTPAssertions(tc.Assertions, resp, e.exprEvaluator(tc, hookCtx, result), result)
+	e.runGraphQLAssertions(tc.Assertions, gqlErrors, result)
+}
+
+// templateVariables collects the variables available to GraphQL documents and expr assertions:
+// the active environment plus responses saved by setup hooks
+func (e *UnifiedTestExecutor) templateVariables(hookCtx map[string]interface{}) map[string]interface{} {
+	vars := make(map[string]interface{})
+	if provider, ok := e.variableInjector.(environmentVariableProvider); ok && e.executionParams != nil {
+		if envVars, err := provider.GetExecutionVariables(context.Background(), e.executionParams); err == nil {
+			for k, v := range envVars {
+				vars[k] = v
+			}
+		}
+	}
+	for k, v := range hookCtx {
+		vars[k] = v
+	}
+	return vars
+}
+
+// graphQLHTTPRequest builds the HTTP request carrying a GraphQL operation.
+// POST sends a JSON body; GET encodes the operation as query parameters.
+func graphQLHTTPRequest(cfg *GraphQLTest, query string, variables map[string]interface{}) *HTTPTest {
+	httpCfg := cfg.HTTPTest
+	httpCfg.RawBody, httpCfg.Form, httpCfg.Multipart = "", nil, nil
+
+	headers := make(map[string]string, len(cfg.Headers)+1)
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+	if _, ok := headers["Accept"]; !ok {
+		headers["Accept"] = "application/graphql-response+json, application/json"
+	}
+	httpCfg.Headers = headers
+
+	if strings.EqualFold(httpCfg.Method, http.MethodGet) {
+		params := map[string]string{"query": query}
+		if cfg.OperationName != "" {
+			params["operationName"] = cfg.OperationName
+		}
+		if len(variables) > 0 {
+			data, _ := json.Marshal(variables)
+			params["variables"] = string(data)
+		}
+		httpCfg.Query = params
+		httpCfg.Body = nil
+		return &httpCfg
+	}
+
+	httpCfg.Method = http.MethodPost
+	httpCfg.Query = nil
+	httpCfg.Body = map[string]interface{}{"query": query}
+	if cfg.OperationName != "" {
+		httpCfg.Body["operationName"] = cfg.OperationName
+	}
+	if variables != nil {
+		httpCfg.Body["variables"] = variables
+	}
+	return &httpCfg
+}
+
+// splitGraphQLResponse separates the data and errors members of a GraphQL response
+func splitGraphQLResponse(body interface{}) (interface{}, []interface{}) {
+	payload, ok := body.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	errs, _ := payload["errors"].([]interface{})
+	return payload["data"], errs
+}
+
+// runGraphQLAssertions runs GraphQL specific assertions
+func (e *UnifiedTestExecutor) runGraphQLAssertions(assertions []Assertion, gqlErrors []interface{}, result *TestResult) {
+	for _, assertion := range assertions {
+		if assertion.Type != "graphql_no_errors" {
+			continue
+		}
+		e.recordCheck(assertion, "errors", result, func() bool {
+			appendGraphQLFailures(gqlErrors, result)
+			return len(gqlErrors) == 0
+		})
+	}
+}
+
+// appendGraphQLFailures reports each GraphQL error as a failure
+func appendGraphQLFailures(gqlErrors []interface{}, result *TestResult) {
+	for _, gqlErr := range gqlErrors {
+		message := fmt.Sprintf("%v", gqlErr)
+		if m, ok := gqlErr.(map[string]interface{}); ok {
+			if msg, ok := m["message"].(string); ok {
+				message = msg
+			}
+			if path, ok := m["path"].([]interface{}); ok && len(path) > 0 {
+				message = fmt.Sprintf("%s (path: %v)", message, path)
+			}
+		}
+		result.Failures = append(result.Failures, fmt.Sprintf("GraphQL error: %s", message))
+	}
+}
+
+// ===== Schema introspection =====
+
+type cachedSchema struct {
+	schema    *ast.Schema
+	fetchedAt time.Time
+}
+
+var (
+	schemaCacheMu sync.Mutex
+	schemaCache   = make(map[string]*cachedSchema)
+)
+
+// loadGraphQLSchema returns the endpoint schema, introspecting it when not cached
+func (e *UnifiedTestExecutor) loadGraphQLSchema(cfg *GraphQLTest, sessions *SessionStore) (*ast.Schema, error) {
+	introspection := cfg.HTTPTest
+	if cfg.SchemaURL != "" {
+		introspection.URL = cfg.SchemaURL
+	}
+	endpoint, err := e.resolveURL(&introspection, nil)
+	if err != nil {
+		return nil, err
+	}
+	key := e.tokenCacheScope() + "|" + endpoint
+
+	schemaCacheMu.Lock()
+	cached := schemaCache[key]
+	schemaCacheMu.Unlock()
+	if cached != nil && time.Since(cached.fetchedAt) < schemaCacheTTL {
+		return cached.schema, nil
+	}
+
+	reqCfg := graphQLHTTPRequest(&GraphQLTest{HTTPTest: introspection}, introspectionQuery, nil)
+	_, resp, err := e.doHTTP(reqCfg, sessions)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("introspection failed with status %d", resp.StatusCode)
+	}
+
+	data, gqlErrors := splitGraphQLResponse(resp.Body)
+	if len(gqlErrors) > 0 {
+		return nil, fmt.Errorf("introspection returned errors: %v", gqlErrors)
+	}
+	dataMap, _ := data.(map[string]interface{})
+	schemaData, ok := dataMap["__schema"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("introspection response has no __schema")
+	}
+
+	sdl := IntrospectionToSDL(schemaData)
+	schema, loadErr := gqlparser.LoadSchema(&ast.Source{Name: endpoint, Input: sdl})
+	if loadErr != nil {
+		return nil, fmt.Errorf("invalid introspected schema: %v", loadErr)
+	}
+
+	schemaCacheMu.Lock()
+	schemaCache[key] = &cachedSchema{schema: schema, fetchedAt: time.Now()}
+	schemaCacheMu.Unlock()
+	return schema, nil
+}
+
+// builtinScalars and builtinDirectives are provided by the parser prelude
+var (
+	builtinScalars    = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+	builtinDirectives = map[string]bool{"skip": true, "include": true, "deprecated": true, "specifiedBy": true, "oneOf": true, "defer": true}
+)
+
+// IntrospectionToSDL converts the __schema member of an introspection result to SDL
+func IntrospectionToSDL(schema map[string]interface{}) string {
+	var b strings.Builder
+
+	rootName := func(key string) string {
+		if m, ok := schema[key].(map[string]interface{}); ok {
+			name, _ := m["name"].(string)
+			return name
+		}
+		return ""
+	}
+	b.WriteString("schema {\n")
+	for _, op := range []struct{ field, key string }{
+		{"query", "queryType"}, {"mutation", "mutationType"}, {"subscription", "subscriptionType"},
+	} {
+		if name := rootName(op.key); name != "" {
+			fmt.Fprintf(&b, "  %s: %s\n", op.field, name)
+		}
+	}
+	b.WriteString("}\n\n")
+
+	directives, _ := schema["directives"].([]interface{})
+	for _, d := range directives {
+		dm, _ := d.(map[string]interface{})
+		name, _ := dm["name"].(string)
+		if name == "" || builtinDirectives[name] {
+			continue
+		}
+		var locations []string
+		for _, loc := range asSlice(dm["locations"]) {
+			if s, ok := loc.(string); ok {
+				locations = append(locations, s)
+			}
+		}
+		repeatable := ""
+		if r, _ := dm["isRepeatable"].(bool); r {
+			repeatable = " repeatable"
+		}
+		fmt.Fprintf(&b, "directive @%s%s%s on %s\n\n", name, sdlArgs(dm["args"]), repeatable, strings.Join(locations, " | "))
+	}
+
+	types, _ := schema["types"].([]interface{})
+	for _, t := range types {
+		tm, _ := t.(map[string]interface{})
+		name, _ := tm["name"].(string)
+		kind, _ := tm["kind"].(string)
+		if name == "" || strings.HasPrefix(name, "__") || (kind == "SCALAR" && builtinScalars[name]) {
+			continue
+		}
+
+		switch kind {
+		case "SCALAR":
+			fmt.Fprintf(&b, "scalar %s\n\n", name)
+
+		case "OBJECT", "INTERFACE":
+			keyword := "type"
+			if kind == "INTERFACE" {
+				keyword = "interface"
+			}
+			fmt.Fprintf(&b, "%s %s", keyword, name)
+			var interfaces []string
+			for _, iface := range asSlice(tm["interfaces"]) {
+				if im, ok := iface.(map[string]interface{}); ok {
+					if n, ok := im["name"].(string); ok {
+						interfaces = append(interfaces, n)
+					}
+				}
+			}
+			if len(interfaces) > 0 {
+				fmt.Fprintf(&b, " implements %s", strings.Join(interfaces, " & "))
+			}
+			b.WriteString(" {\n")
+			for _, f := range asSlice(tm["fields"]) {
+				fm, _ := f.(map[string]interface{})
+				fmt.Fprintf(&b, "  %s%s: %s\n", fm["name"], sdlArgs(fm["args"]), sdlTypeRef(fm["type"]))
+			}
+			b.WriteString("}\n\n")
+
+		case "UNION":
+			var members []string
+			for _, p := range asSlice(tm["possibleTypes"]) {
+				if pm, ok := p.(map[string]interface{}); ok {
+					if n, ok := pm["name"].(string); ok {
+						members = append(members, n)
+					}
+				}
+			}
+			fmt.Fprintf(&b, "union %s = %s\n\n", name, strings.Join(members, " | "))
+
+		case "ENUM":
+			fmt.Fprintf(&b, "enum %s {\n", name)
+			for _, v := range asSlice(tm["enumValues"]) {
+				if vm, ok := v.(map[string]interface{}); ok {
+					fmt.Fprintf(&b, "  %s\n", vm["name"])
+				}
+			}
+			b.WriteString("}\n\n")
+
+		case "INPUT_OBJECT":
+			fmt.Fprintf(&b, "input %s {\n", name)
+			for _, f := range asSlice(tm["inputFields"]) {
+				fmt.Fprintf(&b, "  %s\n", sdlInputValue(f))
+			}
+			b.WriteString("}\n\n")
+		}
+	}
+
+	return b.String()
+}
+
+func sdlArgs(args interface{}) string {
+	list := asSlice(args)
+	if len(list) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(list))
+	for _, a := range list {
+		parts = append(parts, sdlInputValue(a))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func sdlInputValue(v interface{}) string {
+	m, _ := v.(map[string]interface{})
+	s := fmt.Sprintf("%s: %s", m["name"], sdlTypeRef(m["type"]))
+	if def, ok := m["defaultValue"].(string); ok && def != "" {
+		s += " = " + def
+	}
+	return s
+}
+
+func sdlTypeRef(t interface{}) string {
+	m, _ := t.(map[string]interface{})
+	switch m["kind"] {
+	case "NON_NULL":
+		return sdlTypeRef(m["ofType"]) + "!"
+	case "LIST":
+		return "[" + sdlTypeRef(m["ofType"]) + "]"
+	}
+	name, _ := m["name"].(string)
+	return name
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+// introspectionQuery is the standard GraphQL introspection query
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives { name locations args { ...InputValue } }
+  }
+}
+fragment FullType on __Type {
+  kind
+  name
+  fields(includeDeprecated: true) { name args { ...InputValue } type { ...TypeRef } }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) { name }
+  possibleTypes { ...TypeRef }
+}
+fragment InputValue on __InputValue { name type { ...TypeRef } defaultValue }
+fragment TypeRef on __Type {
+  kind name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } }
+}`
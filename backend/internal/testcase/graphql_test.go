@@ -0,0 +1,116 @@
+package testcase
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIntrospection = `{"data":{"__schema":{
+  "queryType":{"name":"Query"},"mutationType":null,"subscriptionType":null,
+  "directives":[],
+  "types":[
+    {"kind":"OBJECT","name":"Query","fields":[
+      {"name":"user","args":[{"name":"id","type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"ID","ofType":null}},"defaultValue":null}],
+       "type":{"kind":"OBJECT","name":"User","ofType":null}}],
+     "inputFields":null,"interfaces":[],"enumValues":null,"possibleTypes":null},
+    {"kind":"OBJECT","name":"User","fields":[
+      {"name":"id","args":[],"type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"ID","ofType":null}}},
+      {"name":"roles","args":[],"type":{"kind":"LIST","name":null,"ofType":{"kind":"ENUM","name":"Role","ofType":null}}}],
+     "inputFields":null,"interfaces":[],"enumValues":null,"possibleTypes":null},
+    {"kind":"ENUM","name":"Role","fields":null,"inputFields":null,"interfaces":null,"enumValues":[{"name":"ADMIN"},{"name":"USER"}],"possibleTypes":null},
+    {"kind":"SCALAR","name":"ID","fields":null,"inputFields":null,"interfaces":null,"enumValues":null,"possibleTypes":null},
+    {"kind":"OBJECT","name":"__Type","fields":[],"inputFields":null,"interfaces":[],"enumValues":null,"possibleTypes":null}
+  ]}}}`
+
+func newGraphQLServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query         string                 `json:"query"`
+			OperationName string                 `json:"operationName"`
+			Variables     map[string]interface{} `json:"variables"`
+		}
+		data, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(data, &req))
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(req.Query, "__schema"):
+			io.WriteString(w, testIntrospection)
+		case req.Variables["id"] == "missing":
+			io.WriteString(w, `{"data":{"user":null},"errors":[{"message":"user not found","path":["user"]}]}`)
+		default:
+			io.WriteString(w, `{"data":{"user":{"id":"`+req.Variables["id"].(string)+`"}}}`)
+		}
+	}))
+}
+
+func TestExecuteGraphQL(t *testing.T) {
+	server := newGraphQLServer(t)
+	defer server.Close()
+	executor := NewExecutor(server.URL)
+
+	t.Run("data and errors are split", func(t *testing.T) {
+		result := executor.Execute(&TestCase{ID: "gql", Type: "graphql",
+			GraphQL: &GraphQLTest{
+				HTTPTest:  HTTPTest{Path: "/graphql"},
+				Query:     `query GetUser($id: ID!) { user(id: $id) { id } }`,
+				Variables: map[string]interface{}{"id": "u1"},
+			},
+			Assertions: []Assertion{
+				{Type: "graphql_no_errors"},
+				{Type: "json_path", Path: "$.data.user.id", Expected: "u1"},
+			},
+		})
+		assert.Equal(t, "passed", result.Status, strings.Join(result.Failures, "; "))
+		assert.Equal(t, map[string]interface{}{"user": map[string]interface{}{"id": "u1"}}, result.Response["data"])
+		assert.Empty(t, result.Response["errors"])
+	})
+
+	t.Run("graphql_no_errors fails on errors", func(t *testing.T) {
+		result := executor.Execute(&TestCase{ID: "gql", Type: "graphql",
+			GraphQL: &GraphQLTest{
+				HTTPTest:  HTTPTest{Path: "/graphql"},
+				Query:     `query GetUser($id: ID!) { user(id: $id) { id } }`,
+				Variables: map[string]interface{}{"id": "missing"},
+			},
+			Assertions: []Assertion{{Type: "graphql_no_errors"}},
+		})
+		assert.Equal(t, "failed", result.Status)
+		require.Len(t, result.Failures, 1)
+		assert.Contains(t, result.Failures[0], "user not found")
+	})
+
+	t.Run("schema validation rejects unknown fields before sending", func(t *testing.T) {
+		result := executor.Execute(&TestCase{ID: "gql", Type: "graphql",
+			GraphQL: &GraphQLTest{
+				HTTPTest:       HTTPTest{Path: "/graphql"},
+				Query:          `{ user(id: "1") { id email } }`,
+				ValidateSchema: true,
+			},
+		})
+		assert.Equal(t, "failed", result.Status)
+		require.NotEmpty(t, result.Failures)
+		assert.Contains(t, result.Failures[0], "email")
+		assert.Nil(t, result.Response)
+	})
+
+	t.Run("schema validation accepts valid queries", func(t *testing.T) {
+		result := executor.Execute(&TestCase{ID: "gql", Type: "graphql",
+			GraphQL: &GraphQLTest{
+				HTTPTest:       HTTPTest{Path: "/graphql"},
+				Query:          `query($id: ID!) { user(id: $id) { id roles } }`,
+				Variables:      map[string]interface{}{"id": "u2"},
+				ValidateSchema: true,
+			},
+		})
+		assert.Equal(t, "passed", result.Status, result.Error)
+	})
+}
@@ -0,0 +1,538 @@
+package testcase
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"test-management-service/internal/extract"
+	"test-management-service/internal/sandbox"
+)
+
+// defaultMaxRedirects matches the net/http default redirect limit
+const defaultMaxRedirects = 10
+
+// maxResponseBodyBytes caps the response body read into memory
+const maxResponseBodyBytes = 32 << 20
+
+// Response body types reported in TestResult.Response["bodyType"]
+const (
+	BodyTypeJSON   = "json"
+	BodyTypeXML    = "xml"
+	BodyTypeHTML   = "html"
+	BodyTypeText   = "text"
+	BodyTypeBinary = "binary"
+	BodyTypeEmpty  = "empty"
+)
+
+// httpResponse is a fully read and decoded HTTP response
+type httpResponse struct {
+	StatusCode  int
+	Headers     http.Header
+	ContentType string
+	BodyType    string
+	Body        interface{} // decoded JSON (any shape), string for text/xml/html, base64 string for binary
+	Raw         []byte
+	Duration    time.Duration
+	Cookies     []*http.Cookie
+	Session     map[string]interface{} // Session snapshot when the request used a session
+}
+
+// toMap converts the response to the map stored in TestResult.Response
+func (r *httpResponse) toMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"statusCode":  r.StatusCode,
+		"headers":     r.Headers,
+		"body":        r.Body,
+		"bodyType":    r.BodyType,
+		"contentType": r.ContentType,
+		"size":        len(r.Raw),
+		"durationMs":  r.Duration.Milliseconds(),
+		"cookies":     cookiesToMaps(r.Cookies),
+	}
+	if r.Session != nil {
+		m["session"] = r.Session
+	}
+	if r.BodyType == BodyTypeBinary {
+		m["bodyBase64"] = r.Body
+		m["bodyRaw"] = ""
+	} else {
+		m["bodyRaw"] = string(r.Raw)
+	}
+	return m
+}
+
+// doHTTP builds, sends and decodes an HTTP request described by cfg.
+// When cfg names a session, it is looked up (or created) in sessions.
+func (e *UnifiedTestExecutor) doHTTP(cfg *HTTPTest, sessions *SessionStore) (*http.Request, *httpResponse, error) {
+	var session *Session
+	if cfg.Session != "" {
+		if sessions == nil {
+			sessions = NewSessionStore(nil)
+		}
+		session = sessions.Get(cfg.Session)
+	}
+
+	req, err := e.buildHTTPRequest(cfg, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := e.httpClientFor(cfg)
+	if err != nil {
+		return req, nil, err
+	}
+	if session != nil {
+		sessionClient := *client
+		sessionClient.Jar = session.Jar
+		client = &sessionClient
+	}
+
+	if cfg.Auth != nil {
+		if err := e.applyAuth(req, cfg.Auth, client); err != nil {
+			return req, nil, fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return req, nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	// Digest challenges and revoked OAuth2 tokens are answered with one retry
+	if resp.StatusCode == http.StatusUnauthorized && cfg.Auth != nil {
+		retryReq, buildErr := e.buildHTTPRequest(cfg, session)
+		if buildErr == nil {
+			retry, authErr := e.reauthenticate(retryReq, resp, cfg.Auth, client)
+			if authErr != nil {
+				resp.Body.Close()
+				return req, nil, fmt.Errorf("authentication failed: %w", authErr)
+			}
+			if retry {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				req = retryReq
+				if resp, err = client.Do(req); err != nil {
+					return req, nil, fmt.Errorf("request failed: %w", err)
+				}
+			}
+		}
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyBytes+1))
+	if err != nil {
+		return req, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if len(raw) > maxResponseBodyBytes {
+		return req, nil, fmt.Errorf("response body exceeds %d bytes", maxResponseBodyBytes)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	body, bodyType := decodeResponseBody(contentType, raw)
+
+	var sessionSnapshot map[string]interface{}
+	if session != nil {
+		session.recordCookies(resp.Cookies())
+		sessionSnapshot = session.Snapshot(req.URL)
+	}
+
+	return req, &httpResponse{
+		StatusCode:  resp.StatusCode,
+		Headers:     resp.Header,
+		ContentType: contentType,
+		BodyType:    bodyType,
+		Body:        body,
+		Raw:         raw,
+		Duration:    time.Since(start),
+		Cookies:     resp.Cookies(),
+		Session:     sessionSnapshot,
+	}, nil
+}
+
+// resolveURL builds the request URL and merges query parameters.
+// Priority: URL, then session base URL + Path, then executor baseURL + Path.
+func (e *UnifiedTestExecutor) resolveURL(cfg *HTTPTest, session *Session) (string, error) {
+	rawURL := cfg.URL
+	if rawURL == "" {
+		baseURL := e.baseURL
+		if session != nil && session.BaseURL != "" {
+			baseURL = session.BaseURL
+		}
+		rawURL = baseURL + cfg.Path
+	}
+	if len(cfg.Query) == 0 {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url %q: %w", rawURL, err)
+	}
+	q := u.Query()
+	for k, v := range cfg.Query {
+		q.Set(k, v)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// buildHTTPRequest creates the *http.Request for cfg, encoding the body
+// according to which body field is set
+func (e *UnifiedTestExecutor) buildHTTPRequest(cfg *HTTPTest, session *Session) (*http.Request, error) {
+	reqURL, err := e.resolveURL(cfg, session)
+	if err != nil {
+		return nil, err
+	}
+
+	body, contentType, err := e.encodeRequestBody(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(e.execContext(), strings.ToUpper(method), reqURL, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if session != nil {
+		for k, v := range session.Headers {
+			req.Header.Set(k, v)
+		}
+	}
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	// Explicit Content-Type header wins, except for multipart where the boundary is mandatory
+	if contentType != "" && (req.Header.Get("Content-Type") == "" || cfg.Multipart != nil) {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return req, nil
+}
+
+// encodeRequestBody encodes the request body. Priority: multipart > form > rawBody > body
+func (e *UnifiedTestExecutor) encodeRequestBody(cfg *HTTPTest) ([]byte, string, error) {
+	switch {
+	case cfg.Multipart != nil:
+		return e.encodeMultipart(cfg.Multipart)
+
+	case cfg.Form != nil:
+		values := url.Values{}
+		for k, v := range cfg.Form {
+			values.Set(k, v)
+		}
+		return []byte(values.Encode()), "application/x-www-form-urlencoded", nil
+
+	case cfg.RawBody != "":
+		return []byte(cfg.RawBody), "", nil
+
+	case cfg.Body != nil:
+		data, err := json.Marshal(cfg.Body)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to marshal body: %w", err)
+		}
+		return data, "application/json", nil
+	}
+	return nil, "", nil
+}
+
+// encodeMultipart encodes a multipart/form-data body
+func (e *UnifiedTestExecutor) encodeMultipart(mp *MultipartBody) ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	for k, v := range mp.Fields {
+		if err := w.WriteField(k, v); err != nil {
+			return nil, "", fmt.Errorf("failed to write multipart field %s: %w", k, err)
+		}
+	}
+
+	for _, f := range mp.Files {
+		if f.Field == "" {
+			return nil, "", errors.New("multipart file requires a field name")
+		}
+
+		var content []byte
+		fileName := f.FileName
+		switch {
+		case f.Path != "":
+			data, err := e.readFile(f.Path)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read multipart file %s: %w", f.Path, err)
+			}
+			content = data
+			if fileName == "" {
+				fileName = filepath.Base(f.Path)
+			}
+		case f.ContentBase64 != "":
+			data, err := base64.StdEncoding.DecodeString(f.ContentBase64)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid base64 content for multipart field %s: %w", f.Field, err)
+			}
+			content = data
+		default:
+			content = []byte(f.Content)
+		}
+		if fileName == "" {
+			fileName = f.Field
+		}
+
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(fileName))
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(f.Field), escapeQuotes(fileName)))
+		h.Set("Content-Type", contentType)
+		part, err := w.CreatePart(h)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create multipart part %s: %w", f.Field, err)
+		}
+		if _, err := part.Write(content); err != nil {
+			return nil, "", fmt.Errorf("failed to write multipart part %s: %w", f.Field, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to finalize multipart body: %w", err)
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// httpClientFor returns the client to use for cfg. The shared executor client is
+// reused unless the request overrides timeout, redirect, TLS or proxy settings.
+func (e *UnifiedTestExecutor) httpClientFor(cfg *HTTPTest) (*http.Client, error) {
+	needsTransport := cfg.TLS != nil || cfg.Proxy != ""
+	needsClient := needsTransport || cfg.Timeout > 0 || cfg.FollowRedirects != nil || cfg.MaxRedirects > 0
+	if !needsClient {
+		return e.client, nil
+	}
+
+	client := &http.Client{
+		Timeout:   e.client.Timeout,
+		Transport: e.client.Transport,
+		Jar:       e.client.Jar,
+	}
+	if cfg.Timeout > 0 {
+		client.Timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	follow := cfg.FollowRedirects == nil || *cfg.FollowRedirects
+	maxRedirects := cfg.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !follow {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+
+	if needsTransport {
+		transport, err := e.buildTransport(cfg)
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = transport
+	}
+
+	return client, nil
+}
+
+// buildTransport creates a transport with the request's TLS and proxy settings
+func (e *UnifiedTestExecutor) buildTransport(cfg *HTTPTest) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.Proxy != "" {
+		switch strings.ToLower(cfg.Proxy) {
+		case "none", "direct":
+			transport.Proxy = nil
+		default:
+			proxyURL, err := url.Parse(cfg.Proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid proxy url %q: %w", cfg.Proxy, err)
+			}
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+
+	if cfg.TLS != nil {
+		tlsConfig, err := e.buildTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return transport, nil
+}
+
+// buildTLSConfig converts TLSConfig into a *tls.Config
+func (e *UnifiedTestExecutor) buildTLSConfig(c *TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	caPEM := []byte(c.CACert)
+	if c.CACertFile != "" {
+		data, err := e.readFile(c.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		caPEM = append(caPEM, '\n')
+		caPEM = append(caPEM, data...)
+	}
+	if len(bytes.TrimSpace(caPEM)) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no valid certificates found in CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certPEM, keyPEM := []byte(c.ClientCert), []byte(c.ClientKey)
+	if c.ClientCertFile != "" {
+		data, err := e.readFile(c.ClientCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client certificate: %w", err)
+		}
+		certPEM = data
+	}
+	if c.ClientKeyFile != "" {
+		data, err := e.readFile(c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client key: %w", err)
+		}
+		keyPEM = data
+	}
+	if len(certPEM) > 0 || len(keyPEM) > 0 {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// readFile reads a file named by a test case, which must be inside one of the
+// allowed directories of the executor
+func (e *UnifiedTestExecutor) readFile(path string) ([]byte, error) {
+	if len(e.allowedPaths) == 0 {
+		return nil, fmt.Errorf("reading files is disabled, use inline content instead")
+	}
+	resolved, err := sandbox.ResolvePath(path, e.allowedPaths)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(resolved)
+}
+
+// decodeResponseBody decodes a response body based on its content type, sniffing
+// the content when the type is missing or generic
+func decodeResponseBody(contentType string, raw []byte) (interface{}, string) {
+	if len(raw) == 0 {
+		return nil, BodyTypeEmpty
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	mediaType = strings.ToLower(mediaType)
+	trimmed := bytes.TrimSpace(raw)
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err == nil {
+			return v, BodyTypeJSON
+		}
+		return string(raw), BodyTypeText
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return string(raw), BodyTypeHTML
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return string(raw), BodyTypeXML
+	case strings.HasPrefix(mediaType, "text/"):
+		return string(raw), BodyTypeText
+	case isBinaryMediaType(mediaType):
+		return base64.StdEncoding.EncodeToString(raw), BodyTypeBinary
+	}
+
+	// Unknown or missing content type: sniff
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		var v interface{}
+		if err := json.Unmarshal(trimmed, &v); err == nil {
+			return v, BodyTypeJSON
+		}
+	}
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) {
+		return string(raw), BodyTypeXML
+	}
+	if extract.Sniff(string(trimmed)) == extract.TypeHTML {
+		return string(raw), BodyTypeHTML
+	}
+	if utf8.Valid(raw) {
+		return string(raw), BodyTypeText
+	}
+	return base64.StdEncoding.EncodeToString(raw), BodyTypeBinary
+}
+
+func isBinaryMediaType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"),
+		mediaType == "application/octet-stream",
+		mediaType == "application/pdf",
+		mediaType == "application/zip",
+		mediaType == "application/gzip",
+		mediaType == "application/x-protobuf",
+		mediaType == "application/protobuf":
+		return true
+	}
+	return false
+}
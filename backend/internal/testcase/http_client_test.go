@@ -0,0 +1,124 @@
+package testcase
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeResponseBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		raw         string
+		wantType    string
+		wantBody    interface{}
+	}{
+		{"json object", "application/json", `{"a":1}`, BodyTypeJSON, map[string]interface{}{"a": float64(1)}},
+		{"json array", "application/json; charset=utf-8", `[1,2]`, BodyTypeJSON, []interface{}{float64(1), float64(2)}},
+		{"sniffed json", "", `[{"id":"x"}]`, BodyTypeJSON, []interface{}{map[string]interface{}{"id": "x"}}},
+		{"xml", "application/xml", `<a>1</a>`, BodyTypeXML, `<a>1</a>`},
+		{"sniffed html", "", `<!DOCTYPE html><p>hi</p>`, BodyTypeHTML, `<!DOCTYPE html><p>hi</p>`},
+		{"text", "text/plain", `hello`, BodyTypeText, `hello`},
+		{"binary", "image/png", "\x89PNG", BodyTypeBinary, "iVBORw=="},
+		{"empty", "application/json", ``, BodyTypeEmpty, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, bodyType := decodeResponseBody(tt.contentType, []byte(tt.raw))
+			assert.Equal(t, tt.wantType, bodyType)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestExecuteHTTP_BodiesQueryAndRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/echo", http.StatusFound)
+		case "/upload":
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			f, _, err := r.FormFile("file")
+			require.NoError(t, err)
+			data, _ := io.ReadAll(f)
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `["`+r.FormValue("name")+`","`+string(data)+`"]`)
+		default:
+			data, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, r.URL.RawQuery+"|"+r.Header.Get("Content-Type")+"|"+string(data))
+		}
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(server.URL)
+	noFollow := false
+
+	t.Run("form body with query", func(t *testing.T) {
+		result := executor.Execute(&TestCase{ID: "t1", Type: "http", HTTP: &HTTPTest{
+			Method: "POST", Path: "/echo",
+			Query: map[string]string{"q": "1"},
+			Form:  map[string]string{"a": "b"},
+		}})
+		assert.Equal(t, "passed", result.Status)
+		assert.Equal(t, "q=1|application/x-www-form-urlencoded|a=b", result.Response["body"])
+		assert.Equal(t, BodyTypeText, result.Response["bodyType"])
+	})
+
+	t.Run("multipart upload with array response", func(t *testing.T) {
+		result := executor.Execute(&TestCase{ID: "t2", Type: "http",
+			HTTP: &HTTPTest{Method: "POST", Path: "/upload", Multipart: &MultipartBody{
+				Fields: map[string]string{"name": "report"},
+				Files:  []MultipartFile{{Field: "file", FileName: "r.txt", Content: "data"}},
+			}},
+			Assertions: []Assertion{{Type: "json_path", Path: "$.1", Expected: "data"}},
+		})
+		assert.Equal(t, "passed", result.Status, strings.Join(result.Failures, "; "))
+		assert.Equal(t, []interface{}{"report", "data"}, result.Response["body"])
+	})
+
+	t.Run("multipart files are read from the allowed directories only", func(t *testing.T) {
+		allowed, outside := t.TempDir(), t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(allowed, "r.txt"), []byte("data"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644))
+		require.NoError(t, os.Symlink(filepath.Join(outside, "secret"), filepath.Join(allowed, "link")))
+
+		upload := func(executor *UnifiedTestExecutor, path string) *TestResult {
+			return executor.Execute(&TestCase{ID: "t4", Type: "http",
+				HTTP: &HTTPTest{Method: "POST", Path: "/upload", Multipart: &MultipartBody{
+					Files: []MultipartFile{{Field: "file", Path: path}},
+				}},
+			})
+		}
+		assert.Equal(t, "error", upload(executor, filepath.Join(allowed, "r.txt")).Status, "no allowed directories")
+
+		restricted := NewExecutor(server.URL)
+		restricted.SetAllowedPaths([]string{allowed})
+		result := upload(restricted, filepath.Join(allowed, "r.txt"))
+		assert.Equal(t, "passed", result.Status, result.Error)
+		assert.Equal(t, []interface{}{"", "data"}, result.Response["body"])
+
+		for _, path := range []string{filepath.Join(outside, "secret"), filepath.Join(allowed, "link"), filepath.Join(allowed, "..", filepath.Base(outside), "secret")} {
+			result := upload(restricted, path)
+			assert.Equal(t, "error", result.Status, path)
+			assert.Contains(t, result.Error, "filesystem access denied", path)
+		}
+	})
+
+	t.Run("redirect not followed", func(t *testing.T) {
+		result := executor.Execute(&TestCase{ID: "t3", Type: "http",
+			HTTP:       &HTTPTest{Method: "GET", Path: "/redirect", FollowRedirects: &noFollow},
+			Assertions: []Assertion{{Type: "status_code", Expected: 302}},
+		})
+		assert.Equal(t, "passed", result.Status, strings.Join(result.Failures, "; "))
+	})
+}
@@ -0,0 +1,76 @@
+package testcase
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONPathAssertion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"total":3,"items":[{"id":"a","price":5},{"id":"b","price":15},{"id":"c","price":25}],"meta":null}`)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(server.URL)
+	run := func(assertions ...Assertion) *TestResult {
+		return executor.Execute(&TestCase{ID: "p1", Type: "http",
+			HTTP:       &HTTPTest{Method: "GET", Path: "/items"},
+			Assertions: assertions,
+		})
+	}
+
+	t.Run("passing assertions", func(t *testing.T) {
+		result := run(
+			Assertion{Type: "json_path", Path: "$.total", Expected: 3},
+			Assertion{Type: "json_path", Path: "$.items[1].id", Expected: "b"},
+			Assertion{Type: "json_path", Path: "$.items[?(@.price > 10)].id", Expected: []interface{}{"b", "c"}},
+			Assertion{Type: "json_path", Path: "$.items.length()", Expected: 3},
+			Assertion{Type: "json_path", Path: "$..price", Operator: "contains", Expected: 25},
+			Assertion{Type: "json_path", Path: "$.items[0].id", Operator: "in", Expected: []interface{}{"a", "z"}},
+			Assertion{Type: "json_path", Path: "$.meta", Operator: "exists"},
+			Assertion{Type: "json_path", Path: "$.missing", Operator: "not_exists"},
+			Assertion{Type: "json_path", Path: "$.total", Operator: "not_equals", Expected: 4},
+		)
+		assert.Equal(t, "passed", result.Status, strings.Join(result.Failures, "; "))
+	})
+
+	t.Run("failing assertions", func(t *testing.T) {
+		result := run(
+			Assertion{Type: "json_path", Path: "$.items[?(@.price > 100)]", Operator: "exists"},
+			Assertion{Type: "json_path", Path: "$.total", Expected: "3"},
+			Assertion{Type: "json_path", Path: "$.items[?(@.price >)]", Expected: 1},
+		)
+		assert.Equal(t, "failed", result.Status)
+		require.Len(t, result.Failures, 3)
+		assert.Contains(t, result.Failures[0], "should exist")
+		assert.Contains(t, result.Failures[1], "expected 3, got 3")
+		assert.Contains(t, result.Failures[2], "invalid JSONPath")
+	})
+}
+
+func TestHookSaveResponsePath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"data":{"tokens":[{"value":"t-1"}]}}`)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(server.URL)
+	hook := &Hook{Type: "http", SaveResponse: "token", SaveResponsePath: "$.body.data.tokens[0].value",
+		HTTP: &HTTPTest{Method: "GET", Path: "/login"}}
+
+	ctx := make(map[string]interface{})
+	require.True(t, executor.executeHook(hook, "setup", &TestResult{}, ctx, NewSessionStore(nil)))
+	assert.Equal(t, "t-1", ctx["token"])
+
+	hook.SaveResponsePath = ""
+	require.True(t, executor.executeHook(hook, "setup", &TestResult{}, ctx, NewSessionStore(nil)))
+	assert.Equal(t, 200, ctx["token"].(map[string]interface{})["statusCode"])
+}
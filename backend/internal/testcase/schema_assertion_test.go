@@ -0,0 +1,85 @@
+package testcase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSchemaLoader map[string]interface{}
+
+func (s stubSchemaLoader) LoadSchemaDocument(ctx context.Context, tenantID, projectID, name string) (interface{}, error) {
+	doc, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("schema '%s' not found", name)
+	}
+	return doc, nil
+}
+
+func TestJSONSchemaAssertion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"user":{"id":"42","name":"alice"}}`)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(server.URL)
+	executor.SetSchemaLoader(stubSchemaLoader{
+		"api": map[string]interface{}{
+			"openapi": "3.0.3",
+			"components": map[string]interface{}{"schemas": map[string]interface{}{
+				"User": map[string]interface{}{
+					"type":     "object",
+					"required": []interface{}{"id", "name"},
+					"properties": map[string]interface{}{
+						"id":   map[string]interface{}{"type": "string"},
+						"name": map[string]interface{}{"type": "string"},
+					},
+				},
+			}},
+		},
+	})
+	run := func(assertion Assertion) *TestResult {
+		return executor.Execute(&TestCase{ID: "s1", Type: "http",
+			HTTP:       &HTTPTest{Method: "GET", Path: "/user"},
+			Assertions: []Assertion{assertion},
+		})
+	}
+
+	t.Run("inline schema passes", func(t *testing.T) {
+		result := run(Assertion{Type: "json_schema", Schema: map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"user"},
+		}})
+		assert.Equal(t, "passed", result.Status, strings.Join(result.Failures, "; "))
+	})
+
+	t.Run("violations report instance path and keyword", func(t *testing.T) {
+		result := run(Assertion{Type: "json_schema", Path: "$.user", Draft: "7", Schema: map[string]interface{}{
+			"properties": map[string]interface{}{"id": map[string]interface{}{"type": "integer"}},
+		}})
+		assert.Equal(t, "failed", result.Status)
+		require.Len(t, result.Failures, 1)
+		assert.Contains(t, result.Failures[0], "/id")
+		assert.Contains(t, result.Failures[0], "/properties/id/type")
+	})
+
+	t.Run("openapi component ref", func(t *testing.T) {
+		result := run(Assertion{Type: "json_schema", Path: "$.user", SchemaRef: "api#/components/schemas/User"})
+		assert.Equal(t, "passed", result.Status, strings.Join(result.Failures, "; "))
+	})
+
+	t.Run("unknown stored schema fails", func(t *testing.T) {
+		result := run(Assertion{Type: "json_schema", SchemaRef: "missing"})
+		assert.Equal(t, "failed", result.Status)
+		require.Len(t, result.Failures, 1)
+		assert.Contains(t, result.Failures[0], "not found")
+	})
+}
@@ -0,0 +1,164 @@
+package testcase
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// SessionConfig defines a named HTTP session
+type SessionConfig struct {
+	Name    string            `json:"name"`
+	BaseURL string            `json:"baseUrl,omitempty"` // Used when a request only sets Path
+	Headers map[string]string `json:"headers,omitempty"` // Default headers, overridden by request headers
+}
+
+// Session is a named HTTP session with its own cookie jar. Requests that set
+// HTTPTest.Session share cookies, default headers and base URL.
+type Session struct {
+	Name    string
+	BaseURL string
+	Headers map[string]string
+	Jar     http.CookieJar
+
+	mu      sync.Mutex
+	cookies map[string]*http.Cookie // Last Set-Cookie seen per cookie name
+	defined bool                    // Configured by a SessionConfig rather than created on first use
+}
+
+// SessionStore holds the named sessions of a single execution (a test case
+// run or a workflow run). It is safe for concurrent use by parallel steps.
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// NewSessionStore creates a session store with the given sessions defined
+func NewSessionStore(configs []SessionConfig) *SessionStore {
+	store := &SessionStore{sessions: make(map[string]*Session)}
+	for _, cfg := range configs {
+		store.Define(cfg)
+	}
+	return store
+}
+
+// Define creates or reconfigures a session. Cookies of an existing session are kept.
+func (s *SessionStore) Define(cfg SessionConfig) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[cfg.Name]
+	if !exists {
+		session = newSession(cfg.Name)
+		s.sessions[cfg.Name] = session
+	}
+	session.BaseURL = cfg.BaseURL
+	session.Headers = cfg.Headers
+	session.defined = true
+	return session
+}
+
+// DefineDefault defines a session unless one with the same name was already
+// defined, so the sessions of a test case don't override those of the
+// workflow running it. A session only used so far is configured.
+func (s *SessionStore) DefineDefault(cfg SessionConfig) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[cfg.Name]
+	if !exists {
+		session = newSession(cfg.Name)
+		s.sessions[cfg.Name] = session
+	}
+	if !session.defined {
+		session.BaseURL = cfg.BaseURL
+		session.Headers = cfg.Headers
+		session.defined = true
+	}
+	return session
+}
+
+// Get returns the named session, creating an empty one on first use
+func (s *SessionStore) Get(name string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[name]
+	if !exists {
+		session = newSession(name)
+		s.sessions[name] = session
+	}
+	return session
+}
+
+func newSession(name string) *Session {
+	// cookiejar.New only fails on invalid options
+	jar, _ := cookiejar.New(nil)
+	return &Session{
+		Name:    name,
+		Jar:     jar,
+		cookies: make(map[string]*http.Cookie),
+	}
+}
+
+// recordCookies remembers cookies set by a response so their attributes can be inspected
+func (s *Session) recordCookies(cookies []*http.Cookie) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range cookies {
+		s.cookies[c.Name] = c
+	}
+}
+
+// Snapshot returns the session state exposed in step outputs: the cookies the
+// jar would send to reqURL, plus every cookie the session has received.
+func (s *Session) Snapshot(reqURL *url.URL) map[string]interface{} {
+	jarCookies := make(map[string]interface{})
+	if reqURL != nil {
+		for _, c := range s.Jar.Cookies(reqURL) {
+			jarCookies[c.Name] = c.Value
+		}
+	}
+
+	s.mu.Lock()
+	received := make([]map[string]interface{}, 0, len(s.cookies))
+	for _, c := range s.cookies {
+		received = append(received, cookieToMap(c))
+	}
+	s.mu.Unlock()
+
+	return map[string]interface{}{
+		"name":            s.Name,
+		"cookies":         jarCookies,
+		"receivedCookies": received,
+	}
+}
+
+// cookiesToMaps converts response cookies to their output representation
+func cookiesToMaps(cookies []*http.Cookie) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(cookies))
+	for _, c := range cookies {
+		result = append(result, cookieToMap(c))
+	}
+	return result
+}
+
+func cookieToMap(c *http.Cookie) map[string]interface{} {
+	m := map[string]interface{}{
+		"name":     c.Name,
+		"value":    c.Value,
+		"domain":   c.Domain,
+		"path":     c.Path,
+		"httpOnly": c.HttpOnly,
+		"secure":   c.Secure,
+	}
+	if !c.Expires.IsZero() {
+		m["expires"] = c.Expires.Format(time.RFC3339)
+	}
+	if c.MaxAge != 0 {
+		m["maxAge"] = c.MaxAge
+	}
+	return m
+}
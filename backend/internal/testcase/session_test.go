@@ -0,0 +1,67 @@
+package testcase
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteWithSessions_PersistsCookiesAcrossTestCases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc", Path: "/", HttpOnly: true})
+			w.WriteHeader(http.StatusNoContent)
+		case "/me":
+			cookie, err := r.Cookie("sid")
+			if err != nil || r.Header.Get("X-Client") != "tests" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			io.WriteString(w, cookie.Value)
+		}
+	}))
+	defer server.Close()
+
+	executor := NewExecutor("http://unused.invalid")
+	store := NewSessionStore([]SessionConfig{{
+		Name:    "api",
+		BaseURL: server.URL,
+		Headers: map[string]string{"X-Client": "tests"},
+	}})
+
+	login := executor.ExecuteWithSessions(&TestCase{ID: "login", Type: "http",
+		HTTP: &HTTPTest{Method: "POST", Path: "/login", Session: "api"},
+	}, store)
+	assert.Equal(t, "passed", login.Status, login.Error)
+
+	loginSession := login.Response["session"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"sid": "abc"}, loginSession["cookies"])
+
+	me := executor.ExecuteWithSessions(&TestCase{ID: "me", Type: "http",
+		HTTP:       &HTTPTest{Method: "GET", Path: "/me", Session: "api"},
+		Assertions: []Assertion{{Type: "status_code", Expected: 200}},
+	}, store)
+	assert.Equal(t, "passed", me.Status, strings.Join(me.Failures, "; "))
+	assert.Equal(t, "abc", me.Response["body"])
+
+	// A different session has its own, empty cookie jar
+	other := executor.ExecuteWithSessions(&TestCase{ID: "other", Type: "http",
+		HTTP: &HTTPTest{Method: "GET", URL: server.URL + "/me", Session: "other",
+			Headers: map[string]string{"X-Client": "tests"}},
+		Assertions: []Assertion{{Type: "status_code", Expected: 401}},
+	}, store)
+	assert.Equal(t, "passed", other.Status, strings.Join(other.Failures, "; "))
+
+	// The sessions of a test case don't override those of the store
+	redefined := executor.ExecuteWithSessions(&TestCase{ID: "redefined", Type: "http",
+		Sessions:   []SessionConfig{{Name: "api", BaseURL: "http://unused.invalid"}},
+		HTTP:       &HTTPTest{Method: "GET", Path: "/me", Session: "api"},
+		Assertions: []Assertion{{Type: "status_code", Expected: 200}},
+	}, store)
+	assert.Equal(t, "passed", redefined.Status, redefined.Error)
+}
@@ -0,0 +1,79 @@
+package testcase
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"test-management-service/internal/snapshot"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySnapshotStore keeps the approved snapshot of each key in memory
+type memorySnapshotStore struct {
+	approved map[snapshot.Key]interface{}
+}
+
+func (s *memorySnapshotStore) MatchSnapshot(ctx context.Context, key snapshot.Key, actual interface{}) (*snapshot.Result, error) {
+	expected, ok := s.approved[key]
+	if !ok {
+		s.approved[key] = actual
+		return &snapshot.Result{Name: key.Name, Status: snapshot.StatusRecorded, Version: 1}, nil
+	}
+	if changes := snapshot.Diff(expected, actual); len(changes) > 0 {
+		return &snapshot.Result{Name: key.Name, Status: snapshot.StatusChanged, Version: 1, PendingVersion: 2, Diff: changes}, nil
+	}
+	return &snapshot.Result{Name: key.Name, Status: snapshot.StatusMatched, Version: 1}, nil
+}
+
+func TestSnapshotAssertion(t *testing.T) {
+	body := `{"id":"u-1","name":"alice","createdAt":"2024-01-01"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	store := &memorySnapshotStore{approved: make(map[snapshot.Key]interface{})}
+	executor := NewExecutor(server.URL).WithExecutionParams(&ExecutionParams{TenantID: "t1", ProjectID: "p1"})
+	executor.SetSnapshotStore(store)
+	run := func() *TestResult {
+		return executor.Execute(&TestCase{ID: "snap-1", Type: "http",
+			HTTP:       &HTTPTest{Method: "GET", Path: "/users/1"},
+			Assertions: []Assertion{{Type: "snapshot", Ignore: []string{"$.id", "$.createdAt"}}},
+		})
+	}
+
+	result := run()
+	assert.Equal(t, "passed", result.Status, strings.Join(result.Failures, "; "))
+	require.Len(t, result.Snapshots, 1)
+	assert.Equal(t, snapshot.StatusRecorded, result.Snapshots[0].Status)
+
+	key := snapshot.Key{TenantID: "t1", ProjectID: "p1", TestID: "snap-1", Name: snapshot.DefaultName}
+	assert.Equal(t, map[string]interface{}{"id": snapshot.IgnoredValue, "name": "alice", "createdAt": snapshot.IgnoredValue}, store.approved[key])
+
+	// Ignored paths may change freely
+	body = `{"id":"u-2","name":"alice","createdAt":"2024-02-02"}`
+	result = run()
+	assert.Equal(t, "passed", result.Status, strings.Join(result.Failures, "; "))
+	assert.Equal(t, snapshot.StatusMatched, result.Snapshots[0].Status)
+
+	body = `{"id":"u-2","name":"bob","createdAt":"2024-02-02"}`
+	result = run()
+	assert.Equal(t, "failed", result.Status)
+	require.Len(t, result.Failures, 1)
+	assert.Contains(t, result.Failures[0], `version 2 awaits approval): $.name: "alice" -> "bob"`)
+	assert.Equal(t, snapshot.StatusChanged, result.Snapshots[0].Status)
+
+	// Without a store the assertion cannot pass
+	result = NewExecutor(server.URL).Execute(&TestCase{ID: "snap-1", Type: "http",
+		HTTP:       &HTTPTest{Method: "GET", Path: "/users/1"},
+		Assertions: []Assertion{{Type: "snapshot"}},
+	})
+	assert.Equal(t, "failed", result.Status)
+}
@@ -0,0 +1,72 @@
+package testcase
+
+import (
+	"fmt"
+
+	"test-management-service/internal/assertion"
+	"test-management-service/internal/extract"
+	"test-management-service/internal/models"
+)
+
+// runTextAssertion runs the assertion types that read a body as text or
+// markup rather than JSON. docType is the body type the response reported
+// (xml or html), empty when the content should be sniffed. Other assertion
+// types are ignored.
+func (e *UnifiedTestExecutor) runTextAssertion(a Assertion, target, text, docType string, result *TestResult) {
+	switch a.Type {
+	case "xpath":
+		e.evaluateSelection(a, target, "XPath "+a.Path, text, docType, extract.XPath, result)
+
+	case "css_selector":
+		e.evaluateSelection(a, target, "CSS selector "+a.Path, text, docType, extract.CSS, result)
+
+	case "regex_capture":
+		e.evaluateSelection(a, target, "regex "+a.Path, text, "", extract.Regex, result)
+
+	case "line_count":
+		a.Path = ""
+		e.evaluate(a, target, target+" line count", len(extract.Lines(text)), result)
+
+	case "contains_line":
+		lines := extract.Lines(text)
+		subject := make([]interface{}, len(lines))
+		for i, line := range lines {
+			subject[i] = line
+		}
+		a.Operator, a.Path = "contains", ""
+		e.evaluate(a, target, target+" lines", subject, result)
+	}
+}
+
+// evaluateSelection checks the value the expression in a.Path selects from
+// text. Several matches are checked as an array and no match as missing, so
+// exists and length work on selections as they do on JSON paths.
+func (e *UnifiedTestExecutor) evaluateSelection(a Assertion, target, label, text, docType, lang string, result *TestResult) {
+	var subject interface{} = assertion.Missing
+	values, err := extract.Select(text, docType, lang, a.Path)
+	if err != nil {
+		result.Assertions = append(result.Assertions, models.AssertionResult{
+			Type: a.Type, Target: target, Path: a.Path, Expected: a.Expected, Message: err.Error(),
+		})
+		result.Status = "failed"
+		result.Failures = append(result.Failures, fmt.Sprintf("%s: %s", label, err))
+		return
+	}
+	if value, found := extract.Single(values); found {
+		subject = value
+	}
+
+	expr := a.Path
+	a.Path = ""
+	e.evaluate(a, target, label, subject, result)
+	result.Assertions[len(result.Assertions)-1].Path = expr
+}
+
+// markupType returns the document type of a response body for XPath and CSS
+// assertions, empty when it should be sniffed
+func markupType(bodyType string) string {
+	if bodyType == BodyTypeXML || bodyType == BodyTypeHTML {
+		return bodyType
+	}
+	return ""
+}
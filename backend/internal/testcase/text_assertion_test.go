@@ -0,0 +1,93 @@
+package testcase
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkupAssertions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/soap":
+			w.Header().Set("Content-Type", "text/xml")
+			io.WriteString(w, `<Envelope><Body><GetUserResponse><user id="7"><name>alice</name></user></GetUserResponse></Body></Envelope>`)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<div><h1 class="title">Users</h1><a class="next" href="/page/2">next</a><li>a</li><li>b</li></div>`)
+		}
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(server.URL)
+	run := func(path string, assertions ...Assertion) *TestResult {
+		return executor.Execute(&TestCase{ID: "m1", Type: "http",
+			HTTP:       &HTTPTest{Method: "GET", Path: path},
+			Assertions: assertions,
+		})
+	}
+
+	t.Run("xpath over XML", func(t *testing.T) {
+		result := run("/soap",
+			Assertion{Type: "xpath", Path: "//user/name", Expected: "alice"},
+			Assertion{Type: "xpath", Path: "//user/@id", Expected: "7"},
+			Assertion{Type: "xpath", Path: "count(//user)", Operator: "gte", Expected: 1},
+		)
+		assert.Equal(t, "passed", result.Status, result.Failures)
+		require.Len(t, result.Assertions, 3)
+		assert.Equal(t, "//user/name", result.Assertions[0].Path)
+		assert.Equal(t, "alice", result.Assertions[0].Actual)
+	})
+
+	t.Run("css selectors over HTML", func(t *testing.T) {
+		result := run("/page",
+			Assertion{Type: "css_selector", Path: "h1.title", Expected: "Users"},
+			Assertion{Type: "css_selector", Path: "a.next@href", Expected: "/page/2"},
+			Assertion{Type: "css_selector", Path: "li", Operator: "length", Expected: 2},
+			Assertion{Type: "xpath", Path: "//h1", Expected: "Users"},
+		)
+		assert.Equal(t, "passed", result.Status, result.Failures)
+	})
+
+	t.Run("missing and invalid selections fail", func(t *testing.T) {
+		result := run("/page",
+			Assertion{Type: "css_selector", Path: "table", Operator: "exists"},
+			Assertion{Type: "xpath", Path: "//h1[", Expected: "Users"},
+		)
+		assert.Equal(t, "failed", result.Status)
+		require.Len(t, result.Failures, 2)
+		assert.Contains(t, result.Failures[0], "CSS selector table:")
+		assert.Contains(t, result.Failures[1], "invalid XPath")
+		assert.False(t, result.Assertions[1].Passed)
+	})
+}
+
+func TestTextAssertions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "status: ok\nversion: 2.4.1\nuptime: 31s\n")
+	}))
+	defer server.Close()
+
+	result := NewExecutor(server.URL).Execute(&TestCase{ID: "t1", Type: "http",
+		HTTP: &HTTPTest{Method: "GET", Path: "/health"},
+		Assertions: []Assertion{
+			{Type: "line_count", Expected: 3},
+			{Type: "contains_line", Expected: "status: ok"},
+			{Type: "regex_capture", Path: `version: (\S+)`, Expected: "2.4.1"},
+			{Type: "regex_capture", Path: `uptime: (\d+)s`, Operator: "lt", Expected: 60},
+		},
+	})
+	assert.Equal(t, "passed", result.Status, result.Failures)
+
+	result = NewExecutor(server.URL).Execute(&TestCase{ID: "t2", Type: "http",
+		HTTP:       &HTTPTest{Method: "GET", Path: "/health"},
+		Assertions: []Assertion{{Type: "contains_line", Expected: "status: down"}},
+	})
+	assert.Equal(t, "failed", result.Status)
+	assert.Contains(t, result.Failures[0], "body lines:")
+}
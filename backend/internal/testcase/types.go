@@ -1,17 +1,24 @@
 // Package testcase provides test case type definitions
 package testcase
 
-import "time"
+import (
+	"time"
+
+	"test-management-service/internal/models"
+	"test-management-service/internal/schema"
+	"test-management-service/internal/snapshot"
+)
 
 // TestCase represents a test case to be executed
 type TestCase struct {
 	ID         string       `json:"id"`
 	Name       string       `json:"name"`
-	Type       string       `json:"type"` // http, command, workflow, integration, etc.
+	Type       string       `json:"type"` // http, command, graphql, workflow, integration, etc.
 	GroupID    string       `json:"groupId,omitempty"`
 	Priority   string       `json:"priority,omitempty"`
 	HTTP       *HTTPTest    `json:"http,omitempty"`
 	Command    *CommandTest `json:"command,omitempty"`
+	GraphQL    *GraphQLTest `json:"graphql,omitempty"`
 	Assertions []Assertion  `json:"assertions,omitempty"`
 
 	// Workflow integration support
@@ -21,6 +28,29 @@ type TestCase struct {
 	// Lifecycle hooks
 	SetupHooks    []Hook `json:"setupHooks,omitempty"`
 	TeardownHooks []Hook `json:"teardownHooks,omitempty"`
+
+	// Named HTTP sessions shared by hooks and the main request
+	Sessions []SessionConfig `json:"sessions,omitempty"`
+
+	// Contract validates HTTP exchanges against an OpenAPI document; resolved
+	// from the test group or environment when not set on the test case
+	Contract *Contract `json:"contract,omitempty"`
+
+	// Variables and step outputs of the workflow running the test case, or
+	// the responses saved by the hooks of its test groups; visible to GraphQL
+	// templates and expr assertions like the test's own hook responses
+	Variables   map[string]interface{} `json:"-"`
+	StepOutputs map[string]interface{} `json:"-"`
+}
+
+// Contract links an HTTP test to a stored OpenAPI document
+type Contract struct {
+	Spec string `json:"spec"`           // stored openapi document name
+	Mode string `json:"mode,omitempty"` // strict (default): violations fail the test; warn: reported as warnings; off
+
+	// Load loads the document and the documents it references; defaults to
+	// the executor's schema loader
+	Load schema.DocumentLoader `json:"-"`
 }
 
 // HTTPTest represents an HTTP test configuration
@@ -28,8 +58,58 @@ type HTTPTest struct {
 	Method  string                 `json:"method"`
 	URL     string                 `json:"url,omitempty"`    // Full URL (takes priority over Path)
 	Path    string                 `json:"path,omitempty"`   // Path to append to baseURL
+	Query   map[string]string      `json:"query,omitempty"`  // Query parameters merged into the URL
 	Headers map[string]string      `json:"headers,omitempty"`
-	Body    map[string]interface{} `json:"body,omitempty"`
+	Body    map[string]interface{} `json:"body,omitempty"`   // JSON body
+
+	// Alternative bodies (priority: multipart > form > rawBody > body)
+	RawBody   string            `json:"rawBody,omitempty"`   // Sent as-is
+	Form      map[string]string `json:"form,omitempty"`      // application/x-www-form-urlencoded
+	Multipart *MultipartBody    `json:"multipart,omitempty"` // multipart/form-data with file uploads
+
+	// Transport options
+	Timeout         int        `json:"timeout,omitempty"`         // seconds, overrides the executor default
+	FollowRedirects *bool      `json:"followRedirects,omitempty"` // default true
+	MaxRedirects    int        `json:"maxRedirects,omitempty"`    // default 10
+	TLS             *TLSConfig `json:"tls,omitempty"`
+	Proxy           string     `json:"proxy,omitempty"` // proxy URL, or "none" to bypass environment proxies
+
+	// Session opts the request into a named session (cookie jar, default headers, base URL)
+	Session string `json:"session,omitempty"`
+
+	// Auth authenticates the request (basic, digest, bearer, oauth2, jwt, hmac, aws_sigv4)
+	Auth *AuthConfig `json:"auth,omitempty"`
+}
+
+// MultipartBody represents a multipart/form-data request body
+type MultipartBody struct {
+	Fields map[string]string `json:"fields,omitempty"`
+	Files  []MultipartFile   `json:"files,omitempty"`
+}
+
+// MultipartFile represents a single file part. Content is taken from Path,
+// ContentBase64 or Content, in that order. Path must be inside the allowed
+// directories of the executor.
+type MultipartFile struct {
+	Field         string `json:"field"`
+	FileName      string `json:"fileName,omitempty"`
+	Path          string `json:"path,omitempty"`
+	Content       string `json:"content,omitempty"`
+	ContentBase64 string `json:"contentBase64,omitempty"`
+	ContentType   string `json:"contentType,omitempty"`
+}
+
+// TLSConfig represents TLS options for an HTTP request. The *File paths must
+// be inside the allowed directories of the executor.
+type TLSConfig struct {
+	CACert             string `json:"caCert,omitempty"`         // PEM encoded CA bundle
+	CACertFile         string `json:"caCertFile,omitempty"`     // Path to PEM encoded CA bundle
+	ClientCert         string `json:"clientCert,omitempty"`     // PEM encoded client certificate
+	ClientKey          string `json:"clientKey,omitempty"`      // PEM encoded client key
+	ClientCertFile     string `json:"clientCertFile,omitempty"` // Path to client certificate
+	ClientKeyFile      string `json:"clientKeyFile,omitempty"`  // Path to client key
+	ServerName         string `json:"serverName,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
 }
 
 // CommandTest represents a command line test configuration
@@ -42,21 +122,35 @@ type CommandTest struct {
 
 // Assertion represents a test assertion
 type Assertion struct {
-	Type     string      `json:"type"`     // status_code, json_path, exit_code, stdout_contains, etc.
-	Path     string      `json:"path,omitempty"`
+	Type     string      `json:"type"`           // status_code, json_path, body, exit_code, stdout, stdout_contains, json_schema, snapshot, xpath, css_selector, regex_capture, line_count, contains_line, expr
+	Path     string      `json:"path,omitempty"` // JSONPath; the expression for xpath, css_selector ("a.next@href" selects an attribute) and regex_capture
 	Expected interface{} `json:"expected,omitempty"`
-	Operator string      `json:"operator,omitempty"` // equals, in, exists, contains, etc.
+	Operator string      `json:"operator,omitempty"` // any operator of the assertion engine: equals (default), not_equals, contains, gt, between, in, exists, ...
+
+	// json_schema: inline schema or a stored document ("name" or "name#/components/schemas/Pet")
+	Schema    interface{} `json:"schema,omitempty"`
+	SchemaRef string      `json:"schemaRef,omitempty"`
+	Draft     string      `json:"draft,omitempty"` // 7 or 2020-12
+
+	// expr: boolean expression over response, variables and step outputs,
+	// e.g. "{{response.body.total == response.body.expected}}"
+	Expression string `json:"expression,omitempty"`
+
+	// snapshot: compares the body (or the value at Path) with the approved snapshot
+	Snapshot string   `json:"snapshot,omitempty"` // snapshot name within the test case, default "default"
+	Ignore   []string `json:"ignore,omitempty"`   // JSONPaths of volatile values (timestamps, IDs)
 }
 
 // Hook represents a lifecycle hook (setup or teardown)
 type Hook struct {
-	Type            string       `json:"type"`                      // http, command, sql
-	Name            string       `json:"name"`                      // descriptive name
-	HTTP            *HTTPTest    `json:"http,omitempty"`            // HTTP hook configuration
-	Command         *CommandTest `json:"command,omitempty"`         // Command hook configuration
-	SaveResponse    string       `json:"saveResponse,omitempty"`    // variable name to store response
-	RunOnFailure    bool         `json:"runOnFailure,omitempty"`    // for teardown: run even if test fails
-	ContinueOnError bool         `json:"continueOnError,omitempty"` // don't stop if hook fails
+	Type             string       `json:"type"`                       // http, command, sql
+	Name             string       `json:"name"`                       // descriptive name
+	HTTP             *HTTPTest    `json:"http,omitempty"`             // HTTP hook configuration
+	Command          *CommandTest `json:"command,omitempty"`          // Command hook configuration
+	SaveResponse     string       `json:"saveResponse,omitempty"`     // variable name to store response
+	SaveResponsePath string       `json:"saveResponsePath,omitempty"` // JSONPath into the response; stores only the selected value
+	RunOnFailure     bool         `json:"runOnFailure,omitempty"`     // for teardown: run even if test fails
+	ContinueOnError  bool         `json:"continueOnError,omitempty"`  // don't stop if hook fails
 }
 
 // TestResult represents the result of a test execution
@@ -71,4 +165,11 @@ type TestResult struct {
 	Failures  []string               `json:"failures,omitempty"`
 	Request   map[string]interface{} `json:"request,omitempty"`
 	Response  map[string]interface{} `json:"response,omitempty"`
+	Snapshots []snapshot.Result      `json:"snapshots,omitempty"` // Outcomes of snapshot assertions
+
+	// Assertions holds one structured result per evaluated assertion
+	Assertions []models.AssertionResult `json:"assertions,omitempty"`
+
+	// Warnings holds contract violations reported in warn mode
+	Warnings []string `json:"warnings,omitempty"`
 }
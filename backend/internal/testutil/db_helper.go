@@ -16,6 +16,12 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err)
 
+	// Every connection to :memory: opens a new empty database, so background
+	// goroutines must share the one connection
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
 	// Auto migrate all models including multi-tenancy tables
 	err = db.AutoMigrate(
 		&models.Tenant{},
@@ -33,6 +39,9 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 		&models.WorkflowStepExecution{},
 		&models.WorkflowStepLog{},
 		&models.WorkflowVariableChange{},
+		&models.SchemaDocument{},
+		&models.Snapshot{},
+		&models.TestPlan{},
 	)
 	require.NoError(t, err)
 
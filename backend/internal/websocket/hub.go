@@ -21,10 +21,12 @@ type Hub struct {
 	mu sync.RWMutex
 }
 
-// Message represents a workflow event message
+// Message represents a workflow or test run event message
 type Message struct {
-	RunID   string      `json:"runId"`
-	Type    string      `json:"type"` // step_start, step_complete, step_log, variable_change
+	RunID string `json:"runId"`
+	// Workflow runs: step_start, step_complete, step_log, variable_change.
+	// Test runs: run_start, test_start, test_complete, run_progress, run_complete.
+	Type    string      `json:"type"`
 	Payload interface{} `json:"payload"`
 }
 
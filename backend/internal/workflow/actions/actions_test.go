@@ -3,6 +3,8 @@ package actions
 import (
 	"fmt"
 	"testing"
+
+	"test-management-service/internal/models"
 )
 
 func TestDatabaseAction_Validate(t *testing.T) {
@@ -104,6 +106,33 @@ func TestScriptAction_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "node runtime",
+			action: &ScriptAction{
+				Language: "javascript",
+				Runtime:  "node",
+				Script:   "console.log('hello')",
+			},
+			wantErr: false,
+		},
+		{
+			name: "runtime on non-javascript language",
+			action: &ScriptAction{
+				Language: "python",
+				Runtime:  "node",
+				Script:   "print('hello')",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported runtime",
+			action: &ScriptAction{
+				Language: "javascript",
+				Runtime:  "deno",
+				Script:   "console.log('hello')",
+			},
+			wantErr: true,
+		},
 		{
 			name: "unsupported language",
 			action: &ScriptAction{
@@ -194,6 +223,26 @@ func TestAssertAction_Execute(t *testing.T) {
 			ctx:     &AssertActionContext{},
 			wantErr: true,
 		},
+		{
+			name: "unresolved placeholders are kept",
+			action: &AssertAction{
+				Assertions: []Assertion{
+					{Type: "equals", Actual: "{{missing}}", Expected: "{{missing}}"},
+				},
+			},
+			ctx:     &AssertActionContext{},
+			wantErr: false,
+		},
+		{
+			name: "unresolved placeholders fail in strict mode",
+			action: &AssertAction{
+				Assertions: []Assertion{
+					{Type: "equals", Actual: "{{missing}}", Expected: "{{missing}}"},
+				},
+			},
+			ctx:     &AssertActionContext{Strict: true},
+			wantErr: true,
+		},
 		{
 			name: "contains assertion passes",
 			action: &AssertAction{
@@ -254,6 +303,73 @@ func TestAssertAction_Execute(t *testing.T) {
 			ctx:     &AssertActionContext{},
 			wantErr: false,
 		},
+		{
+			name: "jsonSchema assertion passes",
+			action: &AssertAction{
+				Assertions: []Assertion{
+					{Type: "jsonSchema", Actual: `{"id": 1}`, Schema: map[string]interface{}{
+						"type":     "object",
+						"required": []interface{}{"id"},
+					}},
+				},
+			},
+			ctx:     &AssertActionContext{},
+			wantErr: false,
+		},
+		{
+			name: "jsonSchema assertion fails",
+			action: &AssertAction{
+				Assertions: []Assertion{
+					{Type: "json_schema", Actual: map[string]interface{}{"id": "x"}, Draft: "7", Schema: map[string]interface{}{
+						"properties": map[string]interface{}{"id": map[string]interface{}{"type": "integer"}},
+					}},
+				},
+			},
+			ctx:     &AssertActionContext{},
+			wantErr: true,
+		},
+		{
+			name: "jsonSchema assertion with stored schema",
+			action: &AssertAction{
+				Assertions: []Assertion{
+					{Type: "jsonSchema", Actual: map[string]interface{}{"id": 1}, SchemaRef: "api#/components/schemas/User"},
+				},
+			},
+			ctx: &AssertActionContext{LoadSchema: func(name string) (interface{}, error) {
+				return map[string]interface{}{
+					"openapi": "3.1.0",
+					"components": map[string]interface{}{"schemas": map[string]interface{}{
+						"User": map[string]interface{}{"required": []interface{}{"id"}},
+					}},
+				}, nil
+			}},
+			wantErr: false,
+		},
+		{
+			name: "expr assertion passes",
+			action: &AssertAction{
+				Assertions: []Assertion{
+					{Type: "expr", Expression: "login.statusCode == 200 && token != ''"},
+				},
+			},
+			ctx: &AssertActionContext{
+				Variables:   map[string]interface{}{"token": "abc"},
+				StepOutputs: map[string]interface{}{"login": map[string]interface{}{"statusCode": 200}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "expr assertion fails",
+			action: &AssertAction{
+				Assertions: []Assertion{
+					{Type: "expr", Expression: "{{login.statusCode == 201}}"},
+				},
+			},
+			ctx: &AssertActionContext{
+				StepOutputs: map[string]interface{}{"login": map[string]interface{}{"statusCode": 200}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -271,6 +387,54 @@ func TestAssertAction_Execute(t *testing.T) {
 	}
 }
 
+func TestAssertAction_Results(t *testing.T) {
+	action := &AssertAction{
+		Assertions: []Assertion{
+			{Type: "between", Actual: "{{login.status}}", Expected: []interface{}{200, 299}},
+			{Type: "in", Actual: "{{login.body}}", Path: "$.role", Expected: []interface{}{"admin", "owner"}},
+			{Type: "not_exists", Actual: "{{login.body}}", Path: "$.password"},
+			{Type: "length", Actual: "{{login.body}}", Path: "$.tokens", Expected: 2, Message: "two tokens"},
+		},
+	}
+	ctx := &AssertActionContext{StepOutputs: map[string]interface{}{
+		"login": map[string]interface{}{
+			"status": 200,
+			"body":   map[string]interface{}{"role": "admin", "tokens": []interface{}{"t1"}},
+		},
+	}}
+
+	result, err := action.Execute(ctx)
+	if err == nil {
+		t.Fatal("Execute() expected an error for the failed length assertion")
+	}
+	results, ok := result["assertions"].([]models.AssertionResult)
+	if !ok || len(results) != 4 {
+		t.Fatalf("assertions = %#v", result["assertions"])
+	}
+
+	tests := []struct {
+		typ     string
+		passed  bool
+		message string
+	}{
+		{"between", true, ""},
+		{"in", true, ""},
+		{"notExists", true, ""},
+		{"length", false, "should have length 2, got 1"},
+	}
+	for i, tt := range tests {
+		if results[i].Type != tt.typ || results[i].Passed != tt.passed || results[i].Message != tt.message {
+			t.Errorf("assertion %d = %+v, want type %s passed %v message %q", i, results[i], tt.typ, tt.passed, tt.message)
+		}
+	}
+	if results[3].Target != "{{login.body}}" || results[3].Path != "$.tokens" {
+		t.Errorf("assertion 3 target = %q path = %q", results[3].Target, results[3].Path)
+	}
+	if failures := result["failures"].([]string); failures[0] != "Assertion 4 (two tokens) failed: should have length 2, got 1" {
+		t.Errorf("failures = %v", failures)
+	}
+}
+
 func TestScriptAction_Execute_Python(t *testing.T) {
 	action := &ScriptAction{
 		Language: "python",
@@ -3,11 +3,13 @@ package actions
 import (
 	"encoding/json"
 	"fmt"
-	"reflect"
-	"regexp"
 	"strings"
 
-	"github.com/tidwall/gjson"
+	"test-management-service/internal/assertion"
+	"test-management-service/internal/expression"
+	"test-management-service/internal/jsonpath"
+	"test-management-service/internal/models"
+	"test-management-service/internal/schema"
 )
 
 // AssertAction performs assertions on data
@@ -17,11 +19,20 @@ type AssertAction struct {
 
 // Assertion represents a single assertion
 type Assertion struct {
-	Type     string      `json:"type"`     // equals, notEquals, contains, notContains, regex, jsonPath, exists, greaterThan, lessThan, arrayLength
+	Type     string      `json:"type"`     // any assertion engine operator (equals, contains, regex, gt, between, length, type, exists, in, ...), jsonSchema or expr
 	Actual   interface{} `json:"actual"`   // actual value or expression
 	Expected interface{} `json:"expected"` // expected value
 	Path     string      `json:"path"`     // JSON path for complex data
 	Message  string      `json:"message"`  // custom error message
+
+	// jsonSchema: inline schema or a stored document ("name" or "name#/components/schemas/Pet")
+	Schema    interface{} `json:"schema"`
+	SchemaRef string      `json:"schemaRef"`
+	Draft     string      `json:"draft"` // 7 or 2020-12
+
+	// expr: boolean expression over variables and step outputs, e.g.
+	// "login.response.body.expiresIn > 60 && login.response.statusCode == 200"
+	Expression string `json:"expression"`
 }
 
 // AssertActionContext wraps action execution context
@@ -29,20 +40,24 @@ type AssertActionContext struct {
 	Variables   map[string]interface{}
 	StepOutputs map[string]interface{}
 	Logger      interface{}
+	LoadSchema  schema.DocumentLoader // stored schemas for jsonSchema assertions
+	Strict      bool                  // unresolved {{ }} placeholders fail the assertion
 }
 
 // Execute executes the assert action
 func (a *AssertAction) Execute(ctx *AssertActionContext) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
+	results := make([]models.AssertionResult, 0, len(a.Assertions))
 	var failedAssertions []string
 	passedCount := 0
 
 	for i, assertion := range a.Assertions {
-		err := a.executeAssertion(&assertion, ctx)
-		if err != nil {
-			failedMsg := fmt.Sprintf("Assertion %d failed: %v", i+1, err)
+		outcome := a.executeAssertion(&assertion, ctx)
+		results = append(results, outcome)
+		if !outcome.Passed {
+			failedMsg := fmt.Sprintf("Assertion %d failed: %s", i+1, outcome.Message)
 			if assertion.Message != "" {
-				failedMsg = fmt.Sprintf("Assertion %d (%s) failed: %v", i+1, assertion.Message, err)
+				failedMsg = fmt.Sprintf("Assertion %d (%s) failed: %s", i+1, assertion.Message, outcome.Message)
 			}
 			failedAssertions = append(failedAssertions, failedMsg)
 		} else {
@@ -54,6 +69,7 @@ func (a *AssertAction) Execute(ctx *AssertActionContext) (map[string]interface{}
 	result["passedAssertions"] = passedCount
 	result["failedAssertions"] = len(failedAssertions)
 	result["success"] = len(failedAssertions) == 0
+	result["assertions"] = results
 
 	if len(failedAssertions) > 0 {
 		result["failures"] = failedAssertions
@@ -63,290 +79,97 @@ func (a *AssertAction) Execute(ctx *AssertActionContext) (map[string]interface{}
 	return result, nil
 }
 
-// executeAssertion executes a single assertion
-func (a *AssertAction) executeAssertion(assertion *Assertion, ctx *AssertActionContext) error {
-	actual := assertion.Actual
-	expected := assertion.Expected
-
-	// Helper function to interpolate template strings
-	interpolateValue := func(value interface{}) interface{} {
-		if valStr, ok := value.(string); ok {
-			if strings.HasPrefix(valStr, "{{") && strings.HasSuffix(valStr, "}}") {
-				// Extract variable name
-				varName := strings.TrimSpace(valStr[2 : len(valStr)-2])
-
-				// Check if it's a dotted path (e.g., stepName.field.subfield)
-				if strings.Contains(varName, ".") {
-					parts := strings.SplitN(varName, ".", 2)
-					stepName := parts[0]
-					fieldPath := parts[1]
-
-					// Try to get from StepOutputs first
-					if stepOutput, exists := ctx.StepOutputs[stepName]; exists {
-						// Use gjson to navigate the path
-						stepJSON, err := json.Marshal(stepOutput)
-						if err == nil {
-							result := gjson.GetBytes(stepJSON, fieldPath)
-							if result.Exists() {
-								return result.Value()
-							}
-						}
-					} else if varValue, exists := ctx.Variables[stepName]; exists {
-						// Also try Variables with dotted path
-						varJSON, err := json.Marshal(varValue)
-						if err == nil {
-							result := gjson.GetBytes(varJSON, fieldPath)
-							if result.Exists() {
-								return result.Value()
-							}
-						}
-					}
-				} else {
-					// Simple variable name without dots
-					// Try to get from variables first
-					if val, exists := ctx.Variables[varName]; exists {
-						return val
-					} else if val, exists := ctx.StepOutputs[varName]; exists {
-						return val
-					}
-				}
-			}
-		}
-		return value
-	}
-
-	// Interpolate both actual and expected values
-	actual = interpolateValue(actual)
-	expected = interpolateValue(expected)
+// executeAssertion executes a single assertion with the shared assertion engine
+func (a *AssertAction) executeAssertion(spec *Assertion, ctx *AssertActionContext) models.AssertionResult {
+	actual := spec.Actual
+	expected := spec.Expected
 
-	// If path is specified, extract value using JSON path
-	if assertion.Path != "" {
-		actualJSON, err := json.Marshal(actual)
-		if err != nil {
-			return fmt.Errorf("failed to marshal actual value: %w", err)
-		}
-		result := gjson.GetBytes(actualJSON, assertion.Path)
-		if !result.Exists() {
-			return fmt.Errorf("path '%s' not found in data", assertion.Path)
-		}
-		actual = result.Value()
+	// The target names what was checked: the template, or the literal value
+	target, ok := spec.Actual.(string)
+	if !ok {
+		target = "actual"
 	}
 
-	// Perform assertion based on type
-	switch strings.ToLower(assertion.Type) {
-	case "equals", "equal", "eq":
-		return a.assertEquals(actual, expected)
-
-	case "notequals", "notequal", "ne":
-		if reflect.DeepEqual(actual, expected) {
-			return fmt.Errorf("expected %v not to equal %v", actual, expected)
-		}
-		return nil
-
-	case "contains":
-		return a.assertContains(actual, expected)
-
-	case "notcontains":
-		err := a.assertContains(actual, expected)
-		if err == nil {
-			return fmt.Errorf("expected %v not to contain %v", actual, expected)
-		}
-		return nil
-
-	case "regex", "regexp", "matches":
-		return a.assertRegex(actual, expected)
-
-	case "exists":
-		if actual == nil {
-			return fmt.Errorf("expected value to exist but got nil")
+	// Interpolate both actual and expected values. References that cannot be
+	// resolved are kept, unless the context is strict.
+	evaluator := expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+	evaluator.SetStrict(ctx.Strict)
+	for _, value := range []*interface{}{&actual, &expected} {
+		valStr, ok := (*value).(string)
+		if !ok {
+			continue
 		}
-		return nil
-
-	case "notexists", "null":
-		if actual != nil {
-			return fmt.Errorf("expected value to be nil but got %v", actual)
-		}
-		return nil
-
-	case "greaterthan", "gt":
-		return a.assertGreaterThan(actual, expected)
-
-	case "lessthan", "lt":
-		return a.assertLessThan(actual, expected)
-
-	case "greaterthanorequal", "gte":
-		err := a.assertGreaterThan(actual, expected)
+		interpolated, err := evaluator.Interpolate(valStr)
 		if err != nil {
-			return a.assertEquals(actual, expected)
+			return models.AssertionResult{Type: spec.Type, Target: target, Path: spec.Path, Passed: false, Message: err.Error()}
 		}
-		return nil
-
-	case "lessthanorequal", "lte":
-		err := a.assertLessThan(actual, expected)
-		if err != nil {
-			return a.assertEquals(actual, expected)
-		}
-		return nil
-
-	case "arraylength", "length":
-		return a.assertArrayLength(actual, expected)
-
-	case "typeof", "type":
-		return a.assertType(actual, expected)
-
-	default:
-		return fmt.Errorf("unsupported assertion type: %s", assertion.Type)
-	}
-}
-
-// assertEquals asserts equality
-func (a *AssertAction) assertEquals(actual, expected interface{}) error {
-	// Convert to comparable types
-	actualStr := fmt.Sprintf("%v", actual)
-	expectedStr := fmt.Sprintf("%v", expected)
-
-	if actualStr != expectedStr {
-		return fmt.Errorf("expected %v (type: %T) but got %v (type: %T)", expected, expected, actual, actual)
-	}
-	return nil
-}
-
-// assertContains asserts containment
-func (a *AssertAction) assertContains(actual, expected interface{}) error {
-	actualStr := fmt.Sprintf("%v", actual)
-	expectedStr := fmt.Sprintf("%v", expected)
-
-	if !strings.Contains(actualStr, expectedStr) {
-		return fmt.Errorf("expected %v to contain %v", actual, expected)
+		*value = interpolated
 	}
-	return nil
-}
-
-// assertRegex asserts regex match
-func (a *AssertAction) assertRegex(actual, expected interface{}) error {
-	actualStr := fmt.Sprintf("%v", actual)
-	pattern := fmt.Sprintf("%v", expected)
-
-	matched, err := regexp.MatchString(pattern, actualStr)
-	if err != nil {
-		return fmt.Errorf("invalid regex pattern %s: %w", pattern, err)
-	}
-
-	if !matched {
-		return fmt.Errorf("expected %v to match pattern %v", actual, pattern)
-	}
-	return nil
-}
 
-// assertGreaterThan asserts greater than
-func (a *AssertAction) assertGreaterThan(actual, expected interface{}) error {
-	actualNum, err := toFloat64(actual)
-	if err != nil {
-		return fmt.Errorf("actual value is not a number: %w", err)
+	if spec.Type == assertion.ExprType {
+		return assertion.EvaluateExpr("expression", spec.Expression, expression.NewEvaluator(ctx.Variables, ctx.StepOutputs))
 	}
 
-	expectedNum, err := toFloat64(expected)
-	if err != nil {
-		return fmt.Errorf("expected value is not a number: %w", err)
+	if strings.EqualFold(spec.Type, "jsonSchema") || strings.EqualFold(spec.Type, "json_schema") {
+		outcome := models.AssertionResult{Type: "jsonSchema", Target: target, Path: spec.Path, Passed: true}
+		if spec.Path != "" {
+			value, found, err := jsonpath.Lookup(actual, spec.Path)
+			if err != nil || !found {
+				outcome.Passed, outcome.Message = false, fmt.Sprintf("path '%s' not found in data", spec.Path)
+				return outcome
+			}
+			actual = value
+		}
+		if err := a.assertJSONSchema(actual, spec, ctx); err != nil {
+			outcome.Passed, outcome.Message = false, err.Error()
+		}
+		return outcome
 	}
 
-	if actualNum <= expectedNum {
-		return fmt.Errorf("expected %v to be greater than %v", actual, expected)
+	// A null actual without a path means the value was never produced
+	if actual == nil && spec.Path == "" {
+		actual = assertion.Missing
 	}
-	return nil
+	return assertion.Evaluate(assertion.Assertion{
+		Target:   target,
+		Operator: spec.Type,
+		Path:     spec.Path,
+		Expected: expected,
+	}, actual)
 }
 
-// assertLessThan asserts less than
-func (a *AssertAction) assertLessThan(actual, expected interface{}) error {
-	actualNum, err := toFloat64(actual)
-	if err != nil {
-		return fmt.Errorf("actual value is not a number: %w", err)
-	}
-
-	expectedNum, err := toFloat64(expected)
-	if err != nil {
-		return fmt.Errorf("expected value is not a number: %w", err)
-	}
-
-	if actualNum >= expectedNum {
-		return fmt.Errorf("expected %v to be less than %v", actual, expected)
+// assertJSONSchema asserts that actual conforms to a JSON Schema
+func (a *AssertAction) assertJSONSchema(actual interface{}, assertion *Assertion, ctx *AssertActionContext) error {
+	// Raw response bodies are validated as the JSON they contain
+	if text, ok := actual.(string); ok {
+		var parsed interface{}
+		if json.Unmarshal([]byte(text), &parsed) == nil {
+			actual = parsed
+		}
 	}
-	return nil
-}
 
-// assertArrayLength asserts array length
-func (a *AssertAction) assertArrayLength(actual, expected interface{}) error {
-	// Try to get length
-	v := reflect.ValueOf(actual)
-	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
-		return fmt.Errorf("expected array/slice but got %T", actual)
+	var load schema.DocumentLoader
+	if ctx != nil {
+		load = ctx.LoadSchema
 	}
-
-	expectedLen, err := toInt(expected)
+	violations, err := schema.Validate(schema.Spec{
+		Schema: assertion.Schema,
+		Ref:    assertion.SchemaRef,
+		Draft:  assertion.Draft,
+	}, actual, load)
 	if err != nil {
-		return fmt.Errorf("expected length is not a number: %w", err)
-	}
-
-	actualLen := v.Len()
-	if actualLen != expectedLen {
-		return fmt.Errorf("expected array length %d but got %d", expectedLen, actualLen)
+		return err
 	}
-	return nil
-}
-
-// assertType asserts type
-func (a *AssertAction) assertType(actual, expected interface{}) error {
-	expectedType := fmt.Sprintf("%v", expected)
-	actualType := reflect.TypeOf(actual).String()
-
-	// Normalize type names
-	expectedType = strings.ToLower(expectedType)
-	actualType = strings.ToLower(actualType)
-
-	if !strings.Contains(actualType, expectedType) {
-		return fmt.Errorf("expected type %s but got %s", expectedType, actualType)
+	if len(violations) > 0 {
+		messages := make([]string, len(violations))
+		for i, v := range violations {
+			messages[i] = v.String()
+		}
+		return fmt.Errorf("schema validation failed: %s", strings.Join(messages, "; "))
 	}
 	return nil
 }
 
-// toFloat64 converts value to float64
-func toFloat64(val interface{}) (float64, error) {
-	switch v := val.(type) {
-	case int:
-		return float64(v), nil
-	case int64:
-		return float64(v), nil
-	case float32:
-		return float64(v), nil
-	case float64:
-		return v, nil
-	case string:
-		var f float64
-		_, err := fmt.Sscanf(v, "%f", &f)
-		return f, err
-	default:
-		return 0, fmt.Errorf("cannot convert %T to float64", val)
-	}
-}
-
-// toInt converts value to int
-func toInt(val interface{}) (int, error) {
-	switch v := val.(type) {
-	case int:
-		return v, nil
-	case int64:
-		return int(v), nil
-	case float64:
-		return int(v), nil
-	case string:
-		var i int
-		_, err := fmt.Sscanf(v, "%d", &i)
-		return i, err
-	default:
-		return 0, fmt.Errorf("cannot convert %T to int", val)
-	}
-}
-
 // Validate validates the action configuration
 func (a *AssertAction) Validate() error {
 	if len(a.Assertions) == 0 {
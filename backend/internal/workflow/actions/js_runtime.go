@@ -0,0 +1,631 @@
+package actions
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"test-management-service/internal/sandbox"
+
+	"github.com/dop251/goja"
+	"github.com/google/uuid"
+)
+
+// Embedded runtime defaults
+const (
+	scriptHTTPTimeout      = 30 * time.Second
+	maxScriptHTTPBodyBytes = 10 << 20
+	maxScriptHTTPRedirects = 10
+	RuntimeEmbedded        = "embedded" // pure-Go JavaScript engine (default for javascript)
+	RuntimeNode            = "node"     // host node binary
+)
+
+// ScriptPolicy controls what scripts may access. The zero value denies
+// filesystem and network access.
+type ScriptPolicy struct {
+	AllowFilesystem bool     `json:"allowFilesystem"`
+	AllowedPaths    []string `json:"allowedPaths,omitempty"` // filesystem roots; empty allows any path
+	AllowNetwork    bool     `json:"allowNetwork"`
+	AllowedHosts    []string `json:"allowedHosts,omitempty"` // hostnames; empty allows any host
+	MaxMemoryMB     int      `json:"maxMemoryMB,omitempty"`  // address space of the sandboxed worker, default the sandbox limit
+	MaxTimeout      int      `json:"maxTimeout,omitempty"`   // seconds, caps the step timeout
+
+	// Sandbox applies to host interpreters (python, shell, node). When set,
+	// embedded scripts also run in a sandboxed worker process, so the memory
+	// limit applies to the script alone; otherwise they run in the server
+	// process without a memory limit.
+	Sandbox *sandbox.Config `json:"-"`
+}
+
+// ScriptLogger receives console output of embedded scripts.
+// workflow.StepLogger satisfies this interface.
+type ScriptLogger interface {
+	Debug(stepID, message string)
+	Info(stepID, message string)
+	Warn(stepID, message string)
+	Error(stepID, message string)
+}
+
+// jsRun holds the state of a single embedded script execution
+type jsRun struct {
+	vm        *goja.Runtime
+	ctx       *ScriptActionContext
+	policy    *ScriptPolicy
+	reqCtx    context.Context
+	mu        sync.Mutex
+	stdout    []string
+	stderr    []string
+	outputs   map[string]interface{}
+	variables map[string]interface{}
+	asserts   []map[string]interface{}
+}
+
+// executeEmbeddedJS runs a JavaScript script in the embedded engine. The script
+// body is wrapped in a function, so a top-level `return` sets the output.
+func (a *ScriptAction) executeEmbeddedJS(ctx *ScriptActionContext, timeout time.Duration) (map[string]interface{}, error) {
+	policy := a.Policy
+	if policy == nil {
+		policy = &ScriptPolicy{}
+	}
+
+	source := a.Script
+	if a.File != "" {
+		// The script file is subject to the same filesystem policy as the
+		// files the script reads
+		if !policy.AllowFilesystem {
+			return nil, fmt.Errorf("filesystem access denied: %s", a.File)
+		}
+		path, err := sandbox.ResolvePath(a.File, policy.AllowedPaths)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read script file: %w", err)
+		}
+		source = string(data)
+	}
+	if policy.MaxTimeout > 0 && timeout > time.Duration(policy.MaxTimeout)*time.Second {
+		timeout = time.Duration(policy.MaxTimeout) * time.Second
+	}
+
+	if policy.Sandbox != nil {
+		return a.executeEmbeddedJSInSandbox(ctx, source, policy, timeout)
+	}
+	return a.runEmbeddedJS(ctx, source, policy, timeout)
+}
+
+// runEmbeddedJS runs source in a new VM of the current process
+func (a *ScriptAction) runEmbeddedJS(ctx *ScriptActionContext, source string, policy *ScriptPolicy, timeout time.Duration) (map[string]interface{}, error) {
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	run := &jsRun{
+		vm:        goja.New(),
+		ctx:       ctx,
+		policy:    policy,
+		reqCtx:    cancelCtx,
+		outputs:   make(map[string]interface{}),
+		variables: make(map[string]interface{}),
+	}
+	run.vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	if err := run.install(a); err != nil {
+		return nil, err
+	}
+
+	// CPU-time limit: interrupt the VM when the deadline passes
+	timer := time.AfterFunc(timeout, func() {
+		run.vm.Interrupt(fmt.Sprintf("script execution timeout after %v", timeout))
+		cancel()
+	})
+	defer timer.Stop()
+
+	value, err := run.vm.RunScript("script.js", "(function() {"+source+"\n})()")
+
+	result := map[string]interface{}{
+		"stdout":     strings.Join(run.stdout, "\n"),
+		"stderr":     strings.Join(run.stderr, "\n"),
+		"runtime":    RuntimeEmbedded,
+		"variables":  run.variables,
+		"assertions": run.asserts,
+	}
+
+	if err != nil {
+		if interrupted, ok := err.(*goja.InterruptedError); ok {
+			return nil, fmt.Errorf("%v", interrupted.Value())
+		}
+		result["success"] = false
+		result["exitCode"] = 1
+		result["error"] = scriptErrorMessage(err)
+		return result, nil
+	}
+
+	result["success"] = true
+	result["exitCode"] = 0
+
+	// Output: setOutput values, plus the return value
+	returned := exportValue(value)
+	switch {
+	case len(run.outputs) > 0:
+		if returned != nil {
+			run.outputs["result"] = returned
+		}
+		result["output"] = run.outputs
+	case returned != nil:
+		result["output"] = returned
+	default:
+		// Same convention as host interpreters: JSON printed to stdout is the output
+		stdout := strings.TrimSpace(result["stdout"].(string))
+		if strings.HasPrefix(stdout, "{") || strings.HasPrefix(stdout, "[") {
+			var jsonOutput interface{}
+			if json.Unmarshal([]byte(stdout), &jsonOutput) == nil {
+				result["output"] = jsonOutput
+			}
+		}
+	}
+	return result, nil
+}
+
+// install exposes the script API on the global object
+func (r *jsRun) install(a *ScriptAction) error {
+	vm := r.vm
+	global := vm.GlobalObject()
+
+	vars, err := r.jsonValue(r.ctxVariables())
+	if err != nil {
+		return fmt.Errorf("failed to expose variables: %w", err)
+	}
+	outputs, err := r.jsonValue(r.ctxStepOutputs())
+	if err != nil {
+		return fmt.Errorf("failed to expose step outputs: %w", err)
+	}
+	envValue, _ := r.jsonValue(a.Env)
+	argsValue, _ := r.jsonValue(append([]string{}, a.Args...))
+	// context keeps the shape injected for node scripts: variables and step outputs merged
+	merged := make(map[string]interface{})
+	for k, v := range r.ctxVariables() {
+		merged[k] = v
+	}
+	for k, v := range r.ctxStepOutputs() {
+		merged[k] = v
+	}
+	for k, v := range a.Context {
+		merged[k] = v
+	}
+	contextValue, _ := r.jsonValue(merged)
+
+	set := func(name string, value interface{}) {
+		if err == nil {
+			err = global.Set(name, value)
+		}
+	}
+
+	set("vars", vars)
+	set("outputs", outputs)
+	set("env", envValue)
+	set("args", argsValue)
+	set("context", contextValue)
+
+	set("setOutput", func(name string, value goja.Value) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.outputs[name] = exportValue(value)
+	})
+	set("setVariable", func(name string, value goja.Value) {
+		exported := exportValue(value)
+		r.mu.Lock()
+		r.variables[name] = exported
+		r.mu.Unlock()
+		// Keep the script's own view consistent
+		vars.ToObject(vm).Set(name, value)
+	})
+	set("getVariable", func(name string) goja.Value {
+		return vars.ToObject(vm).Get(name)
+	})
+
+	// Logging
+	console := vm.NewObject()
+	console.Set("log", r.logFunc("info"))
+	console.Set("info", r.logFunc("info"))
+	console.Set("debug", r.logFunc("debug"))
+	console.Set("warn", r.logFunc("warn"))
+	console.Set("error", r.logFunc("error"))
+	set("console", console)
+	set("log", r.logFunc("info"))
+
+	// Assertions
+	set("assert", r.assertObject())
+
+	// Utilities
+	set("base64", r.base64Object())
+	set("crypto", r.cryptoObject())
+
+	// Sandboxed capabilities
+	if r.policy.AllowFilesystem {
+		set("fs", r.fsObject())
+	}
+	if r.policy.AllowNetwork {
+		set("http", r.httpObject())
+	}
+
+	return err
+}
+
+func (r *jsRun) ctxVariables() map[string]interface{} {
+	if r.ctx == nil || r.ctx.Variables == nil {
+		return map[string]interface{}{}
+	}
+	return r.ctx.Variables
+}
+
+func (r *jsRun) ctxStepOutputs() map[string]interface{} {
+	if r.ctx == nil || r.ctx.StepOutputs == nil {
+		return map[string]interface{}{}
+	}
+	return r.ctx.StepOutputs
+}
+
+// jsonValue converts a Go value into native JavaScript objects via JSON, so
+// scripts get real objects and arrays and cannot mutate the caller's maps
+func (r *jsRun) jsonValue(v interface{}) (goja.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return r.vm.NewObject(), nil
+	}
+	parse, _ := goja.AssertFunction(r.vm.Get("JSON").ToObject(r.vm).Get("parse"))
+	return parse(goja.Undefined(), r.vm.ToValue(string(data)))
+}
+
+// exportValue converts a JavaScript value into JSON compatible Go data
+func exportValue(v goja.Value) interface{} {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil
+	}
+	exported := v.Export()
+	data, err := json.Marshal(exported)
+	if err != nil {
+		return exported
+	}
+	var normalized interface{}
+	if json.Unmarshal(data, &normalized) != nil {
+		return exported
+	}
+	return normalized
+}
+
+func scriptErrorMessage(err error) string {
+	if exception, ok := err.(*goja.Exception); ok {
+		return exception.String()
+	}
+	return err.Error()
+}
+
+func (r *jsRun) logFunc(level string) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		parts := make([]string, 0, len(call.Arguments))
+		for _, arg := range call.Arguments {
+			switch exported := exportValue(arg).(type) {
+			case string:
+				parts = append(parts, exported)
+			case nil:
+				parts = append(parts, arg.String())
+			default:
+				data, _ := json.Marshal(exported)
+				parts = append(parts, string(data))
+			}
+		}
+		message := strings.Join(parts, " ")
+
+		r.mu.Lock()
+		if level == "warn" || level == "error" {
+			r.stderr = append(r.stderr, message)
+		} else {
+			r.stdout = append(r.stdout, message)
+		}
+		r.mu.Unlock()
+
+		if r.ctx != nil {
+			if logger, ok := r.ctx.Logger.(ScriptLogger); ok {
+				switch level {
+				case "debug":
+					logger.Debug(r.ctx.StepID, message)
+				case "warn":
+					logger.Warn(r.ctx.StepID, message)
+				case "error":
+					logger.Error(r.ctx.StepID, message)
+				default:
+					logger.Info(r.ctx.StepID, message)
+				}
+			}
+		}
+		return goja.Undefined()
+	}
+}
+
+// assertObject builds assert(cond, msg) with equal/notEqual/deepEqual helpers.
+// A failed assertion is recorded and throws, failing the script.
+func (r *jsRun) assertObject() *goja.Object {
+	vm := r.vm
+	record := func(passed bool, message string, actual, expected interface{}) {
+		r.mu.Lock()
+		r.asserts = append(r.asserts, map[string]interface{}{
+			"passed":   passed,
+			"message":  message,
+			"actual":   actual,
+			"expected": expected,
+		})
+		r.mu.Unlock()
+		if !passed {
+			panic(vm.NewGoError(fmt.Errorf("AssertionError: %s", message)))
+		}
+	}
+	messageArg := func(call goja.FunctionCall, idx int, fallback string) string {
+		if len(call.Arguments) > idx && !goja.IsUndefined(call.Arguments[idx]) {
+			return call.Arguments[idx].String()
+		}
+		return fallback
+	}
+
+	assertFn := vm.ToValue(func(call goja.FunctionCall) goja.Value {
+		passed := call.Argument(0).ToBoolean()
+		record(passed, messageArg(call, 1, "assertion failed"), exportValue(call.Argument(0)), true)
+		return goja.Undefined()
+	}).ToObject(vm)
+
+	equal := func(negate bool) func(goja.FunctionCall) goja.Value {
+		return func(call goja.FunctionCall) goja.Value {
+			actual, expected := exportValue(call.Argument(0)), exportValue(call.Argument(1))
+			passed := reflect.DeepEqual(actual, expected) != negate
+			op := "to equal"
+			if negate {
+				op = "not to equal"
+			}
+			fallback := fmt.Sprintf("expected %v %s %v", actual, op, expected)
+			record(passed, messageArg(call, 2, fallback), actual, expected)
+			return goja.Undefined()
+		}
+	}
+
+	assertFn.Set("ok", assertFn)
+	assertFn.Set("equal", equal(false))
+	assertFn.Set("deepEqual", equal(false))
+	assertFn.Set("notEqual", equal(true))
+	assertFn.Set("fail", func(call goja.FunctionCall) goja.Value {
+		record(false, messageArg(call, 0, "failed"), nil, nil)
+		return goja.Undefined()
+	})
+	return assertFn
+}
+
+func (r *jsRun) base64Object() *goja.Object {
+	obj := r.vm.NewObject()
+	obj.Set("encode", func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) })
+	obj.Set("urlEncode", func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) })
+	obj.Set("decode", func(s string) string {
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			panic(r.vm.NewGoError(err))
+		}
+		return string(data)
+	})
+	obj.Set("urlDecode", func(s string) string {
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+		if err != nil {
+			panic(r.vm.NewGoError(err))
+		}
+		return string(data)
+	})
+	return obj
+}
+
+func (r *jsRun) cryptoObject() *goja.Object {
+	obj := r.vm.NewObject()
+	digest := func(newHash func() hash.Hash) func(string) string {
+		return func(s string) string {
+			h := newHash()
+			h.Write([]byte(s))
+			return hex.EncodeToString(h.Sum(nil))
+		}
+	}
+	obj.Set("md5", digest(md5.New))
+	obj.Set("sha1", digest(sha1.New))
+	obj.Set("sha256", digest(sha256.New))
+	obj.Set("sha512", digest(sha512.New))
+	obj.Set("hmac", func(algorithm, key, data string, encoding goja.Value) string {
+		var newHash func() hash.Hash
+		switch strings.ToLower(algorithm) {
+		case "sha1":
+			newHash = sha1.New
+		case "sha256":
+			newHash = sha256.New
+		case "sha512":
+			newHash = sha512.New
+		case "md5":
+			newHash = md5.New
+		default:
+			panic(r.vm.NewGoError(fmt.Errorf("unsupported hmac algorithm: %s", algorithm)))
+		}
+		mac := hmac.New(newHash, []byte(key))
+		mac.Write([]byte(data))
+		if encoding != nil && strings.EqualFold(encoding.String(), "base64") {
+			return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		}
+		return hex.EncodeToString(mac.Sum(nil))
+	})
+	obj.Set("uuid", func() string { return uuid.New().String() })
+	obj.Set("randomBytes", func(n int) string {
+		if n <= 0 || n > 1024 {
+			panic(r.vm.NewGoError(fmt.Errorf("randomBytes: size must be between 1 and 1024")))
+		}
+		b := make([]byte, n)
+		rand.Read(b)
+		return hex.EncodeToString(b)
+	})
+	return obj
+}
+
+// checkPath resolves path, following symbolic links, and verifies it is
+// inside an allowed root
+func (r *jsRun) checkPath(path string) string {
+	resolved, err := sandbox.ResolvePath(path, r.policy.AllowedPaths)
+	if err != nil {
+		panic(r.vm.NewGoError(err))
+	}
+	return resolved
+}
+
+func (r *jsRun) fsObject() *goja.Object {
+	obj := r.vm.NewObject()
+	obj.Set("readFile", func(path string) string {
+		data, err := os.ReadFile(r.checkPath(path))
+		if err != nil {
+			panic(r.vm.NewGoError(err))
+		}
+		return string(data)
+	})
+	obj.Set("writeFile", func(path, content string) {
+		if err := os.WriteFile(r.checkPath(path), []byte(content), 0644); err != nil {
+			panic(r.vm.NewGoError(err))
+		}
+	})
+	obj.Set("exists", func(path string) bool {
+		_, err := os.Stat(r.checkPath(path))
+		return err == nil
+	})
+	obj.Set("listDir", func(path string) []string {
+		entries, err := os.ReadDir(r.checkPath(path))
+		if err != nil {
+			panic(r.vm.NewGoError(err))
+		}
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	})
+	return obj
+}
+
+// scriptHTTPRequest is the argument of http.request
+type scriptHTTPRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    interface{}       `json:"body"`
+}
+
+func (r *jsRun) httpObject() *goja.Object {
+	obj := r.vm.NewObject()
+	client := &http.Client{
+		Timeout: scriptHTTPTimeout,
+		// Every hop must satisfy the host policy, not only the first request
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxScriptHTTPRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxScriptHTTPRedirects)
+			}
+			if !r.hostAllowed(req.URL.Hostname()) {
+				return fmt.Errorf("network access denied: %s", req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+
+	obj.Set("request", func(arg goja.Value) map[string]interface{} {
+		var req scriptHTTPRequest
+		data, _ := json.Marshal(exportValue(arg))
+		if err := json.Unmarshal(data, &req); err != nil || req.URL == "" {
+			panic(r.vm.NewGoError(fmt.Errorf("http.request requires {url, method, headers, body}")))
+		}
+		if req.Method == "" {
+			req.Method = http.MethodGet
+		}
+
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			panic(r.vm.NewGoError(err))
+		}
+		if !r.hostAllowed(u.Hostname()) {
+			panic(r.vm.NewGoError(fmt.Errorf("network access denied: %s", u.Hostname())))
+		}
+
+		var body io.Reader
+		switch b := req.Body.(type) {
+		case nil:
+		case string:
+			body = strings.NewReader(b)
+		default:
+			encoded, _ := json.Marshal(b)
+			body = strings.NewReader(string(encoded))
+			if req.Headers == nil {
+				req.Headers = map[string]string{}
+			}
+			if _, ok := req.Headers["Content-Type"]; !ok {
+				req.Headers["Content-Type"] = "application/json"
+			}
+		}
+
+		httpReq, err := http.NewRequestWithContext(r.reqCtx, strings.ToUpper(req.Method), u.String(), body)
+		if err != nil {
+			panic(r.vm.NewGoError(err))
+		}
+		for k, v := range req.Headers {
+			httpReq.Header.Set(k, v)
+		}
+
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			panic(r.vm.NewGoError(err))
+		}
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxScriptHTTPBodyBytes))
+
+		headers := make(map[string]interface{}, len(resp.Header))
+		for k := range resp.Header {
+			headers[k] = resp.Header.Get(k)
+		}
+		result := map[string]interface{}{
+			"status":  resp.StatusCode,
+			"headers": headers,
+			"body":    string(respBody),
+		}
+		var parsed interface{}
+		if json.Unmarshal(respBody, &parsed) == nil {
+			result["json"] = parsed
+		}
+		return result
+	})
+	return obj
+}
+
+func (r *jsRun) hostAllowed(host string) bool {
+	if len(r.policy.AllowedHosts) == 0 {
+		return true
+	}
+	for _, allowed := range r.policy.AllowedHosts {
+		if strings.EqualFold(allowed, host) ||
+			(strings.HasPrefix(allowed, "*.") && strings.HasSuffix(strings.ToLower(host), strings.ToLower(allowed[1:]))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,293 @@
+package actions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"test-management-service/internal/sandbox"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Debug(stepID, message string) {
+	l.lines = append(l.lines, "debug:"+stepID+":"+message)
+}
+func (l *recordingLogger) Info(stepID, message string) {
+	l.lines = append(l.lines, "info:"+stepID+":"+message)
+}
+func (l *recordingLogger) Warn(stepID, message string) {
+	l.lines = append(l.lines, "warn:"+stepID+":"+message)
+}
+func (l *recordingLogger) Error(stepID, message string) {
+	l.lines = append(l.lines, "error:"+stepID+":"+message)
+}
+
+func TestEmbeddedJS_VariablesOutputsAndHelpers(t *testing.T) {
+	logger := &recordingLogger{}
+	action := &ScriptAction{
+		Language: "javascript",
+		Script: `
+			assert(vars.user.roles.length === 2, "roles");
+			assert.equal(outputs.login.status, 200);
+			log("token", vars.token);
+			setVariable("hash", crypto.sha256("abc"));
+			setOutput("encoded", base64.encode(vars.token));
+			return { total: vars.user.roles.length };`,
+		Timeout: 5,
+	}
+	ctx := &ScriptActionContext{
+		Variables: map[string]interface{}{
+			"token": "t-1",
+			"user":  map[string]interface{}{"roles": []interface{}{"a", "b"}},
+		},
+		StepOutputs: map[string]interface{}{"login": map[string]interface{}{"status": 200}},
+		Logger:      logger,
+		StepID:      "step-1",
+	}
+
+	result, err := action.Execute(ctx)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result["success"] != true {
+		t.Fatalf("Execute() success = %v, error = %v", result["success"], result["error"])
+	}
+
+	output := result["output"].(map[string]interface{})
+	if output["encoded"] != "dC0x" {
+		t.Errorf("output.encoded = %v, want dC0x", output["encoded"])
+	}
+	if output["result"].(map[string]interface{})["total"] != float64(2) {
+		t.Errorf("output.result = %v", output["result"])
+	}
+
+	variables := result["variables"].(map[string]interface{})
+	if variables["hash"] != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("variables.hash = %v", variables["hash"])
+	}
+	if _, changed := ctx.Variables["hash"]; changed {
+		t.Error("script must not mutate caller variables directly")
+	}
+
+	if len(logger.lines) != 1 || logger.lines[0] != "info:step-1:token t-1" {
+		t.Errorf("logger lines = %v", logger.lines)
+	}
+	if len(result["assertions"].([]map[string]interface{})) != 2 {
+		t.Errorf("assertions = %v", result["assertions"])
+	}
+}
+
+func TestEmbeddedJS_FailedAssertion(t *testing.T) {
+	action := &ScriptAction{Language: "js", Script: `assert.equal(1, 2, "numbers differ")`}
+
+	result, err := action.Execute(&ScriptActionContext{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result["success"] != false {
+		t.Errorf("Execute() success = %v, want false", result["success"])
+	}
+	if !strings.Contains(result["error"].(string), "numbers differ") {
+		t.Errorf("Execute() error = %v", result["error"])
+	}
+}
+
+func TestEmbeddedJS_Timeout(t *testing.T) {
+	action := &ScriptAction{Language: "javascript", Script: `while (true) {}`, Timeout: 1}
+
+	_, err := action.Execute(&ScriptActionContext{})
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("Execute() error = %v, want timeout", err)
+	}
+}
+
+func TestEmbeddedJS_Sandboxed(t *testing.T) {
+	logger := &recordingLogger{}
+	action := &ScriptAction{
+		Language: "javascript",
+		Script: `
+			log("user", vars.user);
+			setVariable("seen", outputs.login.status);
+			return { args: args, env: env.MODE };`,
+		Args:    []string{"a"},
+		Env:     map[string]string{"MODE": "test"},
+		Timeout: 10,
+		Policy:  &ScriptPolicy{Sandbox: &sandbox.Config{}},
+	}
+	result, err := action.Execute(&ScriptActionContext{
+		Variables:   map[string]interface{}{"user": "alice"},
+		StepOutputs: map[string]interface{}{"login": map[string]interface{}{"status": 200}},
+		Logger:      logger,
+		StepID:      "step-1",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result["success"] != true || result["exitCode"] != 0 {
+		t.Fatalf("Execute() success = %v, error = %v", result["success"], result["error"])
+	}
+	output := result["output"].(map[string]interface{})
+	if output["env"] != "test" || len(output["args"].([]interface{})) != 1 {
+		t.Errorf("output = %v", output)
+	}
+	if result["variables"].(map[string]interface{})["seen"] != float64(200) {
+		t.Errorf("variables = %v", result["variables"])
+	}
+	if len(logger.lines) != 1 || logger.lines[0] != "info:step-1:user alice" {
+		t.Errorf("logger lines = %v", logger.lines)
+	}
+}
+
+func TestEmbeddedJS_MemoryLimit(t *testing.T) {
+	action := &ScriptAction{
+		Language: "javascript",
+		Script:   `var s = "xxxxxxxxxxxxxxxx"; while (true) { s = s + s; s.charAt(s.length - 1); }`,
+		Timeout:  30,
+		Policy:   &ScriptPolicy{MaxMemoryMB: 1024, Sandbox: &sandbox.Config{}},
+	}
+
+	_, err := action.Execute(&ScriptActionContext{})
+	if err == nil || !strings.Contains(err.Error(), "memory limit") {
+		t.Errorf("Execute() error = %v, want memory limit", err)
+	}
+}
+
+func TestEmbeddedJS_Policy(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.txt")
+	if err := os.WriteFile(file, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := `return fs.readFile(` + "`" + file + "`" + `)`
+	root := filepath.Join(dir, "sub")
+	link := filepath.Join(root, "link.txt")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(file, link); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		script  string
+		policy  *ScriptPolicy
+		success bool
+	}{
+		{name: "fs denied by default", script: script, policy: nil, success: false},
+		{name: "network denied by default", script: `http.request({url: "http://example.com"})`, policy: nil, success: false},
+		{name: "fs allowed in root", script: script, policy: &ScriptPolicy{AllowFilesystem: true, AllowedPaths: []string{dir}}, success: true},
+		{name: "fs outside root", script: script, policy: &ScriptPolicy{AllowFilesystem: true, AllowedPaths: []string{root}}, success: false},
+		{name: "fs symlink out of root", script: `return fs.readFile(` + "`" + link + "`" + `)`, policy: &ScriptPolicy{AllowFilesystem: true, AllowedPaths: []string{root}}, success: false},
+		{name: "host not allowed", script: `http.request({url: "http://example.com"})`, policy: &ScriptPolicy{AllowNetwork: true, AllowedHosts: []string{"api.internal"}}, success: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := &ScriptAction{Language: "javascript", Script: tt.script, Policy: tt.policy}
+			result, err := action.Execute(&ScriptActionContext{})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result["success"] != tt.success {
+				t.Errorf("Execute() success = %v, want %v (error: %v)", result["success"], tt.success, result["error"])
+			}
+		})
+	}
+}
+
+func TestEmbeddedJS_RedirectPolicy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer target.Close()
+	port := target.URL[strings.LastIndex(target.URL, ":")+1:]
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://"+r.URL.Query().Get("host")+":"+port+"/", http.StatusFound)
+	}))
+	defer redirector.Close()
+
+	policy := &ScriptPolicy{AllowNetwork: true, AllowedHosts: []string{"127.0.0.1"}}
+	tests := []struct {
+		name    string
+		host    string
+		success bool
+	}{
+		{name: "redirect to allowed host", host: "127.0.0.1", success: true},
+		{name: "redirect to host not allowed", host: "localhost", success: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := &ScriptAction{
+				Language: "javascript",
+				Script:   `return http.request({url: "` + redirector.URL + `/?host=` + tt.host + `"}).body`,
+				Policy:   policy,
+			}
+			result, err := action.Execute(&ScriptActionContext{})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result["success"] != tt.success {
+				t.Fatalf("Execute() success = %v, want %v (error: %v)", result["success"], tt.success, result["error"])
+			}
+			if tt.success && result["output"] != "secret" {
+				t.Errorf("Execute() output = %v", result["output"])
+			} else if !tt.success && !strings.Contains(result["error"].(string), "network access denied: localhost") {
+				t.Errorf("Execute() error = %v", result["error"])
+			}
+		})
+	}
+}
+
+func TestEmbeddedJS_ScriptFilePolicy(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "scripts")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	inside := filepath.Join(root, "inside.js")
+	outside := filepath.Join(dir, "outside.js")
+	for _, file := range []string{inside, outside} {
+		if err := os.WriteFile(file, []byte(`return "loaded"`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		policy  *ScriptPolicy
+		wantErr bool
+	}{
+		{name: "file in root", file: inside, policy: &ScriptPolicy{AllowFilesystem: true, AllowedPaths: []string{root}}},
+		{name: "file outside root", file: outside, policy: &ScriptPolicy{AllowFilesystem: true, AllowedPaths: []string{root}}, wantErr: true},
+		{name: "dot-dot out of root", file: filepath.Join(root, "..", "outside.js"), policy: &ScriptPolicy{AllowFilesystem: true, AllowedPaths: []string{root}}, wantErr: true},
+		{name: "filesystem denied by default", file: inside, policy: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := &ScriptAction{Language: "javascript", File: tt.file, Policy: tt.policy}
+			result, err := action.Execute(&ScriptActionContext{})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "filesystem access denied") {
+					t.Errorf("Execute() error = %v, want filesystem access denied", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result["output"] != "loaded" {
+				t.Errorf("Execute() output = %v", result["output"])
+			}
+		})
+	}
+}
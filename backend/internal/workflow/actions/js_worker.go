@@ -0,0 +1,199 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"test-management-service/internal/sandbox"
+)
+
+// Embedded scripts of a sandboxed policy run in a worker process: the server
+// executable started again through sandbox.Run with embeddedJSWorkerEnv set.
+// The worker reads the request file named by its first argument, runs the
+// script and writes the response to stdout.
+const (
+	embeddedJSWorkerEnv   = "TMS_EMBEDDED_JS_WORKER"
+	embeddedJSRequestFile = "request.json"
+	workerStartupGrace    = 5 * time.Second
+)
+
+func init() {
+	if os.Getenv(embeddedJSWorkerEnv) == "1" && len(os.Args) > 1 {
+		os.Exit(serveEmbeddedJS(os.Args[1]))
+	}
+}
+
+// embeddedJSRequest is the script run by a worker
+type embeddedJSRequest struct {
+	Source      string                 `json:"source"`
+	Args        []string               `json:"args,omitempty"`
+	Env         map[string]string      `json:"env,omitempty"`
+	Context     map[string]interface{} `json:"context,omitempty"`
+	Policy      *ScriptPolicy          `json:"policy"`
+	Variables   map[string]interface{} `json:"variables,omitempty"`
+	StepOutputs map[string]interface{} `json:"stepOutputs,omitempty"`
+	StepID      string                 `json:"stepId,omitempty"`
+	Timeout     time.Duration          `json:"timeout"`
+}
+
+// embeddedJSResponse is the outcome of a worker run. Console output is sent
+// back in Logs and passed to the step logger once the script finished.
+type embeddedJSResponse struct {
+	Result map[string]interface{} `json:"result,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+	Logs   []workerLogLine        `json:"logs,omitempty"`
+}
+
+type workerLogLine struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// workerLogger records the console output of a script run by a worker
+type workerLogger struct {
+	lines []workerLogLine
+}
+
+func (l *workerLogger) Debug(stepID, message string) { l.add("debug", message) }
+func (l *workerLogger) Info(stepID, message string)  { l.add("info", message) }
+func (l *workerLogger) Warn(stepID, message string)  { l.add("warn", message) }
+func (l *workerLogger) Error(stepID, message string) { l.add("error", message) }
+
+func (l *workerLogger) add(level, message string) {
+	l.lines = append(l.lines, workerLogLine{Level: level, Message: message})
+}
+
+// executeEmbeddedJSInSandbox runs source in a worker process with the limits
+// of the policy's sandbox. MaxMemoryMB of the policy replaces the sandbox
+// memory limit; it must leave room for the Go runtime of the worker.
+func (a *ScriptAction) executeEmbeddedJSInSandbox(ctx *ScriptActionContext, source string, policy *ScriptPolicy, timeout time.Duration) (map[string]interface{}, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate script worker: %w", err)
+	}
+
+	req := &embeddedJSRequest{
+		Source:  source,
+		Args:    a.Args,
+		Env:     a.Env,
+		Context: a.Context,
+		Policy:  policy,
+		Timeout: timeout,
+	}
+	if ctx != nil {
+		req.Variables = ctx.Variables
+		req.StepOutputs = ctx.StepOutputs
+		req.StepID = ctx.StepID
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode script context: %w", err)
+	}
+
+	cfg := *policy.Sandbox
+	if policy.MaxMemoryMB > 0 {
+		cfg.MaxMemoryMB = policy.MaxMemoryMB
+	}
+	run, err := sandbox.Run(context.Background(), &cfg, &sandbox.Spec{
+		Path:    executable,
+		Args:    []string{embeddedJSRequestFile},
+		Env:     map[string]string{embeddedJSWorkerEnv: "1"},
+		Files:   map[string][]byte{embeddedJSRequestFile: data},
+		Timeout: timeout + workerStartupGrace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start script worker: %w", err)
+	}
+	if run.TimedOut {
+		return nil, fmt.Errorf("script execution timeout after %v", timeout)
+	}
+
+	var resp embeddedJSResponse
+	if err := json.Unmarshal([]byte(run.Stdout), &resp); err != nil {
+		switch {
+		case strings.Contains(run.Stderr, "out of memory"), strings.Contains(run.Stderr, "cannot allocate memory"):
+			limit := cfg.MaxMemoryMB
+			if limit == 0 {
+				limit = sandbox.DefaultMaxMemoryMB
+			}
+			return nil, fmt.Errorf("script memory limit exceeded (%d MB)", limit)
+		case run.Truncated:
+			return nil, fmt.Errorf("script output exceeds the sandbox output limit")
+		}
+		return nil, fmt.Errorf("script worker failed (exit status %d): %s", run.ExitCode, firstLine(run.Stderr))
+	}
+
+	if ctx != nil {
+		if logger, ok := ctx.Logger.(ScriptLogger); ok {
+			for _, line := range resp.Logs {
+				switch line.Level {
+				case "debug":
+					logger.Debug(ctx.StepID, line.Message)
+				case "warn":
+					logger.Warn(ctx.StepID, line.Message)
+				case "error":
+					logger.Error(ctx.StepID, line.Message)
+				default:
+					logger.Info(ctx.StepID, line.Message)
+				}
+			}
+		}
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s", resp.Error)
+	}
+	// JSON numbers decode as float64; keep the exit code an int like in-process runs
+	if code, ok := resp.Result["exitCode"].(float64); ok {
+		resp.Result["exitCode"] = int(code)
+	}
+	return resp.Result, nil
+}
+
+// serveEmbeddedJS runs the request stored in path and writes the response to
+// stdout. It returns the exit code of the worker.
+func serveEmbeddedJS(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read request: %v\n", err)
+		return 2
+	}
+	var req embeddedJSRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid request: %v\n", err)
+		return 2
+	}
+	if req.Policy == nil {
+		req.Policy = &ScriptPolicy{}
+	}
+
+	logger := &workerLogger{}
+	action := &ScriptAction{Language: "javascript", Args: req.Args, Env: req.Env, Context: req.Context}
+	ctx := &ScriptActionContext{
+		Variables:   req.Variables,
+		StepOutputs: req.StepOutputs,
+		Logger:      logger,
+		StepID:      req.StepID,
+	}
+
+	var resp embeddedJSResponse
+	resp.Result, err = action.runEmbeddedJS(ctx, req.Source, req.Policy, req.Timeout)
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	resp.Logs = logger.lines
+	if err := json.NewEncoder(os.Stdout).Encode(&resp); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write response: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -1,24 +1,28 @@
 package actions
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
+
+	"test-management-service/internal/sandbox"
 )
 
 // ScriptAction executes scripts in various languages
 type ScriptAction struct {
 	Language string                 `json:"language"` // python, javascript, shell
+	Runtime  string                 `json:"runtime"`  // javascript only: embedded (default), node
 	Script   string                 `json:"script"`   // script content
 	File     string                 `json:"file"`     // script file path (alternative to Script)
 	Args     []string               `json:"args"`     // script arguments
 	Env      map[string]string      `json:"env"`      // environment variables
 	Timeout  int                    `json:"timeout"`  // timeout in seconds (default: 30)
 	Context  map[string]interface{} `json:"context"`  // context variables to inject
+	Policy   *ScriptPolicy          `json:"-"`        // tenant script policy (embedded runtime)
 }
 
 // ScriptActionContext wraps action execution context
@@ -26,6 +30,7 @@ type ScriptActionContext struct {
 	Variables   map[string]interface{}
 	StepOutputs map[string]interface{}
 	Logger      interface{}
+	StepID      string
 }
 
 // Execute executes the script action
@@ -36,163 +41,98 @@ func (a *ScriptAction) Execute(ctx *ScriptActionContext) (map[string]interface{}
 		timeout = 30
 	}
 
-	var cmd *exec.Cmd
-	var cleanup func()
-
-	switch strings.ToLower(a.Language) {
-	case "python", "python3":
-		cmd, cleanup = a.preparePythonScript(ctx)
-	case "javascript", "js", "node":
-		cmd, cleanup = a.prepareJavaScriptScript(ctx)
-	case "shell", "bash", "sh":
-		cmd, cleanup = a.prepareShellScript()
-	default:
-		return nil, fmt.Errorf("unsupported language: %s", a.Language)
+	if a.usesEmbeddedRuntime() {
+		return a.executeEmbeddedJS(ctx, time.Duration(timeout)*time.Second)
 	}
 
-	if cleanup != nil {
-		defer cleanup()
+	spec, err := a.prepareSpec(ctx)
+	if err != nil {
+		return nil, err
 	}
+	spec.Env = a.Env
+	spec.Timeout = time.Duration(timeout) * time.Second
 
-	// Set environment variables
-	if a.Env != nil {
-		for k, v := range a.Env {
-			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
-		}
+	var sandboxCfg *sandbox.Config
+	if a.Policy != nil {
+		sandboxCfg = a.Policy.Sandbox
 	}
-	// Inherit current environment
-	cmd.Env = append(cmd.Env, os.Environ()...)
 
-	// Capture output
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	// Start command with timeout
-	if err := cmd.Start(); err != nil {
+	run, err := sandbox.Run(context.Background(), sandboxCfg, spec)
+	if err != nil {
 		return nil, fmt.Errorf("failed to start script: %w", err)
 	}
-
-	// Wait with timeout
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Wait()
-	}()
-
-	select {
-	case err := <-done:
-		// Command completed
-		result := make(map[string]interface{})
-		result["stdout"] = stdout.String()
-		result["stderr"] = stderr.String()
-		result["success"] = err == nil
-
-		if err != nil {
-			result["error"] = err.Error()
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				result["exitCode"] = exitErr.ExitCode()
-			}
-		} else {
-			result["exitCode"] = 0
-		}
-
-		// Try to parse stdout as JSON
-		stdoutStr := strings.TrimSpace(stdout.String())
-		if stdoutStr != "" && (strings.HasPrefix(stdoutStr, "{") || strings.HasPrefix(stdoutStr, "[")) {
-			var jsonOutput interface{}
-			if json.Unmarshal([]byte(stdoutStr), &jsonOutput) == nil {
-				result["output"] = jsonOutput
-			}
-		}
-
-		return result, nil
-
-	case <-time.After(time.Duration(timeout) * time.Second):
-		// Timeout
-		cmd.Process.Kill()
+	if run.TimedOut {
 		return nil, fmt.Errorf("script execution timeout after %d seconds", timeout)
 	}
-}
 
-// preparePythonScript prepares Python script execution
-func (a *ScriptAction) preparePythonScript(ctx *ScriptActionContext) (*exec.Cmd, func()) {
-	if a.File != "" {
-		// Use existing file
-		cmd := exec.Command("python3", append([]string{a.File}, a.Args...)...)
-		return cmd, nil
+	result := make(map[string]interface{})
+	result["stdout"] = run.Stdout
+	result["stderr"] = run.Stderr
+	result["success"] = run.ExitCode == 0
+	result["exitCode"] = run.ExitCode
+	if run.ExitCode != 0 {
+		result["error"] = fmt.Sprintf("exit status %d", run.ExitCode)
 	}
-
-	// Create temporary file
-	tmpFile, err := os.CreateTemp("", "script-*.py")
-	if err != nil {
-		return nil, nil
+	if run.Truncated {
+		result["outputTruncated"] = true
 	}
-	tmpPath := tmpFile.Name()
-
-	// Inject context variables
-	script := a.injectContext(a.Script, ctx)
 
-	tmpFile.WriteString(script)
-	tmpFile.Close()
-
-	cmd := exec.Command("python3", append([]string{tmpPath}, a.Args...)...)
-	cleanup := func() { os.Remove(tmpPath) }
+	// Try to parse stdout as JSON
+	stdoutStr := strings.TrimSpace(run.Stdout)
+	if stdoutStr != "" && (strings.HasPrefix(stdoutStr, "{") || strings.HasPrefix(stdoutStr, "[")) {
+		var jsonOutput interface{}
+		if json.Unmarshal([]byte(stdoutStr), &jsonOutput) == nil {
+			result["output"] = jsonOutput
+		}
+	}
 
-	return cmd, cleanup
+	return result, nil
 }
 
-// prepareJavaScriptScript prepares JavaScript script execution
-func (a *ScriptAction) prepareJavaScriptScript(ctx *ScriptActionContext) (*exec.Cmd, func()) {
-	if a.File != "" {
-		// Use existing file
-		cmd := exec.Command("node", append([]string{a.File}, a.Args...)...)
-		return cmd, nil
-	}
-
-	// Create temporary file
-	tmpFile, err := os.CreateTemp("", "script-*.js")
-	if err != nil {
-		return nil, nil
+// usesEmbeddedRuntime reports whether the script runs in the embedded
+// JavaScript engine rather than a host interpreter
+func (a *ScriptAction) usesEmbeddedRuntime() bool {
+	switch strings.ToLower(a.Language) {
+	case "javascript", "js":
+		runtime := strings.ToLower(a.Runtime)
+		return runtime == "" || runtime == RuntimeEmbedded
 	}
-	tmpPath := tmpFile.Name()
-
-	// Inject context variables
-	script := a.injectContext(a.Script, ctx)
-
-	tmpFile.WriteString(script)
-	tmpFile.Close()
+	return false
+}
 
-	cmd := exec.Command("node", append([]string{tmpPath}, a.Args...)...)
-	cleanup := func() { os.Remove(tmpPath) }
+// prepareSpec builds the sandbox spec for host interpreters. Inline scripts
+// are written into the per-run directory of the sandbox.
+func (a *ScriptAction) prepareSpec(ctx *ScriptActionContext) (*sandbox.Spec, error) {
+	var interpreter, fileName string
+	script := a.Script
 
-	return cmd, cleanup
-}
+	switch strings.ToLower(a.Language) {
+	case "python", "python3":
+		interpreter, fileName = "python3", "script.py"
+		script = a.injectContext(script, ctx)
+	case "javascript", "js", "node":
+		interpreter, fileName = "node", "script.js"
+		script = a.injectContext(script, ctx)
+	case "shell", "bash", "sh":
+		interpreter, fileName = "bash", "script.sh"
+	default:
+		return nil, fmt.Errorf("unsupported language: %s", a.Language)
+	}
 
-// prepareShellScript prepares shell script execution
-func (a *ScriptAction) prepareShellScript() (*exec.Cmd, func()) {
 	if a.File != "" {
 		// Use existing file
-		cmd := exec.Command("bash", append([]string{a.File}, a.Args...)...)
-		return cmd, nil
-	}
-
-	// Create temporary file
-	tmpFile, err := os.CreateTemp("", "script-*.sh")
-	if err != nil {
-		return nil, nil
+		path, err := filepath.Abs(a.File)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script file: %w", err)
+		}
+		return &sandbox.Spec{Path: interpreter, Args: append([]string{path}, a.Args...)}, nil
 	}
-	tmpPath := tmpFile.Name()
 
-	tmpFile.WriteString(a.Script)
-	tmpFile.Close()
-
-	// Make executable
-	os.Chmod(tmpPath, 0755)
-
-	cmd := exec.Command("bash", append([]string{tmpPath}, a.Args...)...)
-	cleanup := func() { os.Remove(tmpPath) }
-
-	return cmd, cleanup
+	return &sandbox.Spec{
+		Path:  interpreter,
+		Args:  append([]string{fileName}, a.Args...),
+		Files: map[string][]byte{fileName: []byte(script)},
+	}, nil
 }
 
 // injectContext injects context variables into script
@@ -261,6 +201,17 @@ func (a *ScriptAction) Validate() error {
 		return fmt.Errorf("unsupported language: %s (supported: %v)", a.Language, supportedLanguages)
 	}
 
+	if a.Runtime != "" {
+		switch strings.ToLower(a.Language) {
+		case "javascript", "js":
+		default:
+			return fmt.Errorf("runtime is only supported for javascript")
+		}
+		if r := strings.ToLower(a.Runtime); r != RuntimeEmbedded && r != RuntimeNode {
+			return fmt.Errorf("unsupported runtime: %s (supported: [%s %s])", a.Runtime, RuntimeEmbedded, RuntimeNode)
+		}
+	}
+
 	return nil
 }
 
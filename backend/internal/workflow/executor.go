@@ -10,13 +10,14 @@ import (
 	"time"
 
 	"test-management-service/internal/expression"
+	"test-management-service/internal/extract"
+	"test-management-service/internal/jsonpath"
 	"test-management-service/internal/models"
 	"test-management-service/internal/testcase"
 	"test-management-service/internal/websocket"
 	"test-management-service/internal/workflow/actions"
 
 	"github.com/google/uuid"
-	"github.com/tidwall/gjson"
 	"gorm.io/gorm"
 )
 
@@ -25,6 +26,21 @@ type VariableInjector interface {
 	GetActiveEnvironmentVariables(ctx context.Context, tenantID, projectID string) (map[string]string, error)
 }
 
+// ScriptPolicyProvider resolves the script sandbox policy of a tenant
+type ScriptPolicyProvider interface {
+	GetScriptPolicy(ctx context.Context, tenantID string) (*actions.ScriptPolicy, error)
+}
+
+// SchemaDocumentLoader loads project schema documents for jsonSchema assertions
+type SchemaDocumentLoader interface {
+	LoadSchemaDocument(ctx context.Context, tenantID, projectID, name string) (interface{}, error)
+}
+
+// ContractResolver resolves the OpenAPI contract HTTP steps are validated against
+type ContractResolver interface {
+	ResolveContract(ctx context.Context, tenantID, projectID, groupID string) (*testcase.Contract, error)
+}
+
 // ExecutionParams contains tenant context for workflow execution
 type ExecutionParams struct {
 	TenantID  string
@@ -42,6 +58,10 @@ type WorkflowExecutorImpl struct {
 	variableInjector   VariableInjector
 	actionTemplateRepo ActionTemplateRepository
 	variableResolver   *VariableResolver
+	scriptPolicy       ScriptPolicyProvider
+	schemaLoader       SchemaDocumentLoader
+	contracts          ContractResolver
+	strictVariables    bool
 }
 
 // NewWorkflowExecutor creates a new workflow executor
@@ -72,6 +92,28 @@ func NewWorkflowExecutor(
 	return executor
 }
 
+// SetScriptPolicyProvider sets the provider used to resolve tenant script policies
+func (e *WorkflowExecutorImpl) SetScriptPolicyProvider(provider ScriptPolicyProvider) {
+	e.scriptPolicy = provider
+}
+
+// SetSchemaLoader sets the loader for stored schema documents
+func (e *WorkflowExecutorImpl) SetSchemaLoader(loader SchemaDocumentLoader) {
+	e.schemaLoader = loader
+}
+
+// SetContractResolver sets the resolver for the OpenAPI contract linked to
+// the active environment
+func (e *WorkflowExecutorImpl) SetContractResolver(resolver ContractResolver) {
+	e.contracts = resolver
+}
+
+// SetStrictVariables makes step config interpolation fail on {{ }}
+// placeholders that cannot be resolved instead of leaving them in place
+func (e *WorkflowExecutorImpl) SetStrictVariables(strict bool) {
+	e.strictVariables = strict
+}
+
 func (e *WorkflowExecutorImpl) registerBuiltinActions() {
 	// HTTP and Command actions will be registered here
 	// TestCaseAction is registered separately
@@ -110,6 +152,37 @@ func (e *WorkflowExecutorImpl) Execute(workflowID string, workflowDef interface{
 		StepResults: make(map[string]*StepExecutionResult),
 		Logger:      NewBroadcastStepLogger(e.db, runID, e.hub),
 		VarTracker:  NewDatabaseVariableChangeTracker(e.db, runID),
+		Sessions:    testcase.NewSessionStore(workflow.Sessions),
+		StrictVariables: e.strictVariables,
+	}
+
+	// Resolve the tenant script policy; without one scripts get no fs/network access
+	if e.scriptPolicy != nil && params != nil {
+		policy, err := e.scriptPolicy.GetScriptPolicy(context.Background(), params.TenantID)
+		if err != nil {
+			ctx.Logger.Warn("", fmt.Sprintf("Failed to load script policy: %v", err))
+		} else {
+			ctx.ScriptPolicy = policy
+		}
+	}
+
+	// Stored schemas are resolved in the tenant's project
+	if e.schemaLoader != nil && params != nil {
+		loader, tenantID, projectID := e.schemaLoader, params.TenantID, params.ProjectID
+		ctx.SchemaLoader = func(name string) (interface{}, error) {
+			return loader.LoadSchemaDocument(context.Background(), tenantID, projectID, name)
+		}
+	}
+
+	// HTTP steps are validated against the contract of the active environment
+	if e.contracts != nil && params != nil {
+		contract, err := e.contracts.ResolveContract(context.Background(), params.TenantID, params.ProjectID, "")
+		if err != nil {
+			ctx.Logger.Warn("", fmt.Sprintf("Failed to resolve OpenAPI contract: %v", err))
+		} else if contract != nil {
+			contract.Load = ctx.SchemaLoader
+			ctx.Contract = contract
+		}
 	}
 
 	// Initialize variables map if nil
@@ -140,7 +213,7 @@ func (e *WorkflowExecutorImpl) Execute(workflowID string, workflowDef interface{
 	}
 
 	// === 初始化表达式求值器 ===
-	ctx.Evaluator = expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+	ctx.Evaluator = newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 
 	// Step 5: Build DAG and get execution order
 	layers, err := e.buildDAG(workflow.Steps)
@@ -242,7 +315,7 @@ func (e *WorkflowExecutorImpl) mergeConfig(
 	templateConfig models.JSONB,
 	inputs map[string]string,
 	ctx *ExecutionContext,
-) map[string]interface{} {
+) (map[string]interface{}, error) {
 	merged := make(map[string]interface{})
 
 	// Step 1: Copy template configuration as base
@@ -256,12 +329,15 @@ func (e *WorkflowExecutorImpl) mergeConfig(
 	if inputs != nil {
 		for paramName, paramValue := range inputs {
 			// Resolve variables in parameter value
-			resolvedValue := e.variableResolver.Resolve(paramValue, ctx)
+			resolvedValue, err := e.variableResolver.Resolve(paramValue, ctx)
+			if err != nil {
+				return nil, fmt.Errorf("input %s: %w", paramName, err)
+			}
 			merged[paramName] = resolvedValue
 		}
 	}
 
-	return merged
+	return merged, nil
 }
 
 // parseOutputDefinitions parses JSONArray of outputs into ActionOutput structs
@@ -295,7 +371,8 @@ func (e *WorkflowExecutorImpl) parseOutputDefinitions(outputsJSON models.JSONArr
 }
 
 // extractOutputsFromTemplate extracts outputs from action result using template definitions
-// Uses JSONPath to extract values and maps them to workflow variables
+// Uses JSONPath (or XPath, CSS and regex paths over the response body) to extract values
+// and maps them to workflow variables
 func (e *WorkflowExecutorImpl) extractOutputsFromTemplate(
 	result *ActionResult,
 	outputDefs []ActionOutput,
@@ -307,19 +384,17 @@ func (e *WorkflowExecutorImpl) extractOutputsFromTemplate(
 		return
 	}
 
-	// Convert output to JSON for gjson querying
-	outputJSON, err := json.Marshal(result.Output)
-	if err != nil {
-		ctx.Logger.Warn(stepID, fmt.Sprintf("Failed to marshal output for extraction: %v", err))
-		return
-	}
+	// Outputs are extracted from the JSON form of the result
+	output := jsonpath.Normalize(result.Output)
 
 	// Extract each defined output
 	for _, outputDef := range outputDefs {
-		// Use gjson to extract value from result using JSONPath
-		extractedValue := gjson.GetBytes(outputJSON, outputDef.Path)
-
-		if !extractedValue.Exists() {
+		extractedValue, found, err := extract.Lookup(output, outputDef.Path)
+		if err != nil {
+			ctx.Logger.Warn(stepID, fmt.Sprintf("Invalid output path '%s': %v", outputDef.Path, err))
+			continue
+		}
+		if !found {
 			ctx.Logger.Warn(stepID, fmt.Sprintf("Output path '%s' not found in result", outputDef.Path))
 			continue
 		}
@@ -335,7 +410,7 @@ func (e *WorkflowExecutorImpl) extractOutputsFromTemplate(
 
 		// Track variable change
 		oldValue := ctx.Variables[varName]
-		newValue := extractedValue.Value()
+		newValue := extractedValue
 		ctx.Variables[varName] = newValue
 
 		// Log variable tracking
@@ -535,7 +610,15 @@ func (e *WorkflowExecutorImpl) executeStep(ctx *ExecutionContext, step *Workflow
 		}
 
 		// Merge template config with step inputs
-		finalConfig = e.mergeConfig(template.ConfigTemplate, step.Inputs, ctx)
+		finalConfig, err = e.mergeConfig(template.ConfigTemplate, step.Inputs, ctx)
+		if err != nil {
+			stepExec.Status = "failed"
+			stepExec.Error = fmt.Sprintf("variable interpolation failed: %v", err)
+			stepExec.EndTime = time.Now()
+			stepExec.Duration = int(stepExec.EndTime.Sub(stepExec.StartTime).Milliseconds())
+			e.db.Save(stepExec)
+			return err
+		}
 
 		// Parse output definitions from template
 		outputDefinitions = e.parseOutputDefinitions(template.Outputs)
@@ -583,6 +666,11 @@ func (e *WorkflowExecutorImpl) executeStep(ctx *ExecutionContext, step *Workflow
 		TestCaseRepo:    e.testCaseRepo,
 		UnifiedExecutor: e.unifiedExecutor,
 		Logger:          ctx.Logger,
+		Sessions:        ctx.Sessions,
+		ScriptPolicy:    ctx.ScriptPolicy,
+		SchemaLoader:    ctx.SchemaLoader,
+		Contract:        ctx.Contract,
+		StrictVariables: ctx.StrictVariables,
 	}
 
 	// Execute with retry
@@ -635,6 +723,7 @@ func (e *WorkflowExecutorImpl) executeStep(ctx *ExecutionContext, step *Workflow
 
 	// === Step 3: Extract outputs ===
 	stepExec.Status = "success"
+	applyActionVariables(ctx.Variables, ctx.VarTracker, step.ID, result)
 	if result != nil && result.Output != nil {
 		stepExec.OutputData = models.JSONB(result.Output)
 
@@ -646,9 +735,14 @@ func (e *WorkflowExecutorImpl) executeStep(ctx *ExecutionContext, step *Workflow
 			// Mode 1: Extract from template definitions
 			e.extractOutputsFromTemplate(result, outputDefinitions, step.Outputs, ctx, step.ID)
 		} else if step.Output != nil {
-			// Mode 2: Extract from step output mappings (legacy)
+			// Mode 2: Extract from step output mappings (legacy): output keys,
+			// or extraction paths such as "xpath://order/id"
 			for varName, outputPath := range step.Output {
-				if value, exists := result.Output[outputPath]; exists {
+				value, exists := result.Output[outputPath]
+				if !exists {
+					value, exists, _ = extract.Lookup(jsonpath.Normalize(result.Output), outputPath)
+				}
+				if exists {
 					oldValue := ctx.Variables[varName]
 					ctx.Variables[varName] = value
 					ctx.VarTracker.Track(step.ID, varName, oldValue, value, "update")
@@ -679,6 +773,28 @@ func (e *WorkflowExecutorImpl) executeStep(ctx *ExecutionContext, step *Workflow
 	return nil
 }
 
+// applyActionVariables writes variables set by an action into the run variables
+func applyActionVariables(variables map[string]interface{}, tracker VariableChangeTracker, stepID string, result *ActionResult) {
+	if result == nil || len(result.Variables) == 0 || variables == nil {
+		return
+	}
+	for name, value := range result.Variables {
+		oldValue := variables[name]
+		variables[name] = value
+		if tracker != nil {
+			tracker.Track(stepID, name, oldValue, value, "update")
+		}
+	}
+}
+
+// newEvaluator creates the expression evaluator of a step. Every evaluator
+// of a run is created here, so strict mode applies to all of them.
+func newEvaluator(variables, stepOutputs map[string]interface{}, strict bool) *expression.Evaluator {
+	evaluator := expression.NewEvaluator(variables, stepOutputs)
+	evaluator.SetStrict(strict)
+	return evaluator
+}
+
 // interpolateConfig recursively interpolates variables in config map
 func (e *WorkflowExecutorImpl) interpolateConfig(config map[string]interface{}, variables map[string]interface{}, stepOutputs map[string]interface{}) (map[string]interface{}, error) {
 	if config == nil {
@@ -686,9 +802,8 @@ func (e *WorkflowExecutorImpl) interpolateConfig(config map[string]interface{},
 	}
 
 	// Create evaluator for variable substitution
-	evaluator := expression.NewEvaluator(variables, stepOutputs)
+	evaluator := newEvaluator(variables, stepOutputs, e.strictVariables)
 
-	// Recursively interpolate all values
 	result := make(map[string]interface{})
 	for key, value := range config {
 		interpolatedValue, err := e.interpolateValue(value, evaluator)
@@ -701,45 +816,10 @@ func (e *WorkflowExecutorImpl) interpolateConfig(config map[string]interface{},
 	return result, nil
 }
 
-// interpolateValue recursively interpolates a single value
+// interpolateValue recursively interpolates a single value. A string that is
+// a single placeholder keeps the type of the value it refers to.
 func (e *WorkflowExecutorImpl) interpolateValue(value interface{}, evaluator *expression.Evaluator) (interface{}, error) {
-	switch v := value.(type) {
-	case string:
-		// Interpolate string values that contain {{...}}
-		interpolated, err := evaluator.EvaluateString(v)
-		if err != nil {
-			return nil, err
-		}
-		return interpolated, nil
-
-	case map[string]interface{}:
-		// Recursively interpolate map values
-		result := make(map[string]interface{})
-		for key, val := range v {
-			interpolatedVal, err := e.interpolateValue(val, evaluator)
-			if err != nil {
-				return nil, fmt.Errorf("failed to interpolate map key '%s': %w", key, err)
-			}
-			result[key] = interpolatedVal
-		}
-		return result, nil
-
-	case []interface{}:
-		// Recursively interpolate array elements
-		result := make([]interface{}, len(v))
-		for i, item := range v {
-			interpolatedItem, err := e.interpolateValue(item, evaluator)
-			if err != nil {
-				return nil, fmt.Errorf("failed to interpolate array index %d: %w", i, err)
-			}
-			result[i] = interpolatedItem
-		}
-		return result, nil
-
-	default:
-		// Return non-string values as-is
-		return value, nil
-	}
+	return evaluator.InterpolateValue(value)
 }
 
 // getActionForStep returns the appropriate action for a step
@@ -757,6 +837,8 @@ func (e *WorkflowExecutorImpl) getActionForStep(step *WorkflowStep) (Action, err
 		}, nil
 	case "http":
 		return &HTTPActionWrapper{Config: step.Config}, nil
+	case "graphql":
+		return &GraphQLActionWrapper{Config: step.Config}, nil
 	case "command":
 		return &CommandActionWrapper{Config: step.Config}, nil
 	case "database":
@@ -784,7 +866,7 @@ func (e *WorkflowExecutorImpl) evaluateCondition(expr string, ctx *ExecutionCont
 	}
 
 	// 更新求值器的变量和输出（可能在执行过程中发生变化）
-	evaluator = expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+	evaluator = newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 
 	// 求值布尔表达式
 	result, err := evaluator.EvaluateBool(expr)
@@ -866,9 +948,12 @@ func (a *TestCaseActionWrapper) Execute(ctx *ActionContext) (*ActionResult, erro
 
 	// Convert to testcase.TestCase and execute
 	testCase := &testcase.TestCase{
-		ID:   tc.TestID,
-		Name: tc.Name,
-		Type: tc.Type,
+		ID:          tc.TestID,
+		Name:        tc.Name,
+		Type:        tc.Type,
+		Assertions:  decodeAssertions([]interface{}(tc.Assertions)),
+		Variables:   ctx.Variables,
+		StepOutputs: ctx.StepOutputs,
 	}
 
 	// Apply HTTP/Command config based on type
@@ -883,24 +968,35 @@ func (a *TestCaseActionWrapper) Execute(ctx *ActionContext) (*ActionResult, erro
 		data, _ := json.Marshal(tc.CommandConfig)
 		json.Unmarshal(data, &cmdConfig)
 		testCase.Command = &cmdConfig
+	case "graphql":
+		var graphqlConfig testcase.GraphQLTest
+		data, _ := json.Marshal(tc.GraphQLConfig)
+		json.Unmarshal(data, &graphqlConfig)
+		testCase.GraphQL = &graphqlConfig
 	}
 
-	// Execute
-	result := ctx.UnifiedExecutor.Execute(testCase)
+	// Execute (shares the run's sessions so a login test case keeps its cookies)
+	result := ctx.UnifiedExecutor.ExecuteWithSessions(testCase, ctx.Sessions)
 
 	if result.Status != "passed" {
 		return &ActionResult{
 			Status: "failed",
-			Error:  fmt.Errorf("test failed: %s", result.Error),
+			Output: map[string]interface{}{
+				"status":     result.Status,
+				"response":   result.Response,
+				"assertions": result.Assertions,
+			},
+			Error: fmt.Errorf("test failed: %s", failureMessage(result)),
 		}, nil
 	}
 
 	return &ActionResult{
 		Status: "success",
 		Output: map[string]interface{}{
-			"status":   result.Status,
-			"response": result.Response,
-			"duration": result.Duration.Milliseconds(),
+			"status":     result.Status,
+			"response":   result.Response,
+			"duration":   result.Duration.Milliseconds(),
+			"assertions": result.Assertions,
 		},
 		Duration: int(result.Duration.Milliseconds()),
 	}, nil
@@ -929,30 +1025,47 @@ func (a *HTTPActionWrapper) Execute(ctx *ActionContext) (*ActionResult, error) {
 
 	// Create HTTP test case and execute via UnifiedExecutor
 	testCase := &testcase.TestCase{
-		ID:   ctx.StepID,
-		Name: ctx.StepID,
-		Type: "http",
+		ID:          ctx.StepID,
+		Name:        ctx.StepID,
+		Type:        "http",
+		Variables:   ctx.Variables,
+		StepOutputs: ctx.StepOutputs,
 	}
 
 	var httpConfig testcase.HTTPTest
 	data, _ := json.Marshal(a.Config)
 	json.Unmarshal(data, &httpConfig)
 	testCase.HTTP = &httpConfig
+	testCase.Assertions = decodeAssertions(a.Config["assertions"])
+	testCase.Contract = stepContract(a.Config["contract"], ctx)
 
-	result := ctx.UnifiedExecutor.Execute(testCase)
+	result := ctx.UnifiedExecutor.ExecuteWithSessions(testCase, ctx.Sessions)
+	for _, warning := range result.Warnings {
+		if ctx.Logger != nil {
+			ctx.Logger.Warn(ctx.StepID, warning)
+		}
+	}
 
 	if result.Status != "passed" {
 		return &ActionResult{
 			Status: "failed",
-			Error:  fmt.Errorf("HTTP request failed: %s", result.Error),
+			Output: map[string]interface{}{
+				"status":     result.Status,
+				"response":   result.Response,
+				"assertions": result.Assertions,
+				"warnings":   result.Warnings,
+			},
+			Error: fmt.Errorf("HTTP request failed: %s", failureMessage(result)),
 		}, nil
 	}
 
 	return &ActionResult{
 		Status: "success",
 		Output: map[string]interface{}{
-			"status":   result.Status,
-			"response": result.Response,
+			"status":     result.Status,
+			"response":   result.Response,
+			"assertions": result.Assertions,
+			"warnings":   result.Warnings,
 		},
 		Duration: int(result.Duration.Milliseconds()),
 	}, nil
@@ -962,6 +1075,94 @@ func (a *HTTPActionWrapper) Validate() error {
 	return nil
 }
 
+// stepContract returns the contract a step declares ({"spec", "mode"}), or
+// the run's contract. Stored documents load through the run's schema loader.
+func stepContract(raw interface{}, ctx *ActionContext) *testcase.Contract {
+	if raw == nil {
+		return ctx.Contract
+	}
+	var contract testcase.Contract
+	data, _ := json.Marshal(raw)
+	if err := json.Unmarshal(data, &contract); err != nil || contract.Spec == "" {
+		return ctx.Contract
+	}
+	contract.Load = ctx.SchemaLoader
+	return &contract
+}
+
+// GraphQLActionWrapper wraps GraphQL execution. The query document and
+// variables are interpolated with the rest of the step config.
+type GraphQLActionWrapper struct {
+	Config map[string]interface{}
+}
+
+func (a *GraphQLActionWrapper) Execute(ctx *ActionContext) (*ActionResult, error) {
+	// Handle nil UnifiedExecutor (for testing)
+	if ctx.UnifiedExecutor == nil {
+		return &ActionResult{
+			Status: "success",
+			Output: map[string]interface{}{"status": 200, "mock": true},
+		}, nil
+	}
+
+	testCase := &testcase.TestCase{
+		ID:          ctx.StepID,
+		Name:        ctx.StepID,
+		Type:        "graphql",
+		Variables:   ctx.Variables,
+		StepOutputs: ctx.StepOutputs,
+	}
+
+	var graphqlConfig testcase.GraphQLTest
+	data, _ := json.Marshal(a.Config)
+	json.Unmarshal(data, &graphqlConfig)
+	testCase.GraphQL = &graphqlConfig
+
+	// Assertions declared on the step (e.g. graphql_no_errors)
+	testCase.Assertions = decodeAssertions(a.Config["assertions"])
+
+	result := ctx.UnifiedExecutor.ExecuteWithSessions(testCase, ctx.Sessions)
+
+	var responseData, responseErrors interface{}
+	if result.Response != nil {
+		responseData = result.Response["data"]
+		responseErrors = result.Response["errors"]
+	}
+
+	if result.Status != "passed" {
+		return &ActionResult{
+			Status: "failed",
+			Output: map[string]interface{}{
+				"status":     result.Status,
+				"response":   result.Response,
+				"data":       responseData,
+				"errors":     responseErrors,
+				"assertions": result.Assertions,
+			},
+			Error: fmt.Errorf("GraphQL request failed: %s", failureMessage(result)),
+		}, nil
+	}
+
+	return &ActionResult{
+		Status: "success",
+		Output: map[string]interface{}{
+			"status":     result.Status,
+			"response":   result.Response,
+			"data":       responseData,
+			"errors":     responseErrors,
+			"assertions": result.Assertions,
+		},
+		Duration: int(result.Duration.Milliseconds()),
+	}, nil
+}
+
+func (a *GraphQLActionWrapper) Validate() error {
+	if query, _ := a.Config["query"].(string); strings.TrimSpace(query) == "" {
+		return fmt.Errorf("query is required")
+	}
+	return nil
+}
+
 // CommandActionWrapper wraps command execution
 type CommandActionWrapper struct {
 	Config map[string]interface{}
@@ -977,30 +1178,39 @@ func (a *CommandActionWrapper) Execute(ctx *ActionContext) (*ActionResult, error
 	}
 
 	testCase := &testcase.TestCase{
-		ID:   ctx.StepID,
-		Name: ctx.StepID,
-		Type: "command",
+		ID:          ctx.StepID,
+		Name:        ctx.StepID,
+		Type:        "command",
+		Variables:   ctx.Variables,
+		StepOutputs: ctx.StepOutputs,
 	}
 
 	var cmdConfig testcase.CommandTest
 	data, _ := json.Marshal(a.Config)
 	json.Unmarshal(data, &cmdConfig)
 	testCase.Command = &cmdConfig
+	testCase.Assertions = decodeAssertions(a.Config["assertions"])
 
-	result := ctx.UnifiedExecutor.Execute(testCase)
+	result := ctx.UnifiedExecutor.ExecuteWithSessions(testCase, ctx.Sessions)
 
 	if result.Status != "passed" {
 		return &ActionResult{
 			Status: "failed",
-			Error:  fmt.Errorf("command failed: %s", result.Error),
+			Output: map[string]interface{}{
+				"status":     result.Status,
+				"response":   result.Response,
+				"assertions": result.Assertions,
+			},
+			Error: fmt.Errorf("command failed: %s", failureMessage(result)),
 		}, nil
 	}
 
 	return &ActionResult{
 		Status: "success",
 		Output: map[string]interface{}{
-			"status":   result.Status,
-			"response": result.Response,
+			"status":     result.Status,
+			"response":   result.Response,
+			"assertions": result.Assertions,
 		},
 		Duration: int(result.Duration.Milliseconds()),
 	}, nil
@@ -1010,6 +1220,25 @@ func (a *CommandActionWrapper) Validate() error {
 	return nil
 }
 
+// decodeAssertions reads assertions declared on a step or stored test case;
+// the unified executor evaluates them with the shared assertion engine
+func decodeAssertions(raw interface{}) []testcase.Assertion {
+	var assertions []testcase.Assertion
+	if raw != nil {
+		data, _ := json.Marshal(raw)
+		json.Unmarshal(data, &assertions)
+	}
+	return assertions
+}
+
+// failureMessage describes why a test result did not pass
+func failureMessage(result *testcase.TestResult) string {
+	if result.Error != "" {
+		return result.Error
+	}
+	return strings.Join(result.Failures, "; ")
+}
+
 // DatabaseActionWrapper wraps database action execution
 type DatabaseActionWrapper struct {
 	Config map[string]interface{}
@@ -1070,26 +1299,15 @@ func (a *DatabaseActionWrapper) Execute(ctx *ActionContext) (*ActionResult, erro
 						stepName := parts[0]
 						fieldPath := parts[1]
 
-						// Try to get from StepOutputs first
-						if stepOutput, exists := ctx.StepOutputs[stepName]; exists {
-							// Use gjson to navigate the path
-							stepJSON, err := json.Marshal(stepOutput)
-							if err == nil {
-								result := gjson.GetBytes(stepJSON, fieldPath)
-								if result.Exists() {
-									interpolatedArgs[i] = result.Value()
-									continue
-								}
-							}
-						} else if varValue, exists := ctx.Variables[stepName]; exists {
-							// Also try Variables with dotted path
-							varJSON, err := json.Marshal(varValue)
-							if err == nil {
-								result := gjson.GetBytes(varJSON, fieldPath)
-								if result.Exists() {
-									interpolatedArgs[i] = result.Value()
-									continue
-								}
+						// Try to get from StepOutputs first, then Variables
+						source, exists := ctx.StepOutputs[stepName]
+						if !exists {
+							source, exists = ctx.Variables[stepName]
+						}
+						if exists {
+							if value, found, err := jsonpath.Lookup(jsonpath.Normalize(source), fieldPath); err == nil && found {
+								interpolatedArgs[i] = value
+								continue
 							}
 						}
 					} else {
@@ -1140,18 +1358,23 @@ func (a *ScriptActionWrapper) Execute(ctx *ActionContext) (*ActionResult, error)
 	timeout, _ := a.Config["timeout"].(float64)
 
 	// Create script action context
+	runtime, _ := a.Config["runtime"].(string)
+
 	scriptCtx := &actions.ScriptActionContext{
 		Variables:   ctx.Variables,
 		StepOutputs: ctx.StepOutputs,
 		Logger:      ctx.Logger,
+		StepID:      ctx.StepID,
 	}
 
 	// Create and execute script action
 	scriptAction := &actions.ScriptAction{
 		Language: language,
+		Runtime:  runtime,
 		Script:   script,
 		File:     file,
 		Timeout:  int(timeout),
+		Policy:   ctx.ScriptPolicy,
 	}
 
 	// Get args if present
@@ -1180,10 +1403,23 @@ func (a *ScriptActionWrapper) Execute(ctx *ActionContext) (*ActionResult, error)
 		}, nil
 	}
 
-	return &ActionResult{
+	// Embedded scripts report errors and failed assertions in the result
+	if success, ok := result["success"].(bool); ok && !success && result["runtime"] == actions.RuntimeEmbedded {
+		return &ActionResult{
+			Status: "failed",
+			Output: result,
+			Error:  fmt.Errorf("script failed: %v", result["error"]),
+		}, nil
+	}
+
+	actionResult := &ActionResult{
 		Status: "success",
 		Output: result,
-	}, nil
+	}
+	if variables, ok := result["variables"].(map[string]interface{}); ok && len(variables) > 0 {
+		actionResult.Variables = variables
+	}
+	return actionResult, nil
 }
 
 func (a *ScriptActionWrapper) Validate() error {
@@ -1201,6 +1437,8 @@ func (a *AssertActionWrapper) Execute(ctx *ActionContext) (*ActionResult, error)
 		Variables:   ctx.Variables,
 		StepOutputs: ctx.StepOutputs,
 		Logger:      ctx.Logger,
+		LoadSchema:  ctx.SchemaLoader,
+		Strict:      ctx.StrictVariables,
 	}
 
 	// Parse assertions
@@ -1232,6 +1470,18 @@ func (a *AssertActionWrapper) Execute(ctx *ActionContext) (*ActionResult, error)
 		if message, ok := assertMap["message"]; ok && message != nil {
 			assertion.Message = fmt.Sprintf("%v", message)
 		}
+		if schemaDoc, ok := assertMap["schema"]; ok && schemaDoc != nil {
+			assertion.Schema = schemaDoc
+		}
+		if schemaRef, ok := assertMap["schemaRef"].(string); ok {
+			assertion.SchemaRef = schemaRef
+		}
+		if draft, ok := assertMap["draft"].(string); ok {
+			assertion.Draft = draft
+		}
+		if expr, ok := assertMap["expression"].(string); ok {
+			assertion.Expression = expr
+		}
 
 		assertions[i] = assertion
 	}
@@ -509,3 +509,36 @@ func TestWorkflowExecutor_StepExecutionRecords(t *testing.T) {
 	assert.Equal(t, "step1", executions[0].StepID)
 	assert.Equal(t, "success", executions[0].Status)
 }
+
+// TestExtractOutputsFromTemplate_MarkupPaths tests XPath, CSS and regex output paths
+func TestExtractOutputsFromTemplate_MarkupPaths(t *testing.T) {
+	db := setupTestDB(t)
+	executor := &WorkflowExecutorImpl{db: db}
+	ctx := &ExecutionContext{
+		Variables:  map[string]interface{}{},
+		Logger:     NewDatabaseStepLogger(db, "run-1"),
+		VarTracker: NewDatabaseVariableChangeTracker(db, "run-1"),
+	}
+	soap := `<Envelope><Body><LoginResponse><SessionKey>k-42</SessionKey></LoginResponse></Body></Envelope>`
+	result := &ActionResult{Status: "success", Output: map[string]interface{}{
+		"status": "passed",
+		"response": map[string]interface{}{
+			"statusCode": 200,
+			"body":       soap,
+			"bodyRaw":    soap,
+			"bodyType":   "xml",
+		},
+	}}
+
+	executor.extractOutputsFromTemplate(result, []ActionOutput{
+		{Name: "sessionKey", Path: "xpath://LoginResponse/SessionKey"},
+		{Name: "statusCode", Path: "response.statusCode"},
+		{Name: "keyNumber", Path: `regex:k-(\d+)`},
+		{Name: "missing", Path: "css:table"},
+	}, map[string]string{"sessionKey": "key"}, ctx, "login")
+
+	assert.Equal(t, "k-42", ctx.Variables["key"])
+	assert.Equal(t, float64(200), ctx.Variables["statusCode"])
+	assert.Equal(t, "42", ctx.Variables["keyNumber"])
+	assert.NotContains(t, ctx.Variables, "missing")
+}
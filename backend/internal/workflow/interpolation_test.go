@@ -1,9 +1,11 @@
 package workflow
 
 import (
+	"errors"
 	"testing"
 
 	"test-management-service/internal/expression"
+	"test-management-service/internal/models"
 )
 
 // TestInterpolateValue tests the interpolateValue method
@@ -269,3 +271,39 @@ func deepEqual(a, b interface{}) bool {
 		return a == b
 	}
 }
+
+// TestStepExecutor_StrictVariables tests that a step with an unresolved
+// placeholder fails in strict mode instead of running with the literal text
+func TestStepExecutor_StrictVariables(t *testing.T) {
+	db := setupTestDB(t)
+	executor := NewTestStepExecutor(db, nil, nil, nil)
+	step := &models.TestStep{
+		ID:   "echo",
+		Name: "Echo",
+		Type: models.StepTypeCommand,
+		Config: models.JSONB{
+			"cmd":  "echo",
+			"args": []interface{}{"{{missing}}"},
+		},
+	}
+
+	ctx := NewTestStepExecutionContext("run-lenient", db, nil)
+	execution, err := executor.ExecuteStep(ctx, step)
+	if err != nil {
+		t.Fatalf("ExecuteStep() error = %v", err)
+	}
+	if execution.Status != models.StepStatusPassed {
+		t.Errorf("lenient status = %s, want passed (error: %s)", execution.Status, execution.Error)
+	}
+
+	ctx = NewTestStepExecutionContext("run-strict", db, nil)
+	ctx.StrictVariables = true
+	execution, err = executor.ExecuteStep(ctx, step)
+	var unresolved *expression.UnresolvedError
+	if !errors.As(err, &unresolved) || unresolved.Placeholder != "{{missing}}" {
+		t.Errorf("ExecuteStep() error = %v, want unresolved {{missing}}", err)
+	}
+	if execution.Status != models.StepStatusFailed {
+		t.Errorf("strict status = %s, want failed", execution.Status)
+	}
+}
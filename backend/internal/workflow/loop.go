@@ -3,8 +3,6 @@ package workflow
 import (
 	"fmt"
 	"sync"
-
-	"test-management-service/internal/expression"
 )
 
 // executeWithLoop wraps step execution with loop logic
@@ -36,7 +34,7 @@ func (e *WorkflowExecutorImpl) executeForEach(ctx *ExecutionContext, step *Workf
 	ctx.Logger.Info(step.ID, fmt.Sprintf("Starting forEach loop over: %s", step.LoopOver))
 
 	// 获取表达式求值器
-	evaluator := expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+	evaluator := newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 
 	// 求值循环集合
 	collection, err := evaluator.EvaluateToArray(step.LoopOver)
@@ -62,7 +60,7 @@ func (e *WorkflowExecutorImpl) executeForEach(ctx *ExecutionContext, step *Workf
 		ctx.Variables["$loopItem"] = item
 
 		// 更新求值器
-		ctx.Evaluator = expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+		ctx.Evaluator = newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 
 		// 执行步骤
 		if err := e.executeStep(ctx, step); err != nil {
@@ -94,7 +92,7 @@ func (e *WorkflowExecutorImpl) executeForEachParallel(ctx *ExecutionContext, ste
 	ctx.Logger.Info(step.ID, fmt.Sprintf("Starting parallel forEach loop over: %s", step.LoopOver))
 
 	// 获取表达式求值器
-	evaluator := expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+	evaluator := newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 
 	// 求值循环集合
 	collection, err := evaluator.EvaluateToArray(step.LoopOver)
@@ -132,6 +130,11 @@ func (e *WorkflowExecutorImpl) executeForEachParallel(ctx *ExecutionContext, ste
 				StepResults: ctx.StepResults, // 共享results
 				Logger:      ctx.Logger,
 				VarTracker:  ctx.VarTracker,
+				Sessions:    ctx.Sessions,
+				ScriptPolicy: ctx.ScriptPolicy,
+				SchemaLoader: ctx.SchemaLoader,
+				Contract:     ctx.Contract,
+				StrictVariables: ctx.StrictVariables,
 			}
 
 			// 设置循环变量
@@ -146,7 +149,7 @@ func (e *WorkflowExecutorImpl) executeForEachParallel(ctx *ExecutionContext, ste
 			loopCtx.Variables["$loopItem"] = itm
 
 			// 初始化求值器
-			loopCtx.Evaluator = expression.NewEvaluator(loopCtx.Variables, loopCtx.StepOutputs)
+			loopCtx.Evaluator = newEvaluator(loopCtx.Variables, loopCtx.StepOutputs, loopCtx.StrictVariables)
 
 			// 执行步骤
 			if err := e.executeStep(loopCtx, step); err != nil {
@@ -185,7 +188,7 @@ func (e *WorkflowExecutorImpl) executeWhileLoop(ctx *ExecutionContext, step *Wor
 	iteration := 0
 	for iteration < maxIterations {
 		// 更新求值器
-		evaluator := expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+		evaluator := newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 		ctx.Evaluator = evaluator
 
 		// 检查循环条件
@@ -9,8 +9,10 @@ import (
 
 	"test-management-service/internal/expression"
 	"test-management-service/internal/models"
+	"test-management-service/internal/schema"
 	"test-management-service/internal/testcase"
 	ws "test-management-service/internal/websocket"
+	"test-management-service/internal/workflow/actions"
 
 	"gorm.io/gorm"
 )
@@ -32,6 +34,11 @@ type TestStepExecutionContext struct {
 	Logger      StepLogger
 	VarTracker  VariableChangeTracker
 	Evaluator   *expression.Evaluator
+	Sessions    *testcase.SessionStore // Named HTTP sessions shared across steps
+	ScriptPolicy *actions.ScriptPolicy // Tenant script policy for script steps
+	SchemaLoader schema.DocumentLoader // Project schema documents for jsonSchema assertions
+	Contract     *testcase.Contract    // OpenAPI contract HTTP steps are validated against
+	StrictVariables bool               // Unresolved {{ }} placeholders fail the step
 
 	// Parent context for cancellation
 	Ctx         context.Context
@@ -64,7 +71,8 @@ func NewTestStepExecutionContext(runID string, db *gorm.DB, hub *ws.Hub) *TestSt
 		StepOutputs: stepOutputs,
 		Logger:      NewBroadcastStepLogger(db, runID, hub),
 		VarTracker:  NewDatabaseVariableChangeTracker(db, runID),
-		Evaluator:   expression.NewEvaluator(variables, stepOutputs),
+		Evaluator:   newEvaluator(variables, stepOutputs, false),
+		Sessions:    testcase.NewSessionStore(nil),
 		Ctx:         context.Background(),
 	}
 }
@@ -129,6 +137,7 @@ func (e *TestStepExecutor) resolveInputs(ctx *TestStepExecutionContext, step *mo
 	}
 
 	execution.Inputs = make(map[string]interface{})
+	ctx.Evaluator = newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 
 	for targetVar, sourceExpr := range step.Inputs {
 		value, err := ctx.Evaluator.Evaluate(sourceExpr)
@@ -150,7 +159,7 @@ func (e *TestStepExecutor) evaluateCondition(ctx *TestStepExecutionContext, cond
 	}
 
 	// Refresh evaluator with current variables
-	ctx.Evaluator = expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+	ctx.Evaluator = newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 
 	return ctx.Evaluator.EvaluateBool(condition)
 }
@@ -185,7 +194,7 @@ func (e *TestStepExecutor) executeForEachLoop(ctx *TestStepExecutionContext, ste
 	})
 
 	// Resolve source array from variables
-	ctx.Evaluator = expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+	ctx.Evaluator = newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 	collection, err := ctx.Evaluator.EvaluateToArray(loop.Source)
 	if err != nil {
 		execution.Fail(fmt.Sprintf("Failed to evaluate loop source: %v", err), models.ErrorTypeSystem)
@@ -449,7 +458,7 @@ func (e *TestStepExecutor) executeSingleIteration(ctx *TestStepExecutionContext,
 	e.setLoopVariables(ctx, loop, index, item, totalIterations)
 
 	// Update evaluator with new variables
-	ctx.Evaluator = expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+	ctx.Evaluator = newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 
 	// Execute the step content
 	var childResults []models.StepExecution
@@ -528,7 +537,7 @@ func (e *TestStepExecutor) executeWhileLoop(ctx *TestStepExecutionContext, step
 	iteration := 0
 	for iteration < maxIterations {
 		// Update evaluator with current variables
-		ctx.Evaluator = expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+		ctx.Evaluator = newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 
 		// Check loop condition
 		shouldContinue, err := ctx.Evaluator.EvaluateBool(loop.Condition)
@@ -625,7 +634,7 @@ func (e *TestStepExecutor) executeCountLoop(ctx *TestStepExecutionContext, step
 		count = int(v)
 	case string:
 		// Evaluate as expression
-		ctx.Evaluator = expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+		ctx.Evaluator = newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 		result, err := ctx.Evaluator.Evaluate(v)
 		if err != nil {
 			execution.Fail(fmt.Sprintf("Failed to evaluate loop count: %v", err), models.ErrorTypeSystem)
@@ -760,7 +769,7 @@ func (e *TestStepExecutor) executeBranch(ctx *TestStepExecutionContext, step *mo
 	}
 
 	// Update evaluator
-	ctx.Evaluator = expression.NewEvaluator(ctx.Variables, ctx.StepOutputs)
+	ctx.Evaluator = newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
 
 	// Find matching branch
 	var selectedBranch *models.BranchConfig
@@ -954,6 +963,11 @@ func (e *TestStepExecutor) executeSimpleStep(ctx *TestStepExecutionContext, step
 		TestCaseRepo:    e.testCaseRepo,
 		UnifiedExecutor: e.unifiedExecutor,
 		Logger:          ctx.Logger,
+		Sessions:        ctx.Sessions,
+		ScriptPolicy:    ctx.ScriptPolicy,
+		SchemaLoader:    ctx.SchemaLoader,
+		Contract:        ctx.Contract,
+		StrictVariables: ctx.StrictVariables,
 	}
 
 	// Execute based on step type
@@ -963,6 +977,10 @@ func (e *TestStepExecutor) executeSimpleStep(ctx *TestStepExecutionContext, step
 		action := &HTTPActionWrapper{Config: interpolatedConfig}
 		result, err = action.Execute(actionCtx)
 
+	case models.StepTypeGraphQL:
+		action := &GraphQLActionWrapper{Config: interpolatedConfig}
+		result, err = action.Execute(actionCtx)
+
 	case models.StepTypeCommand:
 		action := &CommandActionWrapper{Config: interpolatedConfig}
 		result, err = action.Execute(actionCtx)
@@ -970,9 +988,6 @@ func (e *TestStepExecutor) executeSimpleStep(ctx *TestStepExecutionContext, step
 	case models.StepTypeAssert:
 		action := &AssertActionWrapper{Config: interpolatedConfig}
 		result, err = action.Execute(actionCtx)
-		if result != nil && result.Output != nil {
-			execution.Assertions = e.extractAssertionResults(result.Output)
-		}
 
 	case models.StepTypeDelay:
 		err = e.executeDelay(step, ctx)
@@ -1002,6 +1017,9 @@ func (e *TestStepExecutor) executeSimpleStep(ctx *TestStepExecutionContext, step
 			case models.StepTypeHTTP:
 				action := &HTTPActionWrapper{Config: interpolatedConfig}
 				result, err = action.Execute(actionCtx)
+			case models.StepTypeGraphQL:
+				action := &GraphQLActionWrapper{Config: interpolatedConfig}
+				result, err = action.Execute(actionCtx)
 			case models.StepTypeCommand:
 				action := &CommandActionWrapper{Config: interpolatedConfig}
 				result, err = action.Execute(actionCtx)
@@ -1016,6 +1034,11 @@ func (e *TestStepExecutor) executeSimpleStep(ctx *TestStepExecutionContext, step
 		}
 	}
 
+	// Assert steps and inline step assertions report structured results
+	if result != nil && result.Output != nil {
+		execution.Assertions = e.extractAssertionResults(result.Output)
+	}
+
 	// Update execution based on result
 	if err != nil {
 		execution.Fail(err.Error(), models.ErrorTypeSystem)
@@ -1027,6 +1050,7 @@ func (e *TestStepExecutor) executeSimpleStep(ctx *TestStepExecutionContext, step
 		execution.Fail(errMsg, models.ErrorTypeSystem)
 	} else {
 		execution.Complete(models.StepStatusPassed)
+		applyActionVariables(ctx.Variables, ctx.VarTracker, step.ID, result)
 		if result != nil && result.Output != nil {
 			execution.Outputs = result.Output
 			// Store in step outputs for later reference
@@ -1107,6 +1131,9 @@ func (e *TestStepExecutor) interpolateConfig(config map[string]interface{}, ctx
 		return nil, nil
 	}
 
+	// Refresh evaluator so it carries the context's strict mode
+	ctx.Evaluator = newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables)
+
 	result := make(map[string]interface{})
 	for key, value := range config {
 		interpolatedValue, err := e.interpolateValue(value, ctx)
@@ -1121,35 +1148,7 @@ func (e *TestStepExecutor) interpolateConfig(config map[string]interface{}, ctx
 
 // interpolateValue recursively interpolates a single value
 func (e *TestStepExecutor) interpolateValue(value interface{}, ctx *TestStepExecutionContext) (interface{}, error) {
-	switch v := value.(type) {
-	case string:
-		return ctx.Evaluator.EvaluateString(v)
-
-	case map[string]interface{}:
-		result := make(map[string]interface{})
-		for key, val := range v {
-			interpolatedVal, err := e.interpolateValue(val, ctx)
-			if err != nil {
-				return nil, err
-			}
-			result[key] = interpolatedVal
-		}
-		return result, nil
-
-	case []interface{}:
-		result := make([]interface{}, len(v))
-		for i, item := range v {
-			interpolatedItem, err := e.interpolateValue(item, ctx)
-			if err != nil {
-				return nil, err
-			}
-			result[i] = interpolatedItem
-		}
-		return result, nil
-
-	default:
-		return value, nil
-	}
+	return ctx.Evaluator.InterpolateValue(value)
 }
 
 // setLoopVariables sets loop-related variables in context
@@ -1201,6 +1200,11 @@ func (e *TestStepExecutor) createIterationContext(parentCtx *TestStepExecutionCo
 		StepOutputs: parentCtx.StepOutputs, // Shared
 		Logger:      parentCtx.Logger,
 		VarTracker:  parentCtx.VarTracker,
+		Sessions:    parentCtx.Sessions,
+		ScriptPolicy: parentCtx.ScriptPolicy,
+		SchemaLoader: parentCtx.SchemaLoader,
+		Contract:     parentCtx.Contract,
+		StrictVariables: parentCtx.StrictVariables,
 		Ctx:         parentCtx.Ctx,
 	}
 
@@ -1208,38 +1212,27 @@ func (e *TestStepExecutor) createIterationContext(parentCtx *TestStepExecutionCo
 	e.setLoopVariables(iterCtx, step.Loop, index, item, total)
 
 	// Create new evaluator with updated variables
-	iterCtx.Evaluator = expression.NewEvaluator(iterCtx.Variables, iterCtx.StepOutputs)
+	iterCtx.Evaluator = newEvaluator(iterCtx.Variables, iterCtx.StepOutputs, iterCtx.StrictVariables)
 
 	return iterCtx
 }
 
 // extractAssertionResults extracts assertion results from action output
 func (e *TestStepExecutor) extractAssertionResults(output map[string]interface{}) []models.AssertionResult {
-	results := make([]models.AssertionResult, 0)
-
-	if assertResults, ok := output["results"].([]interface{}); ok {
-		for _, r := range assertResults {
-			if resultMap, ok := r.(map[string]interface{}); ok {
-				result := models.AssertionResult{
-					Type:   fmt.Sprintf("%v", resultMap["type"]),
-					Target: fmt.Sprintf("%v", resultMap["target"]),
-					Passed: resultMap["passed"] == true,
-				}
-				if expected, ok := resultMap["expected"]; ok {
-					result.Expected = expected
-				}
-				if actual, ok := resultMap["actual"]; ok {
-					result.Actual = actual
-				}
-				if message, ok := resultMap["message"].(string); ok {
-					result.Message = message
-				}
-				results = append(results, result)
-			}
+	switch records := output["assertions"].(type) {
+	case nil:
+		return nil
+	case []models.AssertionResult:
+		return records
+	default:
+		// Records of scripts, or loaded back from storage
+		var results []models.AssertionResult
+		data, err := json.Marshal(records)
+		if err != nil || json.Unmarshal(data, &results) != nil {
+			return nil
 		}
+		return results
 	}
-
-	return results
 }
 
 // broadcastLoopEvent broadcasts a loop-related event via WebSocket
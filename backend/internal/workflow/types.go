@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"test-management-service/internal/models"
+	"test-management-service/internal/schema"
 	"test-management-service/internal/testcase"
+	"test-management-service/internal/workflow/actions"
 )
 
 // WorkflowExecutor executes workflows
@@ -43,14 +45,20 @@ type ActionContext struct {
 	TestCaseRepo    TestCaseRepository
 	UnifiedExecutor *testcase.UnifiedTestExecutor
 	Logger          StepLogger
+	Sessions        *testcase.SessionStore // Named HTTP sessions shared by the run
+	ScriptPolicy    *actions.ScriptPolicy  // Tenant script policy, nil denies fs/network
+	SchemaLoader    schema.DocumentLoader  // Project schema documents for jsonSchema assertions
+	Contract        *testcase.Contract     // OpenAPI contract HTTP steps are validated against
+	StrictVariables bool                   // Unresolved {{ }} placeholders are errors
 }
 
 // ActionResult represents action execution result
 type ActionResult struct {
 	Status   string // success, failed
 	Output   map[string]interface{}
-	Duration int
-	Error    error
+	Duration  int
+	Error     error
+	Variables map[string]interface{} // Variables set by the action (e.g. setVariable in scripts)
 }
 
 // StepLogger for step-level logging
@@ -79,7 +87,7 @@ type ActionTemplateRepository interface {
 // ActionOutput defines output extraction from action results
 type ActionOutput struct {
 	Name        string `json:"name"`        // Output variable name
-	Path        string `json:"path"`        // JSONPath to extract from result
+	Path        string `json:"path"`        // JSONPath to extract from result, or "xpath:", "css:" or "regex:" over the response body
 	Description string `json:"description"` // Human-readable description
 }
 
@@ -91,6 +99,11 @@ type ExecutionContext struct {
 	StepResults map[string]*StepExecutionResult
 	Logger      StepLogger
 	VarTracker  VariableChangeTracker
+	Sessions    *testcase.SessionStore // Named HTTP sessions shared across steps
+	ScriptPolicy *actions.ScriptPolicy // Tenant script policy for script steps
+	SchemaLoader schema.DocumentLoader // Project schema documents for jsonSchema assertions
+	Contract     *testcase.Contract    // OpenAPI contract HTTP steps are validated against
+	StrictVariables bool               // Unresolved {{ }} placeholders are errors
 
 	// === 新增：表达式求值器 ===
 	Evaluator   interface{} // *expression.Evaluator (使用interface避免循环依赖)
@@ -112,7 +125,7 @@ type StepExecutionResult struct {
 	Error    string
 }
 
-// JSON converts StepExecutionResult to JSON string for gjson querying
+// JSON converts StepExecutionResult to JSON string for JSONPath querying
 func (s *StepExecutionResult) JSON() (string, error) {
 	// Create a complete representation of the step result
 	data := map[string]interface{}{
@@ -184,6 +197,7 @@ type WorkflowDefinition struct {
 	Version   string                    `json:"version"`
 	Variables map[string]interface{}    `json:"variables"`
 	Steps     map[string]*WorkflowStep  `json:"steps"`
+	Sessions  []testcase.SessionConfig  `json:"sessions,omitempty"` // Named HTTP sessions
 }
 
 // DataMapper defines data mapping configuration for visual workflow builder
@@ -191,7 +205,7 @@ type WorkflowDefinition struct {
 type DataMapper struct {
 	ID          string `json:"id"`                    // Unique mapper ID
 	SourceStep  string `json:"sourceStep"`            // Source step ID (e.g., "step-login")
-	SourcePath  string `json:"sourcePath"`            // JSONPath to extract (e.g., "output.response.body.token")
+	SourcePath  string `json:"sourcePath"`            // JSONPath to extract (e.g., "output.response.body.token", "$.output.items[0].id")
 	TargetParam string `json:"targetParam"`           // Target parameter name (e.g., "authToken")
 	Transform   string `json:"transform,omitempty"`   // Optional transformation: "uppercase" | "lowercase" | "trim" | "parseInt" | "parseFloat"
 }
@@ -1,12 +1,12 @@
 package workflow
 
 import (
+	"encoding/json"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 
-	"github.com/tidwall/gjson"
+	"test-management-service/internal/jsonpath"
 )
 
 // TransformFunc defines a function that transforms a value
@@ -58,76 +58,43 @@ func parseFloatTransform(v interface{}) interface{} {
 }
 
 // VariableResolver handles variable interpolation in workflow configurations
-// Supports {{variableName}} syntax for variable references
-type VariableResolver struct {
-	// varPattern matches {{variableName}} references
-	varPattern *regexp.Regexp
-}
+// Supports {{ expression }} references, evaluated by the expression engine
+type VariableResolver struct{}
 
 // NewVariableResolver creates a new VariableResolver instance
 func NewVariableResolver() *VariableResolver {
-	return &VariableResolver{
-		varPattern: regexp.MustCompile(`\{\{([^}]+)\}\}`),
-	}
+	return &VariableResolver{}
 }
 
 // Resolve resolves variable references in a string
-// Supports both simple variables ({{var}}) and nested paths ({{step.field}})
+// Supports simple variables ({{var}}), nested paths ({{step.field}}) and
+// functions; references that cannot be resolved are left in place, or
+// reported as an *expression.UnresolvedError when the context is strict.
 // Returns the resolved value as interface{} to preserve type information
-func (r *VariableResolver) Resolve(input string, ctx *ExecutionContext) interface{} {
-	// Find all {{variable}} references
-	matches := r.varPattern.FindAllStringSubmatch(input, -1)
-
-	if len(matches) == 0 {
-		// No variable references found, return original value
-		return input
-	}
-
-	// If the entire string is a single variable reference, return the actual value (preserve type)
-	if len(matches) == 1 && matches[0][0] == input {
-		varName := strings.TrimSpace(matches[0][1])
-		value := r.getVariableValue(varName, ctx)
-		if value != nil {
-			return value
-		}
-		return ""
-	}
-
-	// Multiple references or mixed content - do string substitution
-	result := input
-	for _, match := range matches {
-		placeholder := match[0] // "{{variable}}"
-		varName := strings.TrimSpace(match[1])     // "variable"
-
-		// Get variable value from context
-		value := r.getVariableValue(varName, ctx)
-		if value == nil {
-			value = ""
-		}
-
-		// Replace placeholder with string representation of value
-		result = strings.ReplaceAll(result, placeholder, fmt.Sprintf("%v", value))
-	}
-
-	return result
+func (r *VariableResolver) Resolve(input string, ctx *ExecutionContext) (interface{}, error) {
+	return newEvaluator(ctx.Variables, ctx.StepOutputs, ctx.StrictVariables).Interpolate(input)
 }
 
 // ResolveMap recursively resolves variables in a map
-func (r *VariableResolver) ResolveMap(config map[string]interface{}, ctx *ExecutionContext) map[string]interface{} {
+func (r *VariableResolver) ResolveMap(config map[string]interface{}, ctx *ExecutionContext) (map[string]interface{}, error) {
 	if config == nil {
-		return nil
+		return nil, nil
 	}
 
 	result := make(map[string]interface{})
 	for key, value := range config {
-		result[key] = r.ResolveValue(value, ctx)
+		resolved, err := r.ResolveValue(value, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		result[key] = resolved
 	}
 
-	return result
+	return result, nil
 }
 
 // ResolveValue recursively resolves variables in any value type
-func (r *VariableResolver) ResolveValue(value interface{}, ctx *ExecutionContext) interface{} {
+func (r *VariableResolver) ResolveValue(value interface{}, ctx *ExecutionContext) (interface{}, error) {
 	switch v := value.(type) {
 	case string:
 		// Resolve string variables
@@ -139,71 +106,17 @@ func (r *VariableResolver) ResolveValue(value interface{}, ctx *ExecutionContext
 		// Recursively resolve array elements
 		result := make([]interface{}, len(v))
 		for i, item := range v {
-			result[i] = r.ResolveValue(item, ctx)
+			resolved, err := r.ResolveValue(item, ctx)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			result[i] = resolved
 		}
-		return result
+		return result, nil
 	default:
 		// Return non-string values as-is
-		return value
-	}
-}
-
-// getVariableValue retrieves a variable value from the execution context
-// Supports dotted paths like "step.field.subfield"
-func (r *VariableResolver) getVariableValue(varName string, ctx *ExecutionContext) interface{} {
-	// Check if it's a dotted path (e.g., stepName.field.subfield)
-	if strings.Contains(varName, ".") {
-		parts := strings.SplitN(varName, ".", 2)
-		stepName := parts[0]
-		fieldPath := parts[1]
-
-		// Try to get from StepOutputs first
-		if stepOutput, exists := ctx.StepOutputs[stepName]; exists {
-			return navigatePath(stepOutput, fieldPath)
-		}
-
-		// Then try Variables
-		if varValue, exists := ctx.Variables[stepName]; exists {
-			return navigatePath(varValue, fieldPath)
-		}
-
-		return nil
-	}
-
-	// Simple variable name without dots
-	// Check Variables first
-	if val, exists := ctx.Variables[varName]; exists {
-		return val
-	}
-
-	// Then check StepOutputs
-	if val, exists := ctx.StepOutputs[varName]; exists {
-		return val
-	}
-
-	return nil
-}
-
-// navigatePath navigates a nested structure using dot notation
-// e.g., navigatePath({"user": {"id": 123}}, "user.id") returns 123
-func navigatePath(data interface{}, path string) interface{} {
-	parts := strings.Split(path, ".")
-	current := data
-
-	for _, part := range parts {
-		switch v := current.(type) {
-		case map[string]interface{}:
-			var exists bool
-			current, exists = v[part]
-			if !exists {
-				return nil
-			}
-		default:
-			return nil
-		}
+		return value, nil
 	}
-
-	return current
 }
 
 // ResolveStepInputs resolves step inputs with DataMapper priority
@@ -223,7 +136,11 @@ func (r *VariableResolver) ResolveStepInputs(step *WorkflowStep, ctx *ExecutionC
 	// 2. Fallback: Use Inputs (manual references) - only if not already set by DataMappers
 	for paramName, paramValue := range step.Inputs {
 		if _, exists := resolved[paramName]; !exists {
-			resolved[paramName] = r.Resolve(paramValue, ctx)
+			value, err := r.Resolve(paramValue, ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve input %s: %w", paramName, err)
+			}
+			resolved[paramName] = value
 		}
 	}
 
@@ -239,20 +156,25 @@ func (r *VariableResolver) resolveDataMapper(mapper *DataMapper, ctx *ExecutionC
 		return nil, fmt.Errorf("source step %s not found", mapper.SourceStep)
 	}
 
-	// 2. Convert StepExecutionResult to JSON string for gjson querying
+	// 2. Convert StepExecutionResult to its JSON form for querying
 	jsonStr, err := sourceStepResult.JSON()
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert step result to JSON: %w", err)
 	}
+	var source interface{}
+	if err := json.Unmarshal([]byte(jsonStr), &source); err != nil {
+		return nil, fmt.Errorf("failed to convert step result to JSON: %w", err)
+	}
 
 	// 3. Use JSONPath to extract value from source step output
-	result := gjson.Get(jsonStr, mapper.SourcePath)
-	if !result.Exists() {
+	value, found, err := jsonpath.Lookup(source, mapper.SourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source path for mapper %s: %w", mapper.ID, err)
+	}
+	if !found {
 		return nil, fmt.Errorf("path %s not found in step %s output", mapper.SourcePath, mapper.SourceStep)
 	}
 
-	value := result.Value()
-
 	// 4. Apply transformation function if specified
 	if mapper.Transform != "" {
 		transformFunc, ok := builtInTransforms[mapper.Transform]
@@ -1,7 +1,10 @@
 package workflow
 
 import (
+	"errors"
 	"testing"
+
+	"test-management-service/internal/expression"
 )
 
 // TestBuiltInTransforms tests all built-in transformation functions
@@ -89,6 +92,10 @@ func TestResolveDataMapper(t *testing.T) {
 						},
 						"status": 200,
 					},
+					"items": []interface{}{
+						map[string]interface{}{"id": "a", "price": 5},
+						map[string]interface{}{"id": "b", "price": 15},
+					},
 				},
 			},
 		},
@@ -155,6 +162,38 @@ func TestResolveDataMapper(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "extract with array index",
+			mapper: DataMapper{
+				ID:          "mapper-7",
+				SourceStep:  "step-login",
+				SourcePath:  "$.output.items[-1].id",
+				TargetParam: "lastID",
+			},
+			expected:    "b",
+			expectError: false,
+		},
+		{
+			name: "extract with filter",
+			mapper: DataMapper{
+				ID:          "mapper-8",
+				SourceStep:  "step-login",
+				SourcePath:  "$.output.items[?(@.price > 10)].id.first()",
+				TargetParam: "id",
+			},
+			expected:    "b",
+			expectError: false,
+		},
+		{
+			name: "invalid path",
+			mapper: DataMapper{
+				ID:          "mapper-9",
+				SourceStep:  "step-login",
+				SourcePath:  "$.output.items[?(@.price >)]",
+				TargetParam: "ids",
+			},
+			expectError: true,
+		},
 		{
 			name: "unknown transform function",
 			mapper: DataMapper{
@@ -256,6 +295,28 @@ func TestResolveStepInputs(t *testing.T) {
 	}
 }
 
+// TestResolveStepInputs_Strict tests that unresolved inputs fail in strict mode
+func TestResolveStepInputs_Strict(t *testing.T) {
+	resolver := NewVariableResolver()
+	step := &WorkflowStep{ID: "step-api-call", Inputs: map[string]string{"url": "{{baseURL}}/users"}}
+	ctx := &ExecutionContext{Variables: map[string]interface{}{}, StepOutputs: map[string]interface{}{}}
+
+	resolved, err := resolver.ResolveStepInputs(step, ctx)
+	if err != nil {
+		t.Fatalf("ResolveStepInputs failed: %v", err)
+	}
+	if resolved["url"] != "{{baseURL}}/users" {
+		t.Errorf("url = %v, want the placeholder kept", resolved["url"])
+	}
+
+	ctx.StrictVariables = true
+	_, err = resolver.ResolveStepInputs(step, ctx)
+	var unresolved *expression.UnresolvedError
+	if !errors.As(err, &unresolved) || unresolved.Placeholder != "{{baseURL}}" {
+		t.Errorf("ResolveStepInputs() error = %v, want unresolved {{baseURL}}", err)
+	}
+}
+
 // TestStepExecutionResultJSON tests JSON conversion of StepExecutionResult
 func TestStepExecutionResultJSON(t *testing.T) {
 	result := &StepExecutionResult{
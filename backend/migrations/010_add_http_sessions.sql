@@ -0,0 +1,4 @@
+-- Migration 010: Add named HTTP sessions to test_cases
+-- Sessions hold a cookie jar, default headers and base URL shared by hooks and steps
+
+ALTER TABLE test_cases ADD COLUMN sessions TEXT;
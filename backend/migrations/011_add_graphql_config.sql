@@ -0,0 +1,4 @@
+-- Migration 011: Add graphql_config to test_cases
+-- Stores the query document, operation name, variables and transport options of GraphQL tests
+
+ALTER TABLE test_cases ADD COLUMN graphql_config TEXT;
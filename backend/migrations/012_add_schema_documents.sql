@@ -0,0 +1,20 @@
+-- Migration 012: Add schema_documents table
+-- Stores JSON Schemas and OpenAPI documents referenced by json_schema assertions
+
+CREATE TABLE IF NOT EXISTS schema_documents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id VARCHAR(100),
+    project_id VARCHAR(100),
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL DEFAULT 'json_schema',
+    description TEXT,
+    content TEXT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_schema_documents_tenant_id ON schema_documents(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_schema_documents_project_id ON schema_documents(project_id);
+CREATE INDEX IF NOT EXISTS idx_schema_documents_name ON schema_documents(name);
+CREATE INDEX IF NOT EXISTS idx_schema_documents_deleted_at ON schema_documents(deleted_at);
@@ -0,0 +1,25 @@
+-- Migration 013: Add snapshots table
+-- Stores every version of snapshot assertions with its approval state
+
+CREATE TABLE IF NOT EXISTS snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id VARCHAR(100),
+    project_id VARCHAR(100),
+    test_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT 'default',
+    version INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    content TEXT,
+    diff TEXT,
+    base_version INTEGER,
+    approved_by VARCHAR(100),
+    approved_at DATETIME,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_snapshots_tenant_id ON snapshots(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_snapshots_project_id ON snapshots(project_id);
+CREATE INDEX IF NOT EXISTS idx_snapshots_test_id ON snapshots(test_id);
+CREATE INDEX IF NOT EXISTS idx_snapshots_status ON snapshots(status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_snapshots_version ON snapshots(tenant_id, project_id, test_id, name, version);
@@ -0,0 +1,4 @@
+-- Migration 014: Add assertions to test_results
+-- Stores the structured result (type, expected, actual, path, message) of every assertion
+
+ALTER TABLE test_results ADD COLUMN assertions TEXT;
@@ -0,0 +1,12 @@
+-- Migration 015: Link test groups and environments to OpenAPI contracts
+-- HTTP tests are validated against the operation they match in the linked
+-- openapi schema document; contract_mode is strict, warn or off
+
+ALTER TABLE test_groups ADD COLUMN openapi_spec VARCHAR(255);
+ALTER TABLE test_groups ADD COLUMN contract_mode VARCHAR(20);
+
+ALTER TABLE environments ADD COLUMN openapi_spec VARCHAR(255);
+ALTER TABLE environments ADD COLUMN contract_mode VARCHAR(20);
+
+-- Contract violations reported in warn mode
+ALTER TABLE test_results ADD COLUMN warnings TEXT;
@@ -0,0 +1,15 @@
+-- Migration 016: Add group run options
+-- Group runs execute tests on a bounded worker pool; serial tests run alone,
+-- and a run may stop on the first failure or after a maximum duration
+
+ALTER TABLE test_cases ADD COLUMN serial BOOLEAN DEFAULT 0;
+
+-- Default worker count of the group, 0 uses the service default
+ALTER TABLE test_groups ADD COLUMN concurrency INTEGER DEFAULT 0;
+
+ALTER TABLE test_runs ADD COLUMN group_id VARCHAR(255);
+ALTER TABLE test_runs ADD COLUMN concurrency INTEGER;
+ALTER TABLE test_runs ADD COLUMN fail_fast BOOLEAN DEFAULT 0;
+ALTER TABLE test_runs ADD COLUMN max_duration INTEGER;  -- seconds; status becomes timeout when exceeded
+
+CREATE INDEX IF NOT EXISTS idx_test_runs_group_id ON test_runs(group_id);
@@ -0,0 +1,8 @@
+-- Migration 017: Add rerun links to test_runs
+-- A rerun of the failed tests of a run points to the run it reruns; the
+-- merged view combines their results
+
+ALTER TABLE test_runs ADD COLUMN recursive BOOLEAN DEFAULT 0;  -- subgroups were executed too
+ALTER TABLE test_runs ADD COLUMN parent_run_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_test_runs_parent_run_id ON test_runs(parent_run_id);
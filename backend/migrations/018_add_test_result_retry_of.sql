@@ -0,0 +1,7 @@
+-- Migration 018: Add retry_of to test_results
+-- A result of a rerun points to the result it retries, so a pass after a
+-- failure counts towards the flakiness of the test
+
+ALTER TABLE test_results ADD COLUMN retry_of INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_test_results_retry_of ON test_results(retry_of);
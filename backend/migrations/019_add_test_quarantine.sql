@@ -0,0 +1,18 @@
+-- Migration 019: Add test quarantine
+-- Quarantined tests still run, but their results are excluded from the
+-- verdict of a run; they are released on expiry or after consecutive passes
+
+ALTER TABLE test_cases ADD COLUMN quarantined BOOLEAN DEFAULT 0;
+ALTER TABLE test_cases ADD COLUMN quarantine_reason TEXT;
+ALTER TABLE test_cases ADD COLUMN quarantine_owner VARCHAR(100);
+ALTER TABLE test_cases ADD COLUMN quarantined_at DATETIME;
+ALTER TABLE test_cases ADD COLUMN quarantine_expires_at DATETIME;
+ALTER TABLE test_cases ADD COLUMN quarantine_passes INTEGER DEFAULT 0;  -- consecutive passes while quarantined
+
+CREATE INDEX IF NOT EXISTS idx_test_cases_quarantined ON test_cases(quarantined);
+
+-- The test was quarantined when the result was recorded
+ALTER TABLE test_results ADD COLUMN quarantined BOOLEAN DEFAULT 0;
+
+-- Results of quarantined tests, not counted in passed/failed/errors/skipped
+ALTER TABLE test_runs ADD COLUMN quarantined INTEGER DEFAULT 0;
@@ -0,0 +1,50 @@
+-- Migration 020: Add test_plans table
+-- A test plan saves a selection of tests with run options, retries, a
+-- notification webhook and an optional cron schedule
+
+CREATE TABLE IF NOT EXISTS test_plans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    plan_id VARCHAR(100) NOT NULL,
+    tenant_id VARCHAR(100),
+    project_id VARCHAR(100),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    test_ids TEXT,                   -- JSON array
+    group_ids TEXT,                  -- JSON array
+    recursive BOOLEAN DEFAULT 0,
+    tags TEXT,                       -- JSON array, all must match
+    priorities TEXT,                 -- JSON array, any must match
+    types TEXT,                      -- JSON array, any must match
+    environment_id VARCHAR(50),
+    variables TEXT,                  -- JSON object
+    concurrency INTEGER,
+    fail_fast BOOLEAN DEFAULT 0,
+    max_duration INTEGER,            -- seconds
+    max_retries INTEGER,
+    retry_on VARCHAR(20),            -- failed, errored
+    notify_webhook VARCHAR(500),
+    notify_on VARCHAR(20),           -- always, failure, never
+    schedule VARCHAR(100),           -- cron expression
+    next_run_at DATETIME,
+    last_run_id VARCHAR(255),
+    last_run_at DATETIME,
+    last_status VARCHAR(20),         -- passed, failed
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_test_plans_plan_id ON test_plans(plan_id);
+CREATE INDEX IF NOT EXISTS idx_test_plans_tenant_id ON test_plans(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_test_plans_project_id ON test_plans(project_id);
+CREATE INDEX IF NOT EXISTS idx_test_plans_next_run_at ON test_plans(next_run_at);
+CREATE INDEX IF NOT EXISTS idx_test_plans_deleted_at ON test_plans(deleted_at);
+
+-- Runs started from a selection keep it, with the environment and variable
+-- overrides, so reruns select the same tests
+ALTER TABLE test_runs ADD COLUMN plan_id VARCHAR(100);
+ALTER TABLE test_runs ADD COLUMN selection TEXT;
+ALTER TABLE test_runs ADD COLUMN environment_id VARCHAR(50);
+ALTER TABLE test_runs ADD COLUMN variables TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_test_runs_plan_id ON test_runs(plan_id);
@@ -0,0 +1,7 @@
+-- Migration 021: Add test dependencies to test_cases
+-- Prerequisites run first in batch runs; their outputs (variable name ->
+-- JSONPath into the response) are injected into the tests that list them as inputs
+
+ALTER TABLE test_cases ADD COLUMN depends_on TEXT;  -- JSON array of test IDs
+ALTER TABLE test_cases ADD COLUMN outputs TEXT;     -- JSON object
+ALTER TABLE test_cases ADD COLUMN inputs TEXT;      -- JSON array of variable names
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"test-management-service/internal/websocket"
 
 	"github.com/gin-gonic/gin"
+	gorillaws "github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
@@ -202,7 +204,7 @@ func setupWorkflowTestEnvironment(t *testing.T) (*gin.Engine, *gorm.DB, *websock
 	workflowHandler := handler.NewWorkflowHandler(workflowService)
 	workflowHandler.RegisterRoutes(rg)
 
-	wsHandler := handler.NewWebSocketHandler(hub)
+	wsHandler := handler.NewWebSocketHandler(hub, repository.NewTestRunRepository(db))
 	wsHandler.RegisterRoutes(rg)
 
 	// Suppress warnings
@@ -1089,7 +1091,7 @@ func setupWorkflowTestEnvironmentWithFile(t *testing.T) (*gin.Engine, *gorm.DB,
 	workflowHandler := handler.NewWorkflowHandler(workflowService)
 	workflowHandler.RegisterRoutes(rg)
 
-	wsHandler := handler.NewWebSocketHandler(hub)
+	wsHandler := handler.NewWebSocketHandler(hub, repository.NewTestRunRepository(db))
 	wsHandler.RegisterRoutes(rg)
 
 	return router, db, hub, tmpFile
@@ -1183,3 +1185,37 @@ func TestWorkflow_ParallelExecution(t *testing.T) {
 		t.Logf("Warning: runId not found in response: %+v", result)
 	}
 }
+
+// TestStreamTestRun_TenantIsolation tests that test run streams are limited to the caller's tenant and project
+func TestStreamTestRun_TenantIsolation(t *testing.T) {
+	router, db, _ := setupWorkflowTestEnvironment(t)
+
+	runRepo := repository.NewTestRunRepository(db)
+	require.NoError(t, runRepo.Create(&models.TestRun{RunID: "run-own", TenantID: "default", ProjectID: "default", Status: "running"}))
+	require.NoError(t, runRepo.Create(&models.TestRun{RunID: "run-other", TenantID: "other", ProjectID: "default", Status: "running"}))
+
+	t.Run("OtherTenantRun", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v2/ws/test-runs/run-other", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("UnknownRun", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v2/ws/test-runs/run-missing", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("OwnRun", func(t *testing.T) {
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v2/ws/test-runs/run-own"
+		conn, resp, err := gorillaws.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	})
+}